@@ -3,6 +3,7 @@ package agentstate
 
 import (
 	"os"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -26,6 +27,7 @@ var State = agentState{} //nolint:gochecknoglobals // Global agent state
 type agentState struct {
 	PidFile                        string        // PidFile is the path to the file containing the agent's process ID.
 	HashcatPidFile                 string        // HashcatPidFile is the path to the file containing the Hashcat process ID.
+	ActiveTaskFile                 string        // ActiveTaskFile is the path to the record of the in-progress task, used to resume it after a restart.
 	DataPath                       string        // DataPath is the path to the directory containing the agent's data files.
 	CrackersPath                   string        // CrackersPath is the path to the directory containing the agent's cracker binaries.
 	HashlistPath                   string        // HashlistPath is the path to the directory containing the agent's hashlists.
@@ -34,7 +36,12 @@ type agentState struct {
 	ToolsPath                      string        // ToolsPath is the path to the directory containing the agent's tools.
 	OutPath                        string        // OutPath is the path to the directory containing the agent's output files.
 	FilePath                       string        // FilePath is the path to the file containing various files for attacks.
+	ResourceCachePath              string        // ResourceCachePath is the directory of the content-addressed attack resource cache.
+	ResourceCacheMaxSizeMB         int           // ResourceCacheMaxSizeMB is the resource cache quota in MiB (0 disables eviction).
+	CompressedResourceMode         string        // CompressedResourceMode is how compressed attack resources reach hashcat: "decompress" or "stream".
+	MinFreeDiskMB                  int           // MinFreeDiskMB is the free disk space in MiB kept in the download and output directories.
 	RestoreFilePath                string        // RestoreFilePath is the path to the file containing hashcat's restore data.
+	JournalPath                    string        // JournalPath is the path to the directory containing per-task crack journals.
 	BenchmarkCachePath             string        // BenchmarkCachePath is the path to the JSON file caching benchmark results.
 	Debug                          bool          // Debug specifies whether the agent is running in debug mode.
 	AgentID                        int64         // AgentID is the unique identifier of the agent.
@@ -43,7 +50,6 @@ type agentState struct {
 	AlwaysTrustFiles               bool          // AlwaysTrustFiles specifies whether the agent should trust all files in the files directory and not check checksums.
 	ExtraDebugging                 bool          // ExtraDebugging specifies whether the agent should show extra debugging information. Set once at init; safe to read from any goroutine.
 	StatusTimer                    int           // StatusTimer is the interval in seconds between status updates.
-	WriteZapsToFile                bool          // WriteZapsToFile specifies whether the agent should write zaps to a file.
 	RetainZapsOnCompletion         bool          // RetainZapsOnCompletion specifies whether the agent should retain zaps after a job is completed.
 	EnableAdditionalHashTypes      bool          // EnableAdditionalHashTypes specifies whether the agent should enable additional hash types.
 	HashcatPath                    string        // HashcatPath is the path to the Hashcat binary (empty for auto-detection).
@@ -52,7 +58,15 @@ type agentState struct {
 	InsecureDownloads              bool          // InsecureDownloads skips TLS certificate verification for downloads.
 	DownloadMaxRetries             int           // DownloadMaxRetries is the max number of download retry attempts.
 	DownloadRetryDelay             time.Duration // DownloadRetryDelay is the base delay between download retries.
+	DownloadConcurrency            int           // DownloadConcurrency is the max number of attack resources downloaded at once.
+	DownloadBandwidthLimit         string        // DownloadBandwidthLimit is the global download rate cap (e.g. "20Mbit"); empty means unlimited.
+	DownloadBandwidthPerDownload   string        // DownloadBandwidthPerDownload is the rate cap on each individual download; empty means unlimited.
+	DownloadBandwidthSchedule      string        // DownloadBandwidthSchedule lists time-of-day bands overriding DownloadBandwidthLimit.
+	PrefetchResources              bool          // PrefetchResources enables warming the resource cache for the next task while one runs.
+	PrefetchBandwidthLimit         string        // PrefetchBandwidthLimit is the rate cap on prefetch downloads; empty means only the bandwidth policy applies.
 	TaskTimeout                    time.Duration // TaskTimeout is the max time for a single task before forced termination.
+	StallTimeout                   time.Duration // StallTimeout is how long a session may go without progress or status before it is restarted; zero disables the watchdog.
+	StallMaxRestarts               int           // StallMaxRestarts is how many times a stalled session is restarted before the task is abandoned.
 	MaxHeartbeatBackoff            int           // MaxHeartbeatBackoff is the max multiplier for heartbeat backoff.
 	SleepOnFailure                 time.Duration // SleepOnFailure is how long to wait after a task failure before retrying.
 	ConnectTimeout                 time.Duration // ConnectTimeout is the TCP connect timeout for API requests.
@@ -65,6 +79,7 @@ type agentState struct {
 	CircuitBreakerFailureThreshold int           // CircuitBreakerFailureThreshold is failures before circuit opens.
 	CircuitBreakerTimeout          time.Duration // CircuitBreakerTimeout is the duration before half-open retry.
 	AlwaysUseNativeHashcat         bool          // AlwaysUseNativeHashcat forces using the system's native Hashcat binary.
+	ExternalArchiveFallback        bool          // ExternalArchiveFallback lets the agent hand archives the built-in extractor cannot read to an external 7z.
 	DeferBenchmarks                bool          // DeferBenchmarks skips full benchmarks at startup; uses capability detection instead.
 	BenchmarkWhileIdle             bool          // BenchmarkWhileIdle enables background benchmarking during idle periods.
 	Platform                       string        // Platform is the OS platform the agent is running on (e.g., "linux", "darwin"). Set once before goroutines start; safe to read from any goroutine.
@@ -73,13 +88,29 @@ type agentState struct {
 	PerformanceMonitoringInterval  time.Duration // PerformanceMonitoringInterval is the sampling interval for the performance monitor. Set once in SetupSharedState; safe to read from any goroutine.
 	CollectProcessMetrics          bool          // CollectProcessMetrics enables per-process sampling in the performance monitor. Set once in SetupSharedState; safe to read from any goroutine.
 	CollectPerCPUMetrics           bool          // CollectPerCPUMetrics enables per-core CPU sampling in the performance monitor. Set once in SetupSharedState; safe to read from any goroutine.
+	PerformanceJSONLPath           string        // PerformanceJSONLPath is the JSON-lines file performance samples are appended to (empty disables the sink).
+	PerformanceJSONLMaxSizeMB      int           // PerformanceJSONLMaxSizeMB is the size in MiB at which the JSON-lines file is rotated (0 disables rotation).
+	PerformanceJSONLMaxBackups     int           // PerformanceJSONLMaxBackups is the number of rotated JSON-lines files kept.
+	PerformanceStatsDAddress       string        // PerformanceStatsDAddress is the host:port of the StatsD server performance samples are sent to (empty disables the sink).
+	PerformanceStatsDPrefix        string        // PerformanceStatsDPrefix is prepended to StatsD metric names.
+	PerformanceOTLPEndpoint        string        // PerformanceOTLPEndpoint is the OTLP/HTTP metrics URL performance samples are exported to (empty disables the sink).
+	GPUTempThreshold               int           // GPUTempThreshold is the device temperature in Celsius above which the thermal guard intervenes (0 disables it).
+	GPUTempTripCount               int           // GPUTempTripCount is the number of consecutive over-threshold status updates before the thermal guard acts.
+	GPUTempAction                  string        // GPUTempAction is the thermal guard response to an overheating device: "pause", "stop", or "abort".
+	StatusListen                   string        // StatusListen is the local address of the health and status endpoint, e.g. "127.0.0.1:9401" (empty disables it). Set once in SetupSharedState; safe to read from any goroutine.
+	MetricsListen                  string        // MetricsListen is the local address of the Prometheus metrics endpoint, e.g. "127.0.0.1:9400" (empty disables it). Set once in SetupSharedState; safe to read from any goroutine.
+	DeviceGroups                   string        // DeviceGroups partitions devices into concurrent task slots: "" (one task at a time), "auto", or explicit groups such as "1,2;3,4".
 
 	// Synchronized fields — use getter/setter methods; do not access directly.
 	reload              atomic.Bool
 	jobCheckingStopped  atomic.Bool
+	pauseRequested      atomic.Bool
 	benchmarksSubmitted atomic.Bool
 	forceBenchmarkRun   atomic.Bool
-	hashcatPID          atomic.Int32
+	hashcatPIDsMu       sync.Mutex
+	hashcatPIDs         map[string]int32
+	lastHeartbeat       atomic.Int64 // Unix nanoseconds of the last successful heartbeat; 0 if none yet
+	heartbeatFailures   atomic.Int32
 	currentActivityMu   sync.RWMutex
 	currentActivity     Activity
 }
@@ -125,6 +156,16 @@ func (s *agentState) SetJobCheckingStopped(v bool) {
 	s.jobCheckingStopped.Store(v)
 }
 
+// GetPauseRequested returns whether the server has asked the agent to pause the running task.
+func (s *agentState) GetPauseRequested() bool {
+	return s.pauseRequested.Load()
+}
+
+// SetPauseRequested sets whether the server has asked the agent to pause the running task.
+func (s *agentState) SetPauseRequested(v bool) {
+	s.pauseRequested.Store(v)
+}
+
 // GetBenchmarksSubmitted returns whether the agent has successfully submitted its benchmark data.
 func (s *agentState) GetBenchmarksSubmitted() bool {
 	return s.benchmarksSubmitted.Load()
@@ -145,23 +186,106 @@ func (s *agentState) SetForceBenchmarkRun(v bool) {
 	s.forceBenchmarkRun.Store(v)
 }
 
-// SetHashcatPID records the process ID of the currently running hashcat process,
-// or 0 when none is running. Read by the performance monitor to sample the job's
-// per-process metrics.
-func (s *agentState) SetHashcatPID(pid int32) {
-	s.hashcatPID.Store(pid)
+// SetHashcatPID records the process ID of the hashcat process running as
+// session, or forgets it when pid is 0. Each task slot runs its own session, so
+// every running hashcat process is tracked. Read by the performance monitor to
+// sample the job's per-process metrics.
+func (s *agentState) SetHashcatPID(session string, pid int32) {
+	s.hashcatPIDsMu.Lock()
+	defer s.hashcatPIDsMu.Unlock()
+
+	if pid <= 0 {
+		delete(s.hashcatPIDs, session)
+		return
+	}
+
+	if s.hashcatPIDs == nil {
+		s.hashcatPIDs = make(map[string]int32)
+	}
+
+	s.hashcatPIDs[session] = pid
+}
+
+// ClearHashcatPID forgets session's hashcat PID only if it still equals pid.
+// The guard prevents an older session's teardown from clearing a newer
+// session's PID had they briefly overlapped.
+func (s *agentState) ClearHashcatPID(session string, pid int32) {
+	s.hashcatPIDsMu.Lock()
+	defer s.hashcatPIDsMu.Unlock()
+
+	if s.hashcatPIDs[session] == pid {
+		delete(s.hashcatPIDs, session)
+	}
+}
+
+// ResetHashcatPIDs forgets every recorded hashcat PID.
+func (s *agentState) ResetHashcatPIDs() {
+	s.hashcatPIDsMu.Lock()
+	defer s.hashcatPIDsMu.Unlock()
+
+	s.hashcatPIDs = nil
+}
+
+// GetHashcatPIDs returns the PIDs of all running hashcat processes in
+// ascending order.
+func (s *agentState) GetHashcatPIDs() []int32 {
+	s.hashcatPIDsMu.Lock()
+	defer s.hashcatPIDsMu.Unlock()
+
+	pids := make([]int32, 0, len(s.hashcatPIDs))
+	for _, pid := range s.hashcatPIDs {
+		pids = append(pids, pid)
+	}
+
+	slices.Sort(pids)
+
+	return pids
 }
 
-// GetHashcatPID returns the PID of the running hashcat process, or 0 if none.
+// GetHashcatPID returns the PID of the running hashcat process, or 0 if none
+// is running or several task slots each run one (see GetHashcatPIDs).
 func (s *agentState) GetHashcatPID() int32 {
-	return s.hashcatPID.Load()
+	s.hashcatPIDsMu.Lock()
+	defer s.hashcatPIDsMu.Unlock()
+
+	if len(s.hashcatPIDs) != 1 {
+		return 0
+	}
+
+	for _, pid := range s.hashcatPIDs {
+		return pid
+	}
+
+	return 0
+}
+
+// RecordHeartbeatSuccess records a successful heartbeat at t and resets the
+// consecutive failure count.
+func (s *agentState) RecordHeartbeatSuccess(t time.Time) {
+	s.lastHeartbeat.Store(t.UnixNano())
+	s.heartbeatFailures.Store(0)
+}
+
+// RecordHeartbeatFailure counts a failed heartbeat and returns the number of
+// consecutive failures.
+func (s *agentState) RecordHeartbeatFailure() int {
+	return int(s.heartbeatFailures.Add(1))
+}
+
+// GetLastHeartbeat returns the time of the last successful heartbeat, or the
+// zero time if none has succeeded yet.
+func (s *agentState) GetLastHeartbeat() time.Time {
+	ns := s.lastHeartbeat.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ns)
 }
 
-// ClearHashcatPID zeroes the stored hashcat PID only if it still equals pid.
-// The compare-and-swap guard prevents an older session's teardown from clearing
-// a newer session's PID had they briefly overlapped.
-func (s *agentState) ClearHashcatPID(pid int32) {
-	s.hashcatPID.CompareAndSwap(pid, 0)
+// GetHeartbeatFailures returns the number of consecutive failed heartbeats.
+func (s *agentState) GetHeartbeatFailures() int {
+	return int(s.heartbeatFailures.Load())
 }
 
 // GetCurrentActivity returns the current activity of the agent (thread-safe).
@@ -30,7 +30,6 @@ func TestState_DefaultValues(t *testing.T) {
 	assert.False(t, State.AlwaysTrustFiles)
 	assert.False(t, State.ExtraDebugging)
 	assert.Equal(t, 0, State.StatusTimer)
-	assert.False(t, State.WriteZapsToFile)
 	assert.False(t, State.RetainZapsOnCompletion)
 	assert.False(t, State.EnableAdditionalHashTypes)
 	assert.False(t, State.GetJobCheckingStopped())
@@ -44,7 +43,6 @@ func TestState_Modification(t *testing.T) {
 	origAPIToken := State.APIToken
 	origDebug := State.Debug
 	origStatusTimer := State.StatusTimer
-	origWriteZaps := State.WriteZapsToFile
 
 	defer func() {
 		State.AgentID = origAgentID
@@ -52,7 +50,6 @@ func TestState_Modification(t *testing.T) {
 		State.APIToken = origAPIToken
 		State.Debug = origDebug
 		State.StatusTimer = origStatusTimer
-		State.WriteZapsToFile = origWriteZaps
 	}()
 
 	// Modify state
@@ -61,7 +58,6 @@ func TestState_Modification(t *testing.T) {
 	State.APIToken = "test-token-123"
 	State.Debug = true
 	State.StatusTimer = 10
-	State.WriteZapsToFile = true
 
 	// Verify modifications
 	assert.Equal(t, int64(12345), State.AgentID)
@@ -69,7 +65,6 @@ func TestState_Modification(t *testing.T) {
 	assert.Equal(t, "test-token-123", State.APIToken)
 	assert.True(t, State.Debug)
 	assert.Equal(t, 10, State.StatusTimer)
-	assert.True(t, State.WriteZapsToFile)
 }
 
 func TestActivityConstants(t *testing.T) {
@@ -155,7 +150,6 @@ func TestState_BooleanFlags(t *testing.T) {
 	origDebug := State.Debug
 	origAlwaysTrust := State.AlwaysTrustFiles
 	origExtraDebug := State.ExtraDebugging
-	origWriteZaps := State.WriteZapsToFile
 	origRetainZaps := State.RetainZapsOnCompletion
 	origEnableHash := State.EnableAdditionalHashTypes
 
@@ -167,7 +161,6 @@ func TestState_BooleanFlags(t *testing.T) {
 		State.Debug = origDebug
 		State.AlwaysTrustFiles = origAlwaysTrust
 		State.ExtraDebugging = origExtraDebug
-		State.WriteZapsToFile = origWriteZaps
 		State.RetainZapsOnCompletion = origRetainZaps
 		State.EnableAdditionalHashTypes = origEnableHash
 		State.SetJobCheckingStopped(origJobStopped)
@@ -178,7 +171,6 @@ func TestState_BooleanFlags(t *testing.T) {
 	State.Debug = true
 	State.AlwaysTrustFiles = true
 	State.ExtraDebugging = true
-	State.WriteZapsToFile = true
 	State.RetainZapsOnCompletion = true
 	State.EnableAdditionalHashTypes = true
 	State.SetJobCheckingStopped(true)
@@ -187,7 +179,6 @@ func TestState_BooleanFlags(t *testing.T) {
 	assert.True(t, State.Debug)
 	assert.True(t, State.AlwaysTrustFiles)
 	assert.True(t, State.ExtraDebugging)
-	assert.True(t, State.WriteZapsToFile)
 	assert.True(t, State.RetainZapsOnCompletion)
 	assert.True(t, State.EnableAdditionalHashTypes)
 	assert.True(t, State.GetJobCheckingStopped())
@@ -195,19 +186,44 @@ func TestState_BooleanFlags(t *testing.T) {
 }
 
 func TestState_HashcatPID(t *testing.T) {
-	orig := State.GetHashcatPID()
-	t.Cleanup(func() { State.SetHashcatPID(orig) })
+	State.ResetHashcatPIDs()
+	t.Cleanup(State.ResetHashcatPIDs)
 
-	State.SetHashcatPID(0)
 	assert.Equal(t, int32(0), State.GetHashcatPID(), "default hashcat PID should be zero")
+	assert.Empty(t, State.GetHashcatPIDs())
 
-	State.SetHashcatPID(4321)
+	State.SetHashcatPID("attack-7-slot1", 4321)
 	assert.Equal(t, int32(4321), State.GetHashcatPID())
 
-	// ClearHashcatPID only zeroes when the current PID matches.
-	State.ClearHashcatPID(1111)
-	assert.Equal(t, int32(4321), State.GetHashcatPID(), "clear with non-matching PID must be a no-op")
+	State.SetHashcatPID("attack-8-slot2", 1234)
+	assert.Equal(t, []int32{1234, 4321}, State.GetHashcatPIDs(), "each slot's session is tracked")
+	assert.Equal(t, int32(0), State.GetHashcatPID(), "no single PID while several sessions run")
 
-	State.ClearHashcatPID(4321)
-	assert.Equal(t, int32(0), State.GetHashcatPID(), "clear with matching PID should zero it")
+	// ClearHashcatPID only forgets the session's PID when it matches.
+	State.ClearHashcatPID("attack-7-slot1", 1111)
+	assert.Equal(t, []int32{1234, 4321}, State.GetHashcatPIDs(), "clear with non-matching PID must be a no-op")
+
+	State.ClearHashcatPID("attack-7-slot1", 4321)
+	assert.Equal(t, []int32{1234}, State.GetHashcatPIDs(), "clear leaves other sessions' PIDs")
+	assert.Equal(t, int32(1234), State.GetHashcatPID())
+
+	State.SetHashcatPID("attack-8-slot2", 0)
+	assert.Empty(t, State.GetHashcatPIDs())
+}
+
+func TestState_HeartbeatTracking(t *testing.T) {
+	t.Cleanup(func() { State.lastHeartbeat.Store(0); State.heartbeatFailures.Store(0) })
+
+	State.lastHeartbeat.Store(0)
+	State.heartbeatFailures.Store(0)
+	assert.True(t, State.GetLastHeartbeat().IsZero(), "no heartbeat has succeeded yet")
+
+	assert.Equal(t, 1, State.RecordHeartbeatFailure())
+	assert.Equal(t, 2, State.RecordHeartbeatFailure())
+	assert.Equal(t, 2, State.GetHeartbeatFailures())
+
+	now := time.Unix(1700000000, 0)
+	State.RecordHeartbeatSuccess(now)
+	assert.True(t, now.Equal(State.GetLastHeartbeat()))
+	assert.Zero(t, State.GetHeartbeatFailures(), "a success resets the failure count")
 }
@@ -64,10 +64,34 @@ func init() {
 	err = viper.BindPFlag("gpu_temp_threshold", RootCmd.PersistentFlags().Lookup("gpu-temp-threshold"))
 	cobra.CheckErr(err)
 
+	RootCmd.PersistentFlags().
+		Int("gpu-temp-trip-count", config.DefaultGPUTempTripCount,
+			"Consecutive status updates above the GPU temperature threshold before the thermal guard acts")
+	err = viper.BindPFlag("gpu_temp_trip_count", RootCmd.PersistentFlags().Lookup("gpu-temp-trip-count"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("gpu-temp-action", config.DefaultGPUTempAction,
+			"Thermal guard response to an overheating GPU: pause, stop, or abort (hashcat --hwmon-temp-abort)")
+	err = viper.BindPFlag("gpu_temp_action", RootCmd.PersistentFlags().Lookup("gpu-temp-action"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("device-groups", "",
+			`Run one task per device group: "auto" groups identical devices, or list groups like "1,2;3,4"`)
+	err = viper.BindPFlag("device_groups", RootCmd.PersistentFlags().Lookup("device-groups"))
+	cobra.CheckErr(err)
+
 	RootCmd.PersistentFlags().BoolP("always-use-native-hashcat", "n", false, "Force using the native hashcat binary")
 	err = viper.BindPFlag("always_use_native_hashcat", RootCmd.PersistentFlags().Lookup("always-use-native-hashcat"))
 	cobra.CheckErr(err)
 
+	RootCmd.PersistentFlags().
+		Bool("external-archive-fallback", true,
+			"Use an external 7z for archives the built-in extractor cannot read, when 7z is on the PATH")
+	err = viper.BindPFlag("external_archive_fallback", RootCmd.PersistentFlags().Lookup("external-archive-fallback"))
+	cobra.CheckErr(err)
+
 	RootCmd.PersistentFlags().
 		DurationP("sleep-on-failure", "s", config.DefaultSleepOnFailure, "Duration of sleep after a task failure")
 	err = viper.BindPFlag("sleep_on_failure", RootCmd.PersistentFlags().Lookup("sleep-on-failure"))
@@ -78,6 +102,29 @@ func init() {
 	err = viper.BindPFlag("files_path", RootCmd.PersistentFlags().Lookup("files-path"))
 	cobra.CheckErr(err)
 
+	RootCmd.PersistentFlags().
+		String("resource-cache-path", "", "Directory of the content-addressed cache for attack files")
+	err = viper.BindPFlag("resource_cache_path", RootCmd.PersistentFlags().Lookup("resource-cache-path"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		Int("resource-cache-max-size-mb", 0,
+			"Evict least recently used attack files once the cache exceeds this size in MiB (0 disables eviction)")
+	err = viper.BindPFlag("resource_cache_max_size_mb", RootCmd.PersistentFlags().Lookup("resource-cache-max-size-mb"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("compressed-resource-mode", config.DefaultCompressedResourceMode,
+			"How compressed attack files reach hashcat: decompress to disk, or stream dictionary word lists via stdin")
+	err = viper.BindPFlag("compressed_resource_mode", RootCmd.PersistentFlags().Lookup("compressed-resource-mode"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		Int("min-free-disk-mb", config.DefaultMinFreeDiskMB,
+			"Free disk space in MiB to keep for downloads and hashcat output; tasks that would use it are refused")
+	err = viper.BindPFlag("min_free_disk_mb", RootCmd.PersistentFlags().Lookup("min-free-disk-mb"))
+	cobra.CheckErr(err)
+
 	RootCmd.PersistentFlags().BoolP("extra-debugging", "e", false, "Enable additional debugging information")
 	err = viper.BindPFlag("extra_debugging", RootCmd.PersistentFlags().Lookup("extra-debugging"))
 	cobra.CheckErr(err)
@@ -93,7 +140,7 @@ func init() {
 	cobra.CheckErr(err)
 
 	RootCmd.PersistentFlags().
-		BoolP("write-zaps-to-file", "w", false, "Write zap output to a file in the zaps directory")
+		BoolP("write-zaps-to-file", "w", false, "Deprecated: cracked hashes are always recorded in the crack journal")
 	err = viper.BindPFlag("write_zaps_to_file", RootCmd.PersistentFlags().Lookup("write-zaps-to-file"))
 	cobra.CheckErr(err)
 
@@ -111,6 +158,18 @@ func init() {
 	err = viper.BindPFlag("task_timeout", RootCmd.PersistentFlags().Lookup("task-timeout"))
 	cobra.CheckErr(err)
 
+	RootCmd.PersistentFlags().
+		Duration("stall-timeout", config.DefaultStallTimeout,
+			"Restart a hashcat session that makes no progress for this long (0 disables)")
+	err = viper.BindPFlag("stall_timeout", RootCmd.PersistentFlags().Lookup("stall-timeout"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		Int("stall-max-restarts", config.DefaultStallMaxRestarts,
+			"Restarts of a stalled hashcat session before the task is abandoned")
+	err = viper.BindPFlag("stall_max_restarts", RootCmd.PersistentFlags().Lookup("stall-max-restarts"))
+	cobra.CheckErr(err)
+
 	RootCmd.PersistentFlags().
 		Int("download-max-retries", config.DefaultDownloadMaxRetries, "Maximum number of download retry attempts")
 	err = viper.BindPFlag("download_max_retries", RootCmd.PersistentFlags().Lookup("download-max-retries"))
@@ -121,6 +180,36 @@ func init() {
 	err = viper.BindPFlag("download_retry_delay", RootCmd.PersistentFlags().Lookup("download-retry-delay"))
 	cobra.CheckErr(err)
 
+	RootCmd.PersistentFlags().
+		Int("download-concurrency", config.DefaultDownloadConcurrency, "Maximum number of attack resources downloaded at once")
+	err = viper.BindPFlag("download_concurrency", RootCmd.PersistentFlags().Lookup("download-concurrency"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("download-bandwidth-limit", "", "Total download rate cap, e.g. 20Mbit or 5MB/s (empty for unlimited)")
+	err = viper.BindPFlag("download_bandwidth_limit", RootCmd.PersistentFlags().Lookup("download-bandwidth-limit"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("download-bandwidth-per-download", "", "Download rate cap for each file (empty for unlimited)")
+	err = viper.BindPFlag("download_bandwidth_per_download", RootCmd.PersistentFlags().Lookup("download-bandwidth-per-download"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("download-bandwidth-schedule", "", "Time-of-day download rate caps, e.g. \"08:00-18:00=20Mbit,18:00-08:00=unlimited\"")
+	err = viper.BindPFlag("download_bandwidth_schedule", RootCmd.PersistentFlags().Lookup("download-bandwidth-schedule"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		Bool("prefetch-resources", config.DefaultPrefetchResources, "Fetch the next task's attack files into the cache while a task runs")
+	err = viper.BindPFlag("prefetch_resources", RootCmd.PersistentFlags().Lookup("prefetch-resources"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("prefetch-bandwidth-limit", config.DefaultPrefetchBandwidthLimit, "Download rate cap for prefetching (empty for unlimited)")
+	err = viper.BindPFlag("prefetch_bandwidth_limit", RootCmd.PersistentFlags().Lookup("prefetch-bandwidth-limit"))
+	cobra.CheckErr(err)
+
 	RootCmd.PersistentFlags().
 		Bool("insecure-downloads", false, "Skip TLS certificate verification for downloads (insecure)")
 	err = viper.BindPFlag("insecure_downloads", RootCmd.PersistentFlags().Lookup("insecure-downloads"))
@@ -194,6 +283,64 @@ func init() {
 	err = viper.BindPFlag("collect_per_cpu_metrics", RootCmd.PersistentFlags().Lookup("collect-per-cpu-metrics"))
 	cobra.CheckErr(err)
 
+	RootCmd.PersistentFlags().
+		String("performance-jsonl-path", "", "Append performance samples as JSON lines to this file")
+	err = viper.BindPFlag("performance_jsonl_path", RootCmd.PersistentFlags().Lookup("performance-jsonl-path"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		Int("performance-jsonl-max-size-mb", config.DefaultPerformanceJSONLMaxSizeMB,
+			"Rotate the performance JSON-lines file at this size in MiB (0 disables rotation)")
+	err = viper.BindPFlag(
+		"performance_jsonl_max_size_mb",
+		RootCmd.PersistentFlags().Lookup("performance-jsonl-max-size-mb"),
+	)
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		Int("performance-jsonl-max-backups", config.DefaultPerformanceJSONLMaxBackups,
+			"Number of rotated performance JSON-lines files to keep")
+	err = viper.BindPFlag(
+		"performance_jsonl_max_backups",
+		RootCmd.PersistentFlags().Lookup("performance-jsonl-max-backups"),
+	)
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("performance-statsd-address", "", "Send performance samples to this StatsD server (host:port, UDP)")
+	err = viper.BindPFlag(
+		"performance_statsd_address",
+		RootCmd.PersistentFlags().Lookup("performance-statsd-address"),
+	)
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("performance-statsd-prefix", config.DefaultPerformanceStatsDPrefix,
+			"Prefix for StatsD performance metric names")
+	err = viper.BindPFlag("performance_statsd_prefix", RootCmd.PersistentFlags().Lookup("performance-statsd-prefix"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("performance-otlp-endpoint", "",
+			"Export performance samples to this OTLP/HTTP metrics URL (e.g. http://collector:4318/v1/metrics)")
+	err = viper.BindPFlag(
+		"performance_otlp_endpoint",
+		RootCmd.PersistentFlags().Lookup("performance-otlp-endpoint"),
+	)
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("metrics-listen", config.DefaultMetricsListen,
+			`Serve Prometheus metrics on this address (e.g. "127.0.0.1:9400"); empty disables it`)
+	err = viper.BindPFlag("metrics_listen", RootCmd.PersistentFlags().Lookup("metrics-listen"))
+	cobra.CheckErr(err)
+
+	RootCmd.PersistentFlags().
+		String("status-listen", config.DefaultStatusListen,
+			`Serve health probes and agent status on this address (e.g. "127.0.0.1:9401"); empty disables it`)
+	err = viper.BindPFlag("status_listen", RootCmd.PersistentFlags().Lookup("status-listen"))
+	cobra.CheckErr(err)
+
 	// Register deprecated underscore aliases for backward compatibility.
 	registerDeprecatedAliases()
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -15,13 +16,16 @@ import (
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/apierrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/benchmark"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/config"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/cracker"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/devices"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/display"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/metrics"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/monitor"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/task"
 )
@@ -33,6 +37,9 @@ const (
 	// before giving up. On expiry the restart is skipped to avoid two benchmark
 	// processes contending for the GPU.
 	bgBenchStopTimeout = 600 * time.Millisecond
+	// pausePollInterval is how often a paused task checks whether the server has
+	// lifted the pause.
+	pausePollInterval = time.Second
 )
 
 // bgBenchHandle bundles a background-benchmark goroutine's cancel func with a
@@ -51,9 +58,10 @@ type bgBenchHandle struct {
 //
 //nolint:gochecknoglobals // Package-level managers, initialized in StartAgent
 var (
-	benchmarkMgr *benchmark.Manager
-	taskMgr      *task.Manager
-	deviceMgr    *devices.DeviceManager
+	benchmarkMgr  *benchmark.Manager
+	taskMgr       *task.Manager
+	deviceMgr     *devices.DeviceManager
+	resourceCache *downloader.Cache
 )
 
 // bgBench holds the current background-benchmark goroutine handle. Unlike the
@@ -92,6 +100,7 @@ func setupAPIClient() {
 		agentstate.State.CircuitBreakerFailureThreshold,
 		agentstate.State.CircuitBreakerTimeout,
 	)
+	metrics.Default.SetCircuitState(circuitBreaker.State)
 
 	apiClient, err := api.NewAgentClient(
 		agentstate.State.URL,
@@ -120,6 +129,9 @@ func prepareWorkspace() {
 		agentstate.Logger.Fatal("Error creating lock file", "error", err)
 	}
 
+	resourceCache = downloader.NewCache(agentstate.State.ResourceCachePath,
+		int64(agentstate.State.ResourceCacheMaxSizeMB)<<20)
+
 	// On POSIX, the session directory is resolved from $HOME, not the binary path,
 	// so cleanup works even when hashcat is not yet installed.
 	binaryPath, binaryErr := cracker.FindHashcatBinary()
@@ -150,15 +162,43 @@ func setupDevicesAndMetadata(ctx context.Context) {
 // runBenchmarkPhase submits benchmarks at startup. When benchmarks are needed (or
 // forced) it runs either quick capability detection (deferred mode) or a full
 // benchmark; otherwise it marks benchmarks submitted from the server's valid cache.
-func runBenchmarkPhase(ctx context.Context, benchmarksNeeded bool) {
+// A hashcat just installed by updateCracker is always benchmarked, and rolled
+// back (then benchmarked again) if that first benchmark fails.
+func runBenchmarkPhase(ctx context.Context, benchmarksNeeded bool, install *crackerInstall) {
 	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityBenchmarking)
+
+	if install != nil {
+		// Benchmarks on file were measured with the previous hashcat.
+		benchmarksNeeded = true
+	}
+
+	err := runStartupBenchmarks(ctx, benchmarksNeeded)
+	if err != nil && install != nil {
+		agentstate.Logger.Error("First benchmark with the new hashcat failed, rolling back",
+			"version", install.version, "error", err)
+
+		if rbErr := rollBackCracker(ctx, install, err); rbErr != nil {
+			agentstate.Logger.Fatal("Failed to roll back hashcat", "error", rbErr)
+		}
+
+		err = runStartupBenchmarks(ctx, benchmarksNeeded)
+	}
+
+	if err != nil {
+		agentstate.Logger.Fatal("Failed to submit initial benchmarks", "error", err)
+	}
+}
+
+// runStartupBenchmarks performs the startup benchmark run described on
+// runBenchmarkPhase.
+func runStartupBenchmarks(ctx context.Context, benchmarksNeeded bool) error {
 	forceBenchmark := agentstate.State.GetForceBenchmarkRun()
 
 	if !benchmarksNeeded && !forceBenchmark {
 		agentstate.Logger.Info("Server reports valid benchmarks on file, skipping benchmark run")
 		agentstate.State.SetBenchmarksSubmitted(true)
 
-		return
+		return nil
 	}
 
 	if forceBenchmark && !benchmarksNeeded {
@@ -171,25 +211,23 @@ func runBenchmarkPhase(ctx context.Context, benchmarksNeeded bool) {
 
 		capResults, capErr := benchmarkMgr.RunCapabilityDetection(ctx)
 		if capErr != nil {
-			agentstate.Logger.Fatal("Capability detection failed", "error", capErr)
+			return fmt.Errorf("capability detection failed: %w", capErr)
 		}
 
 		if len(capResults) == 0 {
-			agentstate.Logger.Fatal("Capability detection returned no hash types; " +
+			return errors.New("capability detection returned no hash types; " +
 				"check GPU drivers and hashcat installation")
 		}
 
 		if submitErr := benchmarkMgr.SubmitCapabilityResults(ctx, capResults); submitErr != nil {
-			agentstate.Logger.Fatal("Failed to submit capability detection results", "error", submitErr)
+			return fmt.Errorf("failed to submit capability detection results: %w", submitErr)
 		}
 
-		return
+		return nil
 	}
 
 	// Full benchmark path.
-	if err := benchmarkMgr.UpdateBenchmarks(ctx); err != nil {
-		agentstate.Logger.Fatal("Failed to submit initial benchmarks", "error", err)
-	}
+	return benchmarkMgr.UpdateBenchmarks(ctx)
 }
 
 // StartAgent initializes and starts the CipherSwarm agent.
@@ -225,6 +263,9 @@ func StartAgent() {
 		agentstate.Logger.Fatal("Failed to rebuild API client with server settings", "error", err)
 	}
 
+	// Install the server-designated hashcat before devices are enumerated with it.
+	crackerInstalled := updateCracker(ctx)
+
 	setupDevicesAndMetadata(ctx)
 
 	// Start heartbeat loop early so the UI can see the agent is connected.
@@ -232,9 +273,10 @@ func StartAgent() {
 
 	// Start background system performance monitoring (no-op when disabled).
 	startPerformanceMonitor(ctx)
+	startLocalServers(ctx)
 
 	benchmarksNeeded := initManagers()
-	runBenchmarkPhase(ctx, benchmarksNeeded)
+	runBenchmarkPhase(ctx, benchmarksNeeded, crackerInstalled)
 
 	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityStarting)
 
@@ -276,8 +318,9 @@ func agentIsIdle() bool {
 
 // startPerformanceMonitor launches the background system performance monitor
 // when enabled in configuration. It samples host (and optionally per-process)
-// metrics on the configured interval and reports them through the agent logger,
-// exiting when ctx is cancelled. No-op when monitoring is disabled.
+// metrics on the configured interval and reports them through the agent logger
+// and the metrics registry, exiting when ctx is cancelled. No-op when monitoring
+// is disabled.
 func startPerformanceMonitor(ctx context.Context) {
 	if !agentstate.State.PerformanceMonitoringEnabled {
 		agentstate.Logger.Debug("Performance monitoring disabled")
@@ -290,6 +333,8 @@ func startPerformanceMonitor(ctx context.Context) {
 		CollectProcess: agentstate.State.CollectProcessMetrics,
 		PIDProvider:    hashcatOrSelfPID,
 		Log:            agentstate.Logger.Info,
+		Observe:        metrics.Default.ObserveSystem,
+		Sinks:          buildMonitorSinks(),
 	})
 
 	agentstate.Logger.Info("Starting performance monitor",
@@ -299,10 +344,68 @@ func startPerformanceMonitor(ctx context.Context) {
 	go mon.Run(ctx)
 }
 
+// otlpExportTimeout bounds each OTLP export so a slow collector cannot stall
+// performance sampling.
+const otlpExportTimeout = 10 * time.Second
+
+// buildMonitorSinks creates the configured performance sinks. A sink that
+// cannot be set up is logged and skipped so it does not block the others.
+func buildMonitorSinks() []monitor.Sink {
+	var sinks []monitor.Sink
+
+	add := func(sink monitor.Sink, err error) {
+		if err != nil {
+			agentstate.Logger.Error("Failed to set up performance sink; continuing without it", "error", err)
+			return
+		}
+
+		agentstate.Logger.Info("Writing performance samples to sink", "sink", sink.Name())
+		sinks = append(sinks, sink)
+	}
+
+	if path := agentstate.State.PerformanceJSONLPath; path != "" {
+		const bytesPerMB = 1 << 20
+
+		add(monitor.NewJSONLSink(
+			path,
+			int64(agentstate.State.PerformanceJSONLMaxSizeMB)*bytesPerMB,
+			agentstate.State.PerformanceJSONLMaxBackups,
+		))
+	}
+
+	if addr := agentstate.State.PerformanceStatsDAddress; addr != "" {
+		add(monitor.NewStatsDSink(addr, agentstate.State.PerformanceStatsDPrefix))
+	}
+
+	if endpoint := agentstate.State.PerformanceOTLPEndpoint; endpoint != "" {
+		add(monitor.NewOTLPSink(endpoint, otlpResource(), otlpExportTimeout))
+	}
+
+	return sinks
+}
+
+// otlpResource returns the resource attributes identifying this agent in OTLP
+// exports.
+func otlpResource() map[string]string {
+	resource := map[string]string{
+		"service.name":         "cipherswarm-agent",
+		"service.version":      agentstate.State.AgentVersion,
+		"cipherswarm.agent.id": strconv.FormatInt(agentstate.State.AgentID, 10),
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		resource["host.name"] = hostname
+	}
+
+	return resource
+}
+
 // hashcatOrSelfPID selects which process the performance monitor samples: the
 // running hashcat job when one is active (satisfying issue #15's requirement for
 // hashcat-specific process metrics), otherwise the agent's own process — which
-// keeps the <1% monitoring-overhead target observable while idle.
+// keeps the <1% monitoring-overhead target observable while idle. When several
+// task slots each run hashcat there is no single job to sample, so the agent's
+// own process is sampled then too.
 func hashcatOrSelfPID() (int32, bool) {
 	if pid := agentstate.State.GetHashcatPID(); pid > 0 {
 		return pid, true
@@ -367,7 +470,8 @@ func stopBackgroundBenchmarks() bool {
 
 // initManagers rebuilds the benchmark and task managers from the current API
 // client, server configuration, and the package-level deviceMgr — wiring
-// DeviceConfig and task.Config. Shared by StartAgent and handleReload. It reads
+// DeviceConfig and task.Config — and the task pool when device groups are
+// configured. Shared by StartAgent and handleReload. It reads
 // agentstate (the single legitimate reader) and returns whether the server says
 // benchmarks are needed.
 func initManagers() bool {
@@ -384,21 +488,49 @@ func initManagers() bool {
 		EnableAdditionalHashTypes: agentstate.State.EnableAdditionalHashTypes,
 	}
 
-	taskMgr = task.NewManager(client.Tasks(), client.Attacks())
-	taskMgr.DeviceConfig = dc
-	taskMgr.Config = task.Config{
+	// SetupSharedState has already validated the limit.
+	prefetchRateLimit, _ := bandwidth.ParseRate(agentstate.State.PrefetchBandwidthLimit)
+
+	taskCfg := task.Config{
 		HashlistPath:           agentstate.State.HashlistPath,
 		RestoreFilePath:        agentstate.State.RestoreFilePath,
 		FilePath:               agentstate.State.FilePath,
+		PreprocessorsPath:      agentstate.State.PreprocessorsPath,
+		ResourceCache:          resourceCache,
+		PrefetchRateLimit:      prefetchRateLimit,
 		OutPath:                agentstate.State.OutPath,
 		ZapsPath:               agentstate.State.ZapsPath,
+		MinFreeDisk:            int64(agentstate.State.MinFreeDiskMB) << 20,
+		JournalPath:            agentstate.State.JournalPath,
+		ActiveTaskFile:         agentstate.State.ActiveTaskFile,
 		StatusTimer:            agentstate.State.StatusTimer,
 		RetainZapsOnCompletion: agentstate.State.RetainZapsOnCompletion,
+		GPUTempThreshold:       agentstate.State.GPUTempThreshold,
+		GPUTempTripCount:       agentstate.State.GPUTempTripCount,
+		GPUTempAction:          task.ThermalAction(agentstate.State.GPUTempAction),
+		StallTimeout:           agentstate.State.StallTimeout,
+		StallMaxRestarts:       agentstate.State.StallMaxRestarts,
 	}
 
+	taskMgr = task.NewManager(client.Tasks(), client.Attacks())
+	taskMgr.DeviceConfig = dc
+	taskMgr.Config = taskCfg
+
 	// Log warnings for unrecognized device IDs.
 	dc.WarnInvalidDevices(agentstate.Logger.Warn)
 
+	activePool.Store(nil)
+
+	if groups := resolveDeviceGroups(agentstate.State.DeviceGroups, dc); len(groups) > 0 {
+		p, err := newTaskPool(client, dc, taskCfg, groups)
+		if err != nil {
+			agentstate.Logger.Error("Failed to set up task slots, running one task at a time", "error", err)
+		} else {
+			agentstate.Logger.Info("Running one task per device group", "slots", len(groups), "groups", groups)
+			activePool.Store(p)
+		}
+	}
+
 	return cfg.BenchmarksNeeded
 }
 
@@ -445,9 +577,9 @@ func sleepWithContext(ctx context.Context, d time.Duration) bool {
 
 // startHeartbeatLoop runs the heartbeat loop with exponential backoff on failures.
 // On consecutive failures, it backs off exponentially up to a maximum multiplier.
+// Successes and failures are recorded in agentstate for the status endpoint.
 // cancel is invoked when the server reports StateError, triggering agent shutdown.
 func startHeartbeatLoop(ctx context.Context, cancel context.CancelFunc) {
-	consecutiveFailures := 0
 	maxBackoffMultiplier := agentstate.State.MaxHeartbeatBackoff
 
 	for {
@@ -455,7 +587,7 @@ func startHeartbeatLoop(ctx context.Context, cancel context.CancelFunc) {
 		baseInterval := time.Duration(getConfiguration().Config.AgentUpdateInterval) * time.Second
 
 		if err != nil {
-			consecutiveFailures++
+			consecutiveFailures := agentstate.State.RecordHeartbeatFailure()
 			backoff := calculateHeartbeatBackoff(baseInterval, consecutiveFailures, maxBackoffMultiplier)
 
 			if apierrors.IsCircuitOpen(err) {
@@ -473,11 +605,11 @@ func startHeartbeatLoop(ctx context.Context, cancel context.CancelFunc) {
 			continue
 		}
 
-		if consecutiveFailures > 0 {
-			agentstate.Logger.Info("Heartbeat recovered after failures", "failures", consecutiveFailures)
+		if failures := agentstate.State.GetHeartbeatFailures(); failures > 0 {
+			agentstate.Logger.Info("Heartbeat recovered after failures", "failures", failures)
 		}
 
-		consecutiveFailures = 0
+		agentstate.State.RecordHeartbeatSuccess(time.Now())
 
 		if sleepWithContext(ctx, baseInterval) {
 			return
@@ -512,13 +644,32 @@ func startAgentLoop(ctx context.Context) {
 			}
 		}
 
+		// A reload rebuilds the task pool, so it waits for running pool tasks to finish.
 		if agentstate.State.GetReload() {
-			handleReload(ctx)
-			benchmarkRetryFailures = 0 // Reset retry counter after reload
+			if p := activePool.Load(); p != nil && !p.idle() {
+				agentstate.Logger.Debug("Reload pending, waiting for running tasks to finish")
+			} else {
+				handleReload(ctx)
+				benchmarkRetryFailures = 0 // Reset retry counter after reload
+			}
+		}
+
+		p := activePool.Load()
+
+		// Deliver cracks journaled by earlier tasks (or a previous run) that
+		// could not reach the server at the time. Journals of running pool
+		// tasks are open in their slot's manager, so replay only when idle.
+		if p == nil || p.idle() {
+			taskMgr.ReplayJournals(ctx)
 		}
 
 		if !agentstate.State.GetJobCheckingStopped() {
-			handleNewTask(ctx)
+			switch {
+			case p == nil:
+				handleNewTask(ctx)
+			case !agentstate.State.GetReload():
+				handlePoolTasks(ctx, p)
+			}
 		}
 
 		sleepTime := time.Duration(getConfiguration().Config.AgentUpdateInterval) * time.Second
@@ -557,6 +708,13 @@ func handleReload(ctx context.Context) {
 	// the restart below is skipped to avoid overlapping GPU work.
 	canRestartBg := stopBackgroundBenchmarks()
 
+	// Replacing hashcat while a background benchmark may still be running it is
+	// unsafe, so the update check waits for the next reload in that case.
+	var crackerInstalled *crackerInstall
+	if canRestartBg {
+		crackerInstalled = updateCracker(ctx)
+	}
+
 	// Re-enumerate devices in case hashcat was upgraded or drivers changed.
 	// Always create a fresh DeviceManager so stale device data is never reused.
 	deviceMgr = &devices.DeviceManager{}
@@ -573,13 +731,24 @@ func handleReload(ctx context.Context) {
 	// Recreate managers with new API client sub-clients and updated configs.
 	benchmarksNeeded := initManagers()
 
-	if benchmarksNeeded {
+	if benchmarksNeeded || crackerInstalled != nil {
 		agentstate.State.SetCurrentActivity(agentstate.CurrentActivityBenchmarking)
 		// Server-initiated reload must re-run benchmarks (not use stale cache).
 		// Use defer to ensure the flag is always reset even if UpdateBenchmarks panics.
 		agentstate.State.SetForceBenchmarkRun(true)
 		defer func() { agentstate.State.SetForceBenchmarkRun(false) }()
-		if err := benchmarkMgr.UpdateBenchmarks(ctx); err != nil {
+		err := benchmarkMgr.UpdateBenchmarks(ctx)
+		if err != nil && crackerInstalled != nil {
+			agentstate.Logger.Error("First benchmark with the new hashcat failed, rolling back",
+				"version", crackerInstalled.version, "error", err)
+
+			if rbErr := rollBackCracker(ctx, crackerInstalled, err); rbErr != nil {
+				agentstate.Logger.Error("Failed to roll back hashcat", "error", rbErr)
+			} else {
+				err = benchmarkMgr.UpdateBenchmarks(ctx)
+			}
+		}
+		if err != nil {
 			agentstate.Logger.Error("Benchmark update failed during reload, task processing paused",
 				"error", err)
 			cserrors.SendAgentError(
@@ -610,13 +779,35 @@ func handleNewTask(ctx context.Context) {
 		return
 	}
 
-	// GetNewTask only polls the API — it does not use the GPU — so background
+	slot := newTaskSlot(0, nil, taskMgr)
+	soloSlot.Store(slot)
+	defer soloSlot.Store(nil)
+
+	// A task interrupted by a restart takes priority over new work.
+	if t, pending := interruptedTask(ctx, slot.mgr); pending {
+		if t != nil {
+			runTaskExclusively(ctx, slot, t)
+		}
+
+		return
+	}
+
+	// fetchNewTask only polls the API — it does not use the GPU — so background
 	// benchmarks keep running undisturbed during the poll.
-	newTask, err := taskMgr.GetNewTask(ctx)
+	if newTask := fetchNewTask(ctx, slot.mgr); newTask != nil {
+		runTaskExclusively(ctx, slot, newTask)
+	}
+}
+
+// fetchNewTask polls the server for a new task. It returns nil when no task is
+// available or the poll failed; failures are logged and followed by the
+// configured failure sleep.
+func fetchNewTask(ctx context.Context, mgr *task.Manager) *api.Task {
+	newTask, err := mgr.GetNewTask(ctx)
 	if err != nil {
 		if errors.Is(err, task.ErrNoTaskAvailable) {
 			agentstate.Logger.Debug("No new task available")
-			return
+			return nil
 		}
 
 		if apierrors.IsCircuitOpen(err) {
@@ -626,30 +817,84 @@ func handleNewTask(ctx context.Context) {
 		}
 		sleepWithContext(ctx, agentstate.State.SleepOnFailure)
 
-		return
+		return nil
 	}
 
-	if newTask == nil {
-		return
-	}
+	return newTask
+}
 
-	// A real task is about to run — stop background benchmarks now to avoid GPU contention.
+// runTaskExclusively processes a task with background benchmarks stopped, to
+// avoid GPU contention, and restarts them afterwards unless the prior
+// benchmark goroutine did not stop cleanly.
+func runTaskExclusively(ctx context.Context, slot *taskSlot, t *api.Task) {
 	canRestartBg := stopBackgroundBenchmarks()
-	processTask(ctx, newTask)
+	processTask(ctx, slot, t)
 
-	// Restart background benchmarks after the task completes, unless the prior
-	// goroutine did not stop cleanly above.
 	if canRestartBg {
 		startBackgroundBenchmarks(ctx)
 	}
 }
 
-func processTask(ctx context.Context, t *api.Task) {
-	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityCracking)
+// interruptedTask returns the task recorded as in progress when the agent last
+// stopped, provided the server still has it assigned; hashcat continues from
+// the task's restore file. A task that can no longer be resumed has its record
+// and local files discarded. pending is true if a recorded task was found, in
+// which case no new task should be fetched; t is nil when the record could not
+// be checked yet.
+func interruptedTask(ctx context.Context, mgr *task.Manager) (t *api.Task, pending bool) {
+	record, err := mgr.LoadActiveTask()
+	if err != nil {
+		agentstate.Logger.Error("Failed to read active task record, discarding it", "error", err)
+		mgr.ClearActiveTask()
+
+		return nil, false
+	}
+
+	if record == nil {
+		return nil, false
+	}
+
+	agentstate.Logger.Info("Found interrupted task, checking whether it can be resumed",
+		"task_id", record.TaskID, "attack_id", record.AttackID)
+
+	t, err = mgr.RevalidateActiveTask(ctx, record)
+	if err != nil {
+		if errors.Is(err, task.ErrTaskNotFound) || errors.Is(err, task.ErrTaskNotResumable) {
+			agentstate.Logger.Info("Interrupted task can no longer be resumed, discarding it",
+				"task_id", record.TaskID, "reason", err)
+			mgr.ClearActiveTask()
+			task.CleanupTaskFiles(record.AttackID, mgr.Config.HashlistPath, mgr.Config.RestoreFilePath)
+
+			return nil, false
+		}
+
+		agentstate.Logger.Warn("Could not revalidate interrupted task, will retry",
+			"task_id", record.TaskID, "error", err)
+
+		return nil, true
+	}
+
+	agentstate.Logger.Info("Resuming interrupted task", "task_id", t.Id, "attack_id", t.AttackId)
+
+	return t, true
+}
+
+// processTask takes a task through acceptance, downloads, and execution on the
+// given slot, abandoning it on failure.
+func processTask(ctx context.Context, slot *taskSlot, t *api.Task) {
+	slot.taskID.Store(t.Id)
+	slot.attackID.Store(t.AttackId)
+
+	defer func() {
+		slot.taskID.Store(0)
+		slot.attackID.Store(0)
+	}()
+
+	slot.setActivity(agentstate.CurrentActivityCracking)
 
 	display.NewTask(t)
 
-	attack, err := taskMgr.GetAttackParameters(ctx, t.AttackId)
+	attack, err := slot.mgr.GetAttackParameters(ctx, t.AttackId)
 	if err != nil || attack == nil {
 		agentstate.Logger.Error("Failed to get attack parameters", "error", err)
 
@@ -659,9 +904,9 @@ func processTask(ctx context.Context, t *api.Task) {
 		}
 
 		cserrors.SendAgentError(ctx, errMsg, t, api.SeverityFatal)
-		agentstate.State.SetCurrentActivity(agentstate.CurrentActivityWaiting)
+		slot.setActivity(agentstate.CurrentActivityWaiting)
 		//nolint:contextcheck // must-complete: prevents task starvation on server
-		taskMgr.AbandonTask(context.Background(), t)
+		slot.mgr.AbandonTask(context.Background(), t)
 		sleepWithContext(ctx, agentstate.State.SleepOnFailure)
 
 		return
@@ -669,15 +914,34 @@ func processTask(ctx context.Context, t *api.Task) {
 
 	// cleanupFiles removes this attack's local hashlist and restore files.
 	cleanupFiles := func() {
-		task.CleanupTaskFiles(attack.Id, taskMgr.Config.HashlistPath, taskMgr.Config.RestoreFilePath)
+		task.CleanupTaskFiles(attack.Id, slot.mgr.Config.HashlistPath, slot.mgr.Config.RestoreFilePath)
 	}
 
 	display.NewAttack(attack)
 
-	err = taskMgr.AcceptTask(ctx, t)
+	if err := slot.mgr.CheckDiskSpace(ctx, attack); err != nil {
+		agentstate.Logger.Error("Refusing task, not enough disk space", "task_id", t.Id, "error", err)
+
+		var opts []cserrors.ErrorOption
+
+		var diskErr *task.DiskSpaceError
+		if errors.As(err, &diskErr) {
+			opts = diskErr.ReportOptions()
+		}
+
+		cserrors.SendAgentError(ctx, err.Error(), t, api.SeverityMajor, opts...)
+		slot.setActivity(agentstate.CurrentActivityWaiting)
+		//nolint:contextcheck // must-complete: prevents task starvation on server
+		slot.mgr.AbandonTask(context.Background(), t)
+		sleepWithContext(ctx, agentstate.State.SleepOnFailure)
+
+		return
+	}
+
+	err = slot.mgr.AcceptTask(ctx, t)
 	if err != nil {
 		agentstate.Logger.Error("Failed to accept task", "task_id", t.Id, "error", err)
-		agentstate.State.SetCurrentActivity(agentstate.CurrentActivityWaiting)
+		slot.setActivity(agentstate.CurrentActivityWaiting)
 
 		if errors.Is(err, task.ErrTaskAcceptNotFound) {
 			// Task vanished before we could accept it — normal race condition.
@@ -688,7 +952,7 @@ func processTask(ctx context.Context, t *api.Task) {
 		}
 
 		//nolint:contextcheck // must-complete: prevents task starvation on server
-		taskMgr.AbandonTask(context.Background(), t)
+		slot.mgr.AbandonTask(context.Background(), t)
 		cleanupFiles()
 		sleepWithContext(ctx, agentstate.State.SleepOnFailure)
 
@@ -697,38 +961,106 @@ func processTask(ctx context.Context, t *api.Task) {
 
 	display.RunTaskAccepted(t)
 
-	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityDownloading)
+	if err := slot.mgr.SaveActiveTask(t); err != nil {
+		agentstate.Logger.Warn("Failed to record active task; it cannot be resumed after a restart", "error", err)
+	}
 
-	if err := task.DownloadFiles(ctx, attack, taskMgr.Config.FilePath, taskMgr.Config.HashlistPath); err != nil {
-		agentstate.Logger.Error("Failed to download files", "error", err)
-		cserrors.SendAgentError(ctx, err.Error(), t, api.SeverityFatal)
-		agentstate.State.SetCurrentActivity(agentstate.CurrentActivityWaiting)
-		//nolint:contextcheck // must-complete: prevents task starvation on server
-		taskMgr.AbandonTask(context.Background(), t)
-		cleanupFiles()
-		sleepWithContext(ctx, agentstate.State.SleepOnFailure)
+	defer func() {
+		// Keep the record when the agent is shutting down so the task is
+		// resumed after a restart; otherwise the task is finished with.
+		if ctx.Err() == nil {
+			slot.mgr.ClearActiveTask()
+		}
+	}()
+
+	// releaseFiles unpins the task's cached resources so they may be evicted.
+	releaseFiles := func() {}
+	defer func() { releaseFiles() }()
+
+	// downloadFiles fetches the hashlist and attack resources, abandoning the task
+	// on failure. The hashlist is fetched again on resume because session cleanup
+	// removes it.
+	downloadFiles := func() bool {
+		slot.setActivity(agentstate.CurrentActivityDownloading)
+		cancelPrefetches()
+
+		downloadMu.Lock()
+		release, err := task.DownloadFiles(ctx, attack,
+			slot.mgr.Config.FilePath, slot.mgr.Config.PreprocessorsPath, slot.mgr.Config.HashlistPath,
+			slot.mgr.Config.ResourceCache)
+		downloadMu.Unlock()
+
+		if err != nil {
+			agentstate.Logger.Error("Failed to download files", "error", err)
+
+			var opts []cserrors.ErrorOption
+
+			var resourceErr *task.ResourceError
+			if errors.As(err, &resourceErr) {
+				opts = append(opts, cserrors.WithContext(map[string]any{"resource_id": resourceErr.ResourceID}))
+			}
+
+			cserrors.SendAgentError(ctx, err.Error(), t, api.SeverityFatal, opts...)
+			slot.setActivity(agentstate.CurrentActivityWaiting)
+			//nolint:contextcheck // must-complete: prevents task starvation on server
+			slot.mgr.AbandonTask(context.Background(), t)
+			cleanupFiles()
+			sleepWithContext(ctx, agentstate.State.SleepOnFailure)
+
+			return false
+		}
+
+		// A resumed task pins its resources afresh; drop the earlier pins.
+		releaseFiles()
+		releaseFiles = release
 
+		slot.setActivity(agentstate.CurrentActivityCracking)
+
+		return true
+	}
+
+	if !downloadFiles() {
 		return
 	}
 
-	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityCracking)
+	err = runTaskWithPrefetch(ctx, slot, t, attack)
+	for errors.Is(err, task.ErrTaskPaused) {
+		// Activity stays "cracking" while paused so further "stopped" heartbeats
+		// keep the pause in place rather than halting job checking.
+		agentstate.Logger.Info("Task paused, waiting for server to resume it", "task_id", t.Id)
+
+		if !task.WaitForResume(ctx, pausePollInterval) {
+			return
+		}
+
+		cserrors.SendAgentError(ctx, "Resuming paused task from checkpoint", t, api.SeverityInfo,
+			cserrors.WithContext(map[string]any{"task_status": string(api.Running)}))
 
-	if err := taskMgr.RunTask(ctx, t, attack); err != nil {
+		if !downloadFiles() {
+			return
+		}
+
+		err = runTaskWithPrefetch(ctx, slot, t, attack)
+	}
+
+	if err != nil {
 		// Note: RunTask returns nil from runAttackTask (which handles its own
 		// cleanup via sess.Cleanup()). This fallback only triggers for
-		// NewHashcatSession failures.
-		agentstate.State.SetCurrentActivity(agentstate.CurrentActivityWaiting)
+		// NewHashcatSession failures and tasks abandoned, either after repeated
+		// stalls or because their range lies past the attack keyspace.
+		slot.setActivity(agentstate.CurrentActivityWaiting)
 		cleanupFiles()
 
 		return
 	}
 
-	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityWaiting)
+	slot.setActivity(agentstate.CurrentActivityWaiting)
 }
 
 // heartbeat sends a heartbeat to the server and processes the response.
 // It returns an error if the heartbeat failed. On StateError, it calls cancel
-// to initiate agent shutdown.
+// to initiate agent shutdown. StateStopped while cracking requests a checkpoint
+// pause, which any later non-stopped response lifts.
 func heartbeat(ctx context.Context, cancel context.CancelFunc) error {
 	if agentstate.State.ExtraDebugging {
 		agentstate.Logger.Debug("Sending heartbeat")
@@ -739,6 +1071,13 @@ func heartbeat(ctx context.Context, cancel context.CancelFunc) error {
 		return err
 	}
 
+	// Any directive other than "stopped" lifts a pause; the task loop then
+	// resumes the checkpointed task from its restore file.
+	if (state == nil || *state != api.StateStopped) && agentstate.State.GetPauseRequested() {
+		agentstate.Logger.Info("Server lifted pause, resuming task")
+		agentstate.State.SetPauseRequested(false)
+	}
+
 	if state == nil {
 		// No state change needed (HTTP 204 or similar)
 		return nil
@@ -755,7 +1094,15 @@ func heartbeat(ctx context.Context, cancel context.CancelFunc) error {
 			agentstate.State.SetReload(true)
 		}
 	case api.StateStopped:
-		if agentstate.State.GetCurrentActivity() != agentstate.CurrentActivityCracking {
+		if agentstate.State.GetCurrentActivity() == agentstate.CurrentActivityCracking {
+			// A stop while cracking pauses the task at a hashcat checkpoint instead
+			// of discarding its progress.
+			if !agentstate.State.GetPauseRequested() {
+				agentstate.Logger.Info("Server requested pause, checkpointing running task")
+			}
+
+			agentstate.State.SetPauseRequested(true)
+		} else {
 			agentstate.State.SetCurrentActivity(agentstate.CurrentActivityStopping)
 			agentstate.Logger.Debug("Agent is stopped, stopping processing")
 
@@ -58,6 +58,7 @@ func saveAndRestoreState(t *testing.T) func() {
 	// Save synchronized fields via getters
 	origReload := agentstate.State.GetReload()
 	origJobCheckingStopped := agentstate.State.GetJobCheckingStopped()
+	origPauseRequested := agentstate.State.GetPauseRequested()
 	origBenchmarksSubmitted := agentstate.State.GetBenchmarksSubmitted()
 	origCurrentActivity := agentstate.State.GetCurrentActivity()
 
@@ -89,6 +90,7 @@ func saveAndRestoreState(t *testing.T) func() {
 
 		agentstate.State.SetReload(origReload)
 		agentstate.State.SetJobCheckingStopped(origJobCheckingStopped)
+		agentstate.State.SetPauseRequested(origPauseRequested)
 		agentstate.State.SetBenchmarksSubmitted(origBenchmarksSubmitted)
 		agentstate.State.SetCurrentActivity(origCurrentActivity)
 	}
@@ -128,23 +130,64 @@ func TestStartPerformanceMonitor(t *testing.T) {
 	})
 }
 
+func TestBuildMonitorSinks(t *testing.T) {
+	origPath := agentstate.State.PerformanceJSONLPath
+	origStatsD := agentstate.State.PerformanceStatsDAddress
+	origOTLP := agentstate.State.PerformanceOTLPEndpoint
+	t.Cleanup(func() {
+		agentstate.State.PerformanceJSONLPath = origPath
+		agentstate.State.PerformanceStatsDAddress = origStatsD
+		agentstate.State.PerformanceOTLPEndpoint = origOTLP
+	})
+
+	t.Run("no sinks configured", func(t *testing.T) {
+		agentstate.State.PerformanceJSONLPath = ""
+		agentstate.State.PerformanceStatsDAddress = ""
+		agentstate.State.PerformanceOTLPEndpoint = ""
+		assert.Empty(t, buildMonitorSinks())
+	})
+
+	t.Run("skips sinks that cannot be set up", func(t *testing.T) {
+		agentstate.State.PerformanceJSONLPath = filepath.Join(t.TempDir(), "metrics", "perf.jsonl")
+		agentstate.State.PerformanceStatsDAddress = ""
+		agentstate.State.PerformanceOTLPEndpoint = "not a url"
+
+		sinks := buildMonitorSinks()
+		require.Len(t, sinks, 1, "the invalid OTLP endpoint should be skipped")
+		assert.Equal(t, "jsonl", sinks[0].Name())
+		require.NoError(t, sinks[0].Close())
+	})
+}
+
 func TestHashcatOrSelfPID(t *testing.T) {
-	orig := agentstate.State.GetHashcatPID()
-	t.Cleanup(func() { agentstate.State.SetHashcatPID(orig) })
+	agentstate.State.ResetHashcatPIDs()
+	t.Cleanup(agentstate.State.ResetHashcatPIDs)
+
+	wantSelf := int32(os.Getpid()) //nolint:gosec // G115 - a process ID always fits in int32
 
 	t.Run("returns hashcat PID when a job is running", func(t *testing.T) {
-		agentstate.State.SetHashcatPID(54321)
+		agentstate.State.SetHashcatPID("attack-7", 54321)
+		t.Cleanup(agentstate.State.ResetHashcatPIDs)
+
 		pid, ok := hashcatOrSelfPID()
 		assert.True(t, ok)
 		assert.Equal(t, int32(54321), pid, "should sample the running hashcat process")
 	})
 
 	t.Run("falls back to the agent process when idle", func(t *testing.T) {
-		agentstate.State.SetHashcatPID(0)
-		wantPID := int32(os.Getpid()) //nolint:gosec // G115 - a process ID always fits in int32
 		pid, ok := hashcatOrSelfPID()
 		assert.True(t, ok)
-		assert.Equal(t, wantPID, pid, "should fall back to the agent's own PID when idle")
+		assert.Equal(t, wantSelf, pid, "should fall back to the agent's own PID when idle")
+	})
+
+	t.Run("falls back to the agent process when several slots run hashcat", func(t *testing.T) {
+		agentstate.State.SetHashcatPID("attack-7-slot1", 54321)
+		agentstate.State.SetHashcatPID("attack-8-slot2", 54322)
+		t.Cleanup(agentstate.State.ResetHashcatPIDs)
+
+		pid, ok := hashcatOrSelfPID()
+		assert.True(t, ok)
+		assert.Equal(t, wantSelf, pid, "no single hashcat process to sample")
 	})
 }
 
@@ -728,7 +771,8 @@ func TestHeartbeat_StateStopped(t *testing.T) {
 }
 
 // TestHeartbeat_StateStopped_WhileCracking verifies that a StateStopped
-// heartbeat response does NOT change state when the agent is cracking.
+// heartbeat response while cracking requests a checkpoint pause instead of
+// stopping job checking.
 func TestHeartbeat_StateStopped_WhileCracking(t *testing.T) {
 	cleanup := saveAndRestoreState(t)
 	defer cleanup()
@@ -743,6 +787,7 @@ func TestHeartbeat_StateStopped_WhileCracking(t *testing.T) {
 
 	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityCracking)
 	agentstate.State.SetJobCheckingStopped(false)
+	agentstate.State.SetPauseRequested(false)
 
 	cancel := func() {} // no-op cancel for test
 	err := heartbeat(context.Background(), cancel)
@@ -752,9 +797,39 @@ func TestHeartbeat_StateStopped_WhileCracking(t *testing.T) {
 		agentstate.State.GetJobCheckingStopped(),
 		"StateStopped during cracking should NOT set JobCheckingStopped",
 	)
+	assert.True(t, agentstate.State.GetPauseRequested(), "StateStopped during cracking should request a pause")
 	assert.Equal(t, agentstate.CurrentActivityCracking, agentstate.State.GetCurrentActivity())
 }
 
+// TestHeartbeat_LiftsPause verifies that a non-stopped heartbeat response
+// clears a pending pause so the paused task resumes.
+func TestHeartbeat_LiftsPause(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{name: "no content", setup: func() { testhelpers.MockHeartbeatNoContent(123) }},
+		{name: "active", setup: func() { testhelpers.MockHeartbeatResponse(123, api.StateActive) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(saveAndRestoreState(t))
+			t.Cleanup(testhelpers.SetupHTTPMock())
+			t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+
+			tt.setup()
+
+			agentstate.State.SetCurrentActivity(agentstate.CurrentActivityCracking)
+			agentstate.State.SetPauseRequested(true)
+
+			err := heartbeat(context.Background(), func() {})
+			require.NoError(t, err)
+			assert.False(t, agentstate.State.GetPauseRequested(), "pause should be lifted")
+		})
+	}
+}
+
 // TestHeartbeat_StateError verifies that a StateError heartbeat response
 // calls the cancel function to trigger shutdown.
 func TestHeartbeat_StateError(t *testing.T) {
@@ -879,7 +954,7 @@ func TestProcessTask_AcceptFailure(t *testing.T) {
 			)
 
 			testTask := testhelpers.NewTestTask(123, 456)
-			processTask(context.Background(), testTask)
+			processTask(context.Background(), newTaskSlot(0, nil, taskMgr), testTask)
 
 			callInfo := httpmock.GetCallCountInfo()
 			assert.Equal(t, tt.wantAbandonCalls, callInfo[abandonCallKey], tt.wantAbandonMessage)
@@ -12,6 +12,7 @@ import (
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/internal/util"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/config"
 	cserrors "github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/devices"
@@ -178,6 +179,7 @@ func GetAgentConfiguration(ctx context.Context) error {
 	)
 
 	applyRecommendedSettings(agentConfig)
+	applyBandwidthSettings(agentConfig.Config)
 
 	if agentConfig.Config.UseNativeHashcat {
 		if err := metadataProviderImpl.setNativeHashcatPath(ctx); err != nil {
@@ -210,6 +212,10 @@ func mapConfiguration(
 			AgentUpdateInterval: int64(util.UnwrapOr(agentCfg.AgentUpdateInterval, defaultAgentUpdateInterval)),
 			BackendDevices:      util.UnwrapOr(agentCfg.BackendDevice, ""),
 			OpenCLDevices:       util.UnwrapOr(agentCfg.OpenclDevices, ""),
+
+			DownloadBandwidthLimit:       util.UnwrapOr(agentCfg.DownloadBandwidthLimit, ""),
+			DownloadBandwidthPerDownload: util.UnwrapOr(agentCfg.DownloadBandwidthPerDownload, ""),
+			DownloadBandwidthSchedule:    util.UnwrapOr(agentCfg.DownloadBandwidthSchedule, ""),
 		},
 		RecommendedTimeouts:       timeouts,
 		RecommendedRetry:          retry,
@@ -271,6 +277,45 @@ func applyRecommendedSettings(cfg agentConfiguration) {
 	}
 }
 
+// applyBandwidthSettings overrides the local download bandwidth caps with those
+// the server sets, then installs the result. Invalid server values are logged
+// and the local setting kept.
+func applyBandwidthSettings(cfg agentConfig) {
+	if cfg.DownloadBandwidthLimit == "" && cfg.DownloadBandwidthPerDownload == "" &&
+		cfg.DownloadBandwidthSchedule == "" {
+		return
+	}
+
+	if cfg.DownloadBandwidthLimit != "" {
+		if _, err := bandwidth.ParseRate(cfg.DownloadBandwidthLimit); err != nil {
+			agentstate.Logger.Warn("Ignoring invalid server download_bandwidth_limit",
+				"value", cfg.DownloadBandwidthLimit, "error", err)
+		} else {
+			agentstate.State.DownloadBandwidthLimit = cfg.DownloadBandwidthLimit
+		}
+	}
+
+	if cfg.DownloadBandwidthPerDownload != "" {
+		if _, err := bandwidth.ParseRate(cfg.DownloadBandwidthPerDownload); err != nil {
+			agentstate.Logger.Warn("Ignoring invalid server download_bandwidth_per_download",
+				"value", cfg.DownloadBandwidthPerDownload, "error", err)
+		} else {
+			agentstate.State.DownloadBandwidthPerDownload = cfg.DownloadBandwidthPerDownload
+		}
+	}
+
+	if cfg.DownloadBandwidthSchedule != "" {
+		if _, err := bandwidth.ParseSchedule(cfg.DownloadBandwidthSchedule); err != nil {
+			agentstate.Logger.Warn("Ignoring invalid server download_bandwidth_schedule",
+				"value", cfg.DownloadBandwidthSchedule, "error", err)
+		} else {
+			agentstate.State.DownloadBandwidthSchedule = cfg.DownloadBandwidthSchedule
+		}
+	}
+
+	config.ApplyBandwidthPolicy()
+}
+
 // UpdateAgentMetadata updates the agent's metadata and sends it to the CipherSwarm API.
 // It retrieves host information, device list, constructs the agent update request body,
 // and sends the updated metadata to the API. Logs relevant information and handles any API errors.
@@ -360,7 +405,8 @@ func getDevicesList(_ context.Context, dm *devices.DeviceManager) ([]string, err
 // It returns the agent's state object (or nil for no state change) and an error if the heartbeat failed.
 func SendHeartBeat(ctx context.Context) (*api.State, error) {
 	activity := string(agentstate.State.GetCurrentActivity())
-	resp, err := agentstate.State.GetAPIClient().Agents().SendHeartbeat(ctx, agentstate.State.AgentID, activity)
+	resp, err := agentstate.State.GetAPIClient().Agents().SendHeartbeat(
+		ctx, agentstate.State.AgentID, activity, heartbeatSlots())
 	if err != nil {
 		handleHeartbeatError(ctx, err)
 
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/devices"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
 )
@@ -685,6 +686,30 @@ func TestApplyRecommendedSettings_NilKeepsDefaults(t *testing.T) {
 	require.Equal(t, 5, agentstate.State.CircuitBreakerFailureThreshold)
 }
 
+// TestApplyBandwidthSettings_ServerOverrides verifies server bandwidth caps
+// replace local ones, and invalid or absent values leave them alone.
+func TestApplyBandwidthSettings_ServerOverrides(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	agentstate.State.DownloadBandwidthLimit = "100Mbit"
+	agentstate.State.DownloadBandwidthPerDownload = "10Mbit"
+
+	cfg := mapConfiguration(1, api.AdvancedAgentConfiguration{
+		DownloadBandwidthLimit:    new("20Mbit"),
+		DownloadBandwidthSchedule: new("every day"),
+	}, false, nil, nil, nil)
+
+	applyBandwidthSettings(cfg.Config)
+
+	require.Equal(t, "20Mbit", agentstate.State.DownloadBandwidthLimit)
+	require.Equal(t, "10Mbit", agentstate.State.DownloadBandwidthPerDownload, "an absent value keeps the local setting")
+	require.Empty(t, agentstate.State.DownloadBandwidthSchedule, "an invalid value is ignored")
+
+	policy := bandwidth.CurrentPolicy()
+	require.Equal(t, bandwidth.Rate(2_500_000), policy.Limit)
+	require.Equal(t, bandwidth.Rate(1_250_000), policy.PerDownload)
+}
+
 // TestMapConfiguration_NilRecommendedSettings verifies nil pointers are preserved.
 func TestMapConfiguration_NilRecommendedSettings(t *testing.T) {
 	config := api.AdvancedAgentConfiguration{}
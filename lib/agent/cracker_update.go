@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/internal/util"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cracker"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+)
+
+// crackerInstall records a hashcat installation made by updateCracker so it can
+// be rolled back if the first benchmark with the new binary fails.
+type crackerInstall struct {
+	version      string
+	previousPath string // HashcatPath before the install
+}
+
+// updateCracker asks the server which hashcat release this agent should run and
+// installs it when it differs from the current one. Returns the install, or nil
+// when nothing was installed. A failed check or install is logged (and reported
+// to the server) and the agent keeps its current hashcat.
+func updateCracker(ctx context.Context) *crackerInstall {
+	if getConfiguration().Config.UseNativeHashcat {
+		agentstate.Logger.Debug("Using native hashcat, skipping hashcat update check")
+		return nil
+	}
+
+	current, err := cracker.GetCurrentHashcatVersion(ctx)
+	if err != nil {
+		agentstate.Logger.Debug("No usable hashcat installed", "error", err)
+	}
+
+	resp, err := agentstate.State.GetAPIClient().Crackers().CheckForCrackerUpdate(ctx, runtime.GOOS, current)
+	if err != nil {
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			agentstate.Logger.Debug("Server does not designate a hashcat release")
+			return nil
+		}
+
+		agentstate.Logger.Warn("Failed to check for a hashcat update", "error", err)
+
+		return nil
+	}
+
+	update := resp.JSON200
+	if update == nil || !update.Available {
+		agentstate.Logger.Debug("Hashcat is up to date", "version", current)
+		return nil
+	}
+
+	previousActivity := agentstate.State.GetCurrentActivity()
+	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityUpdating)
+	defer agentstate.State.SetCurrentActivity(previousActivity)
+
+	install, err := installCracker(ctx, update)
+	if err != nil {
+		//nolint:errcheck // Error already logged and reported; the agent keeps the current hashcat
+		_ = cserrors.LogAndSendError(ctx, "Hashcat update failed, keeping the current version",
+			err, api.SeverityMajor, nil)
+
+		return nil
+	}
+
+	agentstate.Logger.Info("Installed hashcat", "version", install.version, "previous_version", current)
+
+	return install
+}
+
+// installCracker downloads, verifies, and installs the designated release.
+func installCracker(ctx context.Context, update *api.CrackerUpdate) (*crackerInstall, error) {
+	downloadURL := util.UnwrapOr(update.DownloadUrl, "")
+	if downloadURL == "" {
+		return nil, errors.New("server designated a hashcat update without a download URL")
+	}
+
+	checksum, err := downloader.ParseChecksum(util.UnwrapOr(update.Checksum, ""))
+	if err != nil {
+		return nil, fmt.Errorf("parsing hashcat checksum: %w", err)
+	}
+
+	if checksum.IsZero() {
+		return nil, errors.New("server designated a hashcat update without a checksum")
+	}
+
+	agentstate.Logger.Info("Downloading hashcat", "version", util.UnwrapOr(update.LatestVersion, ""), "url", downloadURL)
+
+	downloadPath := filepath.Join(agentstate.State.CrackersPath, "hashcat-download.7z")
+	if err := downloader.DownloadFile(ctx, downloadURL, downloadPath, checksum); err != nil {
+		_ = os.Remove(downloadPath)
+		return nil, fmt.Errorf("downloading hashcat: %w", err)
+	}
+
+	downloader.RemoveChecksumSidecar(downloadPath)
+
+	archivePath, err := cracker.MoveArchiveFile(downloadPath)
+	if err != nil {
+		_ = os.Remove(downloadPath)
+		return nil, fmt.Errorf("moving hashcat archive: %w", err)
+	}
+
+	defer func() {
+		if err := os.Remove(archivePath); err != nil && !os.IsNotExist(err) {
+			agentstate.Logger.Warn("Failed to remove hashcat archive", "path", archivePath, "error", err)
+		}
+	}()
+
+	binaryPath, version, err := cracker.InstallHashcat(ctx, archivePath, util.UnwrapOr(update.ExecName, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	install := &crackerInstall{version: version, previousPath: agentstate.State.HashcatPath}
+	setHashcatPath(binaryPath)
+
+	return install, nil
+}
+
+// rollBackCracker restores the hashcat installation replaced by install.
+func rollBackCracker(ctx context.Context, install *crackerInstall, cause error) error {
+	if err := cracker.RestoreHashcatBackup(); err != nil {
+		return fmt.Errorf("restoring previous hashcat: %w", err)
+	}
+
+	setHashcatPath(install.previousPath)
+
+	cserrors.SendAgentError(ctx,
+		fmt.Sprintf("Benchmark failed with hashcat %s, rolled back to the previous version: %v", install.version, cause),
+		nil, api.SeverityMajor)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+// useCrackersMock installs an API client whose cracker update check is answered
+// by check, restoring the previous client and configuration afterwards.
+func useCrackersMock(t *testing.T, check func(ctx context.Context, os, version string) (*api.CheckForCrackerUpdateResponse, error)) {
+	t.Helper()
+
+	origClient := agentstate.State.GetAPIClient()
+	origConfig := getConfiguration()
+	t.Cleanup(func() {
+		agentstate.State.SetAPIClient(origClient)
+		SetConfiguration(origConfig)
+	})
+
+	agentstate.State.SetAPIClient(&api.MockClient{
+		CrackersImpl: &api.MockCrackersClient{CheckForCrackerUpdateFunc: check},
+		AgentsImpl:   &api.MockAgentsClient{},
+	})
+	SetConfiguration(agentConfiguration{})
+}
+
+func TestUpdateCracker(t *testing.T) {
+	cleanup := testhelpers.SetupMinimalTestState(1)
+	t.Cleanup(cleanup)
+
+	t.Run("native hashcat skips the check", func(t *testing.T) {
+		called := false
+		useCrackersMock(t, func(context.Context, string, string) (*api.CheckForCrackerUpdateResponse, error) {
+			called = true
+			return nil, nil
+		})
+		SetConfiguration(agentConfiguration{Config: agentConfig{UseNativeHashcat: true}})
+
+		assert.Nil(t, updateCracker(context.Background()))
+		assert.False(t, called)
+	})
+
+	t.Run("up to date installs nothing", func(t *testing.T) {
+		var gotOS string
+		useCrackersMock(t, func(_ context.Context, os, _ string) (*api.CheckForCrackerUpdateResponse, error) {
+			gotOS = os
+			return &api.CheckForCrackerUpdateResponse{JSON200: &api.CrackerUpdate{Available: false}}, nil
+		})
+
+		assert.Nil(t, updateCracker(context.Background()))
+		assert.Equal(t, runtime.GOOS, gotOS)
+	})
+
+	t.Run("server without the endpoint", func(t *testing.T) {
+		useCrackersMock(t, func(context.Context, string, string) (*api.CheckForCrackerUpdateResponse, error) {
+			return nil, &api.APIError{StatusCode: http.StatusNotFound, Message: "not found"}
+		})
+
+		assert.Nil(t, updateCracker(context.Background()))
+	})
+
+	t.Run("update without checksum is refused", func(t *testing.T) {
+		url := "https://example.com/hashcat.7z"
+		useCrackersMock(t, func(context.Context, string, string) (*api.CheckForCrackerUpdateResponse, error) {
+			return &api.CheckForCrackerUpdateResponse{JSON200: &api.CrackerUpdate{
+				Available:   true,
+				DownloadUrl: &url,
+			}}, nil
+		})
+		agentstate.State.SetCurrentActivity(agentstate.CurrentActivityStarting)
+
+		assert.Nil(t, updateCracker(context.Background()))
+		assert.Equal(t, agentstate.CurrentActivityStarting, agentstate.State.GetCurrentActivity(),
+			"activity should be restored after the update attempt")
+	})
+}
+
+func TestRollBackCracker_NoBackup(t *testing.T) {
+	cleanup := testhelpers.SetupMinimalTestState(1)
+	t.Cleanup(cleanup)
+
+	err := rollBackCracker(context.Background(), &crackerInstall{version: "v6.2.6"}, assert.AnError)
+	require.Error(t, err)
+}
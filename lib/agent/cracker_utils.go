@@ -23,6 +23,13 @@ func setNativeHashcatPath(ctx context.Context) error {
 	}
 
 	agentstate.Logger.Info("Found Hashcat binary", "path", binPath)
+	setHashcatPath(binPath)
+
+	return nil
+}
+
+// setHashcatPath points the agent at binPath and persists it so it survives a restart.
+func setHashcatPath(binPath string) {
 	agentstate.State.HashcatPath = binPath
 	viper.Set("hashcat_path", binPath)
 
@@ -30,6 +37,4 @@ func setNativeHashcatPath(ctx context.Context) error {
 		agentstate.Logger.Warn("Failed to persist hashcat path to config; path will be lost on restart",
 			"error", err, "hashcat_path", binPath)
 	}
-
-	return nil
 }
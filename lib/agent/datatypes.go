@@ -6,6 +6,10 @@ type agentConfig struct {
 	AgentUpdateInterval int64  `json:"agent_update_interval"     yaml:"agent_update_interval"`     // AgentUpdateInterval specifies the interval in seconds at which the agent should check in with the server.
 	BackendDevices      string `json:"backend_devices,omitempty" yaml:"backend_devices,omitempty"` // BackendDevices specifies the devices to use for the backend.
 	OpenCLDevices       string `json:"opencl_devices,omitempty"  yaml:"opencl_devices,omitempty"`  // OpenCLDevices specifies the OpenCL devices to use.
+
+	DownloadBandwidthLimit       string `json:"download_bandwidth_limit,omitempty"        yaml:"download_bandwidth_limit,omitempty"`        // DownloadBandwidthLimit is the server's global download rate cap; empty leaves the local setting.
+	DownloadBandwidthPerDownload string `json:"download_bandwidth_per_download,omitempty" yaml:"download_bandwidth_per_download,omitempty"` // DownloadBandwidthPerDownload is the server's per-download rate cap; empty leaves the local setting.
+	DownloadBandwidthSchedule    string `json:"download_bandwidth_schedule,omitempty"     yaml:"download_bandwidth_schedule,omitempty"`     // DownloadBandwidthSchedule is the server's time-of-day rate caps; empty leaves the local setting.
 }
 
 // RecommendedTimeouts holds server-recommended timeout settings (values in seconds).
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/metrics"
+)
+
+// localShutdownTimeout bounds how long in-flight requests to the local
+// endpoints may run after the agent context is cancelled.
+const localShutdownTimeout = 5 * time.Second
+
+// localReadHeaderTimeout guards the local listeners against slow clients.
+const localReadHeaderTimeout = 10 * time.Second
+
+// startLocalServers serves the optional local HTTP endpoints — Prometheus
+// metrics on metrics_listen, health and status on status_listen — until ctx is
+// cancelled. Endpoints configured with the same address share one listener. No-op
+// when neither is configured. A listener that cannot be opened is logged and
+// does not stop the agent.
+func startLocalServers(ctx context.Context) {
+	muxes := make(map[string]*http.ServeMux)
+	muxFor := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+
+		return muxes[addr]
+	}
+
+	if addr := agentstate.State.MetricsListen; addr != "" {
+		muxFor(addr).Handle("/metrics", metrics.Default.Handler())
+	} else {
+		agentstate.Logger.Debug("Metrics endpoint disabled")
+	}
+
+	if addr := agentstate.State.StatusListen; addr != "" {
+		registerStatusHandlers(muxFor(addr))
+	} else {
+		agentstate.Logger.Debug("Status endpoint disabled")
+	}
+
+	for addr, mux := range muxes {
+		bound, err := serveLocal(ctx, addr, mux)
+		if err != nil {
+			agentstate.Logger.Error("Failed to start local endpoint", "address", addr, "error", err)
+
+			continue
+		}
+
+		agentstate.Logger.Info("Serving local endpoints", "address", bound.String())
+	}
+}
+
+// serveLocal listens on addr and serves handler in a background goroutine,
+// shutting the server down when ctx is cancelled. It returns the bound address.
+func serveLocal(ctx context.Context, addr string, handler http.Handler) (net.Addr, error) {
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: localReadHeaderTimeout,
+	}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			agentstate.Logger.Error("Local endpoint stopped", "address", addr, "error", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+
+		//nolint:contextcheck // must-complete: ctx is already cancelled
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), localShutdownTimeout)
+		defer cancel()
+
+		//nolint:errcheck // Best effort during shutdown
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	return ln.Addr(), nil
+}
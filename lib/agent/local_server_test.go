@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/metrics"
+)
+
+func TestServeLocal(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.AddCrackSent()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry.Handler())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, err := serveLocal(ctx, "127.0.0.1:0", mux)
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr.String()+"/metrics", http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "cipherswarm_cracks_sent_total 1")
+}
+
+func TestServeLocal_ListenError(t *testing.T) {
+	_, err := serveLocal(context.Background(), "not-an-address", http.NewServeMux())
+	require.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/task"
+)
+
+// peekUnsupported is set once the server has shown it cannot preview the next
+// task, so the agent stops asking.
+//
+//nolint:gochecknoglobals // Process-wide server capability flag
+var peekUnsupported atomic.Bool
+
+// prefetches holds the cancel functions of running prefetches, so a task's own
+// downloads can stop them instead of waiting behind a rate-limited prefetch of
+// the same file.
+//
+//nolint:gochecknoglobals // Process-wide prefetch registry
+var prefetches struct {
+	mu      sync.Mutex
+	next    int
+	cancels map[int]context.CancelFunc
+}
+
+// cancelPrefetches stops every running prefetch. Whatever they downloaded is
+// resumed by the downloads that follow.
+func cancelPrefetches() {
+	prefetches.mu.Lock()
+	defer prefetches.mu.Unlock()
+
+	for _, cancel := range prefetches.cancels {
+		cancel()
+	}
+}
+
+// registerPrefetch records cancel and returns the function removing it again.
+func registerPrefetch(cancel context.CancelFunc) func() {
+	prefetches.mu.Lock()
+	defer prefetches.mu.Unlock()
+
+	if prefetches.cancels == nil {
+		prefetches.cancels = make(map[int]context.CancelFunc)
+	}
+
+	id := prefetches.next
+	prefetches.next++
+	prefetches.cancels[id] = cancel
+
+	return func() {
+		prefetches.mu.Lock()
+		defer prefetches.mu.Unlock()
+
+		delete(prefetches.cancels, id)
+	}
+}
+
+// startPrefetch warms the resource cache for the task the server expects to
+// assign after t, in the background while t runs. The returned function stops
+// the prefetch and waits for it to exit; call it when t stops running so the
+// next task's own downloads do not compete with it. Any slot starting its own
+// downloads also stops it, via cancelPrefetches.
+func startPrefetch(ctx context.Context, slot *taskSlot, t *api.Task) func() {
+	if !agentstate.State.PrefetchResources || slot.mgr.Config.ResourceCache == nil || peekUnsupported.Load() {
+		return func() {}
+	}
+
+	prefetchCtx, cancel := context.WithCancel(ctx)
+	unregister := registerPrefetch(cancel)
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		err := slot.mgr.PrefetchNext(prefetchCtx, t)
+		switch {
+		case errors.Is(err, task.ErrPeekUnsupported):
+			if !peekUnsupported.Swap(true) {
+				agentstate.Logger.Info("Server cannot preview the next task; resource prefetching disabled")
+			}
+		case err == nil, prefetchCtx.Err() != nil:
+		default:
+			agentstate.Logger.Debug("Resource prefetch failed", "task_id", t.Id, "error", err)
+		}
+	})
+
+	return func() {
+		cancel()
+		wg.Wait()
+		unregister()
+	}
+}
+
+// runTaskWithPrefetch runs t on the slot while prefetching the next task's
+// resources.
+func runTaskWithPrefetch(ctx context.Context, slot *taskSlot, t *api.Task, attack *api.Attack) error {
+	stop := startPrefetch(ctx, slot, t)
+	defer stop()
+
+	return slot.mgr.RunTask(ctx, t, attack)
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/task"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+// TestStartPrefetch_StopsAskingUnsupportedServer verifies that a server
+// answering 404 to the preview request is not asked again.
+func TestStartPrefetch_StopsAskingUnsupportedServer(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+	t.Cleanup(func() { peekUnsupported.Store(false) })
+
+	agentstate.State.PrefetchResources = true
+
+	var peeks atomic.Int32
+
+	tasks := &api.MockTasksClient{
+		PeekNextTaskFunc: func(_ context.Context) (*api.PeekNextTaskResponse, error) {
+			peeks.Add(1)
+			return nil, &api.APIError{StatusCode: http.StatusNotFound}
+		},
+	}
+
+	mgr := task.NewManager(tasks, &api.MockAttacksClient{})
+	mgr.Config.ResourceCache = downloader.NewCache(t.TempDir(), 0)
+	slot := newTaskSlot(0, nil, mgr)
+
+	current := &api.Task{Id: 1, AttackId: 1}
+
+	startPrefetch(context.Background(), slot, current)()
+	require.True(t, peekUnsupported.Load())
+
+	startPrefetch(context.Background(), slot, current)()
+	require.Equal(t, int32(1), peeks.Load(), "the server should be asked only once")
+}
+
+// TestCancelPrefetches verifies that running prefetches are cancelled and
+// stopped ones forgotten.
+func TestCancelPrefetches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	unregister := registerPrefetch(cancel)
+
+	cancelPrefetches()
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	unregister()
+
+	prefetches.mu.Lock()
+	defer prefetches.mu.Unlock()
+	require.Empty(t, prefetches.cancels)
+}
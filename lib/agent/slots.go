@@ -0,0 +1,377 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/devices"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/task"
+)
+
+// activePool holds the task pool when device groups are configured, or nil when
+// the agent runs one task at a time. It is replaced by initManagers (only while
+// the pool is idle) and read by the heartbeat goroutine to report slot activity.
+//
+//nolint:gochecknoglobals // Synchronized task pool handle
+var activePool atomic.Pointer[taskPool]
+
+// soloSlot holds the slot running a task when the agent runs one task at a
+// time, or nil. It is read by the status endpoint.
+//
+//nolint:gochecknoglobals // Synchronized task slot handle
+var soloSlot atomic.Pointer[taskSlot]
+
+// downloadMu serializes task file downloads. Pool slots share the resource
+// files directory, so two slots fetching the same wordlist must not overlap.
+//
+//nolint:gochecknoglobals // Process-wide download lock
+var downloadMu sync.Mutex
+
+// taskSlot runs at most one task at a time on its own task.Manager, bound to a
+// group of devices. Slot 0 is the single slot used outside the pool; its
+// activity is the agent's activity.
+type taskSlot struct {
+	id      int   // 1-based pool slot number; 0 outside the pool
+	devices []int // backend device IDs assigned to the slot
+	mgr     *task.Manager
+	pool    *taskPool
+
+	busy     atomic.Bool
+	taskID   atomic.Int64
+	attackID atomic.Int64
+	activity atomic.Value // agentstate.Activity
+}
+
+// newTaskSlot creates an idle slot.
+func newTaskSlot(id int, deviceIDs []int, mgr *task.Manager) *taskSlot {
+	slot := &taskSlot{id: id, devices: deviceIDs, mgr: mgr}
+	slot.activity.Store(agentstate.CurrentActivityWaiting)
+
+	return slot
+}
+
+// currentActivity returns the slot's own activity.
+func (s *taskSlot) currentActivity() agentstate.Activity {
+	a, _ := s.activity.Load().(agentstate.Activity)
+
+	return a
+}
+
+// setActivity records the slot's activity and updates the agent activity: for
+// a pool slot, the busiest activity across all slots.
+func (s *taskSlot) setActivity(a agentstate.Activity) {
+	if s.pool == nil {
+		s.activity.Store(a)
+		agentstate.State.SetCurrentActivity(a)
+
+		return
+	}
+
+	s.pool.activityMu.Lock()
+	defer s.pool.activityMu.Unlock()
+
+	s.activity.Store(a)
+	agentstate.State.SetCurrentActivity(s.pool.aggregateActivity())
+}
+
+// taskPool runs tasks concurrently, one per slot.
+type taskPool struct {
+	slots      []*taskSlot
+	activityMu sync.Mutex // Serializes slot activity changes with the aggregate update
+
+	// Owned by the agent-loop goroutine.
+	holdsGPU     bool // background benchmarks were stopped for pool work
+	canRestartBg bool // the stopped background benchmarks may be restarted
+}
+
+// newTaskPool creates one slot per device group, each with a task.Manager that
+// runs on the group's devices and keeps its hash lists, restore files, zaps,
+// and active task record apart from the other slots.
+func newTaskPool(client api.APIClient, dc devices.DeviceConfig, cfg task.Config, groups [][]int) (*taskPool, error) {
+	p := &taskPool{slots: make([]*taskSlot, 0, len(groups))}
+
+	for i, group := range groups {
+		id := i + 1
+
+		slotCfg := cfg
+		slotCfg.Slot = id
+		slotCfg.HashlistPath = slotDir(cfg.HashlistPath, id)
+		slotCfg.RestoreFilePath = slotDir(cfg.RestoreFilePath, id)
+		slotCfg.ZapsPath = slotDir(cfg.ZapsPath, id)
+		slotCfg.ActiveTaskFile = slotFile(cfg.ActiveTaskFile, id)
+
+		for _, dir := range []string{slotCfg.HashlistPath, slotCfg.RestoreFilePath, slotCfg.ZapsPath} {
+			if dir == "" {
+				continue
+			}
+
+			if err := os.MkdirAll(dir, 0o750); err != nil {
+				return nil, fmt.Errorf("creating slot directory: %w", err)
+			}
+		}
+
+		mgr := task.NewManager(client.Tasks(), client.Attacks())
+		mgr.DeviceConfig = dc.ForDevices(group)
+		mgr.Config = slotCfg
+
+		slot := newTaskSlot(id, group, mgr)
+		slot.pool = p
+		p.slots = append(p.slots, slot)
+	}
+
+	return p, nil
+}
+
+// slotDir returns the per-slot subdirectory of dir.
+func slotDir(dir string, slot int) string {
+	if dir == "" {
+		return ""
+	}
+
+	return filepath.Join(dir, "slot-"+strconv.Itoa(slot))
+}
+
+// slotFile returns the per-slot variant of a file path, e.g. active_task.slot-2.json.
+func slotFile(path string, slot int) string {
+	if path == "" {
+		return ""
+	}
+
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + ".slot-" + strconv.Itoa(slot) + ext
+}
+
+// resolveDeviceGroups turns the device_groups setting into the device groups
+// for the task pool, limited to the devices the agent is configured to use.
+// It returns nil when the agent should run one task at a time.
+func resolveDeviceGroups(spec string, dc devices.DeviceConfig) [][]int {
+	if spec == "" {
+		return nil
+	}
+
+	dm := dc.DeviceManager()
+
+	if spec == devices.DeviceGroupsAuto {
+		if dm == nil {
+			agentstate.Logger.Warn("Automatic device groups need enumerated devices, running one task at a time")
+
+			return nil
+		}
+
+		return dm.GroupByModel(dc.SelectedDeviceIDs())
+	}
+
+	groups, err := devices.ParseDeviceGroups(spec)
+	if err != nil {
+		agentstate.Logger.Warn("Invalid device groups, running one task at a time", "error", err)
+
+		return nil
+	}
+
+	if dm == nil {
+		// Without enumeration the IDs are forwarded unvalidated, like DeviceConfig does.
+		return groups
+	}
+
+	selected := dc.SelectedDeviceIDs()
+	filtered := make([][]int, 0, len(groups))
+
+	for _, group := range groups {
+		kept := make([]int, 0, len(group))
+
+		for _, id := range group {
+			if !slices.Contains(selected, id) {
+				agentstate.Logger.Warn("Device in device groups is not available, leaving it out", "device_id", id)
+
+				continue
+			}
+
+			kept = append(kept, id)
+		}
+
+		if len(kept) > 0 {
+			filtered = append(filtered, kept)
+		}
+	}
+
+	return filtered
+}
+
+// idle reports whether no slot is running a task.
+func (p *taskPool) idle() bool {
+	for _, slot := range p.slots {
+		if slot.busy.Load() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// aggregateActivity returns the busiest slot activity: cracking, then
+// downloading, otherwise waiting.
+func (p *taskPool) aggregateActivity() agentstate.Activity {
+	activity := agentstate.CurrentActivityWaiting
+
+	for _, slot := range p.slots {
+		switch slot.currentActivity() {
+		case agentstate.CurrentActivityCracking:
+			return agentstate.CurrentActivityCracking
+		case agentstate.CurrentActivityDownloading:
+			activity = agentstate.CurrentActivityDownloading
+		}
+	}
+
+	return activity
+}
+
+// slotActivities reports each slot's devices, activity, and task for the heartbeat.
+func (p *taskPool) slotActivities() []api.AgentSlotActivity {
+	activities := make([]api.AgentSlotActivity, 0, len(p.slots))
+
+	for _, slot := range p.slots {
+		activity := api.AgentSlotActivity{
+			Slot:     slot.id,
+			Devices:  slot.devices,
+			Activity: string(slot.currentActivity()),
+		}
+
+		if id := slot.taskID.Load(); id != 0 {
+			activity.TaskId = &id
+		}
+
+		activities = append(activities, activity)
+	}
+
+	return activities
+}
+
+// heartbeatSlots returns the per-slot activity to send with a heartbeat, or nil
+// when the agent runs one task at a time.
+func heartbeatSlots() []api.AgentSlotActivity {
+	p := activePool.Load()
+	if p == nil {
+		return nil
+	}
+
+	return p.slotActivities()
+}
+
+// handlePoolTasks fills idle slots with interrupted or new tasks and returns
+// without waiting for them. Background benchmarks are stopped while any slot
+// runs a task and restarted once the pool drains. While the server has paused
+// the agent, no task is started or resumed; running slots wait for the resume
+// on their own.
+func handlePoolTasks(ctx context.Context, p *taskPool) {
+	defer p.releaseGPUIfIdle(ctx)
+
+	if !agentstate.State.GetBenchmarksSubmitted() {
+		agentstate.Logger.Debug("Benchmarks not yet submitted, skipping task retrieval")
+		return
+	}
+
+	if agentstate.State.GetPauseRequested() {
+		agentstate.Logger.Debug("Agent paused by server, not starting tasks on idle slots")
+		return
+	}
+
+	for _, slot := range p.slots {
+		if slot.busy.Load() {
+			continue
+		}
+
+		t, pending := interruptedTask(ctx, slot.mgr)
+		if pending && t == nil {
+			continue
+		}
+
+		if t == nil {
+			if t = fetchNewTask(ctx, slot.mgr); t == nil {
+				return
+			}
+		}
+
+		p.start(ctx, slot, t)
+	}
+}
+
+// start runs the task on the slot in a new goroutine.
+func (p *taskPool) start(ctx context.Context, slot *taskSlot, t *api.Task) {
+	if !p.holdsGPU {
+		p.canRestartBg = stopBackgroundBenchmarks()
+		p.holdsGPU = true
+	}
+
+	agentstate.Logger.Info("Starting task on slot", "slot", slot.id, "devices", slot.devices, "task_id", t.Id)
+
+	slot.busy.Store(true)
+
+	go func() {
+		defer slot.busy.Store(false)
+
+		processTask(ctx, slot, t)
+	}()
+}
+
+// releaseGPUIfIdle restarts background benchmarks stopped for pool work once no
+// slot is running a task.
+func (p *taskPool) releaseGPUIfIdle(ctx context.Context) {
+	if !p.holdsGPU || !p.idle() {
+		return
+	}
+
+	p.holdsGPU = false
+
+	if p.canRestartBg {
+		startBackgroundBenchmarks(ctx)
+	}
+}
+
+// runningTask identifies the task a slot is working on.
+type runningTask struct {
+	Slot     int    `json:"slot,omitempty"`
+	TaskID   int64  `json:"task_id"`
+	AttackID int64  `json:"attack_id"`
+	Activity string `json:"activity"`
+}
+
+// runningTasks returns the tasks currently being worked on: one per busy pool
+// slot, or the single task outside the pool.
+func runningTasks() []runningTask {
+	slots := []*taskSlot{soloSlot.Load()}
+	if p := activePool.Load(); p != nil {
+		slots = p.slots
+	}
+
+	tasks := make([]runningTask, 0, len(slots))
+
+	for _, slot := range slots {
+		if slot == nil {
+			continue
+		}
+
+		taskID := slot.taskID.Load()
+		if taskID == 0 {
+			continue
+		}
+
+		tasks = append(tasks, runningTask{
+			Slot:     slot.id,
+			TaskID:   taskID,
+			AttackID: slot.attackID.Load(),
+			Activity: string(slot.currentActivity()),
+		})
+	}
+
+	return tasks
+}
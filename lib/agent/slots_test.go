@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/devices"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/task"
+)
+
+func mixedGPUs() *devices.DeviceManager {
+	return devices.NewDeviceManagerForTest([]devices.Device{
+		{ID: 1, Name: "RTX 3090", Type: "GPU", IsAvailable: true},
+		{ID: 2, Name: "RTX 3090", Type: "GPU", IsAvailable: true},
+		{ID: 3, Name: "RTX 4090", Type: "GPU", IsAvailable: true},
+		{ID: 4, Name: "RTX 4090", Type: "GPU", IsAvailable: false},
+	})
+}
+
+func TestResolveDeviceGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		dc   devices.DeviceConfig
+		want [][]int
+	}{
+		{name: "disabled", spec: "", dc: devices.NewDeviceConfig("", "", mixedGPUs()), want: nil},
+		{name: "auto groups by model", spec: "auto", dc: devices.NewDeviceConfig("", "", mixedGPUs()), want: [][]int{{1, 2}, {3}}},
+		{name: "auto honours configured devices", spec: "auto", dc: devices.NewDeviceConfig("2,3", "", mixedGPUs()), want: [][]int{{2}, {3}}},
+		{name: "auto without enumeration", spec: "auto", dc: devices.NewDeviceConfig("", "", nil), want: nil},
+		{name: "explicit groups", spec: "1;2,3", dc: devices.NewDeviceConfig("", "", mixedGPUs()), want: [][]int{{1}, {2, 3}}},
+		{name: "explicit groups drop unavailable devices", spec: "1,2;4,9", dc: devices.NewDeviceConfig("", "", mixedGPUs()), want: [][]int{{1, 2}}},
+		{name: "explicit groups without enumeration", spec: "1;2", dc: devices.NewDeviceConfig("", "", nil), want: [][]int{{1}, {2}}},
+		{name: "invalid groups", spec: "1;x", dc: devices.NewDeviceConfig("", "", mixedGPUs()), want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolveDeviceGroups(tt.spec, tt.dc))
+		})
+	}
+}
+
+func TestNewTaskPool_SeparatesSlots(t *testing.T) {
+	dir := t.TempDir()
+	cfg := task.Config{
+		HashlistPath:    filepath.Join(dir, "hashlists"),
+		RestoreFilePath: filepath.Join(dir, "restore"),
+		ZapsPath:        filepath.Join(dir, "zaps"),
+		FilePath:        filepath.Join(dir, "files"),
+		ActiveTaskFile:  filepath.Join(dir, "active_task.json"),
+	}
+
+	p, err := newTaskPool(&api.MockClient{}, devices.NewDeviceConfig("", "", mixedGPUs()), cfg, [][]int{{1, 2}, {3}})
+	require.NoError(t, err)
+	require.Len(t, p.slots, 2)
+
+	second := p.slots[1]
+	assert.Equal(t, 2, second.id)
+	assert.Equal(t, []int{3}, second.devices)
+	assert.Equal(t, 2, second.mgr.Config.Slot)
+	assert.Equal(t, filepath.Join(dir, "hashlists", "slot-2"), second.mgr.Config.HashlistPath)
+	assert.Equal(t, filepath.Join(dir, "restore", "slot-2"), second.mgr.Config.RestoreFilePath)
+	assert.Equal(t, filepath.Join(dir, "active_task.slot-2.json"), second.mgr.Config.ActiveTaskFile)
+	assert.Equal(t, filepath.Join(dir, "zaps", "slot-2"), second.mgr.Config.ZapsPath)
+	assert.NotEqual(t, p.slots[0].mgr.Config.ZapsPath, second.mgr.Config.ZapsPath,
+		"a slot's session cleanup must not remove another slot's zaps")
+	assert.Equal(t, cfg.FilePath, second.mgr.Config.FilePath, "resource files are shared")
+	assert.Equal(t, "3", second.mgr.DeviceConfig.ResolvedBackendDevices())
+	assert.DirExists(t, second.mgr.Config.RestoreFilePath)
+	assert.DirExists(t, second.mgr.Config.ZapsPath)
+}
+
+func TestTaskPool_Activity(t *testing.T) {
+	t.Cleanup(saveAndRestoreState(t))
+
+	p, err := newTaskPool(&api.MockClient{}, devices.NewDeviceConfig("", "", nil), task.Config{}, [][]int{{1}, {2}})
+	require.NoError(t, err)
+	activePool.Store(p)
+	t.Cleanup(func() { activePool.Store(nil) })
+
+	first, second := p.slots[0], p.slots[1]
+
+	first.setActivity(agentstate.CurrentActivityDownloading)
+	assert.Equal(t, agentstate.CurrentActivityDownloading, agentstate.State.GetCurrentActivity())
+
+	second.taskID.Store(77)
+	second.setActivity(agentstate.CurrentActivityCracking)
+	assert.Equal(t, agentstate.CurrentActivityCracking, agentstate.State.GetCurrentActivity())
+
+	second.setActivity(agentstate.CurrentActivityWaiting)
+	assert.Equal(t, agentstate.CurrentActivityDownloading, agentstate.State.GetCurrentActivity(),
+		"agent activity follows the busiest slot")
+
+	slots := heartbeatSlots()
+	require.Len(t, slots, 2)
+	assert.Equal(t, api.AgentSlotActivity{Slot: 1, Devices: []int{1}, Activity: "downloading"}, slots[0])
+	require.NotNil(t, slots[1].TaskId)
+	assert.Equal(t, int64(77), *slots[1].TaskId)
+}
+
+func TestHeartbeatSlots_SingleTaskMode(t *testing.T) {
+	activePool.Store(nil)
+	assert.Nil(t, heartbeatSlots())
+}
+
+func TestHandlePoolTasks_FillsIdleSlots(t *testing.T) {
+	t.Cleanup(saveAndRestoreState(t))
+
+	polls := 0
+	tasks := &api.MockTasksClient{
+		GetNewTaskFunc: func(context.Context) (*api.GetNewTaskResponse, error) {
+			polls++
+			return &api.GetNewTaskResponse{HTTPResponse: &http.Response{StatusCode: http.StatusNoContent}}, nil
+		},
+	}
+
+	p, err := newTaskPool(&api.MockClient{TasksImpl: tasks}, devices.NewDeviceConfig("", "", nil),
+		task.Config{}, [][]int{{1}, {2}, {3}})
+	require.NoError(t, err)
+
+	agentstate.State.SetBenchmarksSubmitted(false)
+	handlePoolTasks(context.Background(), p)
+	assert.Zero(t, polls, "no tasks are fetched before benchmarks are submitted")
+
+	agentstate.State.SetBenchmarksSubmitted(true)
+	p.slots[0].busy.Store(true)
+	handlePoolTasks(context.Background(), p)
+	assert.Equal(t, 1, polls, "busy slots are skipped and polling stops when no task is available")
+	assert.False(t, p.holdsGPU)
+}
+
+func TestHandlePoolTasks_PausedLeavesIdleSlots(t *testing.T) {
+	t.Cleanup(saveAndRestoreState(t))
+
+	polls := 0
+	tasks := &api.MockTasksClient{
+		GetNewTaskFunc: func(context.Context) (*api.GetNewTaskResponse, error) {
+			polls++
+			return &api.GetNewTaskResponse{HTTPResponse: &http.Response{StatusCode: http.StatusNoContent}}, nil
+		},
+	}
+
+	p, err := newTaskPool(&api.MockClient{TasksImpl: tasks}, devices.NewDeviceConfig("", "", nil),
+		task.Config{}, [][]int{{1}, {2}})
+	require.NoError(t, err)
+
+	agentstate.State.SetBenchmarksSubmitted(true)
+	agentstate.State.SetPauseRequested(true)
+	p.slots[0].busy.Store(true)
+
+	handlePoolTasks(context.Background(), p)
+	assert.Zero(t, polls, "an idle slot fetches no task while the agent is paused")
+	assert.False(t, p.slots[1].busy.Load())
+
+	agentstate.State.SetPauseRequested(false)
+	handlePoolTasks(context.Background(), p)
+	assert.Equal(t, 1, polls, "idle slots poll again once the pause is lifted")
+}
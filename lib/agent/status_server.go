@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+)
+
+// agentStatus is the JSON document served at /status.
+type agentStatus struct {
+	Healthy             bool          `json:"healthy"`
+	Ready               bool          `json:"ready"`
+	Problem             string        `json:"problem,omitempty"` // Why the agent is not ready, if it is not
+	AgentID             int64         `json:"agent_id"`
+	Version             string        `json:"version"`
+	Activity            string        `json:"activity"`
+	LastHeartbeat       *time.Time    `json:"last_heartbeat"`
+	HeartbeatFailures   int           `json:"heartbeat_failures"`
+	CircuitBreaker      string        `json:"circuit_breaker"`
+	Tasks               []runningTask `json:"tasks"`
+	HashcatPID          int32         `json:"hashcat_pid,omitempty"`  // Set only while a single hashcat process runs
+	HashcatPIDs         []int32       `json:"hashcat_pids,omitempty"` // Every running hashcat process, one per busy slot
+	BenchmarksSubmitted bool          `json:"benchmarks_submitted"`
+}
+
+// registerStatusHandlers mounts the health and status endpoints:
+//
+//   - /healthz (liveness) fails once heartbeats have failed more than
+//     max_heartbeat_backoff times in a row, i.e. the backoff is exhausted.
+//   - /readyz (readiness) additionally requires a successful heartbeat,
+//     submitted benchmarks, and a circuit breaker that is not open.
+//   - /status reports the agent's state as JSON.
+func registerStatusHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, livenessProblem())
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, readinessProblem())
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck // The client sees a truncated response; nothing else to do
+		_ = json.NewEncoder(w).Encode(currentStatus())
+	})
+}
+
+// writeProbe answers a probe with 200 "ok", or 503 and the problem.
+func writeProbe(w http.ResponseWriter, problem string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if problem != "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, problem)
+
+		return
+	}
+
+	fmt.Fprintln(w, "ok")
+}
+
+// livenessProblem returns why the agent is unhealthy, or "" when it is healthy.
+func livenessProblem() string {
+	if failures := agentstate.State.GetHeartbeatFailures(); failures > agentstate.State.MaxHeartbeatBackoff {
+		return fmt.Sprintf("heartbeat failed %d times in a row", failures)
+	}
+
+	return ""
+}
+
+// readinessProblem returns why the agent is not ready for work, or "" when it is.
+func readinessProblem() string {
+	if problem := livenessProblem(); problem != "" {
+		return problem
+	}
+
+	switch {
+	case agentstate.State.GetLastHeartbeat().IsZero():
+		return "no successful heartbeat yet"
+	case !agentstate.State.GetBenchmarksSubmitted():
+		return "benchmarks not submitted"
+	case circuitBreakerState() == api.CircuitStateOpen:
+		return "API circuit breaker is open"
+	}
+
+	return ""
+}
+
+// circuitBreakerState returns the shared circuit breaker state, or closed
+// before the API client is set up.
+func circuitBreakerState() string {
+	if circuitBreaker == nil {
+		return api.CircuitStateClosed
+	}
+
+	return circuitBreaker.State()
+}
+
+// currentStatus gathers the agent state served at /status.
+func currentStatus() agentStatus {
+	problem := readinessProblem()
+
+	status := agentStatus{
+		Healthy:             livenessProblem() == "",
+		Ready:               problem == "",
+		Problem:             problem,
+		AgentID:             agentstate.State.AgentID,
+		Version:             agentstate.State.AgentVersion,
+		Activity:            string(agentstate.State.GetCurrentActivity()),
+		HeartbeatFailures:   agentstate.State.GetHeartbeatFailures(),
+		CircuitBreaker:      circuitBreakerState(),
+		Tasks:               runningTasks(),
+		HashcatPID:          agentstate.State.GetHashcatPID(),
+		HashcatPIDs:         agentstate.State.GetHashcatPIDs(),
+		BenchmarksSubmitted: agentstate.State.GetBenchmarksSubmitted(),
+	}
+
+	if last := agentstate.State.GetLastHeartbeat(); !last.IsZero() {
+		status.LastHeartbeat = &last
+	}
+
+	return status
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+)
+
+// setHeartbeatState puts the heartbeat tracking into a known state and restores
+// a clean one after the test. A zero last leaves no heartbeat recorded: the Unix
+// epoch is stored as zero, which agentstate treats as "no heartbeat yet".
+func setHeartbeatState(t *testing.T, last time.Time, failures int) {
+	t.Helper()
+
+	reset := func() {
+		agentstate.State.RecordHeartbeatSuccess(time.Unix(0, 0))
+	}
+	reset()
+	t.Cleanup(reset)
+
+	if !last.IsZero() {
+		agentstate.State.RecordHeartbeatSuccess(last)
+	}
+
+	for range failures {
+		agentstate.State.RecordHeartbeatFailure()
+	}
+}
+
+func probe(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	registerStatusHandlers(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+	return rec
+}
+
+func TestStatusProbes(t *testing.T) {
+	cleanup := saveAndRestoreState(t)
+	defer cleanup()
+
+	origBackoff := agentstate.State.MaxHeartbeatBackoff
+	origBreaker := circuitBreaker
+	t.Cleanup(func() {
+		agentstate.State.MaxHeartbeatBackoff = origBackoff
+		circuitBreaker = origBreaker
+	})
+
+	agentstate.State.MaxHeartbeatBackoff = 2
+	circuitBreaker = api.NewCircuitBreaker(1, time.Hour)
+	agentstate.State.SetBenchmarksSubmitted(true)
+
+	t.Run("ready after a heartbeat", func(t *testing.T) {
+		setHeartbeatState(t, time.Now(), 0)
+
+		assert.Equal(t, http.StatusOK, probe(t, "/healthz").Code)
+		assert.Equal(t, http.StatusOK, probe(t, "/readyz").Code)
+	})
+
+	t.Run("live but not ready before the first heartbeat", func(t *testing.T) {
+		setHeartbeatState(t, time.Time{}, 0)
+
+		assert.Equal(t, http.StatusOK, probe(t, "/healthz").Code)
+		rec := probe(t, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("failures within the backoff keep the agent live", func(t *testing.T) {
+		setHeartbeatState(t, time.Now(), 2)
+
+		assert.Equal(t, http.StatusOK, probe(t, "/healthz").Code)
+	})
+
+	t.Run("failures past the backoff fail both probes", func(t *testing.T) {
+		setHeartbeatState(t, time.Now(), 3)
+
+		rec := probe(t, "/healthz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "heartbeat failed 3 times")
+		assert.Equal(t, http.StatusServiceUnavailable, probe(t, "/readyz").Code)
+	})
+
+	t.Run("open circuit is not ready", func(t *testing.T) {
+		setHeartbeatState(t, time.Now(), 0)
+		circuitBreaker.RecordFailure()
+		t.Cleanup(circuitBreaker.Reset)
+
+		assert.Equal(t, http.StatusOK, probe(t, "/healthz").Code)
+		rec := probe(t, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "circuit breaker is open")
+	})
+}
+
+func TestStatusEndpoint(t *testing.T) {
+	cleanup := saveAndRestoreState(t)
+	defer cleanup()
+
+	t.Cleanup(func() {
+		agentstate.State.ResetHashcatPIDs()
+		soloSlot.Store(nil)
+	})
+
+	last := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	setHeartbeatState(t, last, 1)
+	agentstate.State.SetCurrentActivity(agentstate.CurrentActivityCracking)
+	agentstate.State.SetHashcatPID("attack-22", 4242)
+
+	slot := newTaskSlot(0, nil, nil)
+	slot.taskID.Store(11)
+	slot.attackID.Store(22)
+	slot.activity.Store(agentstate.CurrentActivityCracking)
+	soloSlot.Store(slot)
+
+	rec := probe(t, "/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var status agentStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, "cracking", status.Activity)
+	assert.Equal(t, 1, status.HeartbeatFailures)
+	require.NotNil(t, status.LastHeartbeat)
+	assert.True(t, last.Equal(*status.LastHeartbeat))
+	assert.Equal(t, int32(4242), status.HashcatPID)
+	assert.Equal(t, []int32{4242}, status.HashcatPIDs)
+	assert.Equal(t, []runningTask{{TaskID: 11, AttackID: 22, Activity: "cracking"}}, status.Tasks)
+}
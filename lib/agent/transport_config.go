@@ -5,6 +5,7 @@ import (
 
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/metrics"
 )
 
 // circuitBreaker is the shared circuit breaker instance that survives client rebuilds.
@@ -55,5 +56,6 @@ func transportConfigFromState() api.TransportConfig {
 
 		CircuitBreaker: circuitBreaker,
 		Logger:         logger,
+		OnAPIError:     metrics.Default.AddAPIError,
 	}
 }
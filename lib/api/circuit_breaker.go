@@ -18,6 +18,25 @@ const (
 	stateHalfOpen                     // Testing if service has recovered
 )
 
+// Circuit breaker state names reported by CircuitBreaker.State.
+const (
+	CircuitStateClosed   = "closed"
+	CircuitStateOpen     = "open"
+	CircuitStateHalfOpen = "half_open"
+)
+
+// String returns the state name used in metrics.
+func (s circuitState) String() string {
+	switch s {
+	case stateOpen:
+		return CircuitStateOpen
+	case stateHalfOpen:
+		return CircuitStateHalfOpen
+	default:
+		return CircuitStateClosed
+	}
+}
+
 // CircuitBreaker implements the circuit breaker pattern for API resilience.
 // It tracks consecutive failures and opens the circuit when a threshold is reached,
 // preventing further requests until a timeout expires and a probe request succeeds.
@@ -72,6 +91,16 @@ func (cb *CircuitBreaker) Allow() bool {
 	return false
 }
 
+// State returns the current state name: CircuitStateClosed, CircuitStateOpen,
+// or CircuitStateHalfOpen. An open circuit whose timeout has elapsed still
+// reports open until the next request probes it.
+func (cb *CircuitBreaker) State() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.state.String()
+}
+
 // RecordSuccess records a successful request. Resets failure count and closes the circuit.
 func (cb *CircuitBreaker) RecordSuccess() {
 	cb.mu.Lock()
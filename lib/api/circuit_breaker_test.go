@@ -107,3 +107,18 @@ func TestCircuitBreaker_ErrCircuitOpen(t *testing.T) {
 	err := fmt.Errorf("wrapped: %w", ErrCircuitOpen)
 	require.ErrorIs(t, err, ErrCircuitOpen)
 }
+
+func TestCircuitBreaker_State(t *testing.T) {
+	cb := NewCircuitBreaker(1, 10*time.Millisecond)
+	require.Equal(t, CircuitStateClosed, cb.State())
+
+	cb.RecordFailure()
+	require.Equal(t, CircuitStateOpen, cb.State())
+
+	time.Sleep(15 * time.Millisecond)
+	require.True(t, cb.Allow())
+	require.Equal(t, CircuitStateHalfOpen, cb.State())
+
+	cb.RecordSuccess()
+	require.Equal(t, CircuitStateClosed, cb.State())
+}
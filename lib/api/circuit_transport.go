@@ -14,8 +14,19 @@ type CircuitTransport struct {
 	Base    http.RoundTripper
 	Breaker *CircuitBreaker
 	Logger  *slog.Logger // Optional structured logger; nil disables logging.
+	// OnError is called once per failed attempt with one of the APIError*
+	// reasons. Optional; nil disables it.
+	OnError func(reason string)
 }
 
+// Failure reasons passed to CircuitTransport.OnError.
+const (
+	APIErrorCircuitOpen = "circuit_open" // Rejected without a request because the circuit is open
+	APIErrorNetwork     = "network_error"
+	APIErrorServer      = "server_error" // 5xx response
+	APIErrorClient      = "client_error" // 4xx response
+)
+
 // RoundTrip implements http.RoundTripper with circuit breaker protection.
 func (ct *CircuitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if !ct.Breaker.Allow() {
@@ -23,12 +34,14 @@ func (ct *CircuitTransport) RoundTrip(req *http.Request) (*http.Response, error)
 			ct.Logger.WarnContext(req.Context(), "Circuit breaker is open, rejecting API request",
 				"url", req.URL.String())
 		}
+		ct.reportError(APIErrorCircuitOpen)
 		return nil, fmt.Errorf("%w: server appears unresponsive", ErrCircuitOpen)
 	}
 
 	resp, err := ct.Base.RoundTrip(req)
 	if err != nil {
 		ct.Breaker.RecordFailure()
+		ct.reportError(APIErrorNetwork)
 		if ct.Logger != nil {
 			ct.Logger.DebugContext(req.Context(), "Circuit breaker recorded failure",
 				"reason", "network_error", "error", err)
@@ -38,13 +51,24 @@ func (ct *CircuitTransport) RoundTrip(req *http.Request) (*http.Response, error)
 
 	if resp.StatusCode >= http.StatusInternalServerError {
 		ct.Breaker.RecordFailure()
+		ct.reportError(APIErrorServer)
 		if ct.Logger != nil {
 			ct.Logger.DebugContext(req.Context(), "Circuit breaker recorded failure",
 				"reason", "server_error", "status", resp.StatusCode)
 		}
 	} else {
 		ct.Breaker.RecordSuccess()
+		if resp.StatusCode >= http.StatusBadRequest {
+			ct.reportError(APIErrorClient)
+		}
 	}
 
 	return resp, nil
 }
+
+// reportError passes a failure reason to OnError when set.
+func (ct *CircuitTransport) reportError(reason string) {
+	if ct.OnError != nil {
+		ct.OnError(reason)
+	}
+}
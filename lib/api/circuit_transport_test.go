@@ -130,3 +130,25 @@ func TestCircuitTransport_AllowsProbeInHalfOpen(t *testing.T) {
 	// Should be closed again
 	require.True(t, ct.Breaker.Allow())
 }
+
+func TestCircuitTransport_ReportsErrorReasons(t *testing.T) {
+	statuses := []int{http.StatusOK, http.StatusNotFound, http.StatusBadGateway}
+	calls := 0
+	ct := newTestCircuitTransport(roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+		defer func() { calls++ }()
+		if calls < len(statuses) {
+			return &http.Response{StatusCode: statuses[calls], Body: http.NoBody}, nil
+		}
+		return nil, errors.New("connection refused")
+	}), 2)
+
+	var reasons []string
+	ct.OnError = func(reason string) { reasons = append(reasons, reason) }
+
+	req := mustNewCircuitReq(t)
+	for range 5 {
+		ct.RoundTrip(req) //nolint:errcheck,bodyclose // only the reported reasons matter
+	}
+
+	require.Equal(t, []string{APIErrorClient, APIErrorServer, APIErrorNetwork, APIErrorCircuitOpen}, reasons)
+}
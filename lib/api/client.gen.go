@@ -50,6 +50,7 @@ func (e AgentState) Valid() bool {
 
 // Defines values for AttackAttackMode.
 const (
+	Combinator       AttackAttackMode = "combinator"
 	Dictionary       AttackAttackMode = "dictionary"
 	HybridDictionary AttackAttackMode = "hybrid_dictionary"
 	HybridMask       AttackAttackMode = "hybrid_mask"
@@ -59,6 +60,8 @@ const (
 // Valid indicates whether the value is a known member of the AttackAttackMode enum.
 func (e AttackAttackMode) Valid() bool {
 	switch e {
+	case Combinator:
+		return true
 	case Dictionary:
 		return true
 	case HybridDictionary:
@@ -72,6 +75,24 @@ func (e AttackAttackMode) Valid() bool {
 	}
 }
 
+// Defines values for AttackResourceFileChecksumAlgorithm.
+const (
+	Md5    AttackResourceFileChecksumAlgorithm = "md5"
+	Sha256 AttackResourceFileChecksumAlgorithm = "sha256"
+)
+
+// Valid indicates whether the value is a known member of the AttackResourceFileChecksumAlgorithm enum.
+func (e AttackResourceFileChecksumAlgorithm) Valid() bool {
+	switch e {
+	case Md5:
+		return true
+	case Sha256:
+		return true
+	default:
+		return false
+	}
+}
+
 // Defines values for DeviceStatusDeviceType.
 const (
 	CPU DeviceStatusDeviceType = "CPU"
@@ -188,6 +209,15 @@ type AdvancedAgentConfiguration struct {
 	// BackendDevice The device to use for hashcat, separated by commas
 	BackendDevice *string `json:"backend_device"`
 
+	// DownloadBandwidthLimit Total download rate cap for the agent, e.g. 20Mbit or 5MB/s; unlimited for no cap. Overrides the agent's local setting when present.
+	DownloadBandwidthLimit *string `json:"download_bandwidth_limit,omitempty"`
+
+	// DownloadBandwidthPerDownload Download rate cap for each file. Overrides the agent's local setting when present.
+	DownloadBandwidthPerDownload *string `json:"download_bandwidth_per_download,omitempty"`
+
+	// DownloadBandwidthSchedule Time-of-day download rate caps overriding download_bandwidth_limit, e.g. 08:00-18:00=20Mbit,18:00-08:00=unlimited. Times are in the agent's local time zone.
+	DownloadBandwidthSchedule *string `json:"download_bandwidth_schedule,omitempty"`
+
 	// EnableAdditionalHashTypes Causes hashcat to perform benchmark-all, rather than just benchmark
 	EnableAdditionalHashTypes bool `json:"enable_additional_hash_types"`
 
@@ -230,6 +260,24 @@ type AgentState string
 type AgentHeartbeatRequest struct {
 	// Activity Current agent activity state. Known values: starting, benchmarking, updating, downloading, waiting, cracking, stopping. Future versions may support additional values.
 	Activity *string `json:"activity,omitempty"`
+
+	// Slots Per-slot activity for agents running several tasks concurrently on separate device groups. Omitted by agents running one task at a time.
+	Slots *[]AgentSlotActivity `json:"slots,omitempty"`
+}
+
+// AgentSlotActivity Activity of one task slot of an agent
+type AgentSlotActivity struct {
+	// Activity Current activity of the slot, using the same values as the agent activity
+	Activity string `json:"activity"`
+
+	// Devices Backend device IDs assigned to the slot
+	Devices []int `json:"devices"`
+
+	// Slot Slot number, starting at 1
+	Slot int `json:"slot"`
+
+	// TaskId ID of the task the slot is working on
+	TaskId *int64 `json:"task_id,omitempty"`
 }
 
 // Attack A hashcat attack configuration assigned to an agent
@@ -264,6 +312,9 @@ type Attack struct {
 	// HashListId The id of the hash list
 	HashListId int64 `json:"hash_list_id"`
 
+	// HashListSeparator The character separating fields in the hash list lines; hashcat's --separator
+	HashListSeparator *string `json:"hash_list_separator,omitempty"`
+
 	// HashListUrl The download URL for the hash list
 	HashListUrl *string `json:"hash_list_url"`
 
@@ -297,9 +348,18 @@ type Attack struct {
 	// Optimized Enable hashcat optimized mode
 	Optimized bool `json:"optimized"`
 
+	// Preprocessor A downloadable resource file (word list, rule list, or mask list) used by an attack
+	Preprocessor *AttackResourceFile `json:"preprocessor,omitempty"`
+
+	// PreprocessorArgs Arguments for the preprocessor, which runs in the agent's files directory and whose output hashcat reads as its word list
+	PreprocessorArgs *[]string `json:"preprocessor_args,omitempty"`
+
 	// RightRule The right-hand rule for combinator attacks
 	RightRule *string `json:"right_rule,omitempty"`
 
+	// RightWordList A downloadable resource file (word list, rule list, or mask list) used by an attack
+	RightWordList *AttackResourceFile `json:"right_word_list,omitempty"`
+
 	// RuleList A downloadable resource file (word list, rule list, or mask list) used by an attack
 	RuleList *AttackResourceFile `json:"rule_list,omitempty"`
 
@@ -321,9 +381,12 @@ type AttackAttackMode string
 
 // AttackResourceFile A downloadable resource file (word list, rule list, or mask list) used by an attack
 type AttackResourceFile struct {
-	// Checksum The MD5 checksum of the resource file
+	// Checksum The checksum of the resource file, computed with checksum_algorithm
 	Checksum []byte `json:"checksum"`
 
+	// ChecksumAlgorithm The hash algorithm of the checksum
+	ChecksumAlgorithm *AttackResourceFileChecksumAlgorithm `json:"checksum_algorithm,omitempty"`
+
 	// DownloadUrl The download URL of the resource file
 	DownloadUrl string `json:"download_url"`
 
@@ -334,6 +397,9 @@ type AttackResourceFile struct {
 	Id int64 `json:"id"`
 }
 
+// AttackResourceFileChecksumAlgorithm The hash algorithm of the checksum
+type AttackResourceFileChecksumAlgorithm string
+
 // AuthenticationResponse The response to a successful agent authentication
 type AuthenticationResponse struct {
 	AgentId       int64 `json:"agent_id"`
@@ -403,6 +469,27 @@ type ConfigurationResponse struct {
 	} `json:"recommended_timeouts"`
 }
 
+// CrackerUpdate The hashcat release the server designates for an agent's platform
+type CrackerUpdate struct {
+	// Available Whether the agent should install a different hashcat release than the one it reported
+	Available bool `json:"available"`
+
+	// Checksum The hex-encoded checksum of the archive: MD5, or another algorithm named by a prefix such as sha256:<hex>
+	Checksum *string `json:"checksum,omitempty"`
+
+	// DownloadUrl Download URL of the 7z archive containing the release in a top-level hashcat directory
+	DownloadUrl *string `json:"download_url,omitempty"`
+
+	// ExecName Name of the hashcat executable inside the hashcat directory
+	ExecName *string `json:"exec_name,omitempty"`
+
+	// LatestVersion Version of the designated hashcat release
+	LatestVersion *string `json:"latest_version,omitempty"`
+
+	// Message Human-readable explanation of the result
+	Message *string `json:"message,omitempty"`
+}
+
 // DeviceStatus Status and performance metrics for a single GPU or CPU device
 type DeviceStatus struct {
 	// DeviceId The id of the device
@@ -669,6 +756,24 @@ type UpdateAgentRequest struct {
 	OperatingSystem string `json:"operating_system"`
 }
 
+// ZapRecord A hash already cracked for a task, sent as one line of an NDJSON zap response
+type ZapRecord struct {
+	// Hash The hash as it appears in the hash list
+	Hash string `json:"hash"`
+
+	// PlaintextHex The hex-encoded plaintext
+	PlaintextHex string `json:"plaintext_hex"`
+}
+
+// CheckForCrackerUpdateParams defines parameters for CheckForCrackerUpdate.
+type CheckForCrackerUpdateParams struct {
+	// OperatingSystem Operating system of the agent (linux, windows, or darwin)
+	OperatingSystem string `form:"operating_system" json:"operating_system"`
+
+	// Version Hashcat version the agent is running, or 0.0.0 when none is installed
+	Version string `form:"version" json:"version"`
+}
+
 // UpdateAgentJSONRequestBody defines body for UpdateAgent for application/json ContentType.
 type UpdateAgentJSONRequestBody = UpdateAgentRequest
 
@@ -941,12 +1046,18 @@ type ClientInterface interface {
 	// GetConfiguration request
 	GetConfiguration(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// CheckForCrackerUpdate request
+	CheckForCrackerUpdate(ctx context.Context, params *CheckForCrackerUpdateParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// GetHealth request
 	GetHealth(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	// GetNewTask request
 	GetNewTask(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// PeekNextTask request
+	PeekNextTask(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// GetTask request
 	GetTask(ctx context.Context, id int64, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -1141,6 +1252,18 @@ func (c *Client) GetConfiguration(ctx context.Context, reqEditors ...RequestEdit
 	return c.Client.Do(req)
 }
 
+func (c *Client) CheckForCrackerUpdate(ctx context.Context, params *CheckForCrackerUpdateParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCheckForCrackerUpdateRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) GetHealth(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewGetHealthRequest(c.Server)
 	if err != nil {
@@ -1165,6 +1288,18 @@ func (c *Client) GetNewTask(ctx context.Context, reqEditors ...RequestEditorFn)
 	return c.Client.Do(req)
 }
 
+func (c *Client) PeekNextTask(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPeekNextTaskRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) GetTask(ctx context.Context, id int64, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewGetTaskRequest(c.Server, id)
 	if err != nil {
@@ -1651,6 +1786,63 @@ func NewGetConfigurationRequest(server string) (*http.Request, error) {
 	return req, nil
 }
 
+// NewCheckForCrackerUpdateRequest generates requests for CheckForCrackerUpdate
+func NewCheckForCrackerUpdateRequest(server string, params *CheckForCrackerUpdateParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/client/crackers/check_for_cracker_update")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithOptions("form", true, "operating_system", params.OperatingSystem, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationQuery, Type: "string", Format: ""}); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		if queryFrag, err := runtime.StyleParamWithOptions("form", true, "version", params.Version, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationQuery, Type: "string", Format: ""}); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewGetHealthRequest generates requests for GetHealth
 func NewGetHealthRequest(server string) (*http.Request, error) {
 	var err error
@@ -1705,6 +1897,33 @@ func NewGetNewTaskRequest(server string) (*http.Request, error) {
 	return req, nil
 }
 
+// NewPeekNextTaskRequest generates requests for PeekNextTask
+func NewPeekNextTaskRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/client/tasks/peek")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewGetTaskRequest generates requests for GetTask
 func NewGetTaskRequest(server string, id int64) (*http.Request, error) {
 	var err error
@@ -2050,12 +2269,18 @@ type ClientWithResponsesInterface interface {
 	// GetConfigurationWithResponse request
 	GetConfigurationWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetConfigurationResponse, error)
 
+	// CheckForCrackerUpdateWithResponse request
+	CheckForCrackerUpdateWithResponse(ctx context.Context, params *CheckForCrackerUpdateParams, reqEditors ...RequestEditorFn) (*CheckForCrackerUpdateResponse, error)
+
 	// GetHealthWithResponse request
 	GetHealthWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetHealthResponse, error)
 
 	// GetNewTaskWithResponse request
 	GetNewTaskWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetNewTaskResponse, error)
 
+	// PeekNextTaskWithResponse request
+	PeekNextTaskWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PeekNextTaskResponse, error)
+
 	// GetTaskWithResponse request
 	GetTaskWithResponse(ctx context.Context, id int64, reqEditors ...RequestEditorFn) (*GetTaskResponse, error)
 
@@ -2313,6 +2538,29 @@ func (r GetConfigurationResponse) StatusCode() int {
 	return 0
 }
 
+type CheckForCrackerUpdateResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *CrackerUpdate
+	JSON401      *ErrorObject
+}
+
+// Status returns HTTPResponse.Status
+func (r CheckForCrackerUpdateResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CheckForCrackerUpdateResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type GetHealthResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -2383,6 +2631,29 @@ func (r GetNewTaskResponse) StatusCode() int {
 	return 0
 }
 
+type PeekNextTaskResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Task
+	JSON401      *ErrorObject
+}
+
+// Status returns HTTPResponse.Status
+func (r PeekNextTaskResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PeekNextTaskResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type GetTaskResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -2671,6 +2942,15 @@ func (c *ClientWithResponses) GetConfigurationWithResponse(ctx context.Context,
 	return ParseGetConfigurationResponse(rsp)
 }
 
+// CheckForCrackerUpdateWithResponse request returning *CheckForCrackerUpdateResponse
+func (c *ClientWithResponses) CheckForCrackerUpdateWithResponse(ctx context.Context, params *CheckForCrackerUpdateParams, reqEditors ...RequestEditorFn) (*CheckForCrackerUpdateResponse, error) {
+	rsp, err := c.CheckForCrackerUpdate(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCheckForCrackerUpdateResponse(rsp)
+}
+
 // GetHealthWithResponse request returning *GetHealthResponse
 func (c *ClientWithResponses) GetHealthWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetHealthResponse, error) {
 	rsp, err := c.GetHealth(ctx, reqEditors...)
@@ -2689,6 +2969,15 @@ func (c *ClientWithResponses) GetNewTaskWithResponse(ctx context.Context, reqEdi
 	return ParseGetNewTaskResponse(rsp)
 }
 
+// PeekNextTaskWithResponse request returning *PeekNextTaskResponse
+func (c *ClientWithResponses) PeekNextTaskWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PeekNextTaskResponse, error) {
+	rsp, err := c.PeekNextTask(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePeekNextTaskResponse(rsp)
+}
+
 // GetTaskWithResponse request returning *GetTaskResponse
 func (c *ClientWithResponses) GetTaskWithResponse(ctx context.Context, id int64, reqEditors ...RequestEditorFn) (*GetTaskResponse, error) {
 	rsp, err := c.GetTask(ctx, id, reqEditors...)
@@ -3105,6 +3394,39 @@ func ParseGetConfigurationResponse(rsp *http.Response) (*GetConfigurationRespons
 	return response, nil
 }
 
+// ParseCheckForCrackerUpdateResponse parses an HTTP response from a CheckForCrackerUpdateWithResponse call
+func ParseCheckForCrackerUpdateResponse(rsp *http.Response) (*CheckForCrackerUpdateResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CheckForCrackerUpdateResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest CrackerUpdate
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest ErrorObject
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseGetHealthResponse parses an HTTP response from a GetHealthWithResponse call
 func ParseGetHealthResponse(rsp *http.Response) (*GetHealthResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -3195,6 +3517,39 @@ func ParseGetNewTaskResponse(rsp *http.Response) (*GetNewTaskResponse, error) {
 	return response, nil
 }
 
+// ParsePeekNextTaskResponse parses an HTTP response from a PeekNextTaskWithResponse call
+func ParsePeekNextTaskResponse(rsp *http.Response) (*PeekNextTaskResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PeekNextTaskResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Task
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest ErrorObject
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseGetTaskResponse parses an HTTP response from a GetTaskWithResponse call
 func ParseGetTaskResponse(rsp *http.Response) (*GetTaskResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -7,6 +7,7 @@ import (
 	"io"
 	"log/slog"
 	"math"
+	"mime"
 	"net"
 	"net/http"
 	"runtime"
@@ -22,6 +23,13 @@ var (
 	_ AuthClient    = (*agentAuthClient)(nil)
 )
 
+// Zap formats the agent accepts from get_zaps. The server answers with the
+// structured format if it supports it and with plain text otherwise.
+const (
+	ZapRecordsContentType = "application/x-ndjson" // One ZapRecord per line, plaintext hex-encoded
+	ZapTextContentType    = "text/plain"           // hash<separator>plaintext lines
+)
+
 // TransportConfig holds all configuration for the HTTP transport chain.
 type TransportConfig struct {
 	ConnectTimeout time.Duration // TCP dial timeout
@@ -37,6 +45,10 @@ type TransportConfig struct {
 
 	Logger *slog.Logger // Optional; nil disables transport logging
 
+	// OnAPIError is called for each failed request attempt with a failure
+	// reason (see CircuitTransport.OnError). Optional; nil disables it.
+	OnAPIError func(reason string)
+
 	// BaseTransport overrides the default http.Transport when set.
 	// Used by tests to inject httpmock's transport into the chain.
 	BaseTransport http.RoundTripper
@@ -74,6 +86,7 @@ func NewAgentClient(serverURL, token string, cfg TransportConfig) (*AgentClient,
 		Base:    base,
 		Breaker: circuitBreaker,
 		Logger:  cfg.Logger,
+		OnError: cfg.OnAPIError,
 	}
 
 	retryTransport := &RetryTransport{
@@ -116,6 +129,9 @@ func (a *AgentClient) Agents() AgentsClient { return &agentAgentsClient{client:
 // Auth returns a sub-client for authentication-related API operations.
 func (a *AgentClient) Auth() AuthClient { return &agentAuthClient{client: a.client} }
 
+// Crackers returns a sub-client for cracker-related API operations.
+func (a *AgentClient) Crackers() CrackersClient { return &agentCrackersClient{client: a.client} }
+
 // ---------------------------------------------------------------------------
 // Tasks sub-client
 // ---------------------------------------------------------------------------
@@ -131,6 +147,13 @@ func (t *agentTasksClient) GetNewTask(ctx context.Context) (*GetNewTaskResponse,
 	)
 }
 
+func (t *agentTasksClient) PeekNextTask(ctx context.Context) (*PeekNextTaskResponse, error) {
+	return checkResponse(
+		func() (*PeekNextTaskResponse, error) { return t.client.PeekNextTaskWithResponse(ctx) },
+		func(r *PeekNextTaskResponse) []byte { return r.Body },
+	)
+}
+
 func (t *agentTasksClient) SetTaskAccepted(ctx context.Context, id int64) (*SetTaskAcceptedResponse, error) {
 	return checkResponse(
 		func() (*SetTaskAcceptedResponse, error) { return t.client.SetTaskAcceptedWithResponse(ctx, id) },
@@ -197,13 +220,28 @@ func (t *agentTasksClient) SendCrack(
 	)
 }
 
+func (t *agentTasksClient) GetTask(ctx context.Context, id int64) (*GetTaskResponse, error) {
+	return checkResponse(
+		func() (*GetTaskResponse, error) { return t.client.GetTaskWithResponse(ctx, id) },
+		func(r *GetTaskResponse) []byte { return r.Body },
+	)
+}
+
 func (t *agentTasksClient) GetTaskZaps(ctx context.Context, id int64) (*GetTaskZapsResponse, error) {
 	return checkResponse(
-		func() (*GetTaskZapsResponse, error) { return t.client.GetTaskZapsWithResponse(ctx, id) },
+		func() (*GetTaskZapsResponse, error) {
+			return t.client.GetTaskZapsWithResponse(ctx, id, acceptZapFormats)
+		},
 		func(r *GetTaskZapsResponse) []byte { return r.Body },
 	)
 }
 
+// acceptZapFormats asks for zaps as ZapRecords, falling back to plain text.
+func acceptZapFormats(_ context.Context, req *http.Request) error {
+	req.Header.Set("Accept", ZapRecordsContentType+", "+ZapTextContentType+";q=0.5")
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Attacks sub-client
 // ---------------------------------------------------------------------------
@@ -238,8 +276,13 @@ func (a *agentAgentsClient) SendHeartbeat(
 	ctx context.Context,
 	id int64,
 	activity string,
+	slots []AgentSlotActivity,
 ) (*SendHeartbeatResponse, error) {
 	body := SendHeartbeatJSONRequestBody{Activity: &activity}
+	if len(slots) > 0 {
+		body.Slots = &slots
+	}
+
 	return checkResponse(
 		func() (*SendHeartbeatResponse, error) { return a.client.SendHeartbeatWithResponse(ctx, id, body) },
 		func(r *SendHeartbeatResponse) []byte { return r.Body },
@@ -313,6 +356,28 @@ func (a *agentAuthClient) GetConfiguration(ctx context.Context) (*GetConfigurati
 	)
 }
 
+// ---------------------------------------------------------------------------
+// Crackers sub-client
+// ---------------------------------------------------------------------------
+
+type agentCrackersClient struct {
+	client *ClientWithResponses
+}
+
+func (a *agentCrackersClient) CheckForCrackerUpdate(
+	ctx context.Context,
+	operatingSystem, version string,
+) (*CheckForCrackerUpdateResponse, error) {
+	params := &CheckForCrackerUpdateParams{OperatingSystem: operatingSystem, Version: version}
+
+	return checkResponse(
+		func() (*CheckForCrackerUpdateResponse, error) {
+			return a.client.CheckForCrackerUpdateWithResponse(ctx, params)
+		},
+		func(r *CheckForCrackerUpdateResponse) []byte { return r.Body },
+	)
+}
+
 // ---------------------------------------------------------------------------
 // Helper functions
 // ---------------------------------------------------------------------------
@@ -393,6 +458,19 @@ func ResponseStream(resp *GetTaskZapsResponse) io.ReadCloser {
 	return nil
 }
 
+// ZapContentType returns the media type of a GetTaskZapsResponse, without
+// parameters, or ZapTextContentType if the response does not declare one.
+func ZapContentType(resp *GetTaskZapsResponse) string {
+	if resp == nil || resp.HTTPResponse == nil {
+		return ZapTextContentType
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.HTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		return ZapTextContentType
+	}
+	return mediaType
+}
+
 // HashListResponseStream extracts the response body as an io.Reader from a GetHashListResponse.
 // It uses the parsed Body bytes because the generated oapi-codegen parser reads and closes
 // HTTPResponse.Body during parsing, making the original body stream unavailable.
@@ -102,6 +102,28 @@ func TestResponseStream(t *testing.T) {
 	})
 }
 
+// ---------------------------------------------------------------------------
+// ZapContentType tests
+// ---------------------------------------------------------------------------
+
+func TestZapContentType(t *testing.T) {
+	t.Parallel()
+
+	withContentType := func(contentType string) *GetTaskZapsResponse {
+		header := http.Header{}
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		return &GetTaskZapsResponse{HTTPResponse: &http.Response{Header: header}}
+	}
+
+	assert.Equal(t, ZapTextContentType, ZapContentType(nil))
+	assert.Equal(t, ZapTextContentType, ZapContentType(&GetTaskZapsResponse{}))
+	assert.Equal(t, ZapTextContentType, ZapContentType(withContentType("")), "undeclared format is plain text")
+	assert.Equal(t, ZapTextContentType, ZapContentType(withContentType("text/plain; charset=utf-8")))
+	assert.Equal(t, ZapRecordsContentType, ZapContentType(withContentType("application/x-ndjson")))
+}
+
 // ---------------------------------------------------------------------------
 // HashListResponseStream tests
 // ---------------------------------------------------------------------------
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
 	"time"
@@ -144,3 +145,107 @@ func TestAgentClient_SetTaskAbandoned_4xx(t *testing.T) {
 	require.ErrorAs(t, err, &apiErr, "expected *APIError, got: %T", err)
 	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
 }
+
+// TestAgentClient_SendHeartbeat_Slots verifies that per-slot activity is sent
+// only when the agent reports slots.
+func TestAgentClient_SendHeartbeat_Slots(t *testing.T) {
+	t.Parallel()
+
+	mt := httpmock.NewMockTransport()
+	client := newTestClient(t, mt)
+
+	var bodies []map[string]any
+	mt.RegisterResponder(
+		"POST",
+		testServerURL+"/api/v1/client/agents/5/heartbeat",
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]any
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+			bodies = append(bodies, body)
+
+			return httpmock.NewStringResponse(http.StatusNoContent, ""), nil
+		},
+	)
+
+	_, err := client.Agents().SendHeartbeat(context.Background(), 5, "waiting", nil)
+	require.NoError(t, err)
+
+	taskID := int64(9)
+	_, err = client.Agents().SendHeartbeat(context.Background(), 5, "cracking", []AgentSlotActivity{
+		{Slot: 1, Devices: []int{1, 2}, Activity: "cracking", TaskId: &taskID},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, bodies, 2)
+	assert.NotContains(t, bodies[0], "slots")
+	assert.Equal(t, []any{map[string]any{
+		"slot": float64(1), "devices": []any{float64(1), float64(2)}, "activity": "cracking", "task_id": float64(9),
+	}}, bodies[1]["slots"])
+}
+
+// TestAgentClient_CheckForCrackerUpdate verifies that the platform and current
+// version are sent as query parameters and the designated release is parsed.
+func TestAgentClient_CheckForCrackerUpdate(t *testing.T) {
+	t.Parallel()
+
+	mt := httpmock.NewMockTransport()
+	client := newTestClient(t, mt)
+
+	mt.RegisterMatcherResponder(
+		"GET",
+		testServerURL+"/api/v1/client/crackers/check_for_cracker_update",
+		httpmock.NewMatcher("query", func(req *http.Request) bool {
+			q := req.URL.Query()
+			return q.Get("operating_system") == "linux" && q.Get("version") == "6.2.5"
+		}),
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]any{
+			"available":      true,
+			"latest_version": "6.2.6",
+			"download_url":   "https://example.com/hashcat.7z",
+			"checksum":       "d41d8cd98f00b204e9800998ecf8427e",
+			"exec_name":      "hashcat.bin",
+		}),
+	)
+
+	resp, err := client.Crackers().CheckForCrackerUpdate(context.Background(), "linux", "6.2.5")
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.JSON200)
+	assert.True(t, resp.JSON200.Available)
+	require.NotNil(t, resp.JSON200.LatestVersion)
+	assert.Equal(t, "6.2.6", *resp.JSON200.LatestVersion)
+	require.NotNil(t, resp.JSON200.ExecName)
+	assert.Equal(t, "hashcat.bin", *resp.JSON200.ExecName)
+}
+
+// TestAgentClient_GetTaskZaps_NegotiatesFormat verifies that zaps are requested
+// as ZapRecords with a plain-text fallback, and that the format the server chose
+// is read from the response.
+func TestAgentClient_GetTaskZaps_NegotiatesFormat(t *testing.T) {
+	t.Parallel()
+
+	mt := httpmock.NewMockTransport()
+	client := newTestClient(t, mt)
+
+	var accept string
+	mt.RegisterResponder(
+		"GET",
+		testServerURL+"/api/v1/client/tasks/9/get_zaps",
+		func(req *http.Request) (*http.Response, error) {
+			accept = req.Header.Get("Accept")
+
+			resp := httpmock.NewStringResponse(http.StatusOK, `{"hash":"h:1","plaintext_hex":"3a"}`+"\n")
+			resp.Header.Set("Content-Type", ZapRecordsContentType+"; charset=utf-8")
+
+			return resp, nil
+		},
+	)
+
+	resp, err := client.Tasks().GetTaskZaps(context.Background(), 9)
+	require.NoError(t, err)
+
+	assert.Equal(t, ZapRecordsContentType+", "+ZapTextContentType+";q=0.5", accept)
+	assert.Equal(t, ZapRecordsContentType, ZapContentType(resp))
+}
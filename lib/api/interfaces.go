@@ -13,6 +13,13 @@ type TasksClient interface {
 	// GetNewTask retrieves a new task from the server.
 	GetNewTask(ctx context.Context) (*GetNewTaskResponse, error)
 
+	// PeekNextTask asks which task the server would likely assign next, without
+	// assigning it.
+	PeekNextTask(ctx context.Context) (*PeekNextTaskResponse, error)
+
+	// GetTask retrieves the server's current view of a task.
+	GetTask(ctx context.Context, id int64) (*GetTaskResponse, error)
+
 	// SetTaskAccepted marks a task as accepted.
 	SetTaskAccepted(ctx context.Context, id int64) (*SetTaskAcceptedResponse, error)
 
@@ -48,7 +55,13 @@ type AttacksClient interface {
 // testability gain at this codebase size.
 type AgentsClient interface {
 	// SendHeartbeat sends a heartbeat to the server with the current activity state.
-	SendHeartbeat(ctx context.Context, id int64, activity string) (*SendHeartbeatResponse, error)
+	// slots carries per-slot activity when the agent runs a task pool; nil omits it.
+	SendHeartbeat(
+		ctx context.Context,
+		id int64,
+		activity string,
+		slots []AgentSlotActivity,
+	) (*SendHeartbeatResponse, error)
 
 	// UpdateAgent updates agent metadata.
 	UpdateAgent(
@@ -84,6 +97,13 @@ type AuthClient interface {
 	GetConfiguration(ctx context.Context) (*GetConfigurationResponse, error)
 }
 
+// CrackersClient defines the interface for cracker (hashcat release) API operations.
+type CrackersClient interface {
+	// CheckForCrackerUpdate asks the server which hashcat release the agent should
+	// run on operatingSystem, given the version it currently runs.
+	CheckForCrackerUpdate(ctx context.Context, operatingSystem, version string) (*CheckForCrackerUpdateResponse, error)
+}
+
 // APIClient is the aggregate interface combining all API subsystems.
 // This can be implemented by the AgentClient wrapper or by mocks in tests.
 type APIClient interface { //nolint:revive // stutter is intentional — avoids conflict with generated Client type
@@ -91,4 +111,5 @@ type APIClient interface { //nolint:revive // stutter is intentional — avoids
 	Attacks() AttacksClient
 	Agents() AgentsClient
 	Auth() AuthClient
+	Crackers() CrackersClient
 }
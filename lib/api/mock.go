@@ -7,20 +7,22 @@ import (
 
 // Compile-time interface compliance checks for mocks.
 var (
-	_ APIClient     = (*MockClient)(nil)
-	_ TasksClient   = (*MockTasksClient)(nil)
-	_ AttacksClient = (*MockAttacksClient)(nil)
-	_ AgentsClient  = (*MockAgentsClient)(nil)
-	_ AuthClient    = (*MockAuthClient)(nil)
+	_ APIClient      = (*MockClient)(nil)
+	_ TasksClient    = (*MockTasksClient)(nil)
+	_ AttacksClient  = (*MockAttacksClient)(nil)
+	_ AgentsClient   = (*MockAgentsClient)(nil)
+	_ AuthClient     = (*MockAuthClient)(nil)
+	_ CrackersClient = (*MockCrackersClient)(nil)
 )
 
 // MockClient is a test double for the APIClient interface.
 // Each subsystem client can be configured independently.
 type MockClient struct {
-	TasksImpl   TasksClient
-	AttacksImpl AttacksClient
-	AgentsImpl  AgentsClient
-	AuthImpl    AuthClient
+	TasksImpl    TasksClient
+	AttacksImpl  AttacksClient
+	AgentsImpl   AgentsClient
+	AuthImpl     AuthClient
+	CrackersImpl CrackersClient
 }
 
 // Tasks returns the configured TasksClient, or an unconfigured mock that returns descriptive errors.
@@ -59,10 +61,21 @@ func (m *MockClient) Auth() AuthClient {
 	return &MockAuthClient{}
 }
 
+// Crackers returns the configured CrackersClient, or an unconfigured mock that returns descriptive errors.
+func (m *MockClient) Crackers() CrackersClient {
+	if m.CrackersImpl != nil {
+		return m.CrackersImpl
+	}
+
+	return &MockCrackersClient{}
+}
+
 // MockTasksClient is a configurable mock for TasksClient.
 // Set the function fields to control mock behavior.
 type MockTasksClient struct {
 	GetNewTaskFunc       func(ctx context.Context) (*GetNewTaskResponse, error)
+	PeekNextTaskFunc     func(ctx context.Context) (*PeekNextTaskResponse, error)
+	GetTaskFunc          func(ctx context.Context, id int64) (*GetTaskResponse, error)
 	SetTaskAcceptedFunc  func(ctx context.Context, id int64) (*SetTaskAcceptedResponse, error)
 	SetTaskExhaustedFunc func(ctx context.Context, id int64) (*SetTaskExhaustedResponse, error)
 	SetTaskAbandonedFunc func(ctx context.Context, id int64) (*SetTaskAbandonedResponse, error)
@@ -80,6 +93,15 @@ func (m *MockTasksClient) GetNewTask(ctx context.Context) (*GetNewTaskResponse,
 	return nil, fmt.Errorf("mock method not configured: %T", m)
 }
 
+// PeekNextTask calls the configured function or returns an error if not configured.
+func (m *MockTasksClient) PeekNextTask(ctx context.Context) (*PeekNextTaskResponse, error) {
+	if m.PeekNextTaskFunc != nil {
+		return m.PeekNextTaskFunc(ctx)
+	}
+
+	return nil, fmt.Errorf("mock method not configured: %T", m)
+}
+
 // SetTaskAccepted calls the configured function or returns an error if not configured.
 func (m *MockTasksClient) SetTaskAccepted(ctx context.Context, id int64) (*SetTaskAcceptedResponse, error) {
 	if m.SetTaskAcceptedFunc != nil {
@@ -139,6 +161,15 @@ func (m *MockTasksClient) SendCrack(
 	return nil, fmt.Errorf("mock method not configured: %T", m)
 }
 
+// GetTask calls the configured function or returns an error if not configured.
+func (m *MockTasksClient) GetTask(ctx context.Context, id int64) (*GetTaskResponse, error) {
+	if m.GetTaskFunc != nil {
+		return m.GetTaskFunc(ctx, id)
+	}
+
+	return nil, fmt.Errorf("mock method not configured: %T", m)
+}
+
 // GetTaskZaps calls the configured function or returns an error if not configured.
 func (m *MockTasksClient) GetTaskZaps(ctx context.Context, id int64) (*GetTaskZapsResponse, error) {
 	if m.GetTaskZapsFunc != nil {
@@ -174,7 +205,7 @@ func (m *MockAttacksClient) GetHashList(ctx context.Context, id int64) (*GetHash
 
 // MockAgentsClient is a configurable mock for AgentsClient.
 type MockAgentsClient struct {
-	SendHeartbeatFunc    func(ctx context.Context, id int64, activity string) (*SendHeartbeatResponse, error)
+	SendHeartbeatFunc    func(ctx context.Context, id int64, activity string, slots []AgentSlotActivity) (*SendHeartbeatResponse, error)
 	UpdateAgentFunc      func(ctx context.Context, id int64, body UpdateAgentJSONRequestBody) (*UpdateAgentResponse, error)
 	SubmitBenchmarkFunc  func(ctx context.Context, id int64, body SubmitBenchmarkJSONRequestBody) (*SubmitBenchmarkResponse, error)
 	SubmitErrorAgentFunc func(ctx context.Context, id int64, body SubmitErrorAgentJSONRequestBody) (*SubmitErrorAgentResponse, error)
@@ -186,9 +217,10 @@ func (m *MockAgentsClient) SendHeartbeat(
 	ctx context.Context,
 	id int64,
 	activity string,
+	slots []AgentSlotActivity,
 ) (*SendHeartbeatResponse, error) {
 	if m.SendHeartbeatFunc != nil {
-		return m.SendHeartbeatFunc(ctx, id, activity)
+		return m.SendHeartbeatFunc(ctx, id, activity, slots)
 	}
 
 	return nil, fmt.Errorf("mock method not configured: %T", m)
@@ -268,3 +300,20 @@ func (m *MockAuthClient) GetConfiguration(ctx context.Context) (*GetConfiguratio
 
 	return nil, fmt.Errorf("mock method not configured: %T", m)
 }
+
+// MockCrackersClient is a configurable mock for CrackersClient.
+type MockCrackersClient struct {
+	CheckForCrackerUpdateFunc func(ctx context.Context, operatingSystem, version string) (*CheckForCrackerUpdateResponse, error)
+}
+
+// CheckForCrackerUpdate calls the configured function or returns an error if not configured.
+func (m *MockCrackersClient) CheckForCrackerUpdate(
+	ctx context.Context,
+	operatingSystem, version string,
+) (*CheckForCrackerUpdateResponse, error) {
+	if m.CheckForCrackerUpdateFunc != nil {
+		return m.CheckForCrackerUpdateFunc(ctx, operatingSystem, version)
+	}
+
+	return nil, fmt.Errorf("mock method not configured: %T", m)
+}
@@ -33,7 +33,8 @@ func GetHashcatVersion(ctx context.Context, hashcatPath string) (string, error)
 }
 
 // Extract7z extracts the contents of a 7z archive to the specified destination directory.
-// It uses the `7z` command-line tool to perform the extraction.
+// It uses the `7z` command-line tool to perform the extraction, and is only used
+// as a fallback for archives ExtractArchive cannot read.
 //
 // Parameters:
 //   - ctx: A context for cancellation and deadlines. The command will be cancelled if ctx is cancelled.
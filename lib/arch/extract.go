@@ -0,0 +1,324 @@
+// Package arch provides architecture-specific functionality.
+// This file contains the cross-platform, in-process archive extractor.
+package arch
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	// ErrUnsupportedArchive indicates the archive format, or a compression method or
+	// encryption used inside it, is not supported by the in-process extractor.
+	ErrUnsupportedArchive = errors.New("unsupported archive")
+	// ErrUnsafeArchivePath indicates an archive entry would be written outside the
+	// destination directory.
+	ErrUnsafeArchivePath = errors.New("archive entry escapes destination directory")
+	// ErrArchiveTooLarge indicates an archive expands beyond the extraction limits,
+	// as a decompression bomb would.
+	ErrArchiveTooLarge = errors.New("archive exceeds extraction limits")
+)
+
+// Extraction limits guarding against decompression bombs. A hashcat release
+// unpacks to a few hundred MiB at a modest ratio, well within these.
+const (
+	maxExtractedBytes   int64 = 4 << 30  // Hard cap on the total bytes written
+	maxExtractedEntries       = 100_000  // Cap on the number of files and directories
+	maxCompressionRatio int64 = 100      // Output allowed per byte of archive...
+	ratioAllowanceBytes int64 = 64 << 20 // ...but never less than this
+)
+
+// Archive signatures used to detect the format, since archives are not always
+// named after it.
+var (
+	magic7z   = []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}
+	magicZip  = []byte{'P', 'K', 0x03, 0x04}
+	magicGzip = []byte{0x1F, 0x8B}
+	magicXz   = []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// ExtractArchive extracts a 7z, zip, tar.gz, or tar.xz archive into destDir
+// without external tools. The format is detected from the file's contents.
+//
+// Entries that would land outside destDir are rejected with ErrUnsafeArchivePath,
+// and extraction stops with ErrArchiveTooLarge once the output outgrows the
+// archive by more than a fixed ratio, or an absolute cap. Executable bits are
+// preserved; group and world write bits are dropped. Symbolic links, hard links,
+// and device nodes are skipped. Cancelling ctx aborts extraction, which may leave
+// a partial tree behind.
+func ExtractArchive(ctx context.Context, srcFile, destDir string) error {
+	if err := ValidateArchivePaths(srcFile, destDir); err != nil {
+		return err
+	}
+
+	f, err := os.Open(srcFile) //nolint:gosec // G304 - path validated above
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading archive size: %w", err)
+	}
+
+	dest, err := filepath.Abs(destDir)
+	if err != nil {
+		return fmt.Errorf("resolving destination: %w", err)
+	}
+
+	x := &extractor{
+		ctx:    ctx,
+		dest:   dest,
+		budget: min(maxExtractedBytes, max(ratioAllowanceBytes, info.Size()*maxCompressionRatio)),
+	}
+
+	header := make([]byte, len(magicXz))
+	n, err := f.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading archive header: %w", err)
+	}
+
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, magic7z):
+		return x.extract7z(f, info.Size())
+	case bytes.HasPrefix(header, magicZip):
+		return x.extractZip(f, info.Size())
+	case bytes.HasPrefix(header, magicGzip):
+		gz, err := gzip.NewReader(bufio.NewReader(f))
+		if err != nil {
+			return fmt.Errorf("reading gzip stream: %w", err)
+		}
+		defer gz.Close()
+
+		return x.extractTar(gz)
+	case bytes.HasPrefix(header, magicXz):
+		xzr, err := xz.NewReader(bufio.NewReader(f))
+		if err != nil {
+			return fmt.Errorf("reading xz stream: %w", err)
+		}
+
+		return x.extractTar(xzr)
+	}
+
+	return fmt.Errorf("%w: unrecognized format: %s", ErrUnsupportedArchive, srcFile)
+}
+
+// extractor writes archive entries below dest, enforcing the extraction limits.
+type extractor struct {
+	ctx     context.Context //nolint:containedctx // scoped to a single ExtractArchive call
+	dest    string          // Absolute destination directory
+	budget  int64           // Bytes that may still be written
+	entries int
+}
+
+// extract7z extracts a 7z archive.
+func (x *extractor) extract7z(r io.ReaderAt, size int64) error {
+	archive, err := sevenzip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("reading 7z archive: %w", classify7zError(err))
+	}
+
+	for _, file := range archive.File {
+		err := x.entry(file.Name, file.FileInfo().Mode(), func() (io.ReadCloser, error) {
+			return file.Open()
+		})
+		if err != nil {
+			return classify7zError(err)
+		}
+	}
+
+	return nil
+}
+
+// classify7zError marks failures caused by features the 7z reader lacks, so
+// callers can fall back to another extractor.
+func classify7zError(err error) error {
+	var readErr *sevenzip.ReadError
+	if errors.As(err, &readErr) && readErr.Encrypted {
+		return fmt.Errorf("%w: encrypted 7z archive: %w", ErrUnsupportedArchive, err)
+	}
+
+	// The reader does not export its unsupported-method error.
+	if strings.Contains(err.Error(), "unsupported compression algorithm") {
+		return fmt.Errorf("%w: %w", ErrUnsupportedArchive, err)
+	}
+
+	return err
+}
+
+// extractZip extracts a zip archive.
+func (x *extractor) extractZip(r io.ReaderAt, size int64) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("reading zip archive: %w", err)
+	}
+
+	for _, file := range archive.File {
+		if err := x.entry(file.Name, file.Mode(), file.Open); err != nil {
+			if errors.Is(err, zip.ErrAlgorithm) {
+				return fmt.Errorf("%w: %w", ErrUnsupportedArchive, err)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractTar extracts a tar stream.
+func (x *extractor) extractTar(r io.Reader) error {
+	archive := tar.NewReader(&contextReader{ctx: x.ctx, r: r})
+
+	for {
+		hdr, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			if ctxErr := x.ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			return fmt.Errorf("reading tar archive: %w", err)
+		}
+
+		err = x.entry(hdr.Name, hdr.FileInfo().Mode(), func() (io.ReadCloser, error) {
+			return io.NopCloser(archive), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// entry extracts one archive entry. open is only called for regular files.
+func (x *extractor) entry(name string, mode fs.FileMode, open func() (io.ReadCloser, error)) error {
+	if err := x.ctx.Err(); err != nil {
+		return err
+	}
+
+	x.entries++
+	if x.entries > maxExtractedEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrArchiveTooLarge, maxExtractedEntries)
+	}
+
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mode.IsDir():
+		if err := os.MkdirAll(target, mode.Perm()&0o755|0o700); err != nil {
+			return fmt.Errorf("creating directory %s: %w", name, err)
+		}
+
+		return nil
+	case !mode.IsRegular():
+		// Links could point outside the destination; devices and pipes have no use here.
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", name, err)
+	}
+
+	src, err := open()
+	if err != nil {
+		return fmt.Errorf("opening %s in archive: %w", name, err)
+	}
+	defer src.Close()
+
+	return x.writeFile(target, name, mode.Perm()&0o755|0o600, src)
+}
+
+// writeFile copies src to target, charging the bytes against the budget.
+func (x *extractor) writeFile(target, name string, perm fs.FileMode, src io.Reader) error {
+	//nolint:gosec // G304 - target validated by x.target
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", name, err)
+	}
+
+	// Copy one byte past the budget to detect an entry that exceeds it.
+	n, err := io.Copy(out, io.LimitReader(&contextReader{ctx: x.ctx, r: src}, x.budget+1))
+	closeErr := out.Close()
+
+	x.budget -= n
+
+	switch {
+	case err != nil:
+		if ctxErr := x.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		return fmt.Errorf("extracting %s: %w", name, err)
+	case x.budget < 0:
+		return fmt.Errorf("%w: output too large at %s", ErrArchiveTooLarge, name)
+	case closeErr != nil:
+		return fmt.Errorf("writing %s: %w", name, closeErr)
+	}
+
+	// OpenFile applies perm only to new files; an overwritten file keeps its mode.
+	if err := os.Chmod(target, perm); err != nil {
+		return fmt.Errorf("setting mode of %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// target resolves an entry name to a path below dest, rejecting absolute paths
+// and parent-directory components. Backslashes are treated as separators, as
+// archives built on Windows may use them.
+func (x *extractor) target(name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+
+	if path.IsAbs(clean) || filepath.VolumeName(clean) != "" || strings.Contains(clean, ":") ||
+		clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+
+	target := filepath.Join(x.dest, filepath.FromSlash(clean))
+
+	rel, err := filepath.Rel(x.dest, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+
+	return target, nil
+}
+
+// contextReader fails reads once its context is cancelled, so long copies stop
+// promptly.
+type contextReader struct {
+	ctx context.Context //nolint:containedctx // wraps a single copy
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.r.Read(p)
+}
@@ -0,0 +1,229 @@
+package arch
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+// testEntry describes one file in a generated test archive.
+type testEntry struct {
+	name    string
+	mode    os.FileMode
+	content string
+}
+
+// testEntries mirrors the shape of a hashcat release: a top-level directory
+// holding an executable and a nested data file.
+func testEntries() []testEntry {
+	return []testEntry{
+		{name: "hashcat/", mode: os.ModeDir | 0o755},
+		{name: "hashcat/hashcat.bin", mode: 0o755, content: "#!/bin/sh\n"},
+		{name: "hashcat/OpenCL/inc_types.h", mode: 0o644, content: "// kernel source\n"},
+	}
+}
+
+func writeZip(t *testing.T, path string, entries []testEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		hdr.SetMode(e.mode)
+
+		w, err := zw.CreateHeader(hdr)
+		require.NoError(t, err)
+
+		_, err = io.WriteString(w, e.content)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+func writeTar(t *testing.T, w io.Writer, entries []testEntry) {
+	t.Helper()
+
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm()), Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if e.mode.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+		}
+
+		require.NoError(t, tw.WriteHeader(hdr))
+
+		_, err := io.WriteString(tw, e.content)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+}
+
+func writeTarGz(t *testing.T, path string, entries []testEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	writeTar(t, gz, entries)
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+func writeTarXz(t *testing.T, path string, entries []testEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	xw, err := xz.NewWriter(&buf)
+	require.NoError(t, err)
+	writeTar(t, xw, entries)
+	require.NoError(t, xw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+func TestExtractArchive_Formats(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(*testing.T, string, []testEntry)
+	}{
+		{"zip", writeZip},
+		{"tar.gz", writeTarGz},
+		{"tar.xz", writeTarXz},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// Name every archive .7z, as the agent does, to show detection ignores the extension.
+			archive := filepath.Join(dir, "hashcat.7z")
+			tt.write(t, archive, testEntries())
+
+			dest := mkdest(t, dir)
+			require.NoError(t, ExtractArchive(context.Background(), archive, dest))
+
+			content, err := os.ReadFile(filepath.Join(dest, "hashcat", "OpenCL", "inc_types.h"))
+			require.NoError(t, err)
+			assert.Equal(t, "// kernel source\n", string(content))
+
+			info, err := os.Stat(filepath.Join(dest, "hashcat", "hashcat.bin"))
+			require.NoError(t, err)
+
+			if runtime.GOOS != "windows" {
+				assert.Equal(t, os.FileMode(0o755), info.Mode().Perm(), "executable bits should be preserved")
+			}
+		})
+	}
+}
+
+func TestExtractArchive_RejectsPathTraversal(t *testing.T) {
+	for _, name := range []string{"../escape.txt", "hashcat/../../escape.txt", "/tmp/escape.txt", `..\escape.txt`} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "evil.zip")
+			writeZip(t, archive, []testEntry{{name: name, mode: 0o644, content: "pwned"}})
+
+			dest := mkdest(t, dir)
+			err := ExtractArchive(context.Background(), archive, dest)
+			require.ErrorIs(t, err, ErrUnsafeArchivePath)
+			assert.NoFileExists(t, filepath.Join(dir, "escape.txt"))
+		})
+	}
+}
+
+func TestExtractArchive_SkipsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "links.tar.gz")
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "passwd", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0o600))
+
+	dest := mkdest(t, dir)
+	require.NoError(t, ExtractArchive(context.Background(), archive, dest))
+
+	_, err := os.Lstat(filepath.Join(dest, "passwd"))
+	assert.True(t, os.IsNotExist(err), "symlinks should not be created")
+}
+
+func TestExtractArchive_RejectsDecompressionBomb(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bomb.zip")
+
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+
+	zw := zip.NewWriter(f)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "zeros", Method: zip.Deflate})
+	require.NoError(t, err)
+
+	// Zeros compress about 1000:1, so this stays tiny on disk.
+	_, err = io.CopyN(w, zeroReader{}, ratioAllowanceBytes+1<<20)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	err = ExtractArchive(context.Background(), archive, mkdest(t, dir))
+	require.ErrorIs(t, err, ErrArchiveTooLarge)
+}
+
+func TestExtractArchive_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "hashcat.tar.gz")
+	writeTarGz(t, archive, testEntries())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dest := mkdest(t, dir)
+	err := ExtractArchive(ctx, archive, dest)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, filepath.Join(dest, "hashcat", "hashcat.bin"))
+}
+
+func TestExtractArchive_UnsupportedFormat(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "hashcat.7z")
+	require.NoError(t, os.WriteFile(archive, []byte("not an archive"), 0o600))
+
+	err := ExtractArchive(context.Background(), archive, mkdest(t, dir))
+	require.ErrorIs(t, err, ErrUnsupportedArchive)
+}
+
+// mkdest creates the extraction destination, which must already exist.
+func mkdest(t *testing.T, dir string) string {
+	t.Helper()
+
+	dest := filepath.Join(dir, "out")
+	require.NoError(t, os.Mkdir(dest, 0o750))
+
+	return dest
+}
+
+// zeroReader is an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
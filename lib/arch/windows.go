@@ -36,6 +36,8 @@ func GetHashcatVersion(ctx context.Context, hashcatPath string) (string, error)
 }
 
 // Extract7z extracts the contents of a 7z archive to a specified directory.
+// It shells out to the external 7z tool and is only used as a fallback for
+// archives ExtractArchive cannot read.
 // It takes the source file path of the 7z archive and the destination directory
 // where the contents should be extracted.
 //
@@ -0,0 +1,324 @@
+// Package bandwidth caps how fast the agent downloads, so agents sharing a WAN
+// link with production traffic do not saturate it. A global cap is shared by all
+// downloads, an optional per-download cap applies to each one, and time-of-day
+// bands can change the global cap over the day.
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// maxChunk bounds how much a limited read passes through at once, so waits stay
+// short and the caps hold even for callers reading with large buffers.
+const maxChunk = 32 * 1024
+
+// Rate is a transfer rate in bytes per second. Zero means unlimited.
+type Rate int64
+
+// Unlimited is the Rate that imposes no cap.
+const Unlimited Rate = 0
+
+// rateUnits maps unit suffixes to their size in bytes. Bit units are divided by
+// eight when the rate is computed.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var rateUnits = map[string]float64{
+	"":     1,
+	"b":    1,
+	"kb":   1e3,
+	"mb":   1e6,
+	"gb":   1e9,
+	"kib":  1 << 10,
+	"mib":  1 << 20,
+	"gib":  1 << 30,
+	"bit":  1.0 / 8,
+	"kbit": 1e3 / 8,
+	"mbit": 1e6 / 8,
+	"gbit": 1e9 / 8,
+	"bps":  1.0 / 8,
+	"kbps": 1e3 / 8,
+	"mbps": 1e6 / 8,
+	"gbps": 1e9 / 8,
+}
+
+// ParseRate parses a rate such as "20Mbit", "20 Mbit/s", "2.5MB/s", or "512KiB".
+// Units are case-insensitive: "bit" and "bps" units count bits, the others bytes,
+// and a bare number is bytes per second. "", "0", and "unlimited" mean no cap.
+func ParseRate(s string) (Rate, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == "unlimited" {
+		return Unlimited, nil
+	}
+
+	s = strings.TrimSuffix(s, "/s")
+
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+
+	value, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid bandwidth rate %q", s)
+	}
+
+	unit, ok := rateUnits[strings.TrimSpace(s[end:])]
+	if !ok {
+		return 0, fmt.Errorf("invalid bandwidth unit in %q", s)
+	}
+
+	return Rate(value * unit), nil
+}
+
+// String formats r for logging, e.g. "2.5 MB/s".
+func (r Rate) String() string {
+	if r <= 0 {
+		return "unlimited"
+	}
+
+	return humanize.Bytes(uint64(r)) + "/s"
+}
+
+// Band applies a rate between two times of day. A band whose end is not after
+// its start wraps past midnight.
+type Band struct {
+	Start time.Duration // Offset from midnight
+	End   time.Duration // Offset from midnight
+	Rate  Rate
+}
+
+// contains reports whether the time of day at offset falls within b.
+func (b Band) contains(offset time.Duration) bool {
+	if b.Start < b.End {
+		return offset >= b.Start && offset < b.End
+	}
+
+	return offset >= b.Start || offset < b.End
+}
+
+// Schedule is an ordered list of bands; the first band containing the current
+// time of day applies.
+type Schedule []Band
+
+// ParseSchedule parses bands such as "08:00-18:00=20Mbit, 18:00-08:00=unlimited".
+// Bands are separated by commas or semicolons. An empty string yields no bands.
+func ParseSchedule(s string) (Schedule, error) {
+	var schedule Schedule
+
+	for field := range strings.FieldsFuncSeq(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		span, rateText, found := strings.Cut(field, "=")
+		if !found {
+			return nil, fmt.Errorf("bandwidth band %q must be of the form HH:MM-HH:MM=rate", field)
+		}
+
+		startText, endText, found := strings.Cut(span, "-")
+		if !found {
+			return nil, fmt.Errorf("bandwidth band %q must be of the form HH:MM-HH:MM=rate", field)
+		}
+
+		start, err := parseTimeOfDay(startText)
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseTimeOfDay(endText)
+		if err != nil {
+			return nil, err
+		}
+
+		if start == end {
+			return nil, fmt.Errorf("bandwidth band %q is empty", field)
+		}
+
+		rate, err := ParseRate(rateText)
+		if err != nil {
+			return nil, err
+		}
+
+		schedule = append(schedule, Band{Start: start, End: end, Rate: rate})
+	}
+
+	return schedule, nil
+}
+
+// parseTimeOfDay parses "HH:MM" as an offset from midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", s, err)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// RateAt returns the rate of the band containing the local time of day of now,
+// and whether any band does.
+func (s Schedule) RateAt(now time.Time) (Rate, bool) {
+	year, month, day := now.Date()
+	offset := now.Sub(time.Date(year, month, day, 0, 0, 0, 0, now.Location()))
+
+	for _, band := range s {
+		if band.contains(offset) {
+			return band.Rate, true
+		}
+	}
+
+	return Unlimited, false
+}
+
+// Policy holds the bandwidth caps.
+type Policy struct {
+	Limit       Rate     // Global cap shared by all downloads when no band applies
+	PerDownload Rate     // Cap on each download
+	Schedule    Schedule // Bands overriding Limit at certain times of day
+}
+
+// GlobalRate returns the global cap in force at now.
+func (p Policy) GlobalRate(now time.Time) Rate {
+	if rate, ok := p.Schedule.RateAt(now); ok {
+		return rate
+	}
+
+	return p.Limit
+}
+
+//nolint:gochecknoglobals // process-wide policy and the bucket shared by all downloads
+var (
+	policyMu sync.RWMutex
+	policy   Policy
+	global   bucket
+)
+
+// Configure installs p as the policy for all limited readers, including those
+// already in use.
+func Configure(p Policy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+
+	policy = p
+}
+
+// CurrentPolicy returns the policy installed by Configure.
+func CurrentPolicy() Policy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+
+	return policy
+}
+
+// NewReader returns a reader that passes r through under the global and
+// per-download caps of the current policy. Waiting for bandwidth ends early
+// with ctx's error if ctx is cancelled.
+func NewReader(ctx context.Context, r io.Reader) io.Reader {
+	return NewCappedReader(ctx, r, Unlimited)
+}
+
+// NewCappedReader is like NewReader, but also holds the stream to limit. The
+// tighter of limit and the policy's per-download cap applies.
+func NewCappedReader(ctx context.Context, r io.Reader, limit Rate) io.Reader {
+	return &reader{ctx: ctx, r: r, limit: limit}
+}
+
+// reader is a download stream subject to the bandwidth caps.
+type reader struct {
+	ctx   context.Context //nolint:containedctx // bounds waits during Read
+	r     io.Reader
+	limit Rate   // Cap on this stream alone, besides the policy's
+	own   bucket // The per-download cap
+}
+
+// Read reads from the underlying stream, then waits until the bytes read fit
+// within the caps.
+func (r *reader) Read(p []byte) (int, error) {
+	p = p[:min(len(p), maxChunk)]
+
+	n, err := r.r.Read(p)
+	if n == 0 {
+		return n, err
+	}
+
+	current := CurrentPolicy()
+	t := time.Now()
+
+	wait := max(
+		global.take(n, current.GlobalRate(t), t),
+		r.own.take(n, tighter(current.PerDownload, r.limit), t),
+	)
+	if wait <= 0 {
+		return n, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return n, err
+	case <-r.ctx.Done():
+		return n, r.ctx.Err()
+	}
+}
+
+// tighter returns the lower of two rates, treating Unlimited as the highest.
+func tighter(a, b Rate) Rate {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
+
+// bucket is a token bucket refilled at a rate that may change between calls.
+// It holds at most one second's worth of tokens and may go into debt; callers
+// wait out the debt.
+type bucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take spends n tokens at rate and returns how long the caller must wait for
+// the bucket to be out of debt. An unlimited rate never waits.
+func (b *bucket) take(n int, rate Rate, t time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if rate <= 0 {
+		b.last = time.Time{}
+		return 0
+	}
+
+	burst := float64(rate)
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = min(burst, b.tokens+t.Sub(b.last).Seconds()*float64(rate))
+	}
+
+	b.last = t
+	b.tokens -= float64(n)
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / float64(rate) * float64(time.Second))
+}
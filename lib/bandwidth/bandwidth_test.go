@@ -0,0 +1,161 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configureForTest installs p for the duration of the test.
+func configureForTest(t *testing.T, p Policy) {
+	t.Helper()
+
+	Configure(p)
+	global = bucket{}
+
+	t.Cleanup(func() {
+		Configure(Policy{})
+		global = bucket{}
+	})
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rate
+	}{
+		{"", Unlimited},
+		{"0", Unlimited},
+		{"unlimited", Unlimited},
+		{"1000", 1000},
+		{"20Mbit", 2_500_000},
+		{"20 Mbit/s", 2_500_000},
+		{"100mbps", 12_500_000},
+		{"2.5MB/s", 2_500_000},
+		{"512KiB", 512 * 1024},
+		{"1GiB", 1 << 30},
+		{"8kbit", 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRate(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, bad := range []string{"fast", "-1MB", "10 parsecs", "1.2.3MB"} {
+		_, err := ParseRate(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("08:00-18:00=20Mbit, 18:00-08:00=unlimited")
+	require.NoError(t, err)
+	require.Len(t, schedule, 2)
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, time.March, 4, hour, minute, 0, 0, time.Local)
+	}
+
+	rate, ok := schedule.RateAt(at(12, 0))
+	assert.True(t, ok)
+	assert.Equal(t, Rate(2_500_000), rate)
+
+	rate, ok = schedule.RateAt(at(23, 30))
+	assert.True(t, ok, "a band wrapping past midnight should cover the late evening")
+	assert.Equal(t, Unlimited, rate)
+
+	rate, ok = schedule.RateAt(at(3, 0))
+	assert.True(t, ok, "a band wrapping past midnight should cover the early morning")
+	assert.Equal(t, Unlimited, rate)
+
+	empty, err := ParseSchedule("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	for _, bad := range []string{"08:00-18:00", "08:00=1MB", "25:00-01:00=1MB", "08:00-08:00=1MB", "08:00-18:00=fast"} {
+		_, err := ParseSchedule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPolicyGlobalRate(t *testing.T) {
+	schedule, err := ParseSchedule("09:00-17:00=1MB")
+	require.NoError(t, err)
+
+	p := Policy{Limit: 5_000_000, Schedule: schedule}
+
+	assert.Equal(t, Rate(1_000_000), p.GlobalRate(time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)))
+	assert.Equal(t, Rate(5_000_000), p.GlobalRate(time.Date(2024, 1, 1, 20, 0, 0, 0, time.Local)),
+		"outside every band the flat limit applies")
+}
+
+func TestReader_Throttles(t *testing.T) {
+	// The first second's worth passes at once; the rest takes about half a second more.
+	configureForTest(t, Policy{PerDownload: 64 * 1024})
+
+	data := bytes.Repeat([]byte("x"), 96*1024)
+
+	start := time.Now()
+	got, err := io.ReadAll(NewReader(context.Background(), bytes.NewReader(data)))
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.GreaterOrEqual(t, elapsed, 400*time.Millisecond)
+	assert.Less(t, elapsed, 3*time.Second)
+}
+
+func TestTighter(t *testing.T) {
+	assert.Equal(t, Rate(10), tighter(10, Unlimited))
+	assert.Equal(t, Rate(10), tighter(Unlimited, 10))
+	assert.Equal(t, Rate(5), tighter(10, 5))
+	assert.Equal(t, Unlimited, tighter(Unlimited, Unlimited))
+}
+
+func TestCappedReader_Throttles(t *testing.T) {
+	configureForTest(t, Policy{})
+
+	data := bytes.Repeat([]byte("x"), 96*1024)
+
+	start := time.Now()
+	got, err := io.ReadAll(NewCappedReader(context.Background(), bytes.NewReader(data), 64*1024))
+
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond, "the cap should apply without any policy")
+}
+
+func TestReader_UnlimitedDoesNotWait(t *testing.T) {
+	configureForTest(t, Policy{})
+
+	data := bytes.Repeat([]byte("x"), 1<<20)
+
+	start := time.Now()
+	got, err := io.ReadAll(NewReader(context.Background(), bytes.NewReader(data)))
+
+	require.NoError(t, err)
+	assert.Len(t, got, len(data))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestReader_StopsWaitingWhenCancelled(t *testing.T) {
+	configureForTest(t, Policy{Limit: 1024})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := io.ReadAll(NewReader(ctx, bytes.NewReader(bytes.Repeat([]byte("x"), 64*1024))))
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
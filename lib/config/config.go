@@ -4,12 +4,15 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	gap "github.com/muesli/go-app-paths"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/devices"
 )
 
 // Default configuration values — the single source of truth for all defaults.
@@ -17,6 +20,11 @@ import (
 const (
 	// DefaultGPUTempThreshold is the GPU temperature threshold in Celsius.
 	DefaultGPUTempThreshold = 80
+	// DefaultGPUTempTripCount is the number of consecutive over-threshold status
+	// updates before the thermal guard acts.
+	DefaultGPUTempTripCount = 3
+	// DefaultGPUTempAction is the thermal guard response to an overheating device.
+	DefaultGPUTempAction = "pause"
 	// DefaultSleepOnFailure is the sleep duration after task failure.
 	DefaultSleepOnFailure = 60 * time.Second
 	// DefaultStatusTimer is the status update interval in seconds.
@@ -25,8 +33,26 @@ const (
 	DefaultHeartbeatInterval = 10 * time.Second
 	// DefaultTaskTimeout is the task timeout (long-running tasks are expected).
 	DefaultTaskTimeout = 24 * time.Hour
+	// DefaultStallTimeout is how long a running hashcat session may go without
+	// progress or status before the stall watchdog restarts it.
+	DefaultStallTimeout = 15 * time.Minute
+	// DefaultStallMaxRestarts is how many times a stalled session is restarted
+	// from its restore file before the task is abandoned.
+	DefaultStallMaxRestarts = 2
 	// DefaultDownloadMaxRetries is the max download retry attempts.
 	DefaultDownloadMaxRetries = 3
+	// DefaultDownloadConcurrency is how many attack resources are downloaded at once.
+	DefaultDownloadConcurrency = 4
+	// DefaultCompressedResourceMode decompresses compressed attack resources to disk.
+	DefaultCompressedResourceMode = "decompress"
+	// DefaultMinFreeDiskMB is the free disk space in MiB kept in the download and
+	// output directories.
+	DefaultMinFreeDiskMB = 1024
+	// DefaultPrefetchResources enables prefetching the next task's resources.
+	DefaultPrefetchResources = true
+	// DefaultPrefetchBandwidthLimit caps prefetch downloads so they stay out of
+	// the running task's way.
+	DefaultPrefetchBandwidthLimit = "10MB/s"
 	// DefaultDownloadRetryDelay is the base delay between download retries.
 	DefaultDownloadRetryDelay = 2 * time.Second
 	// DefaultInsecureDownloads controls TLS certificate verification for downloads.
@@ -68,6 +94,20 @@ const (
 	// DefaultCollectPerCPUMetrics controls whether per-logical-core CPU
 	// utilization is collected in addition to the overall figure.
 	DefaultCollectPerCPUMetrics = false
+	// DefaultPerformanceJSONLMaxSizeMB is the size in MiB at which the
+	// performance JSON-lines file is rotated.
+	DefaultPerformanceJSONLMaxSizeMB = 10
+	// DefaultPerformanceJSONLMaxBackups is the number of rotated performance
+	// JSON-lines files kept.
+	DefaultPerformanceJSONLMaxBackups = 3
+	// DefaultPerformanceStatsDPrefix is prepended to StatsD metric names.
+	DefaultPerformanceStatsDPrefix = "cipherswarm.agent"
+	// DefaultMetricsListen is the Prometheus metrics listen address; empty
+	// disables the endpoint.
+	DefaultMetricsListen = ""
+	// DefaultStatusListen is the health and status endpoint listen address;
+	// empty disables the endpoint.
+	DefaultStatusListen = ""
 )
 
 // MinPerformanceMonitoringInterval is the smallest allowed sampling interval —
@@ -176,6 +216,10 @@ func SetupSharedState() {
 		dataRoot,
 		"hashcat.pid",
 	) // Set the default hashcat PID file path
+	agentstate.State.ActiveTaskFile = filepath.Join(
+		dataRoot,
+		"active_task.json",
+	) // Set the active task record path in the shared state
 	agentstate.State.CrackersPath = filepath.Join(
 		dataRoot,
 		"crackers",
@@ -184,6 +228,30 @@ func SetupSharedState() {
 	if agentstate.State.FilePath == "" {
 		agentstate.State.FilePath = filepath.Join(dataRoot, "files")
 	}
+	agentstate.State.ResourceCachePath = viper.GetString("resource_cache_path")
+	if agentstate.State.ResourceCachePath == "" {
+		agentstate.State.ResourceCachePath = filepath.Join(dataRoot, "cache")
+	}
+	agentstate.State.ResourceCacheMaxSizeMB = viper.GetInt("resource_cache_max_size_mb")
+	if agentstate.State.ResourceCacheMaxSizeMB < 0 {
+		agentstate.Logger.Warn("resource_cache_max_size_mb must be >= 0, disabling eviction",
+			"configured", agentstate.State.ResourceCacheMaxSizeMB)
+		agentstate.State.ResourceCacheMaxSizeMB = 0
+	}
+	agentstate.State.CompressedResourceMode = viper.GetString("compressed_resource_mode")
+	switch agentstate.State.CompressedResourceMode {
+	case "decompress", "stream":
+	default:
+		agentstate.Logger.Warn("compressed_resource_mode must be one of decompress, stream; using default",
+			"configured", agentstate.State.CompressedResourceMode, "default", DefaultCompressedResourceMode)
+		agentstate.State.CompressedResourceMode = DefaultCompressedResourceMode
+	}
+	agentstate.State.MinFreeDiskMB = viper.GetInt("min_free_disk_mb")
+	if agentstate.State.MinFreeDiskMB < 0 {
+		agentstate.Logger.Warn("min_free_disk_mb must be >= 0, using default",
+			"configured", agentstate.State.MinFreeDiskMB, "default", DefaultMinFreeDiskMB)
+		agentstate.State.MinFreeDiskMB = DefaultMinFreeDiskMB
+	}
 	agentstate.State.HashlistPath = filepath.Join(
 		dataRoot,
 		"hashlists",
@@ -208,6 +276,10 @@ func SetupSharedState() {
 		dataRoot,
 		"restore",
 	) // Set the restore file path in the shared state
+	agentstate.State.JournalPath = filepath.Join(
+		dataRoot,
+		"journal",
+	) // Set the crack journal path in the shared state
 	agentstate.State.BenchmarkCachePath = filepath.Join(
 		dataRoot,
 		"benchmark_cache.json",
@@ -229,9 +301,10 @@ func SetupSharedState() {
 			"configured", agentstate.State.StatusTimer, "default", DefaultStatusTimer)
 		agentstate.State.StatusTimer = DefaultStatusTimer
 	}
-	agentstate.State.WriteZapsToFile = viper.GetBool(
-		"write_zaps_to_file",
-	) // Set the write zaps to file flag in the shared state
+	if viper.GetBool("write_zaps_to_file") {
+		agentstate.Logger.Warn("write_zaps_to_file is deprecated and ignored; " +
+			"cracked hashes are always recorded in the crack journal")
+	}
 	agentstate.State.RetainZapsOnCompletion = viper.GetBool(
 		"retain_zaps_on_completion",
 	) // Set the retain zaps on completion flag in the shared state
@@ -249,6 +322,7 @@ func SetupSharedState() {
 		)
 	}
 	agentstate.State.AlwaysUseNativeHashcat = viper.GetBool("always_use_native_hashcat")
+	agentstate.State.ExternalArchiveFallback = viper.GetBool("external_archive_fallback")
 	agentstate.State.DeferBenchmarks = viper.GetBool("defer_benchmarks")
 	agentstate.State.BenchmarkWhileIdle = viper.GetBool("benchmark_while_idle")
 
@@ -264,6 +338,60 @@ func SetupSharedState() {
 		agentstate.State.PerformanceMonitoringInterval = MinPerformanceMonitoringInterval
 	}
 
+	agentstate.State.PerformanceJSONLPath = viper.GetString("performance_jsonl_path")
+	agentstate.State.PerformanceJSONLMaxSizeMB = viper.GetInt("performance_jsonl_max_size_mb")
+	if agentstate.State.PerformanceJSONLMaxSizeMB < 0 {
+		agentstate.Logger.Warn("performance_jsonl_max_size_mb must be >= 0, using default",
+			"configured", agentstate.State.PerformanceJSONLMaxSizeMB, "default", DefaultPerformanceJSONLMaxSizeMB)
+		agentstate.State.PerformanceJSONLMaxSizeMB = DefaultPerformanceJSONLMaxSizeMB
+	}
+
+	agentstate.State.PerformanceJSONLMaxBackups = viper.GetInt("performance_jsonl_max_backups")
+	if agentstate.State.PerformanceJSONLMaxBackups < 0 {
+		agentstate.Logger.Warn("performance_jsonl_max_backups must be >= 0, using default",
+			"configured", agentstate.State.PerformanceJSONLMaxBackups, "default", DefaultPerformanceJSONLMaxBackups)
+		agentstate.State.PerformanceJSONLMaxBackups = DefaultPerformanceJSONLMaxBackups
+	}
+
+	agentstate.State.PerformanceStatsDAddress = strings.TrimSpace(viper.GetString("performance_statsd_address"))
+	agentstate.State.PerformanceStatsDPrefix = viper.GetString("performance_statsd_prefix")
+	agentstate.State.PerformanceOTLPEndpoint = strings.TrimSpace(viper.GetString("performance_otlp_endpoint"))
+
+	agentstate.State.MetricsListen = strings.TrimSpace(viper.GetString("metrics_listen"))
+	agentstate.State.StatusListen = strings.TrimSpace(viper.GetString("status_listen"))
+
+	agentstate.State.GPUTempThreshold = viper.GetInt("gpu_temp_threshold")
+	if agentstate.State.GPUTempThreshold < 0 {
+		agentstate.Logger.Warn("gpu_temp_threshold must be >= 0, using default",
+			"configured", agentstate.State.GPUTempThreshold, "default", DefaultGPUTempThreshold)
+		agentstate.State.GPUTempThreshold = DefaultGPUTempThreshold
+	}
+
+	agentstate.State.GPUTempTripCount = viper.GetInt("gpu_temp_trip_count")
+	if agentstate.State.GPUTempTripCount < 1 {
+		agentstate.Logger.Warn("gpu_temp_trip_count must be >= 1, using default",
+			"configured", agentstate.State.GPUTempTripCount, "default", DefaultGPUTempTripCount)
+		agentstate.State.GPUTempTripCount = DefaultGPUTempTripCount
+	}
+
+	agentstate.State.GPUTempAction = viper.GetString("gpu_temp_action")
+	switch agentstate.State.GPUTempAction {
+	case "pause", "stop", "abort":
+	default:
+		agentstate.Logger.Warn("gpu_temp_action must be one of pause, stop, abort; using default",
+			"configured", agentstate.State.GPUTempAction, "default", DefaultGPUTempAction)
+		agentstate.State.GPUTempAction = DefaultGPUTempAction
+	}
+
+	agentstate.State.DeviceGroups = strings.TrimSpace(viper.GetString("device_groups"))
+	if agentstate.State.DeviceGroups != "" && agentstate.State.DeviceGroups != devices.DeviceGroupsAuto {
+		if _, err := devices.ParseDeviceGroups(agentstate.State.DeviceGroups); err != nil {
+			agentstate.Logger.Warn("Invalid device_groups, running one task at a time",
+				"configured", agentstate.State.DeviceGroups, "error", err)
+			agentstate.State.DeviceGroups = ""
+		}
+	}
+
 	// Validate numeric/duration config fields — clamp to defaults with a warning.
 	agentstate.State.DownloadMaxRetries = viper.GetInt("download_max_retries")
 	if agentstate.State.DownloadMaxRetries < 1 {
@@ -274,6 +402,36 @@ func SetupSharedState() {
 
 	agentstate.State.DownloadRetryDelay = viper.GetDuration("download_retry_delay")
 
+	agentstate.State.DownloadConcurrency = viper.GetInt("download_concurrency")
+	if agentstate.State.DownloadConcurrency < 1 {
+		agentstate.Logger.Warn("download_concurrency must be >= 1, using default",
+			"configured", agentstate.State.DownloadConcurrency, "default", DefaultDownloadConcurrency)
+		agentstate.State.DownloadConcurrency = DefaultDownloadConcurrency
+	}
+
+	agentstate.State.DownloadBandwidthLimit = validBandwidthRate(
+		"download_bandwidth_limit", viper.GetString("download_bandwidth_limit"))
+	agentstate.State.DownloadBandwidthPerDownload = validBandwidthRate(
+		"download_bandwidth_per_download", viper.GetString("download_bandwidth_per_download"))
+
+	agentstate.State.DownloadBandwidthSchedule = strings.TrimSpace(viper.GetString("download_bandwidth_schedule"))
+	if _, err := bandwidth.ParseSchedule(agentstate.State.DownloadBandwidthSchedule); err != nil {
+		agentstate.Logger.Warn("Invalid download_bandwidth_schedule, ignoring it",
+			"configured", agentstate.State.DownloadBandwidthSchedule, "error", err)
+		agentstate.State.DownloadBandwidthSchedule = ""
+	}
+
+	ApplyBandwidthPolicy()
+
+	agentstate.State.PrefetchResources = viper.GetBool("prefetch_resources")
+
+	agentstate.State.PrefetchBandwidthLimit = strings.TrimSpace(viper.GetString("prefetch_bandwidth_limit"))
+	if _, err := bandwidth.ParseRate(agentstate.State.PrefetchBandwidthLimit); err != nil {
+		agentstate.Logger.Warn("Invalid prefetch_bandwidth_limit, using default",
+			"configured", agentstate.State.PrefetchBandwidthLimit, "default", DefaultPrefetchBandwidthLimit, "error", err)
+		agentstate.State.PrefetchBandwidthLimit = DefaultPrefetchBandwidthLimit
+	}
+
 	agentstate.State.TaskTimeout = viper.GetDuration("task_timeout")
 	if agentstate.State.TaskTimeout <= 0 {
 		agentstate.Logger.Warn("task_timeout must be > 0, using default",
@@ -281,6 +439,20 @@ func SetupSharedState() {
 		agentstate.State.TaskTimeout = DefaultTaskTimeout
 	}
 
+	agentstate.State.StallTimeout = viper.GetDuration("stall_timeout")
+	if agentstate.State.StallTimeout < 0 {
+		agentstate.Logger.Warn("stall_timeout must be >= 0, using default",
+			"configured", agentstate.State.StallTimeout, "default", DefaultStallTimeout)
+		agentstate.State.StallTimeout = DefaultStallTimeout
+	}
+
+	agentstate.State.StallMaxRestarts = viper.GetInt("stall_max_restarts")
+	if agentstate.State.StallMaxRestarts < 0 {
+		agentstate.Logger.Warn("stall_max_restarts must be >= 0, using default",
+			"configured", agentstate.State.StallMaxRestarts, "default", DefaultStallMaxRestarts)
+		agentstate.State.StallMaxRestarts = DefaultStallMaxRestarts
+	}
+
 	agentstate.State.MaxHeartbeatBackoff = viper.GetInt("max_heartbeat_backoff")
 	if agentstate.State.MaxHeartbeatBackoff < 0 {
 		agentstate.Logger.Warn("max_heartbeat_backoff must be >= 0, using default",
@@ -359,6 +531,37 @@ func SetupSharedState() {
 	}
 }
 
+// validBandwidthRate returns value trimmed if it parses as a bandwidth rate,
+// otherwise logs a warning and returns "" (unlimited).
+func validBandwidthRate(name, value string) string {
+	value = strings.TrimSpace(value)
+	if _, err := bandwidth.ParseRate(value); err != nil {
+		agentstate.Logger.Warn("Invalid bandwidth rate, downloads will not be limited by it",
+			"field", name, "configured", value, "error", err)
+		return ""
+	}
+
+	return value
+}
+
+// ApplyBandwidthPolicy installs the download bandwidth caps from
+// agentstate.State. It runs at startup and again whenever the server changes
+// them, so downloads already in progress pick up the new caps.
+func ApplyBandwidthPolicy() {
+	// SetupSharedState and the server configuration both validate these values
+	// before storing them, so parse errors cannot occur here.
+	limit, _ := bandwidth.ParseRate(agentstate.State.DownloadBandwidthLimit)
+	perDownload, _ := bandwidth.ParseRate(agentstate.State.DownloadBandwidthPerDownload)
+	schedule, _ := bandwidth.ParseSchedule(agentstate.State.DownloadBandwidthSchedule)
+
+	bandwidth.Configure(bandwidth.Policy{Limit: limit, PerDownload: perDownload, Schedule: schedule})
+
+	if limit != bandwidth.Unlimited || perDownload != bandwidth.Unlimited || len(schedule) > 0 {
+		agentstate.Logger.Info("Download bandwidth limited",
+			"limit", limit, "per_download", perDownload, "schedule", agentstate.State.DownloadBandwidthSchedule)
+	}
+}
+
 // SetDefaultConfigValues sets default configuration values.
 func SetDefaultConfigValues() {
 	cwd, err := os.Getwd()
@@ -366,12 +569,19 @@ func SetDefaultConfigValues() {
 
 	viper.SetDefault("data_path", filepath.Join(cwd, "data"))
 	viper.SetDefault("gpu_temp_threshold", DefaultGPUTempThreshold)
+	viper.SetDefault("gpu_temp_trip_count", DefaultGPUTempTripCount)
+	viper.SetDefault("gpu_temp_action", DefaultGPUTempAction)
+	viper.SetDefault("device_groups", "")
 	viper.SetDefault("always_use_native_hashcat", false)
+	viper.SetDefault("external_archive_fallback", true)
 	viper.SetDefault("hashcat_path", "")
 	viper.SetDefault("sleep_on_failure", DefaultSleepOnFailure)
 	viper.SetDefault("always_trust_files", false)
-	// files_path and zap_path are derived from data_path in SetupSharedState
+	// files_path, resource_cache_path, and zap_path are derived from data_path in SetupSharedState
 	// when not explicitly set (avoids eagerly reading data_path before config is loaded).
+	viper.SetDefault("resource_cache_max_size_mb", 0)
+	viper.SetDefault("compressed_resource_mode", DefaultCompressedResourceMode)
+	viper.SetDefault("min_free_disk_mb", DefaultMinFreeDiskMB)
 	viper.SetDefault("extra_debugging", false)
 	viper.SetDefault("status_timer", DefaultStatusTimer)
 	viper.SetDefault("heartbeat_interval", DefaultHeartbeatInterval)
@@ -379,8 +589,16 @@ func SetDefaultConfigValues() {
 	viper.SetDefault("retain_zaps_on_completion", false)
 	viper.SetDefault("enable_additional_hash_types", true)
 	viper.SetDefault("task_timeout", DefaultTaskTimeout)
+	viper.SetDefault("stall_timeout", DefaultStallTimeout)
+	viper.SetDefault("stall_max_restarts", DefaultStallMaxRestarts)
 	viper.SetDefault("download_max_retries", DefaultDownloadMaxRetries)
 	viper.SetDefault("download_retry_delay", DefaultDownloadRetryDelay)
+	viper.SetDefault("download_concurrency", DefaultDownloadConcurrency)
+	viper.SetDefault("download_bandwidth_limit", "")
+	viper.SetDefault("download_bandwidth_per_download", "")
+	viper.SetDefault("download_bandwidth_schedule", "")
+	viper.SetDefault("prefetch_resources", DefaultPrefetchResources)
+	viper.SetDefault("prefetch_bandwidth_limit", DefaultPrefetchBandwidthLimit)
 	viper.SetDefault("insecure_downloads", DefaultInsecureDownloads)
 	viper.SetDefault("max_heartbeat_backoff", DefaultMaxHeartbeatBackoff)
 	viper.SetDefault("force_benchmark_run", false)
@@ -397,6 +615,14 @@ func SetDefaultConfigValues() {
 	viper.SetDefault("benchmark_while_idle", true)
 	viper.SetDefault("performance_monitoring_enabled", DefaultPerformanceMonitoringEnabled)
 	viper.SetDefault("performance_monitoring_interval", DefaultPerformanceMonitoringInterval)
+	viper.SetDefault("performance_jsonl_path", "")
+	viper.SetDefault("performance_jsonl_max_size_mb", DefaultPerformanceJSONLMaxSizeMB)
+	viper.SetDefault("performance_jsonl_max_backups", DefaultPerformanceJSONLMaxBackups)
+	viper.SetDefault("performance_statsd_address", "")
+	viper.SetDefault("performance_statsd_prefix", DefaultPerformanceStatsDPrefix)
+	viper.SetDefault("performance_otlp_endpoint", "")
+	viper.SetDefault("metrics_listen", DefaultMetricsListen)
+	viper.SetDefault("status_listen", DefaultStatusListen)
 	viper.SetDefault("collect_process_metrics", DefaultCollectProcessMetrics)
 	viper.SetDefault("collect_per_cpu_metrics", DefaultCollectPerCPUMetrics)
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
 )
 
 func TestSetDefaultConfigValues(t *testing.T) {
@@ -83,6 +84,24 @@ func TestSetDefaultConfigValues(t *testing.T) {
 				expected: 80,
 				getter:   func(k string) any { return viper.GetInt(k) },
 			},
+			{
+				name:     "gpu_temp_trip_count defaults to 3",
+				key:      "gpu_temp_trip_count",
+				expected: 3,
+				getter:   func(k string) any { return viper.GetInt(k) },
+			},
+			{
+				name:     "gpu_temp_action defaults to pause",
+				key:      "gpu_temp_action",
+				expected: "pause",
+				getter:   func(k string) any { return viper.GetString(k) },
+			},
+			{
+				name:     "device_groups defaults to empty",
+				key:      "device_groups",
+				expected: "",
+				getter:   func(k string) any { return viper.GetString(k) },
+			},
 			{
 				name:     "always_use_native_hashcat defaults to false",
 				key:      "always_use_native_hashcat",
@@ -403,6 +422,206 @@ func TestSetupSharedState_HonoursPerformanceInterval(t *testing.T) {
 	assert.Equal(t, 45*time.Second, agentstate.State.PerformanceMonitoringInterval)
 }
 
+func TestSetupSharedState_PerformanceSinks(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Empty(t, agentstate.State.PerformanceJSONLPath)
+	assert.Equal(t, DefaultPerformanceJSONLMaxSizeMB, agentstate.State.PerformanceJSONLMaxSizeMB)
+	assert.Equal(t, DefaultPerformanceJSONLMaxBackups, agentstate.State.PerformanceJSONLMaxBackups)
+	assert.Empty(t, agentstate.State.PerformanceStatsDAddress)
+	assert.Equal(t, DefaultPerformanceStatsDPrefix, agentstate.State.PerformanceStatsDPrefix)
+	assert.Empty(t, agentstate.State.PerformanceOTLPEndpoint)
+
+	viper.Set("performance_jsonl_max_size_mb", -1)
+	viper.Set("performance_jsonl_max_backups", -2)
+	viper.Set("performance_statsd_address", " localhost:8125 ")
+	SetupSharedState()
+	assert.Equal(t, DefaultPerformanceJSONLMaxSizeMB, agentstate.State.PerformanceJSONLMaxSizeMB,
+		"a negative size should fall back to the default")
+	assert.Equal(t, DefaultPerformanceJSONLMaxBackups, agentstate.State.PerformanceJSONLMaxBackups,
+		"a negative backup count should fall back to the default")
+	assert.Equal(t, "localhost:8125", agentstate.State.PerformanceStatsDAddress)
+}
+
+func TestSetupSharedState_ResourceCache(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Equal(t, filepath.Join(viper.GetString("data_path"), "cache"), agentstate.State.ResourceCachePath)
+	assert.Zero(t, agentstate.State.ResourceCacheMaxSizeMB, "eviction should be disabled by default")
+
+	viper.Set("resource_cache_path", "/srv/cache")
+	viper.Set("resource_cache_max_size_mb", -5)
+	SetupSharedState()
+	assert.Equal(t, "/srv/cache", agentstate.State.ResourceCachePath)
+	assert.Zero(t, agentstate.State.ResourceCacheMaxSizeMB, "a negative quota should disable eviction")
+}
+
+func TestSetupSharedState_MinFreeDisk(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Equal(t, DefaultMinFreeDiskMB, agentstate.State.MinFreeDiskMB)
+
+	viper.Set("min_free_disk_mb", 0)
+	SetupSharedState()
+	assert.Zero(t, agentstate.State.MinFreeDiskMB, "zero should disable the floor")
+
+	viper.Set("min_free_disk_mb", -1)
+	SetupSharedState()
+	assert.Equal(t, DefaultMinFreeDiskMB, agentstate.State.MinFreeDiskMB,
+		"a negative floor should fall back to the default")
+}
+
+func TestSetupSharedState_StallWatchdog(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Equal(t, DefaultStallTimeout, agentstate.State.StallTimeout)
+	assert.Equal(t, DefaultStallMaxRestarts, agentstate.State.StallMaxRestarts)
+
+	viper.Set("stall_timeout", 0)
+	viper.Set("stall_max_restarts", 0)
+	SetupSharedState()
+	assert.Zero(t, agentstate.State.StallTimeout, "zero should disable the watchdog")
+	assert.Zero(t, agentstate.State.StallMaxRestarts, "zero should abandon on the first stall")
+
+	viper.Set("stall_timeout", -time.Minute)
+	viper.Set("stall_max_restarts", -1)
+	SetupSharedState()
+	assert.Equal(t, DefaultStallTimeout, agentstate.State.StallTimeout,
+		"a negative timeout should fall back to the default")
+	assert.Equal(t, DefaultStallMaxRestarts, agentstate.State.StallMaxRestarts,
+		"a negative restart count should fall back to the default")
+}
+
+func TestSetupSharedState_CompressedResourceMode(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Equal(t, DefaultCompressedResourceMode, agentstate.State.CompressedResourceMode)
+
+	viper.Set("compressed_resource_mode", "stream")
+	SetupSharedState()
+	assert.Equal(t, "stream", agentstate.State.CompressedResourceMode)
+
+	viper.Set("compressed_resource_mode", "unzip")
+	SetupSharedState()
+	assert.Equal(t, DefaultCompressedResourceMode, agentstate.State.CompressedResourceMode,
+		"an unknown mode should fall back to the default")
+}
+
+func TestSetupSharedState_DownloadConcurrency(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Equal(t, DefaultDownloadConcurrency, agentstate.State.DownloadConcurrency)
+
+	viper.Set("download_concurrency", 0)
+	SetupSharedState()
+	assert.Equal(t, DefaultDownloadConcurrency, agentstate.State.DownloadConcurrency,
+		"a limit below 1 should fall back to the default")
+}
+
+func TestSetupSharedState_DownloadBandwidth(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+	t.Cleanup(func() { bandwidth.Configure(bandwidth.Policy{}) })
+
+	SetupSharedState()
+	assert.Equal(t, bandwidth.Policy{}, bandwidth.CurrentPolicy(), "downloads should be unlimited by default")
+
+	viper.Set("download_bandwidth_limit", "20Mbit")
+	viper.Set("download_bandwidth_per_download", " 1MB/s ")
+	viper.Set("download_bandwidth_schedule", "08:00-18:00=8Mbit")
+	SetupSharedState()
+
+	assert.Equal(t, "1MB/s", agentstate.State.DownloadBandwidthPerDownload)
+
+	policy := bandwidth.CurrentPolicy()
+	assert.Equal(t, bandwidth.Rate(2_500_000), policy.Limit)
+	assert.Equal(t, bandwidth.Rate(1_000_000), policy.PerDownload)
+	require.Len(t, policy.Schedule, 1)
+	assert.Equal(t, bandwidth.Rate(1_000_000), policy.Schedule[0].Rate)
+
+	viper.Set("download_bandwidth_limit", "fast")
+	viper.Set("download_bandwidth_schedule", "business hours")
+	SetupSharedState()
+
+	assert.Empty(t, agentstate.State.DownloadBandwidthLimit, "an invalid rate should be ignored")
+	assert.Empty(t, agentstate.State.DownloadBandwidthSchedule, "an invalid schedule should be ignored")
+	assert.Equal(t, bandwidth.Unlimited, bandwidth.CurrentPolicy().Limit)
+}
+
+func TestSetupSharedState_Prefetch(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Equal(t, DefaultPrefetchResources, agentstate.State.PrefetchResources)
+	assert.Equal(t, DefaultPrefetchBandwidthLimit, agentstate.State.PrefetchBandwidthLimit)
+
+	viper.Set("prefetch_bandwidth_limit", "quickly")
+	SetupSharedState()
+	assert.Equal(t, DefaultPrefetchBandwidthLimit, agentstate.State.PrefetchBandwidthLimit,
+		"an invalid limit should fall back to the default")
+}
+
+func TestSetupSharedState_MetricsListen(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Empty(t, agentstate.State.MetricsListen, "the metrics endpoint should be disabled by default")
+
+	viper.Set("metrics_listen", " 127.0.0.1:9400 ")
+	SetupSharedState()
+	assert.Equal(t, "127.0.0.1:9400", agentstate.State.MetricsListen)
+}
+
+func TestSetupSharedState_StatusListen(t *testing.T) {
+	viper.Reset()
+	SetDefaultConfigValues()
+
+	SetupSharedState()
+	assert.Empty(t, agentstate.State.StatusListen, "the status endpoint should be disabled by default")
+
+	viper.Set("status_listen", "127.0.0.1:9401")
+	SetupSharedState()
+	assert.Equal(t, "127.0.0.1:9401", agentstate.State.StatusListen)
+}
+
+func TestSetupSharedState_DeviceGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		expected   string
+	}{
+		{name: "auto", configured: "auto", expected: "auto"},
+		{name: "explicit groups", configured: " 1,2;3,4 ", expected: "1,2;3,4"},
+		{name: "invalid groups disable the pool", configured: "1,2;2,3", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			SetDefaultConfigValues()
+
+			viper.Set("device_groups", tt.configured)
+			SetupSharedState()
+
+			assert.Equal(t, tt.expected, agentstate.State.DeviceGroups)
+		})
+	}
+}
+
 func TestSetupSharedState_DerivedPathsFromDataRoot(t *testing.T) {
 	t.Run("default data_path derives files_path and zap_path", func(t *testing.T) {
 		viper.Reset()
@@ -162,6 +162,7 @@ func CreateLockFile() error {
 func CreateDataDirs() error {
 	dataDirs := []string{
 		agentstate.State.FilePath,
+		agentstate.State.ResourceCachePath,
 		agentstate.State.CrackersPath,
 		agentstate.State.HashlistPath,
 		agentstate.State.ZapsPath,
@@ -169,6 +170,7 @@ func CreateDataDirs() error {
 		agentstate.State.ToolsPath,
 		agentstate.State.OutPath,
 		agentstate.State.RestoreFilePath,
+		agentstate.State.JournalPath,
 	}
 
 	for _, dir := range dataDirs {
@@ -209,8 +211,8 @@ func CreateDataDirs() error {
 // It removes any previous backup, backs up the current installation, and extracts
 // the new archive. Returns the path to the newly extracted hashcat directory.
 func ExtractHashcatArchive(ctx context.Context, newArchivePath string) (string, error) {
-	hashcatDirectory := filepath.Join(agentstate.State.CrackersPath, "hashcat")
-	hashcatBackupDirectory := hashcatDirectory + "_old"
+	hashcatDirectory := hashcatDirectory()
+	hashcatBackupDirectory := hashcatBackupDirectory()
 
 	// Remove old backup directory if it exists
 	err := os.RemoveAll(hashcatBackupDirectory)
@@ -229,7 +231,7 @@ func ExtractHashcatArchive(ctx context.Context, newArchivePath string) (string,
 	}
 
 	// Extract new hashcat archive
-	err = arch.Extract7z(ctx, newArchivePath, agentstate.State.CrackersPath)
+	err = extractArchive(ctx, newArchivePath, agentstate.State.CrackersPath)
 	if err != nil {
 		agentstate.Logger.Error("Error extracting file", "error", err)
 
@@ -239,6 +241,24 @@ func ExtractHashcatArchive(ctx context.Context, newArchivePath string) (string,
 	return hashcatDirectory, err
 }
 
+// extractArchive extracts srcFile into destDir in-process. Archives the built-in
+// extractor cannot read are handed to an external 7z when the fallback is enabled
+// and 7z is on the PATH; unsafe or oversized archives are never retried.
+func extractArchive(ctx context.Context, srcFile, destDir string) error {
+	err := arch.ExtractArchive(ctx, srcFile, destDir)
+	if !errors.Is(err, arch.ErrUnsupportedArchive) || !agentstate.State.ExternalArchiveFallback {
+		return err
+	}
+
+	if _, lookErr := exec.LookPath("7z"); lookErr != nil {
+		return err
+	}
+
+	agentstate.Logger.Warn("Built-in extractor cannot read archive, falling back to 7z", "path", srcFile, "error", err)
+
+	return arch.Extract7z(ctx, srcFile, destDir)
+}
+
 // MoveArchiveFile moves a temporary archive file to its final location.
 // It relocates the archive to the crackers path with a standard name.
 // Returns the new path or an error if the move fails.
@@ -0,0 +1,158 @@
+package cracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/arch"
+)
+
+// ErrNoHashcatBackup indicates there is no previous hashcat installation to roll back to.
+var ErrNoHashcatBackup = errors.New("no previous hashcat installation to restore")
+
+// smokeTestTimeout bounds each smoke-test invocation of a newly installed binary.
+// Backend enumeration initializes every compute device, which can take a while.
+const smokeTestTimeout = 2 * time.Minute
+
+// hashcatDirectory returns the managed hashcat installation directory.
+func hashcatDirectory() string {
+	return filepath.Join(agentstate.State.CrackersPath, "hashcat")
+}
+
+// hashcatBackupDirectory returns the directory holding the previous installation.
+func hashcatBackupDirectory() string {
+	return hashcatDirectory() + "_old"
+}
+
+// InstallHashcat installs the hashcat archive at archivePath into the crackers
+// directory, keeping the current installation as a backup. The archive must
+// contain a top-level hashcat directory holding execName (the platform's default
+// binary name when empty). The new binary is smoke-tested with --version and -I;
+// if extraction or the smoke test fails, the previous installation is restored.
+// Returns the path and version of the new binary.
+func InstallHashcat(ctx context.Context, archivePath, execName string) (string, string, error) {
+	if execName == "" {
+		execName = arch.GetDefaultHashcatBinaryName()
+	}
+
+	if filepath.Base(execName) != execName {
+		return "", "", fmt.Errorf("invalid hashcat executable name %q", execName)
+	}
+
+	dir, err := ExtractHashcatArchive(ctx, archivePath)
+	if err != nil {
+		return "", "", rollBackInstall(fmt.Errorf("extracting hashcat archive: %w", err))
+	}
+
+	binaryPath := filepath.Join(dir, execName)
+
+	version, err := SmokeTestHashcat(ctx, binaryPath)
+	if err != nil {
+		return "", "", rollBackInstall(err)
+	}
+
+	return binaryPath, version, nil
+}
+
+// rollBackInstall restores the previous installation after a failed install and
+// returns installErr, annotated if the rollback failed too.
+func rollBackInstall(installErr error) error {
+	if err := RestoreHashcatBackup(); err != nil {
+		if errors.Is(err, ErrNoHashcatBackup) {
+			return installErr
+		}
+
+		return fmt.Errorf("%w (rollback failed: %w)", installErr, err)
+	}
+
+	agentstate.Logger.Warn("Restored previous hashcat installation", "error", installErr)
+
+	return installErr
+}
+
+// RestoreHashcatBackup replaces the managed hashcat installation with the backup
+// kept by ExtractHashcatArchive. Returns ErrNoHashcatBackup when there is none.
+func RestoreHashcatBackup() error {
+	backup := hashcatBackupDirectory()
+
+	if _, err := os.Stat(backup); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNoHashcatBackup
+		}
+
+		return fmt.Errorf("checking hashcat backup: %w", err)
+	}
+
+	if err := os.RemoveAll(hashcatDirectory()); err != nil {
+		return fmt.Errorf("removing failed hashcat installation: %w", err)
+	}
+
+	if err := os.Rename(backup, hashcatDirectory()); err != nil {
+		return fmt.Errorf("restoring hashcat backup: %w", err)
+	}
+
+	return nil
+}
+
+// SmokeTestHashcat checks that the binary at binaryPath runs: it must report its
+// version and list the compute backends (-I) without error. Returns the version.
+func SmokeTestHashcat(ctx context.Context, binaryPath string) (string, error) {
+	info, err := os.Stat(binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("hashcat binary missing after install: %w", err)
+	}
+
+	if info.Mode()&0o111 == 0 {
+		if err := os.Chmod(binaryPath, info.Mode()|0o500); err != nil { //nolint:gosec // G302 - binary must be executable
+			return "", fmt.Errorf("making hashcat binary executable: %w", err)
+		}
+	}
+
+	versionCtx, cancel := context.WithTimeout(ctx, smokeTestTimeout)
+	defer cancel()
+
+	version, err := arch.GetHashcatVersion(versionCtx, binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("hashcat --version failed: %w", err)
+	}
+
+	if version == "" {
+		return "", errors.New("hashcat --version reported no version")
+	}
+
+	backendCtx, cancelBackend := context.WithTimeout(ctx, smokeTestTimeout)
+	defer cancelBackend()
+
+	args := append([]string{"-I"}, arch.GetAdditionalHashcatArgs()...)
+	//nolint:gosec // G204 - binary path validated above and comes from the managed install directory
+	cmd := exec.CommandContext(backendCtx, binaryPath, args...)
+	cmd.Dir = filepath.Dir(binaryPath)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("hashcat -I failed: %w: %s", err, lastLine(out))
+	}
+
+	return version, nil
+}
+
+// lastLine returns the last non-empty line of command output, which is where
+// hashcat prints the reason it failed.
+func lastLine(out []byte) string {
+	end := len(out)
+	for end > 0 && (out[end-1] == '\n' || out[end-1] == '\r' || out[end-1] == ' ') {
+		end--
+	}
+
+	start := end
+	for start > 0 && out[start-1] != '\n' {
+		start--
+	}
+
+	return string(out[start:end])
+}
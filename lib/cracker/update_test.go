@@ -0,0 +1,110 @@
+package cracker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+)
+
+// writeFakeHashcat writes a shell script standing in for hashcat: --version
+// prints version, and -I exits with backendExit.
+func writeFakeHashcat(t *testing.T, path, version string, backendExit int) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake hashcat binaries are shell scripts")
+	}
+
+	script := "#!/bin/sh\n" +
+		"case \"$1\" in\n" +
+		"  --version) echo " + version + " ;;\n" +
+		"  -I) echo 'No devices found/left.'; exit " + strconv.Itoa(backendExit) + " ;;\n" +
+		"esac\n"
+
+	//nolint:gosec // G306: Executable binary needs exec permission (0o700)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o700))
+}
+
+func TestSmokeTestHashcat(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("working binary reports its version", func(t *testing.T) {
+		bin := filepath.Join(dir, "good")
+		writeFakeHashcat(t, bin, "v6.2.6", 0)
+
+		version, err := SmokeTestHashcat(context.Background(), bin)
+		require.NoError(t, err)
+		assert.Equal(t, "v6.2.6", version)
+	})
+
+	t.Run("backend failure fails the smoke test", func(t *testing.T) {
+		bin := filepath.Join(dir, "nobackend")
+		writeFakeHashcat(t, bin, "v6.2.6", 1)
+
+		_, err := SmokeTestHashcat(context.Background(), bin)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "No devices found/left.")
+	})
+
+	t.Run("missing binary", func(t *testing.T) {
+		_, err := SmokeTestHashcat(context.Background(), filepath.Join(dir, "missing"))
+		require.Error(t, err)
+	})
+}
+
+func TestRestoreHashcatBackup(t *testing.T) {
+	cleanup := saveAndRestoreState(t)
+	defer cleanup()
+
+	agentstate.State.CrackersPath = t.TempDir()
+
+	require.ErrorIs(t, RestoreHashcatBackup(), ErrNoHashcatBackup)
+
+	backupDir := filepath.Join(agentstate.State.CrackersPath, "hashcat_old")
+	require.NoError(t, os.MkdirAll(backupDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(backupDir, "marker"), []byte("old"), 0o600))
+
+	currentDir := filepath.Join(agentstate.State.CrackersPath, "hashcat")
+	require.NoError(t, os.MkdirAll(currentDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "marker"), []byte("new"), 0o600))
+
+	require.NoError(t, RestoreHashcatBackup())
+
+	content, err := os.ReadFile(filepath.Join(currentDir, "marker"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content), "the backup should replace the current installation")
+	assert.NoDirExists(t, backupDir)
+}
+
+func TestInstallHashcat_RollsBackFailedExtraction(t *testing.T) {
+	cleanup := saveAndRestoreState(t)
+	defer cleanup()
+
+	agentstate.State.CrackersPath = t.TempDir()
+
+	currentDir := filepath.Join(agentstate.State.CrackersPath, "hashcat")
+	require.NoError(t, os.MkdirAll(currentDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "marker"), []byte("current"), 0o600))
+
+	archive := filepath.Join(agentstate.State.CrackersPath, "hashcat.7z")
+	require.NoError(t, os.WriteFile(archive, []byte("not a real 7z archive"), 0o600))
+
+	_, _, err := InstallHashcat(context.Background(), archive, "")
+	require.Error(t, err)
+
+	content, err := os.ReadFile(filepath.Join(currentDir, "marker"))
+	require.NoError(t, err, "the current installation should be restored")
+	assert.Equal(t, "current", string(content))
+}
+
+func TestInstallHashcat_RejectsExecNameWithPath(t *testing.T) {
+	_, _, err := InstallHashcat(context.Background(), "unused.7z", "../hashcat.bin")
+	require.Error(t, err)
+}
@@ -220,13 +220,21 @@ func handleSendError(ctx context.Context, err error) {
 // skipped only when the APIClient has not been initialized yet.
 // Empty or whitespace-only messages are handled by the centralised guard inside SendAgentError,
 // so callers do not need their own empty-message check.
+// Optional ErrorOption arguments are passed through to SendAgentError.
 // Returns the original error for further handling.
 // Callers control context: pass ctx for cancellable operations, or context.Background() for
 // errors that must be delivered even during shutdown.
-func LogAndSendError(ctx context.Context, message string, err error, severity api.Severity, task *api.Task) error {
+func LogAndSendError(
+	ctx context.Context,
+	message string,
+	err error,
+	severity api.Severity,
+	task *api.Task,
+	opts ...ErrorOption,
+) error {
 	agentstate.ErrorLogger.Error(message, "error", err)
 	// SendAgentError handles nil APIClient internally — no need to guard here.
-	SendAgentError(ctx, message, task, severity)
+	SendAgentError(ctx, message, task, severity, opts...)
 
 	return err
 }
@@ -0,0 +1,260 @@
+// Package decompress recognizes attack resources stored gzip-, zstd-, or
+// bzip2-compressed and turns them back into the plain files hashcat reads.
+// Formats are told apart by their magic bytes, not by file name, since
+// resource names on the server need not carry an extension.
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// PlainSuffix is appended to a compressed file's path to name its
+// decompressed copy.
+const PlainSuffix = ".decompressed"
+
+// Format is a compression format.
+type Format string
+
+const (
+	// None means the file is not compressed.
+	None Format = ""
+	// Gzip is the gzip format.
+	Gzip Format = "gzip"
+	// Zstd is the Zstandard format.
+	Zstd Format = "zstd"
+	// Bzip2 is the bzip2 format.
+	Bzip2 Format = "bzip2"
+)
+
+// magicLen is the length of the longest signature recognized, bzip2's.
+const magicLen = 10
+
+// Lengths of the file ends ContentSize reads: a zstd frame header takes at most
+// 18 bytes, and gzip records the content size in its last 4.
+const (
+	HeaderLen  = 18
+	TrailerLen = 4
+)
+
+// gzipWrap is the modulus of the content size gzip records.
+const gzipWrap = 1 << 32
+
+//nolint:gochecknoglobals // read-only lookup table
+var magics = []struct {
+	format Format
+	magic  []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// bzip2BlockMagic starts the first block of a bzip2 stream, after the "BZh"
+// magic and the block size digit.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+
+// detect returns the format whose magic number header starts with.
+func detect(header []byte) Format {
+	for _, m := range magics {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format
+		}
+	}
+
+	if isBzip2(header) {
+		return Bzip2
+	}
+
+	return None
+}
+
+// isBzip2 reports whether header starts a bzip2 stream: "BZh", a block size
+// digit from 1 to 9, and the block magic. "BZh" alone is too likely to begin a
+// plain word list.
+func isBzip2(header []byte) bool {
+	return len(header) >= magicLen &&
+		bytes.HasPrefix(header, []byte("BZh")) &&
+		header[3] >= '1' && header[3] <= '9' &&
+		bytes.Equal(header[4:magicLen], bzip2BlockMagic)
+}
+
+// DetectHeader returns the compression format of a file starting with header,
+// or None.
+func DetectHeader(header []byte) Format {
+	return detect(header)
+}
+
+// Detect returns the compression format of the file at path, or None.
+func Detect(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return None, err
+	}
+	defer f.Close()
+
+	header := make([]byte, magicLen)
+
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return None, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return detect(header[:n]), nil
+}
+
+// NewReader returns a reader decompressing r from format. Closing it releases
+// the decoder but does not close r.
+func NewReader(format Format, r io.Reader) (io.ReadCloser, error) {
+	switch format {
+	case None:
+		return io.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return decoder.IOReadCloser(), nil
+	case Bzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+}
+
+// OpenFile opens the file at path and returns a reader of its decompressed
+// content, or of the content as is if it is not compressed. Closing the reader
+// closes the file.
+func OpenFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(f)
+
+	header, err := buffered.Peek(magicLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = f.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	r, err := NewReader(detect(header), buffered)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("decompressing %s: %w", path, err)
+	}
+
+	return &fileReader{ReadCloser: r, file: f}, nil
+}
+
+// fileReader closes the decoder and then the file it reads from.
+type fileReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+// Close implements io.Closer.
+func (r *fileReader) Close() error {
+	return errors.Join(r.ReadCloser.Close(), r.file.Close())
+}
+
+// File writes the decompressed content of src to dst, unless dst is already
+// at least as new as src. The content is written to a temporary file renamed
+// into place, so an interrupted run never leaves a truncated dst behind.
+func File(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if dstInfo, err := os.Stat(dst); err == nil && !dstInfo.ModTime().Before(srcInfo.ModTime()) {
+		return nil
+	}
+
+	in, err := OpenFile(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", tmp, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("decompressing %s: %w", src, err)
+	}
+
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("writing %s: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("moving %s into place: %w", dst, err)
+	}
+
+	return nil
+}
+
+// ContentSize returns the size of the decompressed content of a compressed file
+// of size bytes that starts with header and ends with trailer, or -1 if its
+// format does not record it. Gzip records the size modulo 4 GiB in its
+// trailer, for the last member only; a recorded size too far below the
+// compressed size for deflate's small overhead has wrapped and is raised by
+// 4 GiB until it is not. Zstd records the size of the first frame in its
+// header when the compressor knew it. Bzip2 never records it.
+func ContentSize(header, trailer []byte, size int64) int64 {
+	switch detect(header) {
+	case Gzip:
+		if len(trailer) < TrailerLen {
+			return -1
+		}
+
+		content := int64(binary.LittleEndian.Uint32(trailer[len(trailer)-TrailerLen:]))
+		for content+maxGzipOverhead(size) < size {
+			content += gzipWrap
+		}
+
+		return content
+	case Zstd:
+		var frame zstd.Header
+		if err := frame.Decode(header); err != nil || !frame.HasFCS {
+			return -1
+		}
+
+		return int64(frame.FrameContentSize) //nolint:gosec // G115 - frame sizes fit in int64
+	case None, Bzip2:
+		return -1
+	default:
+		return -1
+	}
+}
+
+// maxGzipOverhead bounds how many bytes a gzip file of size bytes can exceed its
+// content by: deflate's stored blocks add 5 bytes per 64 KiB, and the header and
+// trailer a few bytes more, plus any file name.
+func maxGzipOverhead(size int64) int64 {
+	return size/8192 + 1024
+}
@@ -0,0 +1,185 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const plainText = "password\n123456\n"
+
+// bzip2Text is plainText bzip2-compressed; the standard library has no bzip2 writer.
+const bzip2Text = "425a6839314159265359d9052894000005c98000103f002400d880200022000010000260d159e2f3a77783c5dc914e142436414a2500"
+
+// compressed returns plainText in format.
+func compressed(t *testing.T, format Format) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	switch format {
+	case None:
+		buf.WriteString(plainText)
+	case Gzip:
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte(plainText))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	case Zstd:
+		w, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(plainText))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	case Bzip2:
+		data, err := hex.DecodeString(bzip2Text)
+		require.NoError(t, err)
+		buf.Write(data)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDetectAndOpenFile(t *testing.T) {
+	for _, format := range []Format{None, Gzip, Zstd, Bzip2} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wordlist")
+			require.NoError(t, os.WriteFile(path, compressed(t, format), 0o600))
+
+			detected, err := Detect(path)
+			require.NoError(t, err)
+			assert.Equal(t, format, detected)
+
+			r, err := OpenFile(path)
+			require.NoError(t, err)
+
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, plainText, string(data))
+		})
+	}
+}
+
+func TestDetect_ShortFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short")
+	require.NoError(t, os.WriteFile(path, []byte{0x1f}, 0o600))
+
+	format, err := Detect(path)
+	require.NoError(t, err)
+	assert.Equal(t, None, format)
+}
+
+func TestDetectHeader_Bzip2(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected Format
+	}{
+		{name: "bzip2 stream", header: bzip2Text[:20], expected: Bzip2},
+		{name: "word starting with BZh", header: hex.EncodeToString([]byte("BZhello world\n")), expected: None},
+		{name: "block size out of range", header: "425a6830314159265359", expected: None},
+		{name: "missing block magic", header: "425a6839000000000000", expected: None},
+		{name: "truncated", header: "425a683931", expected: None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := hex.DecodeString(tt.header)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, DetectHeader(header))
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "rockyou.txt.zst")
+	dst := src + PlainSuffix
+	require.NoError(t, os.WriteFile(src, compressed(t, Zstd), 0o600))
+
+	require.NoError(t, File(src, dst))
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, plainText, string(data))
+	assert.NoFileExists(t, dst+".tmp")
+
+	// A copy newer than the source is left alone.
+	require.NoError(t, os.WriteFile(dst, []byte("kept"), 0o600))
+	require.NoError(t, File(src, dst))
+
+	data, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(data))
+
+	// A replaced source is decompressed again.
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(src, later, later))
+	require.NoError(t, File(src, dst))
+
+	data, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, plainText, string(data))
+}
+
+func TestFile_CorruptSource(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "broken.gz")
+	dst := src + PlainSuffix
+	require.NoError(t, os.WriteFile(src, compressed(t, Gzip)[:12], 0o600))
+
+	require.Error(t, File(src, dst))
+	assert.NoFileExists(t, dst)
+	assert.NoFileExists(t, dst+".tmp")
+}
+
+// fileEnds returns the header and trailer of data that ContentSize reads.
+func fileEnds(data []byte) ([]byte, []byte) {
+	return data[:min(len(data), HeaderLen)], data[max(len(data)-TrailerLen, 0):]
+}
+
+func TestContentSize(t *testing.T) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithSingleSegment(true))
+	require.NoError(t, err)
+
+	zstdWithSize := encoder.EncodeAll([]byte(plainText), nil)
+	require.NoError(t, encoder.Close())
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected int64
+	}{
+		{name: "gzip", data: compressed(t, Gzip), expected: int64(len(plainText))},
+		{name: "zstd with content size", data: zstdWithSize, expected: int64(len(plainText))},
+		{name: "streamed zstd", data: compressed(t, Zstd), expected: -1},
+		{name: "bzip2", data: compressed(t, Bzip2), expected: -1},
+		{name: "uncompressed", data: compressed(t, None), expected: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, trailer := fileEnds(tt.data)
+			assert.Equal(t, tt.expected, ContentSize(header, trailer, int64(len(tt.data))))
+		})
+	}
+}
+
+// TestContentSize_GzipWrapped verifies a gzip content size recorded modulo
+// 4 GiB is raised past the compressed size.
+func TestContentSize_GzipWrapped(t *testing.T) {
+	header := []byte{0x1f, 0x8b, 0x08, 0x00}
+	trailer := []byte{0x01, 0x00, 0x00, 0x00}
+
+	assert.Equal(t, int64(2*gzipWrap+1), ContentSize(header, trailer, 5<<30))
+}
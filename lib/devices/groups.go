@@ -0,0 +1,102 @@
+package devices
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DeviceGroupsAuto selects automatic device grouping: available devices of the
+// same type and model share a group.
+const DeviceGroupsAuto = "auto"
+
+// ErrInvalidDeviceGroups is returned when a device group specification cannot be parsed.
+var ErrInvalidDeviceGroups = errors.New("invalid device groups")
+
+// ParseDeviceGroups parses an explicit device group specification: groups of
+// comma-separated backend device IDs separated by semicolons (e.g., "1,2;3,4").
+// Empty groups are ignored. A device may belong to only one group. Returns
+// (nil, nil) when raw is empty.
+func ParseDeviceGroups(raw string) ([][]int, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	seen := make(map[int]struct{})
+	groups := make([][]int, 0)
+
+	for part := range strings.SplitSeq(raw, ";") {
+		ids, err := parseDeviceIDString(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidDeviceGroups, err)
+		}
+
+		if len(ids) == 0 {
+			continue
+		}
+
+		for _, id := range ids {
+			if _, dup := seen[id]; dup {
+				return nil, fmt.Errorf("%w: device %d appears in more than one group", ErrInvalidDeviceGroups, id)
+			}
+
+			seen[id] = struct{}{}
+		}
+
+		groups = append(groups, ids)
+	}
+
+	return groups, nil
+}
+
+// GroupByModel partitions the given device IDs into groups of devices sharing
+// the same type and name, in order of first appearance. IDs that are unknown or
+// unavailable are left out.
+func (dm *DeviceManager) GroupByModel(ids []int) [][]int {
+	groups := make([][]int, 0)
+	index := make(map[string]int)
+
+	for _, id := range ids {
+		dev, ok := dm.GetDevice(id)
+		if !ok || !dev.IsAvailable {
+			continue
+		}
+
+		key := strings.ToUpper(dev.Type) + "\x00" + dev.Name
+		if i, ok := index[key]; ok {
+			groups[i] = append(groups[i], id)
+
+			continue
+		}
+
+		index[key] = len(groups)
+		groups = append(groups, []int{id})
+	}
+
+	return groups
+}
+
+// SelectedDeviceIDs returns the backend device IDs this config runs on: the
+// valid configured IDs, or every available device when none are configured.
+// Returns nil when no DeviceManager is available.
+func (dc DeviceConfig) SelectedDeviceIDs() []int {
+	if dc.dm == nil {
+		return nil
+	}
+
+	if len(dc.enabledIDs) == 0 {
+		return dc.dm.GetAvailableDeviceIDs()
+	}
+
+	return dc.dm.ValidateDeviceIDsDetailed(dc.enabledIDs).ValidIDs
+}
+
+// ForDevices returns a copy of the config restricted to the given backend
+// device IDs, keeping the OpenCL device types and DeviceManager.
+func (dc DeviceConfig) ForDevices(ids []int) DeviceConfig {
+	dc.enabledIDs = slices.Clone(ids)
+	dc.rawBackendDevices = intsToCSV(ids)
+
+	return dc
+}
@@ -0,0 +1,78 @@
+package devices
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDeviceGroups(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    [][]int
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: nil},
+		{name: "single group", raw: "1,2", want: [][]int{{1, 2}}},
+		{name: "multiple groups", raw: "1,2; 3 ;4,5", want: [][]int{{1, 2}, {3}, {4, 5}}},
+		{name: "empty groups skipped", raw: "1;;2;", want: [][]int{{1}, {2}}},
+		{name: "non-numeric", raw: "1;gpu", wantErr: true},
+		{name: "duplicate across groups", raw: "1,2;2,3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseDeviceGroups(tt.raw)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidDeviceGroups)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGroupByModel(t *testing.T) {
+	t.Parallel()
+
+	dm := newTestManager([]Device{
+		{ID: 1, Name: "RTX 3090", Type: "GPU", IsAvailable: true},
+		{ID: 2, Name: "RTX 4090", Type: "GPU", IsAvailable: true},
+		{ID: 3, Name: "RTX 3090", Type: "GPU", IsAvailable: true},
+		{ID: 4, Name: "Core i9", Type: "CPU", IsAvailable: true},
+		{ID: 5, Name: "RTX 4090", Type: "GPU", IsAvailable: false},
+	})
+
+	groups := dm.GroupByModel([]int{1, 2, 3, 4, 5, 9})
+	assert.Equal(t, [][]int{{1, 3}, {2}, {4}}, groups)
+}
+
+func TestSelectedDeviceIDs(t *testing.T) {
+	t.Parallel()
+
+	dm := newTestManager(sampleDevicesWithUnavailable())
+
+	assert.Equal(t, []int{1, 3}, NewDeviceConfig("", "", dm).SelectedDeviceIDs())
+	assert.Equal(t, []int{3}, NewDeviceConfig("2,3,7", "", dm).SelectedDeviceIDs())
+	assert.Nil(t, NewDeviceConfig("1", "", nil).SelectedDeviceIDs())
+}
+
+func TestForDevices(t *testing.T) {
+	t.Parallel()
+
+	dm := newTestManager(sampleDevices())
+	dc := NewDeviceConfig("", "2", dm).ForDevices([]int{1, 3})
+
+	assert.Equal(t, "1,3", dc.ResolvedBackendDevices())
+	assert.Equal(t, "2", dc.ResolvedOpenCLDevices())
+	assert.Same(t, dm, dc.DeviceManager())
+}
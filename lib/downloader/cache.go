@@ -0,0 +1,451 @@
+package downloader
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
+)
+
+// cacheIndexFile is the name of the cache's index within its directory.
+const cacheIndexFile = "index.json"
+
+// Cache is a content-addressed store for attack resources. Files are kept under
+// their checksum and linked into the resource directory by name, so a resource
+// shared by several attacks is downloaded once. When the cache outgrows its quota
+// the least recently used entries are evicted, along with the links to them;
+// entries pinned by a running task are never evicted.
+//
+// The index recording sizes, last-use times, and links lives in index.json in
+// the cache directory, so the cache survives restarts.
+type Cache struct {
+	dir   string
+	quota int64 // Maximum total size in bytes; zero means unlimited
+
+	mu         sync.Mutex // Guards index, pins, and fetchLocks
+	index      map[string]*cacheEntry
+	pins       map[string]int
+	fetchLocks map[string]*sync.Mutex // Serialize fetches of an entry so it is never downloaded twice at once
+}
+
+// cacheEntry is the index record for one cached file.
+type cacheEntry struct {
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+	Links    []string  `json:"links,omitempty"` // Paths linked to the entry
+}
+
+// NewCache returns a cache storing files in dir and holding at most quota bytes.
+// A quota of zero or less disables eviction.
+func NewCache(dir string, quota int64) *Cache {
+	return &Cache{
+		dir:        dir,
+		quota:      max(quota, 0),
+		pins:       make(map[string]int),
+		fetchLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// Fetch makes the resource with checksum available at destPath, downloading it
+// from fileURL unless it is already cached. A valid file already at destPath is
+// moved into the cache instead of being downloaded again. destPath becomes a hard
+// link to the cache entry, or a symbolic link where hard links are unavailable.
+//
+// The entry is pinned until the returned release function is called, which must
+// happen once the resource is no longer in use. checksum must not be zero. opts
+// apply to the download, if one is needed.
+func (c *Cache) Fetch(
+	ctx context.Context,
+	fileURL string,
+	checksum Checksum,
+	destPath string,
+	opts ...Option,
+) (func(), error) {
+	if checksum.IsZero() {
+		return nil, errors.New("cannot cache a resource without a checksum")
+	}
+
+	key := checksum.String()
+	release := c.pin(key)
+
+	unlock := c.lockFetch(key)
+	defer unlock()
+
+	entryPath := c.EntryPath(checksum)
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0o750); err != nil {
+		release()
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	c.adopt(destPath, entryPath, checksum)
+
+	if err := DownloadFile(ctx, fileURL, entryPath, checksum, opts...); err != nil {
+		release()
+		return nil, err
+	}
+
+	if err := linkCacheEntry(entryPath, destPath); err != nil {
+		release()
+		return nil, fmt.Errorf("linking cached resource into place: %w", err)
+	}
+
+	c.recordUse(key, entryPath, destPath)
+	c.evict()
+
+	return release, nil
+}
+
+// Warm downloads the resource with checksum from fileURL into the cache unless
+// it is already there, without linking it anywhere, so a later Fetch finds it
+// cached. The entry is not pinned afterwards and may be evicted like any other.
+// checksum must not be zero. opts apply to the download.
+func (c *Cache) Warm(ctx context.Context, fileURL string, checksum Checksum, opts ...Option) error {
+	if checksum.IsZero() {
+		return errors.New("cannot cache a resource without a checksum")
+	}
+
+	key := checksum.String()
+	release := c.pin(key)
+	defer release()
+
+	unlock := c.lockFetch(key)
+	defer unlock()
+
+	entryPath := c.EntryPath(checksum)
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0o750); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	if err := DownloadFile(ctx, fileURL, entryPath, checksum, opts...); err != nil {
+		return err
+	}
+
+	c.recordUse(key, entryPath, "")
+	c.evict()
+
+	return nil
+}
+
+// LinkDecompressed makes the decompressed content of the cached resource with
+// checksum available at destPath, decompressing the entry next to it on first
+// use. The compressed entry stays as downloaded, so its checksum still verifies,
+// and the decompressed copy counts towards the quota and is evicted with it. The
+// entry must have been fetched, and stay pinned, by Fetch.
+func (c *Cache) LinkDecompressed(checksum Checksum, destPath string) error {
+	key := checksum.String()
+
+	unlock := c.lockFetch(key)
+	defer unlock()
+
+	entryPath := c.EntryPath(checksum)
+	plainPath := entryPath + decompress.PlainSuffix
+
+	if err := decompress.File(entryPath, plainPath); err != nil {
+		return err
+	}
+
+	if err := linkCacheEntry(plainPath, destPath); err != nil {
+		return fmt.Errorf("linking decompressed resource into place: %w", err)
+	}
+
+	c.recordUse(key, entryPath, destPath)
+	c.evict()
+
+	return nil
+}
+
+// EntryPath returns where the file with checksum is stored, whether or not it is
+// cached yet.
+func (c *Cache) EntryPath(checksum Checksum) string {
+	return filepath.Join(c.dir, string(checksum.Algorithm), hex.EncodeToString(checksum.Digest))
+}
+
+// lockFetch waits until no other fetch of key is in progress and returns the
+// function ending this one. Fetches of different entries run concurrently.
+func (c *Cache) lockFetch(key string) func() {
+	c.mu.Lock()
+
+	lock, ok := c.fetchLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.fetchLocks[key] = lock
+	}
+
+	c.mu.Unlock()
+
+	lock.Lock()
+
+	return lock.Unlock
+}
+
+// pin protects key from eviction until the returned function is called.
+func (c *Cache) pin(key string) func() {
+	c.mu.Lock()
+	c.pins[key]++
+	c.mu.Unlock()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.pins[key]--; c.pins[key] <= 0 {
+				delete(c.pins, key)
+			}
+		})
+	}
+}
+
+// adopt moves a valid, uncached file at destPath into the cache, so resources
+// downloaded before the cache existed are not fetched again.
+func (c *Cache) adopt(destPath, entryPath string, checksum Checksum) {
+	if _, err := os.Stat(entryPath); err == nil {
+		return
+	}
+
+	info, err := os.Lstat(destPath)
+	if err != nil || !info.Mode().IsRegular() || !FileExistsAndValid(destPath, checksum) {
+		return
+	}
+
+	if err := os.Rename(destPath, entryPath); err != nil {
+		agentstate.Logger.Debug("Could not move existing resource into the cache", "path", destPath, "error", err)
+		return
+	}
+
+	// Carry the verified checksum along so the entry is not rehashed.
+	if err := os.Rename(destPath+checksumSidecarSuffix, entryPath+checksumSidecarSuffix); err != nil {
+		RemoveChecksumSidecar(destPath)
+	}
+
+	agentstate.Logger.Info("Moved existing resource into the cache", "path", destPath, "checksum", checksum.String())
+}
+
+// linkCacheEntry replaces destPath with a link to entryPath, leaving it alone
+// if it already is one.
+func linkCacheEntry(entryPath, destPath string) error {
+	if linksTo(destPath, entryPath) {
+		return nil
+	}
+
+	if err := removeExistingFile(destPath); err != nil {
+		return err
+	}
+
+	RemoveChecksumSidecar(destPath)
+
+	linkErr := os.Link(entryPath, destPath)
+	if linkErr == nil {
+		return nil
+	}
+
+	// Hard links fail across file systems; fall back to a symbolic link.
+	target, err := filepath.Abs(entryPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Symlink(target, destPath); err != nil {
+		return errors.Join(linkErr, err)
+	}
+
+	return nil
+}
+
+// linksTo reports whether path is a hard or symbolic link to entryPath.
+func linksTo(path, entryPath string) bool {
+	linkInfo, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+
+	entryInfo, err := os.Stat(entryPath)
+	if err != nil {
+		return false
+	}
+
+	if linkInfo.Mode()&os.ModeSymlink != 0 {
+		linkInfo, err = os.Stat(path)
+		if err != nil {
+			return false
+		}
+	}
+
+	return os.SameFile(linkInfo, entryInfo)
+}
+
+// recordUse marks key as used now and remembers destPath, if any, as one of its links.
+func (c *Cache) recordUse(key, entryPath, destPath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.loadIndex()
+
+	entry := c.index[key]
+	if entry == nil {
+		entry = &cacheEntry{}
+		c.index[key] = entry
+	}
+
+	entry.Size = 0
+	for _, path := range []string{entryPath, entryPath + decompress.PlainSuffix} {
+		if info, err := os.Stat(path); err == nil {
+			entry.Size += info.Size()
+		}
+	}
+
+	entry.LastUsed = time.Now()
+
+	if destPath != "" && !slices.Contains(entry.Links, destPath) {
+		entry.Links = append(entry.Links, destPath)
+	}
+
+	c.saveIndex()
+}
+
+// evict removes the least recently used unpinned entries until the cache fits
+// its quota.
+func (c *Cache) evict() {
+	if c.quota == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.loadIndex()
+
+	var total int64
+
+	keys := make([]string, 0, len(c.index))
+	for key, entry := range c.index {
+		total += entry.Size
+		keys = append(keys, key)
+	}
+
+	if total <= c.quota {
+		return
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return c.index[keys[i]].LastUsed.Before(c.index[keys[j]].LastUsed)
+	})
+
+	for _, key := range keys {
+		if total <= c.quota {
+			break
+		}
+
+		if c.pins[key] > 0 {
+			continue
+		}
+
+		entry := c.index[key]
+		if err := c.removeEntry(key, entry); err != nil {
+			agentstate.Logger.Warn("Failed to evict cached resource", "checksum", key, "error", err)
+			continue
+		}
+
+		total -= entry.Size
+		delete(c.index, key)
+
+		agentstate.Logger.Info("Evicted cached resource", "checksum", key, "size", entry.Size)
+	}
+
+	if total > c.quota {
+		agentstate.Logger.Warn("Resource cache exceeds its quota; remaining entries are in use",
+			"size", total, "quota", c.quota)
+	}
+
+	c.saveIndex()
+}
+
+// removeEntry deletes a cached file, its checksum sidecar, its decompressed
+// copy, and the links to them. Links that have since been replaced by other
+// files are left alone.
+func (c *Cache) removeEntry(key string, entry *cacheEntry) error {
+	checksum, err := ParseChecksum(key)
+	if err != nil {
+		return err
+	}
+
+	entryPath := c.EntryPath(checksum)
+	plainPath := entryPath + decompress.PlainSuffix
+
+	for _, link := range entry.Links {
+		if linksTo(link, entryPath) || linksTo(link, plainPath) {
+			if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("removing link %s: %w", link, err)
+			}
+		}
+	}
+
+	for _, path := range []string{entryPath, plainPath} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	RemoveChecksumSidecar(entryPath)
+
+	return nil
+}
+
+// loadIndex reads the index from disk on first use. A missing or corrupt index
+// starts empty; entries are re-recorded as they are used. Callers hold c.mu.
+func (c *Cache) loadIndex() {
+	if c.index != nil {
+		return
+	}
+
+	c.index = make(map[string]*cacheEntry)
+
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheIndexFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			agentstate.Logger.Warn("Failed to read resource cache index", "error", err)
+		}
+
+		return
+	}
+
+	if err := json.Unmarshal(data, &c.index); err != nil {
+		agentstate.Logger.Warn("Resource cache index is corrupt, starting afresh", "error", err)
+
+		c.index = make(map[string]*cacheEntry)
+	}
+}
+
+// saveIndex writes the index to disk, replacing the previous one atomically.
+// Callers hold c.mu.
+func (c *Cache) saveIndex() {
+	data, err := json.MarshalIndent(c.index, "", "  ")
+	if err != nil {
+		agentstate.Logger.Warn("Failed to encode resource cache index", "error", err)
+		return
+	}
+
+	path := filepath.Join(c.dir, cacheIndexFile)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		agentstate.Logger.Warn("Failed to write resource cache index", "error", err)
+		return
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		agentstate.Logger.Warn("Failed to replace resource cache index", "error", err)
+	}
+}
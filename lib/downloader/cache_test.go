@@ -0,0 +1,183 @@
+package downloader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
+)
+
+// unusedURL is never fetched: the tests seed the cache by adopting valid files
+// already at the destination.
+const unusedURL = "http://127.0.0.1:1/unused"
+
+// seedResource writes content to dir/name and returns its path and SHA-256 checksum.
+func seedResource(t *testing.T, dir, name, content string) (string, Checksum) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	sum := sha256.Sum256([]byte(content))
+
+	return path, Checksum{Algorithm: ChecksumSHA256, Digest: sum[:]}
+}
+
+func TestCacheFetch_AdoptsAndLinks(t *testing.T) {
+	filesDir := t.TempDir()
+	cache := NewCache(t.TempDir(), 0)
+
+	dest, checksum := seedResource(t, filesDir, "rockyou.txt", "password\n123456\n")
+
+	release, err := cache.Fetch(context.Background(), unusedURL, checksum, dest)
+	require.NoError(t, err)
+	defer release()
+
+	entry := cache.EntryPath(checksum)
+	require.FileExists(t, entry, "the existing file should be moved into the cache")
+	assert.True(t, linksTo(dest, entry), "the destination should link to the cache entry")
+
+	// A second attack naming the same content differently shares the entry.
+	other := filepath.Join(filesDir, "copy.txt")
+	release2, err := cache.Fetch(context.Background(), unusedURL, checksum, other)
+	require.NoError(t, err)
+	defer release2()
+
+	assert.True(t, linksTo(other, entry))
+
+	data, err := os.ReadFile(other)
+	require.NoError(t, err)
+	assert.Equal(t, "password\n123456\n", string(data))
+}
+
+func TestCacheFetch_RequiresChecksum(t *testing.T) {
+	cache := NewCache(t.TempDir(), 0)
+
+	_, err := cache.Fetch(context.Background(), unusedURL, Checksum{}, filepath.Join(t.TempDir(), "x"))
+	require.Error(t, err)
+}
+
+func TestCacheEvict_LeastRecentlyUsedFirst(t *testing.T) {
+	filesDir := t.TempDir()
+	// Each resource is 10 bytes; the quota holds two.
+	cache := NewCache(t.TempDir(), 25)
+
+	destA, sumA := seedResource(t, filesDir, "a.txt", "aaaaaaaaa\n")
+	destB, sumB := seedResource(t, filesDir, "b.txt", "bbbbbbbbb\n")
+	destC, sumC := seedResource(t, filesDir, "c.txt", "ccccccccc\n")
+
+	releaseA, err := cache.Fetch(context.Background(), unusedURL, sumA, destA)
+	require.NoError(t, err)
+	releaseA()
+
+	releaseB, err := cache.Fetch(context.Background(), unusedURL, sumB, destB)
+	require.NoError(t, err)
+	releaseB()
+
+	releaseC, err := cache.Fetch(context.Background(), unusedURL, sumC, destC)
+	require.NoError(t, err)
+	defer releaseC()
+
+	assert.NoFileExists(t, cache.EntryPath(sumA), "the least recently used entry should be evicted")
+	assert.NoFileExists(t, destA, "links to an evicted entry should be removed")
+	assert.FileExists(t, cache.EntryPath(sumB))
+	assert.FileExists(t, cache.EntryPath(sumC))
+	assert.FileExists(t, destC)
+}
+
+func TestCacheEvict_SkipsPinnedEntries(t *testing.T) {
+	filesDir := t.TempDir()
+	cache := NewCache(t.TempDir(), 15)
+
+	destA, sumA := seedResource(t, filesDir, "a.txt", "aaaaaaaaa\n")
+	destB, sumB := seedResource(t, filesDir, "b.txt", "bbbbbbbbb\n")
+
+	// A is in use by a running task while B is fetched.
+	releaseA, err := cache.Fetch(context.Background(), unusedURL, sumA, destA)
+	require.NoError(t, err)
+	defer releaseA()
+
+	releaseB, err := cache.Fetch(context.Background(), unusedURL, sumB, destB)
+	require.NoError(t, err)
+	defer releaseB()
+
+	assert.FileExists(t, cache.EntryPath(sumA), "a pinned entry must not be evicted")
+	assert.FileExists(t, destA)
+	assert.FileExists(t, cache.EntryPath(sumB), "a pinned entry must not be evicted")
+}
+
+func TestCache_IndexSurvivesRestart(t *testing.T) {
+	filesDir := t.TempDir()
+	cacheDir := t.TempDir()
+
+	destA, sumA := seedResource(t, filesDir, "a.txt", "aaaaaaaaa\n")
+	releaseA, err := NewCache(cacheDir, 0).Fetch(context.Background(), unusedURL, sumA, destA)
+	require.NoError(t, err)
+	releaseA()
+
+	// A new process with a quota too small for A evicts it on its next fetch.
+	restarted := NewCache(cacheDir, 15)
+
+	destB, sumB := seedResource(t, filesDir, "b.txt", "bbbbbbbbb\n")
+	releaseB, err := restarted.Fetch(context.Background(), unusedURL, sumB, destB)
+	require.NoError(t, err)
+	defer releaseB()
+
+	assert.NoFileExists(t, restarted.EntryPath(sumA))
+	assert.FileExists(t, restarted.EntryPath(sumB))
+}
+
+// gzipped returns content gzip-compressed.
+func gzipped(t *testing.T, content string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.String()
+}
+
+// TestCacheLinkDecompressed verifies that a compressed entry's decompressed
+// copy is kept in the cache, linked into place, counted towards the quota, and
+// evicted with its entry.
+func TestCacheLinkDecompressed(t *testing.T) {
+	filesDir := t.TempDir()
+	cache := NewCache(t.TempDir(), 0)
+
+	dest, sum := seedResource(t, filesDir, "rockyou.txt.gz", gzipped(t, "password\n123456\n"))
+	plainDest := dest + decompress.PlainSuffix
+
+	release, err := cache.Fetch(context.Background(), unusedURL, sum, dest)
+	require.NoError(t, err)
+	require.NoError(t, cache.LinkDecompressed(sum, plainDest))
+	release()
+
+	data, err := os.ReadFile(plainDest)
+	require.NoError(t, err)
+	assert.Equal(t, "password\n123456\n", string(data))
+	assert.True(t, linksTo(plainDest, cache.EntryPath(sum)+decompress.PlainSuffix))
+
+	entry := cache.index[sum.String()]
+	require.NotNil(t, entry)
+
+	compressedInfo, err := os.Stat(cache.EntryPath(sum))
+	require.NoError(t, err)
+	assert.Equal(t, compressedInfo.Size()+int64(len(data)), entry.Size,
+		"the decompressed copy should count towards the entry's size")
+
+	require.NoError(t, cache.removeEntry(sum.String(), entry))
+	assert.NoFileExists(t, cache.EntryPath(sum)+decompress.PlainSuffix)
+	assert.NoFileExists(t, plainDest)
+	assert.NoFileExists(t, dest)
+}
@@ -0,0 +1,105 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mustParseChecksum parses s or fails the test.
+func mustParseChecksum(t *testing.T, s string) Checksum {
+	t.Helper()
+
+	checksum, err := ParseChecksum(s)
+	require.NoError(t, err)
+
+	return checksum
+}
+
+func TestParseChecksum(t *testing.T) {
+	sha := sha256.Sum256([]byte("test content"))
+	shaHex := hex.EncodeToString(sha[:])
+
+	tests := []struct {
+		name      string
+		input     string
+		algorithm ChecksumAlgorithm
+		wantErr   error
+		errSubstr string
+	}{
+		{name: "bare hex is MD5", input: "9473fdd0d880a43c21b7778d34872157", algorithm: ChecksumMD5},
+		{name: "md5 prefix", input: "md5:9473fdd0d880a43c21b7778d34872157", algorithm: ChecksumMD5},
+		{name: "sha256 prefix", input: "sha256:" + shaHex, algorithm: ChecksumSHA256},
+		{name: "prefix is case-insensitive", input: "SHA256:" + shaHex, algorithm: ChecksumSHA256},
+		{name: "empty is zero", input: "  "},
+		{name: "unknown algorithm", input: "crc32:deadbeef", wantErr: ErrUnsupportedChecksumAlgorithm},
+		{name: "invalid hex", input: "not-valid-hex", errSubstr: "decoding checksum"},
+		{name: "wrong length for algorithm", input: "sha256:9473fdd0d880a43c21b7778d34872157", errSubstr: "must be 32 bytes"},
+		{name: "prefix without digest", input: "sha256:", errSubstr: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checksum, err := ParseChecksum(tt.input)
+
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.errSubstr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errSubstr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.algorithm, checksum.Algorithm)
+			}
+		})
+	}
+}
+
+func TestNewChecksum(t *testing.T) {
+	sha := sha256.Sum256([]byte("test content"))
+
+	checksum, err := NewChecksum("sha256", sha[:])
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sha[:]), checksum.String())
+
+	checksum, err = NewChecksum("", nil)
+	require.NoError(t, err)
+	assert.True(t, checksum.IsZero(), "an empty digest means no checksum")
+
+	_, err = NewChecksum("md5", sha[:])
+	require.Error(t, err, "a SHA-256 digest is not a valid MD5 checksum")
+}
+
+func TestFileExistsAndValid_ChecksumSidecar(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "wordlist.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("test content"), 0o600))
+
+	sum := sha256.Sum256([]byte("test content"))
+	checksum := Checksum{Algorithm: ChecksumSHA256, Digest: sum[:]}
+	sidecarPath := filePath + checksumSidecarSuffix
+
+	require.True(t, FileExistsAndValid(filePath, checksum))
+	require.FileExists(t, sidecarPath, "a verified checksum should be cached")
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.True(t, sidecarMatches(filePath, info, checksum))
+	assert.False(t, sidecarMatches(filePath, info, mustParseChecksum(t, "9473fdd0d880a43c21b7778d34872157")),
+		"the cache only vouches for the checksum it recorded")
+
+	// Same size, new contents and modification time: the cache must not be trusted.
+	require.NoError(t, os.WriteFile(filePath, []byte("TEST CONTENT"), 0o600))
+	later := info.ModTime().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filePath, later, later))
+
+	require.False(t, FileExistsAndValid(filePath, checksum))
+	assert.NoFileExists(t, filePath, "a mismatched file should be removed")
+	assert.NoFileExists(t, sidecarPath, "the cache should be removed with its file")
+}
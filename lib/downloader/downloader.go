@@ -3,23 +3,22 @@ package downloader
 
 import (
 	"context"
-	"crypto/md5" //nolint:gosec // G501 - checksum verification // DevSkim: ignore DS126858
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
-	"github.com/cavaliergopher/grab/v3"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/config"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/progress"
 )
@@ -34,9 +33,35 @@ type Getter interface {
 	Get() error
 }
 
+// Option configures optional download behavior.
+type Option func(*downloadOptions)
+
+// downloadOptions holds optional configuration for a download.
+type downloadOptions struct {
+	tracker   progress.Tracker
+	rateLimit bandwidth.Rate
+}
+
+// WithProgress reports download progress to tracker instead of the default
+// per-file progress bar.
+func WithProgress(tracker progress.Tracker) Option {
+	return func(o *downloadOptions) {
+		o.tracker = tracker
+	}
+}
+
+// WithRateLimit holds the download to rate, on top of the configured bandwidth
+// caps. Background downloads use it to stay out of the way of running work.
+func WithRateLimit(rate bandwidth.Rate) Option {
+	return func(o *downloadOptions) {
+		o.rateLimit = rate
+	}
+}
+
 // DownloadFile downloads a file from a given URL and saves it to the specified path with optional checksum verification.
 // If the URL is invalid, it returns an error. If the file already exists and the checksum matches, the download is skipped.
-func DownloadFile(ctx context.Context, fileURL, filePath, checksum string) error {
+// A zero checksum disables verification.
+func DownloadFile(ctx context.Context, fileURL, filePath string, checksum Checksum, opts ...Option) error {
 	parsedURL, err := url.Parse(fileURL)
 	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
 		agentstate.Logger.Error("Invalid URL", "url", fileURL)
@@ -48,7 +73,12 @@ func DownloadFile(ctx context.Context, fileURL, filePath, checksum string) error
 		return nil
 	}
 
-	if err := downloadAndVerifyFile(ctx, fileURL, filePath, checksum); err != nil {
+	options := downloadOptions{tracker: progress.DefaultProgressBar}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := downloadAndVerifyFile(ctx, fileURL, filePath, checksum, options); err != nil {
 		return err
 	}
 
@@ -58,8 +88,8 @@ func DownloadFile(ctx context.Context, fileURL, filePath, checksum string) error
 // FileExistsAndValid checks if a file exists at the given path and, if a checksum is provided, verifies its validity.
 // The function returns true if the file exists and matches the given checksum, or if no checksum is provided
 // and the file is non-empty. If the file does not exist or the checksum verification fails, appropriate error
-// messages are logged.
-func FileExistsAndValid(filePath, checksum string) bool {
+// messages are logged. A verified checksum is cached in a sidecar file, so an unchanged file is not rehashed.
+func FileExistsAndValid(filePath string, checksum Checksum) bool {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -69,7 +99,7 @@ func FileExistsAndValid(filePath, checksum string) bool {
 		return false
 	}
 
-	if strings.TrimSpace(checksum) == "" {
+	if checksum.IsZero() {
 		if info.Size() == 0 {
 			agentstate.Logger.Warn("Existing file is empty, will re-download", "path", filePath)
 
@@ -79,14 +109,20 @@ func FileExistsAndValid(filePath, checksum string) bool {
 		return true
 	}
 
-	fileChecksum, err := fileMD5(filePath) // DevSkim: ignore DS126858
+	if sidecarMatches(filePath, info, checksum) {
+		return true
+	}
+
+	sum, err := fileChecksum(filePath, checksum.Algorithm)
 	if err != nil {
 		agentstate.Logger.Error("Error calculating file checksum", "path", filePath, "error", err)
 
 		return false
 	}
 
-	if fileChecksum == checksum {
+	if checksum.Matches(sum) {
+		writeChecksumSidecar(filePath, checksum)
+
 		return true
 	}
 
@@ -95,132 +131,293 @@ func FileExistsAndValid(filePath, checksum string) bool {
 		"path",
 		filePath,
 		"url_checksum",
-		checksum,
+		checksum.String(),
 		"file_checksum",
-		fileChecksum,
+		hex.EncodeToString(sum),
 	)
 
 	if err := os.Remove(filePath); err != nil {
 		agentstate.Logger.Error("Error removing file with mismatched checksum", "path", filePath, "error", err)
 	}
 
+	RemoveChecksumSidecar(filePath)
+
 	return false
 }
 
-// grabDownloader wraps a single download attempt using grab.Client,
-// implementing the Getter interface to plug into downloadWithRetry.
-type grabDownloader struct {
-	client   *grab.Client
-	ctx      context.Context //nolint:containedctx // context is part of the download lifecycle
-	url      string
-	dst      string
-	checksum string
-	tracker  progress.Tracker
+// httpDownloader performs a single download attempt, implementing the Getter
+// interface to plug into downloadWithRetry. The file is written to dst+".part"
+// and renamed into place once complete. An interrupted transfer leaves the
+// partial file and its metadata behind, so the next attempt, even after an agent
+// restart, resumes it with an HTTP Range request instead of starting over.
+type httpDownloader struct {
+	client    *http.Client
+	ctx       context.Context //nolint:containedctx // context is part of the download lifecycle
+	url       string
+	dst       string
+	checksum  Checksum
+	progress  progress.DownloadProgress // Shared by every attempt; finished by the caller
+	rateLimit bandwidth.Rate            // Cap on this download besides the bandwidth policy
 }
 
-// Get performs one complete download attempt with progress tracking.
-func (g *grabDownloader) Get() error {
-	req, err := grab.NewRequest(g.dst, g.url)
+// Get performs one download attempt, reporting to the download's progress. When
+// a checksum is set, the download is hashed as it streams to disk and removed if
+// the complete file does not match.
+func (d *httpDownloader) Get() error {
+	meta, offset := loadPartial(d.dst, d.url)
+
+	resp, err := d.request(meta, offset)
 	if err != nil {
-		return fmt.Errorf("creating download request: %w", err)
+		return err
 	}
+	defer resp.Body.Close()
+
+	complete := false
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// The server ignored the range or the file changed: start over.
+		offset = 0
+		meta = newPartialDownload(d.url, resp)
+		savePartial(d.dst, meta)
+	case http.StatusPartialContent:
+		start, size, ok := contentRangeStart(resp.Header.Get("Content-Range"))
+		if offset == 0 || !ok || start != offset {
+			removePartial(d.dst)
+			return fmt.Errorf("downloading %s: unexpected Content-Range %q for offset %d",
+				d.url, resp.Header.Get("Content-Range"), offset)
+		}
 
-	req = req.WithContext(g.ctx)
+		if size > 0 {
+			meta.Size = size
+		}
 
-	if g.checksum != "" {
-		checksumBytes, decErr := hex.DecodeString(g.checksum)
-		if decErr != nil {
-			return fmt.Errorf("decoding checksum: %w", decErr)
+		agentstate.Logger.Info("Resuming download", "path", d.dst, "offset", offset, "size", meta.Size)
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset == 0 || offset != meta.Size {
+			removePartial(d.dst)
+			return fmt.Errorf("downloading %s: server rejected resume at offset %d", d.url, offset)
 		}
-		//nolint:gosec // G401 - MD5 used for file integrity check, not security
-		req.SetChecksum(md5.New(), checksumBytes, true) // DevSkim: ignore DS126858
+
+		complete = true
+	default:
+		return fmt.Errorf("downloading %s: unexpected status %s", d.url, resp.Status)
 	}
 
-	resp := g.client.Do(req)
-	dp := g.tracker.StartTracking(g.dst, resp.Size())
+	h, err := d.hashPrefix(offset)
+	if err != nil {
+		return err
+	}
 
-	ticker := time.NewTicker(progressPollInterval)
-	defer ticker.Stop()
+	d.progress.SetTotal(meta.Size)
+	d.progress.Update(offset)
 
-	for {
-		select {
-		case <-ticker.C:
-			dp.Update(resp.BytesComplete())
+	if !complete {
+		err = d.transfer(resp.Body, offset, h)
+	}
 
-			if resp.IsComplete() {
-				dp.Finish()
+	if err != nil {
+		if ctxErr := d.ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("downloading %s: %w", d.url, ctxErr)
+		}
 
-				if err := resp.Err(); err != nil {
-					return fmt.Errorf("downloading %s: %w", g.url, err)
-				}
+		return fmt.Errorf("downloading %s: %w", d.url, err)
+	}
 
-				return nil
-			}
-		case <-g.ctx.Done():
-			// Wait for Grab to finalize the transfer (close body, flush writer)
-			// before returning. resp.Err() blocks until resp.Done is closed.
-			err := resp.Err()
-			dp.Update(resp.BytesComplete())
-			dp.Finish()
-
-			if err != nil {
-				return fmt.Errorf("downloading %s: %w", g.url, err)
-			}
+	return d.finish(h)
+}
+
+// request sends the GET for the download, asking only for the bytes after
+// offset when a partial download is being resumed.
+func (d *httpDownloader) request(meta partialDownload, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating download request: %w", err)
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", meta.validator())
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", d.url, err)
+	}
+
+	return resp, nil
+}
+
+// hashPrefix returns the hash for the download, already fed with the first
+// offset bytes of the partial file, or nil if there is no checksum to verify.
+func (d *httpDownloader) hashPrefix(offset int64) (hash.Hash, error) {
+	if d.checksum.IsZero() {
+		return nil, nil //nolint:nilnil // no checksum means nothing to hash
+	}
+
+	h := d.checksum.Algorithm.newHash()
+	if offset == 0 {
+		return h, nil
+	}
+
+	f, err := os.Open(d.dst + partialSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("opening partial download for checksum: %w", err)
+	}
+	defer f.Close()
 
-			return g.ctx.Err()
+	if _, err := io.CopyN(h, f, offset); err != nil {
+		return nil, fmt.Errorf("hashing partial download: %w", err)
+	}
+
+	return h, nil
+}
+
+// transfer writes body to the partial file, appending after offset when
+// resuming, and feeds it into h if set. Reads are held to the bandwidth caps.
+// The final byte count is reported to the progress once the transfer ends.
+func (d *httpDownloader) transfer(body io.Reader, offset int64, h hash.Hash) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(d.dst+partialSuffix, flags, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening partial download: %w", err)
+	}
+
+	pw := &progressWriter{dp: d.progress, written: offset}
+
+	writers := []io.Writer{f, pw}
+	if h != nil {
+		writers = append(writers, h)
+	}
+
+	_, copyErr := io.Copy(io.MultiWriter(writers...), bandwidth.NewCappedReader(d.ctx, body, d.rateLimit))
+	closeErr := f.Close()
+
+	d.progress.Update(pw.written)
+
+	if copyErr != nil {
+		return copyErr
+	}
+
+	return closeErr
+}
+
+// finish verifies the complete partial file against the checksum, if any, and
+// moves it into place. A file failing verification is deleted so the next
+// attempt starts from scratch instead of resuming corrupt data.
+func (d *httpDownloader) finish(h hash.Hash) error {
+	partPath := d.dst + partialSuffix
+
+	if h != nil {
+		if sum := h.Sum(nil); !d.checksum.Matches(sum) {
+			removePartial(d.dst)
+			return fmt.Errorf("%w: %s: expected %s, got %s",
+				ErrChecksumMismatch, d.dst, d.checksum, hex.EncodeToString(sum))
 		}
 	}
+
+	if err := os.Rename(partPath, d.dst); err != nil {
+		return fmt.Errorf("moving completed download into place: %w", err)
+	}
+
+	removePartial(d.dst)
+
+	return nil
+}
+
+// progressWriter reports the running byte count of a download, at most once per
+// progressPollInterval.
+type progressWriter struct {
+	dp         progress.DownloadProgress
+	written    int64
+	lastUpdate time.Time
+}
+
+// Write counts p and updates the progress display if it is due.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+
+	if now := time.Now(); now.Sub(w.lastUpdate) >= progressPollInterval {
+		w.dp.Update(w.written)
+		w.lastUpdate = now
+	}
+
+	return len(p), nil
 }
 
-// downloadAndVerifyFile downloads a file from the given URL and saves it to the specified path.
-// Uses grab/v3 for the actual download with native checksum verification.
-// Uses retry logic for transient failures.
-func downloadAndVerifyFile(ctx context.Context, fileURL, filePath, checksum string) error {
-	insecure := agentstate.State.InsecureDownloads
-	if insecure {
+// downloadAndVerifyFile downloads a file from the given URL and saves it to the specified path,
+// verifying the checksum as the file streams to disk and caching the verified checksum in a
+// sidecar file. Progress is reported to the options' tracker, which tracks the file once across
+// all attempts. Uses retry logic for transient failures; each retry resumes the partial download.
+func downloadAndVerifyFile(
+	ctx context.Context,
+	fileURL, filePath string,
+	checksum Checksum,
+	options downloadOptions,
+) error {
+	if agentstate.State.InsecureDownloads {
 		agentstate.Logger.Warn("TLS certificate verification disabled for download",
 			"url", fileURL, "dst", filePath)
 	}
 
-	grabClient := grab.NewClient()
-	if insecure {
-		if err := applyInsecureTransport(grabClient); err != nil {
-			return fmt.Errorf("insecure download mode configured but cannot be applied: %w", err)
-		}
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return err
 	}
 
-	dl := &grabDownloader{
-		client:   grabClient,
-		ctx:      ctx,
-		url:      fileURL,
-		dst:      filePath,
-		checksum: strings.TrimSpace(checksum),
-		tracker:  progress.DefaultProgressBar,
+	// One progress display covers every attempt; each resumes where the last
+	// one stopped and sets the size once the server reports it.
+	dp := options.tracker.StartTracking(filePath, -1)
+	defer dp.Finish()
+
+	dl := &httpDownloader{
+		client:    httpClient,
+		ctx:       ctx,
+		url:       fileURL,
+		dst:       filePath,
+		checksum:  checksum,
+		progress:  dp,
+		rateLimit: options.rateLimit,
 	}
 
+	// Any cached checksum describes the file being replaced.
+	RemoveChecksumSidecar(filePath)
+
 	maxRetries := agentstate.State.DownloadMaxRetries
 	baseDelay := agentstate.State.DownloadRetryDelay
 
-	if err := downloadWithRetry(ctx, dl, maxRetries, baseDelay); err != nil {
+	if err = downloadWithRetry(ctx, dl, maxRetries, baseDelay); err != nil {
 		return err
 	}
 
-	if strings.TrimSpace(checksum) != "" && !FileExistsAndValid(filePath, checksum) {
-		return errors.New("downloaded file checksum does not match")
+	if !checksum.IsZero() {
+		writeChecksumSidecar(filePath, checksum)
 	}
 
 	return nil
 }
 
-// applyInsecureTransport disables TLS certificate verification on the grab client's
-// HTTP transport. Returns an error if the transport chain cannot be unwrapped, ensuring
-// the caller never silently falls back to secure TLS when insecure mode was requested.
-func applyInsecureTransport(grabClient *grab.Client) error {
-	httpClient, ok := grabClient.HTTPClient.(*http.Client)
-	if !ok {
-		return fmt.Errorf("unexpected HTTP client type %T", grabClient.HTTPClient)
+// newHTTPClient returns the client for fetching resources, with TLS certificate
+// verification disabled when agentstate.State.InsecureDownloads is set.
+func newHTTPClient() (*http.Client, error) {
+	httpClient := &http.Client{}
+	if agentstate.State.InsecureDownloads {
+		if err := applyInsecureTransport(httpClient); err != nil {
+			return nil, fmt.Errorf("insecure download mode configured but cannot be applied: %w", err)
+		}
 	}
 
+	return httpClient, nil
+}
+
+// applyInsecureTransport disables TLS certificate verification on the HTTP client's
+// transport. Returns an error if the transport cannot be unwrapped, ensuring the
+// caller never silently falls back to secure TLS when insecure mode was requested.
+func applyInsecureTransport(httpClient *http.Client) error {
 	// A nil Transport means http.DefaultTransport is used implicitly.
 	roundTripper := httpClient.Transport
 	if roundTripper == nil {
@@ -329,7 +526,7 @@ func DownloadHashList(ctx context.Context, attack *api.Attack, hashlistDir strin
 		return errors.New("response stream is nil")
 	}
 
-	if err := writeResponseToFile(responseStream, hashlistPath); err != nil {
+	if err := writeResponseToFile(bandwidth.NewReader(ctx, responseStream), hashlistPath); err != nil {
 		return err
 	}
 
@@ -388,31 +585,6 @@ func writeResponseToFile(responseStream io.Reader, filePath string) error {
 	return nil
 }
 
-// fileMD5 computes the MD5 hex digest of the file at the given path.
-// MD5 is used because the CipherSwarm server provides MD5 checksums in its API.
-// This is for integrity verification (bit-flip detection), not cryptographic security.
-// Migration to SHA-256 requires a server-side API change.
-func fileMD5(filePath string) (string, error) { // DevSkim: ignore DS126858
-	f, err := os.Open(filePath)
-	if err != nil {
-		return "", fmt.Errorf("opening file %q for checksum: %w", filePath, err)
-	}
-
-	defer func() {
-		if cerr := f.Close(); cerr != nil {
-			agentstate.Logger.Error("Error closing file after checksum",
-				"path", filePath, "error", cerr)
-		}
-	}()
-
-	h := md5.New() //nolint:gosec // G401 - MD5 used for file integrity check, not security // DevSkim: ignore DS126858
-	if _, err := io.Copy(h, f); err != nil {
-		return "", fmt.Errorf("reading file %q for checksum: %w", filePath, err)
-	}
-
-	return hex.EncodeToString(h.Sum(nil)), nil
-}
-
 // CleanupTempDir removes the specified temporary directory and its contents.
 // It logs any errors encountered during the removal process.
 func CleanupTempDir(tempDir string) error {
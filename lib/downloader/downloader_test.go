@@ -2,6 +2,7 @@ package downloader
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +14,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/cavaliergopher/grab/v3"
 	"github.com/stretchr/testify/require"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/config"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/progress"
@@ -26,7 +26,7 @@ func TestFileExistsAndValid(t *testing.T) {
 	tests := []struct {
 		name           string
 		setupFile      func() string
-		checksum       string
+		checksum       Checksum
 		expectedResult bool
 	}{
 		{
@@ -37,7 +37,7 @@ func TestFileExistsAndValid(t *testing.T) {
 				require.NoError(t, err)
 				return filePath
 			},
-			checksum:       "9473fdd0d880a43c21b7778d34872157", // MD5 of "test content"
+			checksum:       mustParseChecksum(t, "9473fdd0d880a43c21b7778d34872157"), // MD5 of "test content"
 			expectedResult: true,
 		},
 		{
@@ -48,7 +48,7 @@ func TestFileExistsAndValid(t *testing.T) {
 				require.NoError(t, err)
 				return filePath
 			},
-			checksum:       "",
+			checksum:       Checksum{},
 			expectedResult: true,
 		},
 		{
@@ -59,7 +59,7 @@ func TestFileExistsAndValid(t *testing.T) {
 				require.NoError(t, err)
 				return filePath
 			},
-			checksum:       "",
+			checksum:       Checksum{},
 			expectedResult: false,
 		},
 		{
@@ -70,7 +70,7 @@ func TestFileExistsAndValid(t *testing.T) {
 				require.NoError(t, err)
 				return filePath
 			},
-			checksum:       "wrongchecksum123456789012345678901",
+			checksum:       mustParseChecksum(t, "00000000000000000000000000000000"),
 			expectedResult: false,
 		},
 		{
@@ -78,7 +78,7 @@ func TestFileExistsAndValid(t *testing.T) {
 			setupFile: func() string {
 				return filepath.Join(tempDir, "nonexistent.txt")
 			},
-			checksum:       "somechecksum",
+			checksum:       mustParseChecksum(t, "9473fdd0d880a43c21b7778d34872157"),
 			expectedResult: false,
 		},
 	}
@@ -269,11 +269,11 @@ func TestDownloadWithRetry_NoOverflowAtMaxShift(t *testing.T) {
 		"all attempts should run without an overflow-induced early exit")
 }
 
-// TestFileMD5_OpenErrorWrapped verifies fileMD5 wraps an open failure with context.
-func TestFileMD5_OpenErrorWrapped(t *testing.T) {
+// TestFileChecksum_OpenErrorWrapped verifies fileChecksum wraps an open failure with context.
+func TestFileChecksum_OpenErrorWrapped(t *testing.T) {
 	missing := filepath.Join(t.TempDir(), "no-such-file")
 
-	_, err := fileMD5(missing)
+	_, err := fileChecksum(missing, ChecksumMD5)
 	require.Error(t, err)
 	require.ErrorIs(t, err, os.ErrNotExist, "open failure should wrap the underlying cause")
 	require.Contains(t, err.Error(), "no-such-file", "wrapped error should include the path")
@@ -320,41 +320,45 @@ func TestDownloadWithRetry_ContextCancellation(t *testing.T) {
 }
 
 func TestApplyInsecureTransport_Success(t *testing.T) {
-	client := grab.NewClient()
+	client := &http.Client{}
 	err := applyInsecureTransport(client)
 	require.NoError(t, err)
 
 	// Verify TLS config was applied
-	httpClient, ok := client.HTTPClient.(*http.Client)
-	require.True(t, ok)
-	transport, ok := httpClient.Transport.(*http.Transport)
+	transport, ok := client.Transport.(*http.Transport)
 	require.True(t, ok)
 	require.NotNil(t, transport.TLSClientConfig)
 	require.True(t, transport.TLSClientConfig.InsecureSkipVerify)
 }
 
-func TestApplyInsecureTransport_BadHTTPClient(t *testing.T) {
-	client := grab.NewClient()
-	client.HTTPClient = &badHTTPClient{}
+func TestApplyInsecureTransport_BadTransport(t *testing.T) {
+	client := &http.Client{Transport: &badTransport{}}
 	err := applyInsecureTransport(client)
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "unexpected HTTP client type")
+	require.Contains(t, err.Error(), "unexpected transport type")
 }
 
-// badHTTPClient implements grab's HTTPClient interface but is not *http.Client.
-type badHTTPClient struct{}
+// badTransport implements http.RoundTripper but is not *http.Transport.
+type badTransport struct{}
 
-func (b *badHTTPClient) Do(req *http.Request) (*http.Response, error) {
-	return http.DefaultClient.Do(req) //nolint:gosec // G107 - test-only mock, not real SSRF
+func (b *badTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return http.DefaultTransport.RoundTrip(req)
 }
 
-// recordingProgress captures Update and Finish calls for assertion.
+// recordingProgress captures SetTotal, Update and Finish calls for assertion.
 type recordingProgress struct {
+	totals   []int64
 	updates  []int64
 	finished bool
 	mu       sync.Mutex
 }
 
+func (r *recordingProgress) SetTotal(totalSize int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.totals = append(r.totals, totalSize)
+}
+
 func (r *recordingProgress) Update(bytesComplete int64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -367,19 +371,22 @@ func (r *recordingProgress) Finish() {
 	r.finished = true
 }
 
-// recordingTracker implements progress.Tracker, returning a recordingProgress.
+// recordingTracker implements progress.Tracker, returning a recordingProgress
+// and counting how often tracking was started.
 type recordingTracker struct {
-	dp *recordingProgress
+	dp     *recordingProgress
+	starts atomic.Int32
 }
 
 func (rt *recordingTracker) StartTracking(_ string, _ int64) progress.DownloadProgress {
+	rt.starts.Add(1)
 	return rt.dp
 }
 
-// TestGrabDownloader_Get_HappyPath verifies that a successful download
-// writes the file to disk, calls dp.Finish(), and returns nil.
-func TestGrabDownloader_Get_HappyPath(t *testing.T) {
-	content := "hello, grab download test"
+// TestHTTPDownloader_Get_HappyPath verifies that a successful download
+// writes the file to disk, reports its size and final byte count, and returns nil.
+func TestHTTPDownloader_Get_HappyPath(t *testing.T) {
+	content := "hello, download test"
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
 		if _, err := w.Write([]byte(content)); err != nil {
@@ -392,12 +399,12 @@ func TestGrabDownloader_Get_HappyPath(t *testing.T) {
 	dst := filepath.Join(tmpDir, "downloaded.txt")
 
 	dp := &recordingProgress{}
-	dl := &grabDownloader{
-		client:  grab.NewClient(),
-		ctx:     context.Background(),
-		url:     srv.URL + "/file.txt",
-		dst:     dst,
-		tracker: &recordingTracker{dp: dp},
+	dl := &httpDownloader{
+		client:   &http.Client{},
+		ctx:      context.Background(),
+		url:      srv.URL + "/file.txt",
+		dst:      dst,
+		progress: dp,
 	}
 
 	err := dl.Get()
@@ -411,13 +418,15 @@ func TestGrabDownloader_Get_HappyPath(t *testing.T) {
 	// Verify progress tracking
 	dp.mu.Lock()
 	defer dp.mu.Unlock()
-	require.True(t, dp.finished, "dp.Finish() should have been called")
+	require.Equal(t, []int64{int64(len(content))}, dp.totals, "the size should be reported once known")
 	require.NotEmpty(t, dp.updates, "dp.Update() should have been called at least once")
+	require.Equal(t, int64(len(content)), dp.updates[len(dp.updates)-1], "the final byte count should be reported")
+	require.False(t, dp.finished, "the caller finishes the progress after the last attempt")
 }
 
-// TestGrabDownloader_Get_ChecksumMismatch verifies that a checksum mismatch
-// returns an error from grab's native verification.
-func TestGrabDownloader_Get_ChecksumMismatch(t *testing.T) {
+// TestHTTPDownloader_Get_ChecksumMismatch verifies that a checksum mismatch
+// detected while streaming returns ErrChecksumMismatch and removes the file.
+func TestHTTPDownloader_Get_ChecksumMismatch(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		if _, err := w.Write([]byte("actual content")); err != nil {
 			return
@@ -429,113 +438,113 @@ func TestGrabDownloader_Get_ChecksumMismatch(t *testing.T) {
 	dst := filepath.Join(tmpDir, "bad-checksum.txt")
 
 	dp := &recordingProgress{}
-	dl := &grabDownloader{
-		client:   grab.NewClient(),
+	dl := &httpDownloader{
+		client:   &http.Client{},
 		ctx:      context.Background(),
 		url:      srv.URL + "/file.txt",
 		dst:      dst,
-		checksum: "0000000000000000000000000000dead", // valid hex, wrong checksum
-		tracker:  &recordingTracker{dp: dp},
+		checksum: mustParseChecksum(t, "0000000000000000000000000000dead"), // valid hex, wrong checksum
+		progress: dp,
 	}
 
 	err := dl.Get()
-	require.Error(t, err, "checksum mismatch should return an error")
-
-	dp.mu.Lock()
-	defer dp.mu.Unlock()
-	require.True(t, dp.finished, "dp.Finish() should be called even on checksum failure")
+	require.ErrorIs(t, err, ErrChecksumMismatch, "checksum mismatch should return an error")
+	require.NoFileExists(t, dst, "a download failing verification should not be moved into place")
+	require.NoFileExists(t, dst+partialSuffix, "a download failing verification should be removed")
 }
 
-// TestGrabDownloader_Get_InvalidChecksum verifies that an invalid hex checksum
-// returns an error before starting the download.
-func TestGrabDownloader_Get_InvalidChecksum(t *testing.T) {
-	dl := &grabDownloader{
-		client:   grab.NewClient(),
+// TestHTTPDownloader_Get_SHA256 verifies that a SHA-256 checksum is verified
+// while the download streams to disk.
+func TestHTTPDownloader_Get_SHA256(t *testing.T) {
+	content := "wordlist contents"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(content)); err != nil {
+			return
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sum := sha256.Sum256([]byte(content))
+	dst := filepath.Join(t.TempDir(), "wordlist.txt")
+
+	dl := &httpDownloader{
+		client:   &http.Client{},
 		ctx:      context.Background(),
-		url:      "http://localhost/unused",
-		dst:      filepath.Join(t.TempDir(), "unused"),
-		checksum: "not-valid-hex",
-		tracker:  &recordingTracker{dp: &recordingProgress{}},
+		url:      srv.URL + "/wordlist.txt",
+		dst:      dst,
+		checksum: Checksum{Algorithm: ChecksumSHA256, Digest: sum[:]},
+		progress: &recordingProgress{},
 	}
 
-	err := dl.Get()
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "decoding checksum")
+	require.NoError(t, dl.Get())
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
 }
 
-// finalizationProgress implements progress.DownloadProgress with a gate on
-// Finish() for synchronization testing. Update() is a no-op. Finish() signals
-// that it was entered (via finishStarted) and then blocks until finishGate
-// is closed, giving the test control over when Get() can complete.
+// finalizationProgress implements progress.DownloadProgress with a gate on the
+// final Update() for synchronization testing. Once armed, Update() signals that
+// it was entered (via finishStarted) and then blocks until finishGate is
+// closed, giving the test control over when Get() can complete. Updates before
+// arming and SetTotal() and Finish() are no-ops.
 type finalizationProgress struct {
+	armed         atomic.Bool
 	finishOnce    sync.Once
 	finishStarted chan struct{}
 	finishGate    chan struct{}
 }
 
-func (f *finalizationProgress) Update(_ int64) {}
+func (f *finalizationProgress) SetTotal(_ int64) {}
+
+func (f *finalizationProgress) Update(_ int64) {
+	if !f.armed.Load() {
+		return
+	}
 
-func (f *finalizationProgress) Finish() {
 	f.finishOnce.Do(func() {
 		close(f.finishStarted)
 	})
 	<-f.finishGate
 }
 
-// finalizationTracker implements progress.Tracker, returning a gated
-// finalizationProgress that allows tests to block Get() at the dp.Finish()
-// call site for deterministic ordering assertions.
-type finalizationTracker struct {
-	finishStarted chan struct{}
-	finishGate    chan struct{}
-}
-
-func (ft *finalizationTracker) StartTracking(_ string, _ int64) progress.DownloadProgress {
-	return &finalizationProgress{
-		finishStarted: ft.finishStarted,
-		finishGate:    ft.finishGate,
-	}
-}
+func (f *finalizationProgress) Finish() {}
 
-// TestGrabDownloader_CancellationWaitsForFinalization verifies that
-// grabDownloader.Get() waits for Grab's response to finalize before returning
-// when the context is cancelled during an active download.
+// TestHTTPDownloader_CancellationWaitsForFinalization verifies that
+// httpDownloader.Get() finishes the transfer (closing the body and the partial
+// file) and reports its final progress before returning when the context is
+// cancelled during an active download.
 //
-// The old early-return implementation would return from Get() as soon as
-// ctx.Done() fired, without calling resp.Err() to wait for Grab's async
-// response lifecycle. This test proves that Get() blocks until Grab's
-// transfer goroutine finishes (closing the body, flushing the file) before
-// returning to the caller.
-//
-// The test uses a gated finalizationTracker whose dp.Finish() blocks until
-// the test releases a gate channel. Because the correct implementation calls
-// dp.Finish() before returning from Get(), the gate deterministically holds
-// Get() from completing. The old early-return implementation never calls
-// dp.Finish(), so it fails at Phase 1 (timeout waiting for dp.Finish entry).
+// The test uses a gated finalizationProgress whose Update() blocks, once armed,
+// until the test releases a gate channel. The test arms it just before
+// cancelling, while the server holds the transfer idle, so the only update
+// that follows is the final one the correct implementation reports after the
+// transfer ends and before Get() returns. An implementation returning as soon
+// as the context is cancelled never reports it, so it fails at Phase 1.
 //
 // The test uses four phases:
-//  1. After cancel(), wait for dp.Finish() to be entered — proves Get()
-//     proceeded through resp.Err() to the finalization path.
-//  2. Assert Get() has NOT returned yet — dp.Finish() is blocked on the gate,
+//  1. After cancel(), wait for the final Update() to be entered — proves Get()
+//     proceeded through the transfer to the finalization path.
+//  2. Assert Get() has NOT returned yet — Update() is blocked on the gate,
 //     so this is deterministic regardless of scheduler timing.
 //  3. Wait for the handler to observe the client disconnect — proves the HTTP
 //     transfer was genuinely active.
 //  4. Release the finish gate and handler gate, require Get() to return
-//     promptly with a cancellation-related error.
+//     promptly with a cancellation error.
 //
 // An additional handler gate keeps the server blocked during finalization for
 // realism. The actual ordering proof relies on the finish gate, not the
-// handler gate, because Grab's client-side resp.Err() returns independently
-// of the server handler lifecycle.
-func TestGrabDownloader_CancellationWaitsForFinalization(t *testing.T) {
+// handler gate, because the client-side transfer ends independently of the
+// server handler lifecycle.
+func TestHTTPDownloader_CancellationWaitsForFinalization(t *testing.T) {
 	// Synchronization channels for ordering assertions.
 	requestStarted := make(chan struct{})        // closed when server receives the request
 	handlerDisconnectSeen := make(chan struct{}) // closed when handler detects client disconnect
 	handlerGate := make(chan struct{})           // test closes to release handler from finalization
 	handlerDone := make(chan struct{})           // closed after handler exits
 	getReturned := make(chan error, 1)           // receives Get()'s return value
-	finishStarted := make(chan struct{})         // closed when dp.Finish() is entered
-	finishGate := make(chan struct{})            // test closes to let dp.Finish() return
+	finishStarted := make(chan struct{})         // closed when the final Update() is entered
+	finishGate := make(chan struct{})            // test closes to let the final Update() return
 
 	// Ensure gates are released on cleanup to prevent hangs if the test fails
 	// before reaching the explicit close() calls. t.Cleanup runs LIFO, so
@@ -562,11 +571,11 @@ func TestGrabDownloader_CancellationWaitsForFinalization(t *testing.T) {
 		if _, writeErr := w.Write([]byte("partial data")); writeErr != nil {
 			return
 		}
-		// Flush to ensure the client receives data and Grab's transfer is active.
+		// Flush to ensure the client receives data and the transfer is active.
 		if f, ok := w.(http.Flusher); ok {
 			f.Flush()
 		}
-		// Block until the client disconnects, proving Grab is still active.
+		// Block until the client disconnects, proving the transfer is still active.
 		<-r.Context().Done()
 		close(handlerDisconnectSeen)
 		// Block on the handler gate to simulate server-side finalization work.
@@ -581,15 +590,13 @@ func TestGrabDownloader_CancellationWaitsForFinalization(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
 
-	dl := &grabDownloader{
-		client: grab.NewClient(),
-		ctx:    ctx,
-		url:    srv.URL + "/testfile",
-		dst:    dst,
-		tracker: &finalizationTracker{
-			finishStarted: finishStarted,
-			finishGate:    finishGate,
-		},
+	dp := &finalizationProgress{finishStarted: finishStarted, finishGate: finishGate}
+	dl := &httpDownloader{
+		client:   &http.Client{},
+		ctx:      ctx,
+		url:      srv.URL + "/testfile",
+		dst:      dst,
+		progress: dp,
 	}
 
 	// Run Get() in a goroutine so the test can observe ordering.
@@ -604,33 +611,42 @@ func TestGrabDownloader_CancellationWaitsForFinalization(t *testing.T) {
 		t.Fatal("timed out waiting for server to receive the request")
 	}
 
-	// Cancel the context to trigger the cancellation path in Get().
+	// Wait for the first bytes to reach the partial file, so the transfer is
+	// underway rather than still waiting for the response headers.
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(dst + partialSuffix)
+		return err == nil && info.Size() > 0
+	}, 5*time.Second, 10*time.Millisecond, "timed out waiting for the transfer to start")
+
+	// Cancel the context to trigger the cancellation path in Get(). The server
+	// sends nothing more, so the next update is the final one.
+	dp.armed.Store(true)
 	cancel()
 
-	// Phase 1: Wait for dp.Finish() to be entered. The correct implementation
-	// calls resp.Err() (blocking until Grab finalizes), then dp.Update(), then
-	// dp.Finish(). The old early-return implementation returns directly from
-	// <-g.ctx.Done() without calling dp.Finish(), so this would timeout.
+	// Phase 1: Wait for the final Update() to be entered. The correct
+	// implementation ends the transfer when the body read fails, closes the
+	// partial file, then reports the final byte count. An early return on
+	// cancellation would time out here.
 	select {
 	case <-finishStarted:
-		// Good: Get() reached dp.Finish(), proving it went through resp.Err().
+		// Good: Get() reached the final Update() after ending the transfer.
 	case <-time.After(5 * time.Second):
-		t.Fatal("timed out waiting for dp.Finish() — " +
+		t.Fatal("timed out waiting for the final Update() — " +
 			"Get() likely returned without finalization (early-return bug)")
 	}
 
-	// Phase 2: dp.Finish() is blocked on finishGate, so Get() cannot have
+	// Phase 2: Update() is blocked on finishGate, so Get() cannot have
 	// returned yet. This is deterministic — no scheduler timing can cause a
 	// false pass because the gate is held closed by the test.
 	select {
 	case err := <-getReturned:
 		t.Fatalf(
-			"Get() returned while dp.Finish() is blocked "+
+			"Get() returned while the final Update() is blocked "+
 				"(error: %v); finalization was bypassed",
 			err,
 		)
 	default:
-		// Good: Get() is still blocked inside dp.Finish().
+		// Good: Get() is still blocked inside the final Update().
 	}
 
 	// Phase 3: Wait for the handler to observe the client disconnect. This
@@ -649,7 +665,7 @@ func TestGrabDownloader_CancellationWaitsForFinalization(t *testing.T) {
 
 	select {
 	case err := <-getReturned:
-		require.Error(t, err, "Get() should return a cancellation-related error")
+		require.ErrorIs(t, err, context.Canceled, "Get() should return a cancellation error")
 	case <-time.After(5 * time.Second):
 		t.Fatal("timed out waiting for Get() to return after gates released")
 	}
@@ -0,0 +1,157 @@
+package downloader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+)
+
+const (
+	partialSuffix     = ".part"      // Appended to a download's path while it is incomplete
+	partialMetaSuffix = ".part.json" // Appended to a download's path to name its partial metadata
+)
+
+// partialDownload describes an incomplete download kept on disk so a later
+// attempt, possibly after an agent restart, can resume it with a Range request.
+// The validators are sent in If-Range, so the server restarts the transfer
+// instead of appending bytes from a different version of the file.
+type partialDownload struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size"` // Full size of the file; zero or less if unknown
+}
+
+// newPartialDownload records the validators and size from a full response to fileURL.
+func newPartialDownload(fileURL string, resp *http.Response) partialDownload {
+	return partialDownload{
+		URL:          fileURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Size:         resp.ContentLength,
+	}
+}
+
+// validator returns the value to send in If-Range, or "" if the server gave
+// nothing that can safely guard a resume. Weak ETags are not allowed in If-Range.
+func (p partialDownload) validator() string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+
+	return p.LastModified
+}
+
+// sameResource reports whether fileURL names the resource the partial download
+// came from. The query is ignored because presigned URLs change on every request.
+func (p partialDownload) sameResource(fileURL string) bool {
+	saved, err := url.Parse(p.URL)
+	if err != nil {
+		return false
+	}
+
+	current, err := url.Parse(fileURL)
+	if err != nil {
+		return false
+	}
+
+	return saved.Scheme == current.Scheme && saved.Host == current.Host && saved.Path == current.Path
+}
+
+// loadPartial returns the metadata and length of a resumable partial download of
+// fileURL to filePath. A partial file that cannot be resumed is deleted and a
+// zero offset returned, so the download starts over.
+func loadPartial(filePath, fileURL string) (partialDownload, int64) {
+	info, err := os.Stat(filePath + partialSuffix)
+	if err != nil || info.Size() == 0 {
+		return partialDownload{}, 0
+	}
+
+	var meta partialDownload
+
+	data, err := os.ReadFile(filePath + partialMetaSuffix)
+	if err == nil {
+		err = json.Unmarshal(data, &meta)
+	}
+
+	switch {
+	case err != nil:
+		agentstate.Logger.Debug("Discarding partial download without metadata", "path", filePath, "error", err)
+	case !meta.sameResource(fileURL):
+		agentstate.Logger.Debug("Discarding partial download of a different resource", "path", filePath)
+	case meta.validator() == "":
+		agentstate.Logger.Debug("Discarding partial download the server cannot validate", "path", filePath)
+	case meta.Size > 0 && info.Size() > meta.Size:
+		agentstate.Logger.Debug("Discarding partial download larger than its resource", "path", filePath)
+	default:
+		return meta, info.Size()
+	}
+
+	removePartial(filePath)
+
+	return partialDownload{}, 0
+}
+
+// savePartial records meta for the partial download of filePath. Failures are
+// logged; the only cost is restarting the download if it is interrupted.
+func savePartial(filePath string, meta partialDownload) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		agentstate.Logger.Warn("Failed to encode partial download metadata", "path", filePath, "error", err)
+		return
+	}
+
+	if err := os.WriteFile(filePath+partialMetaSuffix, data, 0o600); err != nil {
+		agentstate.Logger.Warn("Failed to write partial download metadata", "path", filePath, "error", err)
+	}
+}
+
+// removePartial deletes the partial file and metadata for filePath, if any.
+func removePartial(filePath string) {
+	for _, path := range []string{filePath + partialSuffix, filePath + partialMetaSuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			agentstate.Logger.Warn("Failed to remove partial download", "path", path, "error", err)
+		}
+	}
+}
+
+// contentRangeStart returns the first byte position and full length from a
+// Content-Range header such as "bytes 100-199/200". The length is -1 if the
+// server reports it as unknown.
+func contentRangeStart(header string) (int64, int64, bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+
+	span, total, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if total == "*" {
+		return start, -1, true
+	}
+
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return start, size, true
+}
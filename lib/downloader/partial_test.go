@@ -0,0 +1,265 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+)
+
+// resumeServer serves content with an ETag and honours Range and If-Range
+// requests. The first drops full requests send half the body and then close
+// the connection, as a flaky network would.
+type resumeServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	content []byte
+	etag    string
+	drops   int
+	ranges  []string // Range header of every request, "" for full requests
+}
+
+func newResumeServer(t *testing.T, content string, drops int) *resumeServer {
+	t.Helper()
+
+	rs := &resumeServer{content: []byte(content), etag: `"v1"`, drops: drops}
+	rs.Server = httptest.NewServer(http.HandlerFunc(rs.serve))
+	t.Cleanup(rs.Close)
+
+	return rs
+}
+
+func (rs *resumeServer) serve(w http.ResponseWriter, r *http.Request) {
+	rs.mu.Lock()
+	rs.ranges = append(rs.ranges, r.Header.Get("Range"))
+	content, etag := rs.content, rs.etag
+	drop := r.Header.Get("Range") == "" && rs.drops > 0
+	if drop {
+		rs.drops--
+	}
+	rs.mu.Unlock()
+
+	if !drop {
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+
+		return
+	}
+
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\nETag: %s\r\n\r\n", len(content), etag)
+	buf.Write(content[:len(content)/2]) //nolint:errcheck // the connection is dropped regardless
+	buf.Flush()                         //nolint:errcheck // the connection is dropped regardless
+}
+
+// requestedRanges returns the Range header of every request served so far.
+func (rs *resumeServer) requestedRanges() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return append([]string(nil), rs.ranges...)
+}
+
+func newTestDownloader(ctx context.Context, fileURL, dst string, checksum Checksum) *httpDownloader {
+	return &httpDownloader{
+		client:   &http.Client{},
+		ctx:      ctx,
+		url:      fileURL,
+		dst:      dst,
+		checksum: checksum,
+		progress: &recordingProgress{},
+	}
+}
+
+func sha256Checksum(content string) Checksum {
+	sum := sha256.Sum256([]byte(content))
+	return Checksum{Algorithm: ChecksumSHA256, Digest: sum[:]}
+}
+
+func TestHTTPDownloader_ResumesOnRetry(t *testing.T) {
+	content := strings.Repeat("wordlist entry\n", 1000)
+	srv := newResumeServer(t, content, 1)
+	dst := filepath.Join(t.TempDir(), "wordlist.txt")
+
+	dl := newTestDownloader(context.Background(), srv.URL+"/wordlist.txt", dst, sha256Checksum(content))
+	require.NoError(t, downloadWithRetry(context.Background(), dl, 2, time.Millisecond))
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(content)/2)}, srv.requestedRanges(),
+		"the retry should request only the missing bytes")
+	assert.NoFileExists(t, dst+partialSuffix)
+	assert.NoFileExists(t, dst+partialMetaSuffix)
+}
+
+// TestDownloadAndVerifyFile_TracksOnce verifies a download that needs a retry
+// is shown as one download, resuming its progress, rather than once per attempt.
+func TestDownloadAndVerifyFile_TracksOnce(t *testing.T) {
+	retries, delay := agentstate.State.DownloadMaxRetries, agentstate.State.DownloadRetryDelay
+	agentstate.State.DownloadMaxRetries, agentstate.State.DownloadRetryDelay = 2, time.Millisecond
+	t.Cleanup(func() {
+		agentstate.State.DownloadMaxRetries, agentstate.State.DownloadRetryDelay = retries, delay
+	})
+
+	content := strings.Repeat("wordlist entry\n", 1000)
+	srv := newResumeServer(t, content, 1)
+	dst := filepath.Join(t.TempDir(), "wordlist.txt")
+
+	tracker := &recordingTracker{dp: &recordingProgress{}}
+	err := downloadAndVerifyFile(context.Background(), srv.URL+"/wordlist.txt", dst,
+		sha256Checksum(content), downloadOptions{tracker: tracker})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), tracker.starts.Load())
+	assert.Len(t, srv.requestedRanges(), 2, "the download should have been retried")
+	assert.True(t, tracker.dp.finished)
+	assert.Equal(t, int64(len(content)), tracker.dp.updates[len(tracker.dp.updates)-1])
+}
+
+func TestHTTPDownloader_ResumesAfterRestart(t *testing.T) {
+	content := strings.Repeat("rule\n", 2000)
+	srv := newResumeServer(t, content, 1)
+	dst := filepath.Join(t.TempDir(), "best64.rule")
+	checksum := sha256Checksum(content)
+
+	err := newTestDownloader(context.Background(), srv.URL+"/best64.rule?sig=first", dst, checksum).Get()
+	require.Error(t, err, "the dropped connection should fail the attempt")
+	require.FileExists(t, dst+partialSuffix, "the partial download should be kept")
+	require.FileExists(t, dst+partialMetaSuffix)
+	require.NoFileExists(t, dst)
+
+	// A new downloader, as after an agent restart, with a freshly signed URL.
+	err = newTestDownloader(context.Background(), srv.URL+"/best64.rule?sig=second", dst, checksum).Get()
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.Equal(t, fmt.Sprintf("bytes=%d-", len(content)/2), srv.requestedRanges()[1])
+}
+
+func TestHTTPDownloader_RestartsWhenResourceChanged(t *testing.T) {
+	original := strings.Repeat("a", 4096)
+	srv := newResumeServer(t, original, 1)
+	dst := filepath.Join(t.TempDir(), "mask.hcmask")
+
+	require.Error(t, newTestDownloader(context.Background(), srv.URL+"/mask.hcmask", dst, Checksum{}).Get())
+
+	// The file changes on the server before the download resumes.
+	updated := strings.Repeat("b", 4096)
+	srv.mu.Lock()
+	srv.content, srv.etag = []byte(updated), `"v2"`
+	srv.mu.Unlock()
+
+	require.NoError(t, newTestDownloader(context.Background(), srv.URL+"/mask.hcmask", dst, sha256Checksum(updated)).Get())
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, updated, string(data), "stale partial data must not be kept")
+}
+
+func TestHTTPDownloader_DiscardsCorruptPartial(t *testing.T) {
+	content := strings.Repeat("x", 4096)
+	srv := newResumeServer(t, content, 1)
+	dst := filepath.Join(t.TempDir(), "wordlist.txt")
+	checksum := sha256Checksum(content)
+
+	require.Error(t, newTestDownloader(context.Background(), srv.URL+"/wordlist.txt", dst, checksum).Get())
+	require.NoError(t, os.WriteFile(dst+partialSuffix, bytes.Repeat([]byte("y"), len(content)/2), 0o600))
+
+	err := newTestDownloader(context.Background(), srv.URL+"/wordlist.txt", dst, checksum).Get()
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.NoFileExists(t, dst+partialSuffix, "a partial download failing verification should be removed")
+	assert.NoFileExists(t, dst+partialMetaSuffix)
+
+	require.NoError(t, newTestDownloader(context.Background(), srv.URL+"/wordlist.txt", dst, checksum).Get(),
+		"the next attempt should start from scratch")
+}
+
+func TestLoadPartial(t *testing.T) {
+	const fileURL = "https://storage.example.com/wordlists/rockyou.txt?X-Amz-Signature=abc"
+
+	tests := []struct {
+		name   string
+		meta   *partialDownload
+		offset int64
+	}{
+		{name: "no metadata"},
+		{name: "different resource", meta: &partialDownload{URL: "https://storage.example.com/other.txt", ETag: `"v1"`}},
+		{name: "no validator", meta: &partialDownload{URL: fileURL}},
+		{name: "weak etag only", meta: &partialDownload{URL: fileURL, ETag: `W/"v1"`}},
+		{name: "larger than resource", meta: &partialDownload{URL: fileURL, ETag: `"v1"`, Size: 2}},
+		{
+			name:   "resumable with new signature",
+			meta:   &partialDownload{URL: "https://storage.example.com/wordlists/rockyou.txt?X-Amz-Signature=xyz", ETag: `"v1"`, Size: 10},
+			offset: 4,
+		},
+		{
+			name:   "resumable by modification time",
+			meta:   &partialDownload{URL: fileURL, LastModified: "Wed, 21 Oct 2015 07:28:00 GMT"},
+			offset: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "rockyou.txt")
+			require.NoError(t, os.WriteFile(dst+partialSuffix, []byte("pass"), 0o600))
+
+			if tt.meta != nil {
+				savePartial(dst, *tt.meta)
+			}
+
+			_, offset := loadPartial(dst, fileURL)
+			assert.Equal(t, tt.offset, offset)
+
+			if tt.offset == 0 {
+				assert.NoFileExists(t, dst+partialSuffix, "an unresumable partial download should be removed")
+			}
+		})
+	}
+}
+
+func TestContentRangeStart(t *testing.T) {
+	tests := []struct {
+		header string
+		start  int64
+		size   int64
+		ok     bool
+	}{
+		{header: "bytes 100-199/200", start: 100, size: 200, ok: true},
+		{header: "bytes 100-199/*", start: 100, size: -1, ok: true},
+		{header: "bytes */200"},
+		{header: "items 0-1/2"},
+		{header: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, size, ok := contentRangeStart(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.size, size)
+		})
+	}
+}
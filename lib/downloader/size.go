@@ -0,0 +1,209 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
+)
+
+// PendingBytes returns how many bytes downloading fileURL to filePath would
+// still write: zero if the file is already there, otherwise the remote size
+// less any partial download waiting to be resumed. It returns -1 if the server
+// does not report the size.
+func PendingBytes(ctx context.Context, fileURL, filePath string) (int64, error) {
+	if info, err := os.Stat(filePath); err == nil && info.Mode().IsRegular() {
+		return 0, nil
+	}
+
+	size, err := RemoteSize(ctx, fileURL)
+	if err != nil || size < 0 {
+		return size, err
+	}
+
+	var have int64
+	if info, err := os.Stat(filePath + partialSuffix); err == nil {
+		have = info.Size()
+	}
+
+	return max(size-have, 0), nil
+}
+
+// RemoteSize returns the size of the file at fileURL, or -1 if the server does
+// not report it. It asks with a HEAD request first; presigned URLs are often
+// signed for GET only, so it falls back to a GET of the first byte and reads the
+// size from Content-Range.
+func RemoteSize(ctx context.Context, fileURL string) (int64, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return -1, err
+	}
+
+	size, err := sizeRequest(ctx, client, http.MethodHead, fileURL)
+	if err == nil && size >= 0 {
+		return size, nil
+	}
+
+	return sizeRequest(ctx, client, http.MethodGet, fileURL)
+}
+
+// sizeRequest sends one request for fileURL's size. GET requests ask for the
+// first byte only and never read more than that.
+func sizeRequest(ctx context.Context, client *http.Client, method, fileURL string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fileURL, nil)
+	if err != nil {
+		return -1, fmt.Errorf("creating size request: %w", err)
+	}
+
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return -1, fmt.Errorf("requesting size of %s: %w", fileURL, err)
+	}
+	defer resp.Body.Close()
+
+	if method == http.MethodGet {
+		// Drain the single requested byte so the connection can be reused.
+		_, _ = io.CopyN(io.Discard, resp.Body, 1) //nolint:errcheck // best-effort drain
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// A GET answered in full means the server ignored the range; the body
+		// is abandoned unread.
+		return resp.ContentLength, nil
+	case http.StatusPartialContent:
+		if _, size, ok := contentRangeStart(resp.Header.Get("Content-Range")); ok {
+			return size, nil
+		}
+
+		return -1, nil
+	default:
+		return -1, fmt.Errorf("requesting size of %s: unexpected status %s", fileURL, resp.Status)
+	}
+}
+
+// PendingPlainBytes returns how many bytes the decompressed copy of the resource
+// downloaded from fileURL to filePath would still write: zero if the resource is
+// not compressed or the copy is already there, otherwise the content size its
+// compressed form records (see decompress.ContentSize). The ends of the file are
+// read from filePath once downloaded, and from the server with Range requests
+// before. It returns -1 if the size is not recorded or cannot be read.
+func PendingPlainBytes(ctx context.Context, fileURL, filePath string) (int64, error) {
+	if _, err := os.Stat(filePath + decompress.PlainSuffix); err == nil {
+		return 0, nil
+	}
+
+	header, trailer, size, err := localEnds(filePath)
+	if err != nil {
+		header, trailer, size, err = remoteEnds(ctx, fileURL)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	if decompress.DetectHeader(header) == decompress.None {
+		return 0, nil
+	}
+
+	return decompress.ContentSize(header, trailer, size), nil
+}
+
+// localEnds returns the first decompress.HeaderLen and last decompress.TrailerLen
+// bytes of the file at path, and its size.
+func localEnds(path string) ([]byte, []byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	header := make([]byte, min(info.Size(), decompress.HeaderLen))
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return nil, nil, 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	trailer := make([]byte, min(info.Size(), decompress.TrailerLen))
+	if _, err := f.ReadAt(trailer, info.Size()-int64(len(trailer))); err != nil {
+		return nil, nil, 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return header, trailer, info.Size(), nil
+}
+
+// remoteEnds returns the first decompress.HeaderLen bytes of the file at fileURL
+// and its size, and for a gzip file, which records its content size at the end,
+// its last decompress.TrailerLen bytes.
+func remoteEnds(ctx context.Context, fileURL string) ([]byte, []byte, int64, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	header, size, err := rangeRequest(ctx, client, fileURL, fmt.Sprintf("bytes=0-%d", decompress.HeaderLen-1))
+	if err != nil || decompress.DetectHeader(header) != decompress.Gzip {
+		return header, nil, size, err
+	}
+
+	trailer, _, err := rangeRequest(ctx, client, fileURL, fmt.Sprintf("bytes=-%d", decompress.TrailerLen))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return header, trailer, size, nil
+}
+
+// rangeRequest GETs the byte range spec of fileURL and returns those bytes and
+// the file's size, or -1 if the server does not report it. A server ignoring
+// the range only serves the right bytes for a range at the start of the file;
+// the rest of the body is abandoned unread.
+func rangeRequest(ctx context.Context, client *http.Client, fileURL, spec string) ([]byte, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, -1, fmt.Errorf("creating range request: %w", err)
+	}
+
+	req.Header.Set("Range", spec)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, -1, fmt.Errorf("requesting %s of %s: %w", spec, fileURL, err)
+	}
+	defer resp.Body.Close()
+
+	size := int64(-1)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if !strings.HasPrefix(spec, "bytes=0-") {
+			return nil, -1, fmt.Errorf("requesting %s of %s: server ignored the range", spec, fileURL)
+		}
+
+		size = resp.ContentLength
+	case http.StatusPartialContent:
+		if _, total, ok := contentRangeStart(resp.Header.Get("Content-Range")); ok {
+			size = total
+		}
+	default:
+		return nil, -1, fmt.Errorf("requesting %s of %s: unexpected status %s", spec, fileURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, decompress.HeaderLen))
+	if err != nil {
+		return nil, -1, fmt.Errorf("reading %s of %s: %w", spec, fileURL, err)
+	}
+
+	return body, size, nil
+}
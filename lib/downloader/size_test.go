@@ -0,0 +1,123 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
+)
+
+// sizeServer serves content, rejecting HEAD requests when getOnly is set, as
+// a presigned URL signed for GET would.
+func sizeServer(t *testing.T, content []byte, getOnly bool) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if getOnly && r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRemoteSize(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 4096)
+
+	for _, getOnly := range []bool{false, true} {
+		server := sizeServer(t, content, getOnly)
+
+		size, err := RemoteSize(context.Background(), server.URL)
+		require.NoError(t, err, "getOnly=%v", getOnly)
+		assert.Equal(t, int64(len(content)), size, "getOnly=%v", getOnly)
+	}
+}
+
+func TestRemoteSize_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	size, err := RemoteSize(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Equal(t, int64(-1), size)
+}
+
+func TestPendingBytes(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 1000)
+	server := sizeServer(t, content, false)
+	dir := t.TempDir()
+
+	fresh := filepath.Join(dir, "fresh.txt")
+	pending, err := PendingBytes(context.Background(), server.URL, fresh)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), pending)
+
+	partial := filepath.Join(dir, "partial.txt")
+	require.NoError(t, os.WriteFile(partial+partialSuffix, content[:400], 0o600))
+	pending, err = PendingBytes(context.Background(), server.URL, partial)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), pending, "a partial download should count only its missing bytes")
+
+	existing := filepath.Join(dir, "existing.txt")
+	require.NoError(t, os.WriteFile(existing, content, 0o600))
+	pending, err = PendingBytes(context.Background(), "http://127.0.0.1:0/unreachable", existing)
+	require.NoError(t, err)
+	assert.Zero(t, pending, "a file already in place should need no download")
+}
+
+func TestPendingPlainBytes(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 10_000)
+	compressed := []byte(gzipped(t, string(content)))
+	dir := t.TempDir()
+
+	for _, getOnly := range []bool{false, true} {
+		server := sizeServer(t, compressed, getOnly)
+
+		plain, err := PendingPlainBytes(context.Background(), server.URL, filepath.Join(dir, "fresh.txt.gz"))
+		require.NoError(t, err, "getOnly=%v", getOnly)
+		assert.Equal(t, int64(len(content)), plain, "the gzip trailer should be read from the server")
+	}
+
+	downloaded := filepath.Join(dir, "downloaded.txt.gz")
+	require.NoError(t, os.WriteFile(downloaded, compressed, 0o600))
+	plain, err := PendingPlainBytes(context.Background(), "http://127.0.0.1:0/unreachable", downloaded)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), plain, "a downloaded file should be read locally")
+
+	require.NoError(t, os.WriteFile(downloaded+decompress.PlainSuffix, content, 0o600))
+	plain, err = PendingPlainBytes(context.Background(), "http://127.0.0.1:0/unreachable", downloaded)
+	require.NoError(t, err)
+	assert.Zero(t, plain, "a decompressed copy already in place should need no space")
+
+	plain, err = PendingPlainBytes(context.Background(), sizeServer(t, content, false).URL,
+		filepath.Join(dir, "plain.txt"))
+	require.NoError(t, err)
+	assert.Zero(t, plain, "an uncompressed resource has no decompressed copy")
+}
+
+// TestPendingPlainBytes_RangeIgnored verifies that a gzip trailer cannot be
+// read from a server that ignores Range requests.
+func TestPendingPlainBytes_RangeIgnored(t *testing.T) {
+	compressed := []byte(gzipped(t, strings.Repeat("x", 10_000)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(compressed) //nolint:errcheck // test server
+	}))
+	t.Cleanup(server.Close)
+
+	plain, err := PendingPlainBytes(context.Background(), server.URL, filepath.Join(t.TempDir(), "list.gz"))
+	require.Error(t, err)
+	assert.Equal(t, int64(-1), plain)
+}
@@ -0,0 +1,65 @@
+package hashcat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// associationScanBuffer is the largest line countLines accepts; hint lines are
+// short, but hashes such as NetNTLMv2 responses run to several kilobytes.
+const associationScanBuffer = 1 << 20
+
+// validateAssociationPairing checks that the hint word list of an association
+// attack has exactly one line per hash in hashFile. hashcat pairs them by line
+// number, so a list generated for a different version of the hash list would
+// test every hint against the wrong hash without reporting anything.
+func validateAssociationPairing(wordList, hashFile string) error {
+	hints, err := countLines(wordList, false)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWordlistNotOpened, err)
+	}
+
+	hashes, err := countLines(hashFile, true)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrHashFileNotReadable, err)
+	}
+
+	if hints != hashes {
+		return fmt.Errorf("%w: %d wordlist lines for %d hashes", ErrAssociationLineCount, hints, hashes)
+	}
+
+	return nil
+}
+
+// countLines returns the number of lines in the file at path. A final line
+// without a trailing newline counts. With skipBlank, lines holding only
+// whitespace are not counted, as hashcat skips them when loading hashes; in a
+// word list an empty line is still a candidate and keeps its place.
+func countLines(path string, skipBlank bool) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), associationScanBuffer)
+
+	count := 0
+
+	for scanner.Scan() {
+		if skipBlank && len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		count++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,78 @@
+package hashcat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+)
+
+func TestValidate_AssociationAttack(t *testing.T) {
+	require.ErrorIs(t, Params{AttackMode: AttackModeAssociation}.Validate(), ErrAssociationAttackWordlist)
+	require.NoError(t, Params{AttackMode: AttackModeAssociation, WordListFilename: "hints.txt"}.Validate())
+}
+
+func TestParams_ToCmdArgs_AssociationAttack(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	hints := createTestFile(t, agentstate.State.FilePath, "hints.txt", "alice\nbob@example.com\n")
+	rules := createTestFile(t, agentstate.State.FilePath, "best64.rule", ":\n")
+	hashFile := createTestFile(t, t.TempDir(), "hashes.txt",
+		"5f4dcc3b5aa765d61d8327deb882cf99\ne10adc3949ba59abbe56e057f20f883e\n\n")
+
+	params := withInjectedTestPaths(Params{
+		AttackMode:       AttackModeAssociation,
+		WordListFilename: "hints.txt",
+		RuleListFilename: "best64.rule",
+	})
+
+	args, err := params.toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{hashFile, hints, "-r", rules}, args[len(args)-4:])
+	assert.Contains(t, args, "9")
+}
+
+func TestParams_ToCmdArgs_AssociationLineCountMismatch(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	createTestFile(t, agentstate.State.FilePath, "hints.txt", "alice\nbob\ncarol\n")
+	hashFile := createTestFile(t, t.TempDir(), "hashes.txt",
+		"5f4dcc3b5aa765d61d8327deb882cf99\ne10adc3949ba59abbe56e057f20f883e\n")
+
+	params := withInjectedTestPaths(Params{AttackMode: AttackModeAssociation, WordListFilename: "hints.txt"})
+
+	_, err := params.toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.ErrorIs(t, err, ErrAssociationLineCount)
+	assert.Contains(t, err.Error(), "3 wordlist lines for 2 hashes")
+}
+
+func TestCountLines(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		content   string
+		skipBlank bool
+		want      int
+	}{
+		{name: "empty", content: "", want: 0},
+		{name: "trailing newline", content: "a\nb\n", want: 2},
+		{name: "no trailing newline", content: "a\nb", want: 2},
+		{name: "empty word kept", content: "a\n\nb\n", want: 3},
+		{name: "blank hash lines skipped", content: "a\n\n \r\nb\r\n", skipBlank: true, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestFile(t, dir, "lines.txt", tt.content)
+
+			got, err := countLines(path, tt.skipBlank)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
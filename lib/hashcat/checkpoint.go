@@ -0,0 +1,118 @@
+package hashcat
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrCheckpointUnsupported is returned by RequestCheckpoint for a session that
+// reads its candidates from stdin. Hashcat then takes no keyboard input and
+// writes no restore file, so the session is killed instead, and the attack
+// starts over from the beginning when it is run again.
+var ErrCheckpointUnsupported = errors.New("session reads candidates from stdin and cannot stop at a checkpoint")
+
+// CheckpointReason names who asked a session to stop at its next checkpoint.
+type CheckpointReason string
+
+// checkpointSet holds a session's pending checkpoint requests.
+type checkpointSet map[CheckpointReason]struct{}
+
+// RequestCheckpoint asks hashcat, on behalf of reason, to stop at the next
+// restore point. The process then exits with ExitCodeCheckpoint once the
+// restore file has been written. Hashcat's checkpoint key toggles the stop, so
+// it is only sent when no other request is pending; a request already pending
+// is not repeated. A session reading stdin is killed by the first request,
+// which returns ErrCheckpointUnsupported; later requests are only recorded.
+func (sess *Session) RequestCheckpoint(reason CheckpointReason) error {
+	sess.checkpointMu.Lock()
+	defer sess.checkpointMu.Unlock()
+
+	if _, ok := sess.checkpoints[reason]; ok {
+		return nil
+	}
+
+	var unsupported error
+
+	if len(sess.checkpoints) == 0 {
+		err := sess.sendKey(keyCheckpoint)
+		if errors.Is(err, ErrWordListStreamed) || errors.Is(err, ErrPreprocessorStdin) {
+			if killErr := sess.Kill(); killErr != nil {
+				return fmt.Errorf("%w: killing session: %w", ErrCheckpointUnsupported, killErr)
+			}
+
+			sess.checkpointKilled = true
+			unsupported = fmt.Errorf("%w: %w", ErrCheckpointUnsupported, err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if sess.checkpoints == nil {
+		sess.checkpoints = make(checkpointSet)
+	}
+
+	sess.checkpoints[reason] = struct{}{}
+
+	return unsupported
+}
+
+// CancelCheckpoint withdraws reason's request to stop at the next checkpoint.
+// The pending stop is only cancelled, by sending the checkpoint key again, once
+// no other request remains. A session already killed in place of a checkpoint
+// cannot be continued, so the request stays recorded.
+func (sess *Session) CancelCheckpoint(reason CheckpointReason) error {
+	sess.checkpointMu.Lock()
+	defer sess.checkpointMu.Unlock()
+
+	if _, ok := sess.checkpoints[reason]; !ok || sess.checkpointKilled {
+		return nil
+	}
+
+	if len(sess.checkpoints) == 1 {
+		if err := sess.sendKey(keyCheckpoint); err != nil {
+			return err
+		}
+	}
+
+	delete(sess.checkpoints, reason)
+
+	return nil
+}
+
+// CheckpointKilled reports whether a checkpoint request killed the session
+// because it reads stdin, so it left no restore file to continue from.
+func (sess *Session) CheckpointKilled() bool {
+	sess.checkpointMu.Lock()
+	defer sess.checkpointMu.Unlock()
+
+	return sess.checkpointKilled
+}
+
+// CheckpointRequested reports whether reason has a pending request to stop at
+// the next checkpoint.
+func (sess *Session) CheckpointRequested(reason CheckpointReason) bool {
+	sess.checkpointMu.Lock()
+	defer sess.checkpointMu.Unlock()
+
+	_, ok := sess.checkpoints[reason]
+
+	return ok
+}
+
+// CheckpointRequests returns, sorted, who has a pending request to stop at the
+// next checkpoint. Once hashcat has stopped at one, or been killed in its
+// place, they are who asked for it.
+func (sess *Session) CheckpointRequests() []CheckpointReason {
+	sess.checkpointMu.Lock()
+	defer sess.checkpointMu.Unlock()
+
+	reasons := make([]CheckpointReason, 0, len(sess.checkpoints))
+	for reason := range sess.checkpoints {
+		reasons = append(reasons, reason)
+	}
+
+	slices.Sort(reasons)
+
+	return reasons
+}
@@ -0,0 +1,60 @@
+package hashcat
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckpointRequests verifies that hashcat's checkpoint key, a toggle, is
+// only sent when the first request arrives and when the last is withdrawn, so
+// one requester cannot cancel a stop another still wants.
+func TestCheckpointRequests(t *testing.T) {
+	var buf strings.Builder
+	sess := NewTestSession(true)
+	sess.SetTestStdin(&buf)
+
+	require.NoError(t, sess.RequestCheckpoint("pause"))
+	require.NoError(t, sess.RequestCheckpoint("pause"))
+	require.NoError(t, sess.RequestCheckpoint("disk_space"))
+	assert.Equal(t, "c\n", buf.String(), "the stop is requested once")
+	assert.Equal(t, []CheckpointReason{"disk_space", "pause"}, sess.CheckpointRequests())
+
+	require.NoError(t, sess.CancelCheckpoint("pause"))
+	assert.Equal(t, "c\n", buf.String(), "the disk space stop stays pending")
+	assert.False(t, sess.CheckpointRequested("pause"))
+	assert.True(t, sess.CheckpointRequested("disk_space"))
+
+	require.NoError(t, sess.CancelCheckpoint("pause"))
+	require.NoError(t, sess.CancelCheckpoint("disk_space"))
+	assert.Equal(t, "c\nc\n", buf.String(), "the last withdrawal cancels the stop")
+	assert.Empty(t, sess.CheckpointRequests())
+}
+
+// TestRequestCheckpoint_NotRunning verifies a request whose key could not be
+// sent is not recorded, so it is retried.
+func TestRequestCheckpoint_NotRunning(t *testing.T) {
+	sess := NewTestSession(true)
+
+	require.ErrorIs(t, sess.RequestCheckpoint("pause"), ErrSessionNotRunning)
+	assert.False(t, sess.CheckpointRequested("pause"))
+}
+
+// TestRequestCheckpoint_StreamedWordList verifies a session reading its word
+// list from stdin is killed by the first checkpoint request, and that later
+// requests and withdrawals neither kill it again nor forget who asked.
+func TestRequestCheckpoint_StreamedWordList(t *testing.T) {
+	sess := &Session{proc: &exec.Cmd{Stdin: strings.NewReader("password\n")}}
+
+	err := sess.RequestCheckpoint("pause")
+	require.ErrorIs(t, err, ErrCheckpointUnsupported)
+	require.ErrorIs(t, err, ErrWordListStreamed)
+	assert.True(t, sess.CheckpointKilled())
+
+	require.NoError(t, sess.RequestCheckpoint("disk_space"))
+	require.NoError(t, sess.CancelCheckpoint("pause"))
+	assert.Equal(t, []CheckpointReason{"disk_space", "pause"}, sess.CheckpointRequests())
+}
@@ -0,0 +1,57 @@
+package hashcat
+
+import (
+	"errors"
+
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
+)
+
+// ErrWordListStreamed is returned for interactive commands sent to a session
+// reading its word list from stdin, since hashcat then takes no keyboard input.
+var ErrWordListStreamed = errors.New("hashcat reads the word list from stdin and takes no interactive commands")
+
+// CanStreamWordList reports whether hashcat can read the word list of an attack
+// in attackMode from stdin. Only dictionary attacks can.
+func CanStreamWordList(attackMode int64) bool {
+	return attackMode == attackModeDictionary
+}
+
+// plainPath returns the file hashcat should read for the resource at path:
+// path itself, or for a compressed resource its decompressed copy, written now
+// unless the download already wrote it.
+func plainPath(path string) (string, error) {
+	format, err := decompress.Detect(path)
+	if err != nil || format == decompress.None {
+		return path, err
+	}
+
+	plain := path + decompress.PlainSuffix
+	if err := decompress.File(path, plain); err != nil {
+		return "", err
+	}
+
+	return plain, nil
+}
+
+// streamedWordList returns the word list to feed hashcat through stdin, or ""
+// if hashcat reads its word list itself. Only a compressed word list of a
+// dictionary attack is streamed, when StreamCompressedWordList is set and no
+// --skip or --limit applies, since hashcat cannot seek in stdin. A preprocessor
+// attack leaves the word list to the preprocessor.
+func (params Params) streamedWordList() string {
+	if !params.StreamCompressedWordList || !CanStreamWordList(params.AttackMode) ||
+		params.Skip > 0 || params.Limit > 0 || params.PreprocessorFilename != "" {
+		return ""
+	}
+
+	path, err := safePath(params.FilePath, params.WordListFilename)
+	if err != nil {
+		return ""
+	}
+
+	if format, err := decompress.Detect(path); err != nil || format == decompress.None {
+		return ""
+	}
+
+	return path
+}
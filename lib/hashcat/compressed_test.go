@@ -0,0 +1,124 @@
+package hashcat
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
+)
+
+// createGzipFile writes content gzip-compressed to dir/name and returns its path.
+func createGzipFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	return path
+}
+
+func TestParams_ToCmdArgs_CompressedResources(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	wordlist := createGzipFile(t, agentstate.State.FilePath, "wordlist.txt.gz", "password\n")
+	rules := createGzipFile(t, agentstate.State.FilePath, "rules.rule.gz", ":\n")
+	hashFile := createTestHashFile(t)
+
+	params := Params{
+		AttackMode:       attackModeDictionary,
+		WordListFilename: "wordlist.txt.gz",
+		RuleListFilename: "rules.rule.gz",
+	}
+
+	args, err := withInjectedTestPaths(params).toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.NoError(t, err)
+
+	assert.Contains(t, args, wordlist+decompress.PlainSuffix)
+	assert.Contains(t, args, rules+decompress.PlainSuffix)
+	assert.NotContains(t, args, wordlist, "hashcat should not be handed the compressed file")
+
+	data, err := os.ReadFile(wordlist + decompress.PlainSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, "password\n", string(data))
+}
+
+func TestParams_ToCmdArgs_StreamedWordList(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	wordlist := createGzipFile(t, agentstate.State.FilePath, "wordlist.txt.gz", "password\n")
+	hashFile := createTestHashFile(t)
+
+	params := withInjectedTestPaths(Params{
+		AttackMode:               attackModeDictionary,
+		WordListFilename:         "wordlist.txt.gz",
+		StreamCompressedWordList: true,
+	})
+
+	assert.Equal(t, wordlist, params.streamedWordList())
+
+	args, err := params.toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.NoError(t, err)
+	assert.Equal(t, hashFile, args[len(args)-1], "a streamed word list should not be named on the command line")
+	assert.NoFileExists(t, wordlist+decompress.PlainSuffix, "a streamed word list should not be decompressed to disk")
+
+	// hashcat cannot skip into stdin, so a task with --skip gets a decompressed copy.
+	params.Skip = 100
+	assert.Empty(t, params.streamedWordList())
+
+	args, err = params.toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.NoError(t, err)
+	assert.Contains(t, args, wordlist+decompress.PlainSuffix)
+}
+
+func TestStreamedWordList_OnlyCompressedDictionary(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	createTestFile(t, agentstate.State.FilePath, "plain.txt", "password\n")
+	createGzipFile(t, agentstate.State.FilePath, "wordlist.txt.gz", "password\n")
+
+	plain := withInjectedTestPaths(Params{
+		AttackMode:               attackModeDictionary,
+		WordListFilename:         "plain.txt",
+		StreamCompressedWordList: true,
+	})
+	assert.Empty(t, plain.streamedWordList(), "an uncompressed word list is read by hashcat directly")
+
+	hybrid := withInjectedTestPaths(Params{
+		AttackMode:               attackModeHybridDM,
+		WordListFilename:         "wordlist.txt.gz",
+		Mask:                     "?d?d",
+		StreamCompressedWordList: true,
+	})
+	assert.Empty(t, hybrid.streamedWordList(), "only dictionary attacks read stdin")
+}
+
+func TestSendKey_StreamedWordList(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	createGzipFile(t, agentstate.State.FilePath, "wordlist.txt.gz", "password\n")
+
+	stream, err := decompress.OpenFile(filepath.Join(agentstate.State.FilePath, "wordlist.txt.gz"))
+	require.NoError(t, err)
+
+	sess := &Session{wordListStream: stream, proc: &exec.Cmd{Stdin: stream}}
+	require.ErrorIs(t, sess.Pause(), ErrWordListStreamed)
+	require.NoError(t, stream.Close())
+}
@@ -0,0 +1,93 @@
+package hashcat
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrKeyspaceStdin indicates a keyspace was requested for an attack whose
+// candidates arrive on stdin, which hashcat cannot count in advance.
+var ErrKeyspaceStdin = errors.New("keyspace cannot be computed for candidates read from stdin")
+
+// NewKeyspaceSession creates a session that runs hashcat --keyspace for the
+// attack described by params instead of the attack itself. Its only output is
+// a stdout line with the number of base candidates the attack searches, the
+// unit --skip and --limit count in (see ParseKeyspace). The session reads the
+// attack's word lists, rules, and masks but no hash file, and its Cleanup
+// leaves the attack's hash file, restore file, and zaps alone.
+func NewKeyspaceSession(ctx context.Context, id string, params Params) (*Session, error) {
+	params.keyspace = true
+	params.HashFile = ""
+	params.RestoreFilePath = ""
+	params.ZapsPath = ""
+
+	return NewHashcatSession(ctx, id, params)
+}
+
+// ParseKeyspace parses a line of hashcat --keyspace output. It returns false
+// for any line that is not a keyspace.
+func ParseKeyspace(line string) (int64, bool) {
+	keyspace, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+	if err != nil || keyspace < 0 {
+		return 0, false
+	}
+
+	return keyspace, true
+}
+
+// toKeyspaceArgs constructs the hashcat --keyspace arguments for the attack,
+// including the options that change which candidates it generates.
+func (params Params) toKeyspaceArgs(session string) ([]string, error) {
+	if params.readsStdin() {
+		return nil, ErrKeyspaceStdin
+	}
+
+	params, err := params.resolveFiles(false)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, defaultArgsCapacity)
+	args = append(
+		args,
+		"--keyspace",
+		"--quiet",
+		"--session", sessionPrefix+session,
+		"-a", strconv.FormatInt(params.AttackMode, 10),
+		"-m", strconv.FormatInt(params.HashType, 10),
+	)
+
+	args = append(args, params.AdditionalArgs...)
+
+	if params.SlowCandidates {
+		args = append(args, "-S")
+	}
+
+	if params.ClassicMarkov {
+		args = append(args, "--markov-classic")
+	}
+
+	if params.DisableMarkov {
+		args = append(args, "--markov-disable")
+	}
+
+	if params.MarkovThreshold > 0 {
+		args = append(args, "--markov-threshold", strconv.FormatInt(params.MarkovThreshold, 10))
+	}
+
+	args = append(args, params.attackArgs(false)...)
+
+	switch params.AttackMode {
+	case AttackModeMask, attackModeHybridDM, attackModeHybridMD:
+		maskArgs, err := params.maskArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, maskArgs...)
+	}
+
+	return args, nil
+}
@@ -0,0 +1,80 @@
+package hashcat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+)
+
+func TestParseKeyspace(t *testing.T) {
+	tests := []struct {
+		line     string
+		keyspace int64
+		ok       bool
+	}{
+		{"14344384", 14344384, true},
+		{"  95\n", 95, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"", 0, false},
+		{"Started: Thu Oct 16 10:00:00 2026", 0, false},
+	}
+
+	for _, tt := range tests {
+		keyspace, ok := ParseKeyspace(tt.line)
+		assert.Equal(t, tt.ok, ok, "ParseKeyspace(%q)", tt.line)
+		assert.Equal(t, tt.keyspace, keyspace, "ParseKeyspace(%q)", tt.line)
+	}
+}
+
+func TestParams_ToKeyspaceArgs(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	wordlist := createTestFile(t, agentstate.State.FilePath, "words.txt", "password\n")
+	rules := createTestFile(t, agentstate.State.FilePath, "best64.rule", ":\n")
+
+	params := withInjectedTestPaths(Params{
+		AttackMode:       attackModeDictionary,
+		HashType:         1000,
+		WordListFilename: "words.txt",
+		RuleListFilename: "best64.rule",
+		SlowCandidates:   true,
+		OptimizedKernels: true,
+		Skip:             100,
+		Limit:            200,
+		keyspace:         true,
+	})
+
+	args, err := params.toCmdArgs("test-session", "/tmp/hashes.txt", "/tmp/out.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--keyspace", "--quiet", "--session", sessionPrefix + "test-session",
+		"-a", "0", "-m", "1000", "-S", wordlist, "-r", rules,
+	}, args, "no hash file, range, or output options")
+}
+
+func TestParams_ToKeyspaceArgs_Mask(t *testing.T) {
+	params := Params{
+		AttackMode:         AttackModeMask,
+		Mask:               "?1?d?d",
+		MaskCustomCharsets: []string{"abc"},
+		MarkovThreshold:    10,
+		keyspace:           true,
+	}
+
+	args, err := params.toKeyspaceArgs("test-session")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--markov-threshold", "10", "?1?d?d", "--custom-charset1", "abc",
+	}, args[len(args)-5:])
+}
+
+func TestParams_ToKeyspaceArgs_Stdin(t *testing.T) {
+	params := Params{AttackMode: attackModeDictionary, PreprocessorFilename: "pp64.bin", keyspace: true}
+
+	_, err := params.toKeyspaceArgs("test-session")
+	require.ErrorIs(t, err, ErrKeyspaceStdin)
+}
@@ -1,6 +1,6 @@
 // Package hashcat provides parameter validation and command-line argument generation
 // for hashcat operations. It handles configuration for various attack modes including
-// dictionary, mask, and hybrid attacks, with comprehensive validation and error handling.
+// dictionary, combinator, mask, and hybrid attacks, with comprehensive validation and error handling.
 package hashcat
 
 import (
@@ -15,6 +15,8 @@ import (
 )
 
 const (
+	minWorkloadProfile  = 1  // Lowest hashcat workload profile (-w 1, low)
+	maxWorkloadProfile  = 4  // Highest hashcat workload profile (-w 4, nightmare)
 	maxCharsets         = 4  // Maximum custom charsets allowed by hashcat
 	defaultArgsCapacity = 32 // Default slice capacity for command arguments
 	maskArgsCapacity    = 6  // Expected capacity for mask-specific arguments
@@ -25,6 +27,8 @@ var (
 	ErrUnsupportedAttackMode = errors.New("unsupported attack mode")
 	// ErrDictionaryAttackWordlist indicates a dictionary attack is missing its required wordlist.
 	ErrDictionaryAttackWordlist = errors.New("expected 1 wordlist for dictionary attack, but none given")
+	// ErrCombinatorAttackWordlists indicates a combinator attack is missing one or both of its wordlists.
+	ErrCombinatorAttackWordlists = errors.New("expected 2 wordlists for combinator attack")
 	// ErrMaskAttackNoMask indicates a mask attack is missing both mask and mask list.
 	ErrMaskAttackNoMask = errors.New("using mask attack, but no mask was given")
 	// ErrMaskAttackBothMaskAndList indicates both mask and mask list were provided (mutually exclusive).
@@ -33,8 +37,28 @@ var (
 	ErrHybridAttackNoMask = errors.New("using hybrid attack, but no mask was given")
 	// ErrHybridAttackNoWordlist indicates a hybrid attack is missing its required wordlist.
 	ErrHybridAttackNoWordlist = errors.New("expected 1 wordlist for hybrid attack, but none given")
+	// ErrAssociationAttackWordlist indicates an association attack is missing its hint wordlist.
+	ErrAssociationAttackWordlist = errors.New("expected 1 hint wordlist for association attack, but none given")
+	// ErrAssociationLineCount indicates an association attack's hint wordlist does not pair
+	// one line with each line of the hash list.
+	ErrAssociationLineCount = errors.New("association attack wordlist does not match the hash list line for line")
+	// ErrPreprocessorAttackMode indicates a preprocessor was given for an attack mode other than
+	// dictionary, the only one in which hashcat reads candidates from stdin.
+	ErrPreprocessorAttackMode = errors.New("preprocessors are only supported for dictionary attacks")
+	// ErrPreprocessorSkipLimit indicates --skip or --limit was given for a preprocessor attack;
+	// hashcat cannot seek in candidates read from stdin.
+	ErrPreprocessorSkipLimit = errors.New("skip and limit are not supported with a preprocessor")
+	// ErrPreprocessorNotOpened indicates the specified preprocessor cannot be accessed.
+	ErrPreprocessorNotOpened = errors.New("provided preprocessor couldn't be opened on filesystem")
+	// ErrInvalidSeparator indicates a hash list separator the agent cannot use: not
+	// a single character, a line break, or a hex digit.
+	ErrInvalidSeparator = errors.New("invalid hash list separator")
 	// ErrTooManyCustomCharsets indicates more custom charsets were provided than supported.
 	ErrTooManyCustomCharsets = errors.New("too many custom charsets supplied")
+	// ErrInvalidWorkloadProfile indicates a workload profile outside hashcat's 1-4 range.
+	ErrInvalidWorkloadProfile = errors.New("invalid workload profile")
+	// ErrInvalidMarkovThreshold indicates a negative Markov threshold.
+	ErrInvalidMarkovThreshold = errors.New("invalid markov threshold")
 	// ErrWordlistNotOpened indicates the specified wordlist file cannot be accessed.
 	ErrWordlistNotOpened = errors.New("provided word list couldn't be opened on filesystem")
 	// ErrRuleListNotOpened indicates the specified rule list file cannot be accessed.
@@ -53,7 +77,7 @@ var (
 // It contains all settings needed to configure and execute an attack including attack mode,
 // hash type, input files, optimization flags, and device selection.
 type Params struct {
-	AttackMode                int64    `json:"attack_mode"`                  // Attack mode (0=dictionary, 3=mask, 6/7=hybrid, 9=benchmark)
+	AttackMode                int64    `json:"attack_mode"`                  // Attack mode (0=dictionary, 1=combinator, 3=mask, 6/7=hybrid, 9=association)
 	HashType                  int64    `json:"hash_type"`                    // Hashcat hash type code
 	HashFile                  string   `json:"hash_file"`                    // Path to file containing target hashes
 	Mask                      string   `json:"mask,omitempty"`               // Mask pattern for mask/hybrid attacks
@@ -61,18 +85,29 @@ type Params struct {
 	MaskIncrementMin          int64    `json:"mask_increment_min"`           // Minimum mask length for increment mode
 	MaskIncrementMax          int64    `json:"mask_increment_max"`           // Maximum mask length for increment mode
 	MaskCustomCharsets        []string `json:"mask_custom_charsets"`         // Custom character sets for mask attacks
-	WordListFilename          string   `json:"wordlist_filename"`            // Path to wordlist file
+	WordListFilename          string   `json:"wordlist_filename"`            // Path to wordlist file (left-hand wordlist for combinator attacks)
+	RightWordListFilename     string   `json:"right_wordlist_filename"`      // Path to right-hand wordlist file for combinator attacks
+	LeftRule                  string   `json:"left_rule,omitempty"`          // Single rule applied to each left-hand word in combinator attacks (-j)
+	RightRule                 string   `json:"right_rule,omitempty"`         // Single rule applied to each right-hand word in combinator attacks (-k)
 	RuleListFilename          string   `json:"rules_filename"`               // Path to rules file for dictionary attacks
 	MaskListFilename          string   `json:"mask_list_filename"`           // Path to mask list file
 	AdditionalArgs            []string `json:"additional_args"`              // Extra command-line arguments
 	OptimizedKernels          bool     `json:"optimized_kernels"`            // Use optimized kernels (-O flag)
 	SlowCandidates            bool     `json:"slow_candidates"`              // Enable slow candidate generators (-S flag)
+	WorkloadProfile           int64    `json:"workload_profile,omitempty"`   // Workload profile 1-4 (-w flag); 0 uses the hashcat default
+	ClassicMarkov             bool     `json:"classic_markov"`               // Use classic (per-position) Markov chains (--markov-classic)
+	DisableMarkov             bool     `json:"disable_markov"`               // Disable Markov chains entirely (--markov-disable)
+	MarkovThreshold           int64    `json:"markov_threshold,omitempty"`   // Markov threshold (--markov-threshold); 0 uses the hashcat default
+	HwmonTempAbort            int64    `json:"hwmon_temp_abort,omitempty"`   // Abort when a device exceeds this temperature in Celsius (--hwmon-temp-abort); 0 uses the hashcat default
 	Skip                      int64    `json:"skip,omitempty"`               // Skip N candidates from start
 	Limit                     int64    `json:"limit,omitempty"`              // Stop after processing N candidates
 	BackendDevices            string   `json:"backend_devices,omitempty"`    // Backend devices to use (pre-resolved by DeviceConfig)
 	OpenCLDevices             string   `json:"opencl_devices,omitempty"`     // OpenCL device types (pre-resolved by DeviceConfig)
 	EnableAdditionalHashTypes bool     `json:"enable_additional_hash_types"` // Enable all hash types in benchmark mode
 	RestoreFilePath           string   `json:"restore_file_path,omitempty"`  // Path to restore file for session resumption
+	PreprocessorFilename      string   `json:"preprocessor_filename"`        // Candidate generator whose stdout hashcat reads as its word list
+	PreprocessorArgs          []string `json:"preprocessor_args"`            // Arguments for the preprocessor, run in FilePath
+	Separator                 string   `json:"separator,omitempty"`          // Hash list field separator (--separator); empty uses hashcat's ":"
 
 	// Runtime configuration injected by the agent at session construction. These are
 	// NOT part of the server API contract (json:"-"); they replace direct agentstate
@@ -80,37 +115,90 @@ type Params struct {
 	OutPath                string `json:"-"` // Directory for the output file and charset temp files
 	ZapsPath               string `json:"-"` // Directory for zaps (--outfile-check-dir); also cleaned up
 	FilePath               string `json:"-"` // Base directory for wordlist/rule/mask files
+	PreprocessorsPath      string `json:"-"` // Base directory for preprocessor executables
 	StatusTimer            int    `json:"-"` // Status/outfile-check timer in seconds
 	RetainZapsOnCompletion bool   `json:"-"` // Whether Cleanup keeps the zaps directory
+	// StreamCompressedWordList feeds a compressed dictionary word list to hashcat
+	// through stdin instead of decompressing it to disk (see streamedWordList).
+	StreamCompressedWordList bool `json:"-"`
+
+	keyspace bool // Run hashcat --keyspace instead of the attack (see NewKeyspaceSession)
 }
 
 // Validate verifies that the Params configuration is valid for the specified attack mode.
 // It delegates to attack-mode-specific validation functions and returns an error if
 // the configuration is invalid or if an unsupported attack mode is specified.
 func (params Params) Validate() error {
+	var err error
+
 	switch params.AttackMode {
 	case attackModeDictionary:
-		return validateDictionaryAttack(params)
+		err = validateDictionaryAttack(params)
+	case attackModeCombinator:
+		err = validateCombinatorAttack(params)
 	case AttackModeMask:
-		return validateMaskAttack(params)
+		err = validateMaskAttack(params)
 	case attackModeHybridDM, attackModeHybridMD:
-		return validateHybridAttack(params)
+		err = validateHybridAttack(params)
+	case AttackModeAssociation:
+		err = validateAssociationAttack(params)
 	case AttackBenchmark, AttackBenchmarkSingle, AttackHashInfo:
 		return nil
 	default:
 		return fmt.Errorf("%w: %d", ErrUnsupportedAttackMode, params.AttackMode)
 	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := validatePreprocessor(params); err != nil {
+		return err
+	}
+
+	if err := validateSeparator(params.Separator); err != nil {
+		return err
+	}
+
+	return validateTuning(params)
+}
+
+// validateTuning checks the workload profile and Markov settings shared by all
+// attack modes. Zero values mean "use the hashcat default" and are always valid.
+func validateTuning(params Params) error {
+	if params.WorkloadProfile != 0 &&
+		(params.WorkloadProfile < minWorkloadProfile || params.WorkloadProfile > maxWorkloadProfile) {
+		return fmt.Errorf("%w: %d (must be %d-%d)",
+			ErrInvalidWorkloadProfile, params.WorkloadProfile, minWorkloadProfile, maxWorkloadProfile)
+	}
+
+	if params.MarkovThreshold < 0 {
+		return fmt.Errorf("%w: %d (must be >= 0)", ErrInvalidMarkovThreshold, params.MarkovThreshold)
+	}
+
+	return nil
 }
 
-// validateDictionaryAttack ensures a wordlist is specified for dictionary attacks.
+// validateDictionaryAttack ensures a wordlist is specified for dictionary attacks,
+// unless a preprocessor generates the candidates instead.
 func validateDictionaryAttack(params Params) error {
-	if strings.TrimSpace(params.WordListFilename) == "" {
+	if strings.TrimSpace(params.WordListFilename) == "" && strings.TrimSpace(params.PreprocessorFilename) == "" {
 		return ErrDictionaryAttackWordlist
 	}
 
 	return nil
 }
 
+// validateCombinatorAttack ensures both the left and right wordlists are specified
+// for combinator attacks. The -j/-k rules are optional.
+func validateCombinatorAttack(params Params) error {
+	if strings.TrimSpace(params.WordListFilename) == "" || strings.TrimSpace(params.RightWordListFilename) == "" {
+		return ErrCombinatorAttackWordlists
+	}
+
+	return nil
+}
+
 // validateMaskAttack ensures either a mask or mask list is provided, but not both.
 func validateMaskAttack(params Params) error {
 	if strings.TrimSpace(params.Mask) == "" && strings.TrimSpace(params.MaskListFilename) == "" {
@@ -137,6 +225,50 @@ func validateHybridAttack(params Params) error {
 	return nil
 }
 
+// validateAssociationAttack ensures a hint wordlist is provided for association attacks.
+// Whether it pairs with the hash list is checked against the files in toCmdArgs.
+func validateAssociationAttack(params Params) error {
+	if strings.TrimSpace(params.WordListFilename) == "" {
+		return ErrAssociationAttackWordlist
+	}
+
+	return nil
+}
+
+// validateSeparator ensures the hash list separator, if given, is a single
+// byte other than a line break, as hashcat's --separator requires, and not a
+// hex digit, which would be ambiguous in the hex-encoded plaintexts of outfile
+// and zap lines.
+func validateSeparator(sep string) error {
+	if sep == "" {
+		return nil
+	}
+
+	if len(sep) != 1 || sep == "\n" || sep == "\r" || strings.ContainsAny(sep, "0123456789abcdefABCDEF") {
+		return fmt.Errorf("%w: %q", ErrInvalidSeparator, sep)
+	}
+
+	return nil
+}
+
+// validatePreprocessor ensures a preprocessor is only used where hashcat can read
+// its output: a dictionary attack without --skip or --limit.
+func validatePreprocessor(params Params) error {
+	if strings.TrimSpace(params.PreprocessorFilename) == "" {
+		return nil
+	}
+
+	if params.AttackMode != attackModeDictionary {
+		return fmt.Errorf("%w: attack mode %d", ErrPreprocessorAttackMode, params.AttackMode)
+	}
+
+	if params.Skip > 0 || params.Limit > 0 {
+		return ErrPreprocessorSkipLimit
+	}
+
+	return nil
+}
+
 // maskArgs constructs command-line arguments for mask-based attacks.
 // It validates the number of custom charsets and generates arguments for charset
 // definitions and mask increment settings. Returns an error if validation fails.
@@ -220,6 +352,10 @@ func (params Params) toCmdArgs(session, hashFile, outFile string) ([]string, err
 		return args, nil
 	}
 
+	if params.keyspace {
+		return params.toKeyspaceArgs(session)
+	}
+
 	// Standard attack mode arguments
 	args = append(
 		args,
@@ -237,6 +373,10 @@ func (params Params) toCmdArgs(session, hashFile, outFile string) ([]string, err
 		"-m", strconv.FormatInt(params.HashType, 10),
 	)
 
+	if params.Separator != "" && params.Separator != DefaultSeparator {
+		args = append(args, "--separator", params.Separator)
+	}
+
 	if strings.TrimSpace(params.RestoreFilePath) != "" {
 		args = append(args, "--restore-file-path", params.RestoreFilePath)
 	}
@@ -251,6 +391,26 @@ func (params Params) toCmdArgs(session, hashFile, outFile string) ([]string, err
 		args = append(args, "-S")
 	}
 
+	if params.WorkloadProfile > 0 {
+		args = append(args, "-w", strconv.FormatInt(params.WorkloadProfile, 10))
+	}
+
+	if params.ClassicMarkov {
+		args = append(args, "--markov-classic")
+	}
+
+	if params.DisableMarkov {
+		args = append(args, "--markov-disable")
+	}
+
+	if params.MarkovThreshold > 0 {
+		args = append(args, "--markov-threshold", strconv.FormatInt(params.MarkovThreshold, 10))
+	}
+
+	if params.HwmonTempAbort > 0 {
+		args = append(args, "--hwmon-temp-abort", strconv.FormatInt(params.HwmonTempAbort, 10))
+	}
+
 	if params.Skip > 0 {
 		args = append(args, "--skip", strconv.FormatInt(params.Skip, 10))
 	}
@@ -259,19 +419,73 @@ func (params Params) toCmdArgs(session, hashFile, outFile string) ([]string, err
 		args = append(args, "--limit", strconv.FormatInt(params.Limit, 10))
 	}
 
-	if strings.TrimSpace(params.WordListFilename) != "" {
-		wordList, err := resolveOptionalPath(params.FilePath, params.WordListFilename, ErrWordlistNotOpened)
+	stdin := params.readsStdin()
+
+	params, err := params.resolveFiles(stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateHashFile(hashFile); err != nil {
+		return nil, err
+	}
+
+	if params.AttackMode == AttackModeAssociation {
+		if err := validateAssociationPairing(params.WordListFilename, hashFile); err != nil {
+			return nil, err
+		}
+	}
+
+	args = append(args, hashFile)
+	args = append(args, params.attackArgs(stdin)...)
+
+	switch params.AttackMode {
+	case AttackModeMask, attackModeHybridDM, attackModeHybridMD:
+		maskArgs, err := params.maskArgs()
 		if err != nil {
 			return nil, err
 		}
 
+		args = append(args, maskArgs...)
+	}
+
+	args = appendDeviceFlags(args, params)
+
+	return args, nil
+}
+
+// readsStdin reports whether hashcat reads its candidates from stdin: a
+// streamed word list or a preprocessor's output, read when no word list is named.
+func (params Params) readsStdin() bool {
+	return params.streamedWordList() != "" || strings.TrimSpace(params.PreprocessorFilename) != ""
+}
+
+// resolveFiles returns params with its word lists, rules, and mask list
+// resolved to paths under FilePath, checking that each exists. A mask list
+// replaces the mask. The word list is left alone when hashcat reads stdin.
+func (params Params) resolveFiles(stdin bool) (Params, error) {
+	if strings.TrimSpace(params.WordListFilename) != "" && !stdin {
+		wordList, err := resolveOptionalPath(params.FilePath, params.WordListFilename, ErrWordlistNotOpened)
+		if err != nil {
+			return params, err
+		}
+
 		params.WordListFilename = wordList
 	}
 
+	if strings.TrimSpace(params.RightWordListFilename) != "" {
+		rightWordList, err := resolveOptionalPath(params.FilePath, params.RightWordListFilename, ErrWordlistNotOpened)
+		if err != nil {
+			return params, err
+		}
+
+		params.RightWordListFilename = rightWordList
+	}
+
 	if strings.TrimSpace(params.RuleListFilename) != "" {
 		ruleList, err := resolveOptionalPath(params.FilePath, params.RuleListFilename, ErrRuleListNotOpened)
 		if err != nil {
-			return nil, err
+			return params, err
 		}
 
 		params.RuleListFilename = ruleList
@@ -280,26 +494,42 @@ func (params Params) toCmdArgs(session, hashFile, outFile string) ([]string, err
 	if strings.TrimSpace(params.MaskListFilename) != "" {
 		maskList, err := resolveOptionalPath(params.FilePath, params.MaskListFilename, ErrMaskListNotOpened)
 		if err != nil {
-			return nil, err
+			return params, err
 		}
 
 		params.Mask = maskList
 	}
 
-	if err := validateHashFile(hashFile); err != nil {
-		return nil, err
-	}
+	return params, nil
+}
 
-	args = append(args, hashFile)
+// attackArgs returns the attack-mode-specific arguments that follow the hash
+// file: word lists, masks, and rules. Files must already be resolved. The word
+// list is left out of a dictionary attack when hashcat reads stdin.
+func (params Params) attackArgs(stdin bool) []string {
+	var args []string
 
 	switch params.AttackMode {
 	case attackModeDictionary:
-		args = append(args, params.WordListFilename)
+		if !stdin {
+			args = append(args, params.WordListFilename)
+		}
 
 		if strings.TrimSpace(params.RuleListFilename) != "" {
 			args = append(args, "-r", params.RuleListFilename)
 		}
 
+	case attackModeCombinator:
+		args = append(args, params.WordListFilename, params.RightWordListFilename)
+
+		if params.LeftRule != "" {
+			args = append(args, "-j", params.LeftRule)
+		}
+
+		if params.RightRule != "" {
+			args = append(args, "-k", params.RightRule)
+		}
+
 	case AttackModeMask:
 		args = append(args, params.Mask)
 
@@ -308,21 +538,16 @@ func (params Params) toCmdArgs(session, hashFile, outFile string) ([]string, err
 
 	case attackModeHybridMD:
 		args = append(args, params.Mask, params.WordListFilename)
-	}
 
-	switch params.AttackMode {
-	case AttackModeMask, attackModeHybridDM, attackModeHybridMD:
-		maskArgs, err := params.maskArgs()
-		if err != nil {
-			return nil, err
-		}
+	case AttackModeAssociation:
+		args = append(args, params.WordListFilename)
 
-		args = append(args, maskArgs...)
+		if strings.TrimSpace(params.RuleListFilename) != "" {
+			args = append(args, "-r", params.RuleListFilename)
+		}
 	}
 
-	args = appendDeviceFlags(args, params)
-
-	return args, nil
+	return args
 }
 
 // toRestoreArgs constructs arguments for restoring a hashcat session.
@@ -373,8 +598,10 @@ func safePath(base, filename string) (string, error) {
 }
 
 // resolveOptionalPath resolves a relative filename against base using safePath,
-// verifies the result exists on disk, and returns the absolute path.
-// If either check fails, sentinel is returned wrapped around the underlying error.
+// verifies the result exists on disk, and returns the absolute path. For a
+// compressed file it returns the path of the decompressed copy instead, since
+// hashcat cannot read every compression format itself.
+// If any step fails, sentinel is returned wrapped around the underlying error.
 func resolveOptionalPath(base, filename string, sentinel error) (string, error) {
 	resolved, err := safePath(base, filename)
 	if err != nil {
@@ -388,7 +615,12 @@ func resolveOptionalPath(base, filename string, sentinel error) (string, error)
 		return "", fmt.Errorf("%w: %s: %w", sentinel, resolved, err)
 	}
 
-	return resolved, nil
+	plain, err := plainPath(resolved)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", sentinel, resolved, err)
+	}
+
+	return plain, nil
 }
 
 // hashFileReadBufSize is the number of bytes read to check for non-whitespace content.
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -290,6 +291,131 @@ func TestParams_Validate_HybridAttack(t *testing.T) {
 	}
 }
 
+func TestParams_Validate_CombinatorAttack(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      Params
+		expectError error
+	}{
+		{
+			name: "valid combinator attack",
+			params: Params{
+				AttackMode:            attackModeCombinator,
+				WordListFilename:      "left.txt",
+				RightWordListFilename: "right.txt",
+			},
+			expectError: nil,
+		},
+		{
+			name: "valid combinator attack with rules",
+			params: Params{
+				AttackMode:            attackModeCombinator,
+				WordListFilename:      "left.txt",
+				RightWordListFilename: "right.txt",
+				LeftRule:              "c",
+				RightRule:             "$!",
+			},
+			expectError: nil,
+		},
+		{
+			name: "combinator attack without left wordlist",
+			params: Params{
+				AttackMode:            attackModeCombinator,
+				RightWordListFilename: "right.txt",
+			},
+			expectError: ErrCombinatorAttackWordlists,
+		},
+		{
+			name: "combinator attack without right wordlist",
+			params: Params{
+				AttackMode:       attackModeCombinator,
+				WordListFilename: "left.txt",
+			},
+			expectError: ErrCombinatorAttackWordlists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate()
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestParams_Validate_Tuning(t *testing.T) {
+	base := Params{
+		AttackMode: AttackModeMask,
+		Mask:       "?a?a?a",
+	}
+
+	tests := []struct {
+		name        string
+		mutate      func(p *Params)
+		expectError error
+	}{
+		{name: "defaults are valid", mutate: func(*Params) {}},
+		{name: "lowest workload profile", mutate: func(p *Params) { p.WorkloadProfile = 1 }},
+		{name: "highest workload profile", mutate: func(p *Params) { p.WorkloadProfile = 4 }},
+		{
+			name:        "workload profile too high",
+			mutate:      func(p *Params) { p.WorkloadProfile = 5 },
+			expectError: ErrInvalidWorkloadProfile,
+		},
+		{
+			name:        "negative workload profile",
+			mutate:      func(p *Params) { p.WorkloadProfile = -1 },
+			expectError: ErrInvalidWorkloadProfile,
+		},
+		{name: "positive markov threshold", mutate: func(p *Params) { p.MarkovThreshold = 64 }},
+		{
+			name:        "negative markov threshold",
+			mutate:      func(p *Params) { p.MarkovThreshold = -1 },
+			expectError: ErrInvalidMarkovThreshold,
+		},
+		{name: "single-character separator", mutate: func(p *Params) { p.Separator = "|" }},
+		{
+			name:        "multi-character separator",
+			mutate:      func(p *Params) { p.Separator = "::" },
+			expectError: ErrInvalidSeparator,
+		},
+		{
+			name:        "line break separator",
+			mutate:      func(p *Params) { p.Separator = "\n" },
+			expectError: ErrInvalidSeparator,
+		},
+		{
+			name:        "hex digit separator",
+			mutate:      func(p *Params) { p.Separator = "a" },
+			expectError: ErrInvalidSeparator,
+		},
+		{
+			name:        "numeric separator",
+			mutate:      func(p *Params) { p.Separator = "0" },
+			expectError: ErrInvalidSeparator,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := base
+			tt.mutate(&params)
+
+			err := params.Validate()
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectError)
+			}
+		})
+	}
+}
+
 func TestParams_Validate_BenchmarkMode(t *testing.T) {
 	params := Params{
 		AttackMode: AttackBenchmark,
@@ -915,6 +1041,143 @@ func TestParams_ToCmdArgs_HybridMDAttack(t *testing.T) {
 	assert.Contains(t, args, "7") // Hybrid MD mode
 }
 
+func TestParams_ToCmdArgs_CombinatorAttack(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	left := createTestFile(t, agentstate.State.FilePath, "left.txt", "pass\n")
+	right := createTestFile(t, agentstate.State.FilePath, "right.txt", "word\n")
+	hashFile := createTestHashFile(t)
+
+	params := Params{
+		AttackMode:            attackModeCombinator,
+		HashType:              0,
+		WordListFilename:      "left.txt",
+		RightWordListFilename: "right.txt",
+		LeftRule:              "c",
+		RightRule:             "$!",
+	}
+
+	args, err := withInjectedTestPaths(params).toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+
+	require.NoError(t, err)
+
+	// The left and right wordlists follow the hash file in order.
+	hashIdx := slices.Index(args, hashFile)
+	require.GreaterOrEqual(t, hashIdx, 0)
+	require.Greater(t, len(args), hashIdx+2)
+	assert.Equal(t, left, args[hashIdx+1])
+	assert.Equal(t, right, args[hashIdx+2])
+
+	assert.Equal(t, "1", args[slices.Index(args, "-a")+1])
+	assert.Equal(t, "c", args[slices.Index(args, "-j")+1])
+	assert.Equal(t, "$!", args[slices.Index(args, "-k")+1])
+}
+
+func TestParams_ToCmdArgs_CombinatorAttackWithoutRules(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	createTestFile(t, agentstate.State.FilePath, "left.txt", "pass\n")
+	createTestFile(t, agentstate.State.FilePath, "right.txt", "word\n")
+	hashFile := createTestHashFile(t)
+
+	params := Params{
+		AttackMode:            attackModeCombinator,
+		WordListFilename:      "left.txt",
+		RightWordListFilename: "right.txt",
+	}
+
+	args, err := withInjectedTestPaths(params).toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+
+	require.NoError(t, err)
+	assert.NotContains(t, args, "-j")
+	assert.NotContains(t, args, "-k")
+}
+
+func TestParams_ToCmdArgs_CombinatorMissingRightWordlist(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	createTestFile(t, agentstate.State.FilePath, "left.txt", "pass\n")
+
+	params := Params{
+		AttackMode:            attackModeCombinator,
+		WordListFilename:      "left.txt",
+		RightWordListFilename: "nonexistent.txt",
+	}
+
+	_, err := withInjectedTestPaths(params).toCmdArgs("test-session", "/tmp/hashes.txt", "/tmp/out.txt")
+
+	assert.ErrorIs(t, err, ErrWordlistNotOpened)
+}
+
+func TestParams_ToCmdArgs_TuningFlags(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	hashFile := createTestHashFile(t)
+
+	params := Params{
+		AttackMode:      AttackModeMask,
+		Mask:            "?a?a?a?a",
+		WorkloadProfile: 4,
+		ClassicMarkov:   true,
+		DisableMarkov:   true,
+		MarkovThreshold: 32,
+		HwmonTempAbort:  85,
+	}
+
+	args, err := withInjectedTestPaths(params).toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+
+	require.NoError(t, err)
+	assert.Equal(t, "4", args[slices.Index(args, "-w")+1])
+	assert.Equal(t, "85", args[slices.Index(args, "--hwmon-temp-abort")+1])
+	assert.Contains(t, args, "--markov-classic")
+	assert.Contains(t, args, "--markov-disable")
+	assert.Equal(t, "32", args[slices.Index(args, "--markov-threshold")+1])
+}
+
+func TestParams_ToCmdArgs_TuningDefaultsOmitted(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	hashFile := createTestHashFile(t)
+
+	params := Params{
+		AttackMode: AttackModeMask,
+		Mask:       "?a?a?a?a",
+	}
+
+	args, err := withInjectedTestPaths(params).toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+
+	require.NoError(t, err)
+	assert.NotContains(t, args, "-w")
+	assert.NotContains(t, args, "--markov-classic")
+	assert.NotContains(t, args, "--markov-disable")
+	assert.NotContains(t, args, "--markov-threshold")
+	assert.NotContains(t, args, "--hwmon-temp-abort")
+}
+
+func TestParams_ToCmdArgs_Separator(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	hashFile := createTestHashFile(t)
+
+	params := Params{AttackMode: AttackModeMask, Mask: "?a?a?a?a", Separator: "|"}
+
+	args, err := withInjectedTestPaths(params).toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "|", args[slices.Index(args, "--separator")+1])
+
+	params.Separator = DefaultSeparator
+
+	args, err = withInjectedTestPaths(params).toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.NoError(t, err)
+	assert.NotContains(t, args, "--separator", "hashcat's default separator is not passed")
+}
+
 func TestParams_ToCmdArgs_ValidationFails(t *testing.T) {
 	params := Params{
 		AttackMode: 99, // Invalid
@@ -0,0 +1,200 @@
+package hashcat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+)
+
+const (
+	preprocessorStderrLimit = 4096  // Bytes of preprocessor stderr kept for error reports
+	executablePermissions   = 0o700 // Permissions given to a downloaded preprocessor
+)
+
+// ErrPreprocessorStdin is returned for interactive commands sent to a session
+// reading a preprocessor's output from stdin, since hashcat then takes no
+// keyboard input.
+var ErrPreprocessorStdin = errors.New("hashcat reads preprocessor output from stdin and takes no interactive commands")
+
+// PreprocessorError reports a preprocessor that failed while feeding hashcat.
+// The session stops hashcat when this happens, so the attack did not exhaust
+// its keyspace even if hashcat saw the end of its input.
+type PreprocessorError struct {
+	Err    error  // Exit status or other error from the preprocessor
+	Stderr string // Last lines the preprocessor wrote to stderr
+}
+
+// Error implements the error interface.
+func (e *PreprocessorError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("preprocessor failed: %v", e.Err)
+	}
+
+	return fmt.Sprintf("preprocessor failed: %v: %s", e.Err, e.Stderr)
+}
+
+// Unwrap returns the underlying preprocessor error.
+func (e *PreprocessorError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the preprocessor's exit code, or -1 if it did not exit
+// normally.
+func (e *PreprocessorError) ExitCode() int {
+	var exitErr *exec.ExitError
+	if errors.As(e.Err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// stderrTail keeps the last preprocessorStderrLimit bytes written to it.
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write implements io.Writer.
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if excess := len(t.buf) - preprocessorStderrLimit; excess > 0 {
+		t.buf = t.buf[excess:]
+	}
+
+	return len(p), nil
+}
+
+// String returns the kept output with surrounding whitespace removed.
+func (t *stderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return strings.TrimSpace(string(t.buf))
+}
+
+// newPreprocessorCmd builds the command for the preprocessor named in params,
+// found in PreprocessorsPath and run in FilePath so its arguments can name the
+// attack's downloaded resources. A downloaded preprocessor is made executable.
+func newPreprocessorCmd(ctx context.Context, params Params) (*exec.Cmd, *stderrTail, error) {
+	path, err := safePath(params.PreprocessorsPath, params.PreprocessorFilename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrPreprocessorNotOpened, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %w", ErrPreprocessorNotOpened, path, err)
+	}
+
+	if info.Mode()&0o100 == 0 {
+		if err := os.Chmod(path, executablePermissions); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %w", ErrPreprocessorNotOpened, path, err)
+		}
+	}
+
+	stderr := &stderrTail{}
+
+	//nolint:gosec // G204 - the preprocessor and its arguments are chosen by the server, like hashcat's
+	cmd := exec.CommandContext(ctx, path, params.PreprocessorArgs...)
+	cmd.Dir = params.FilePath
+	cmd.Stderr = stderr
+
+	return cmd, stderr, nil
+}
+
+// pipePreprocessor connects the preprocessor's stdout to hashcat's stdin. It
+// must be called before the hashcat process starts.
+func (sess *Session) pipePreprocessor() error {
+	out, err := sess.preprocessor.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("couldn't attach preprocessor to hashcat: %w", err)
+	}
+
+	sess.proc.Stdin = out
+
+	return nil
+}
+
+// startPreprocessor starts the preprocessor and a goroutine that waits for it.
+// It must be called after the hashcat process starts, so a failing preprocessor
+// always has a process to stop.
+func (sess *Session) startPreprocessor() error {
+	agentstate.Logger.Debug("Running preprocessor command", "command", sess.preprocessor.String())
+
+	if err := sess.preprocessor.Start(); err != nil {
+		return fmt.Errorf("couldn't start preprocessor: %w", err)
+	}
+
+	sess.preprocessorDone = make(chan struct{})
+	sess.wg.Go(sess.watchPreprocessor)
+
+	return nil
+}
+
+// watchPreprocessor waits for the preprocessor to exit. If it fails before the
+// session stopped it, hashcat is killed rather than left to treat the truncated
+// input as an exhausted keyspace, and the failure is kept for mergePreprocessorExit.
+func (sess *Session) watchPreprocessor() {
+	defer close(sess.preprocessorDone)
+
+	err := sess.preprocessor.Wait()
+	if err == nil || sess.preprocessorKilled.Load() || sess.ctx.Err() != nil {
+		return
+	}
+
+	sess.preprocessorErr = &PreprocessorError{Err: err, Stderr: sess.preprocessorStderr.String()}
+
+	agentstate.Logger.Error("Preprocessor failed, stopping hashcat", "error", err)
+
+	if killErr := sess.proc.Process.Kill(); killErr != nil && !errors.Is(killErr, os.ErrProcessDone) {
+		agentstate.Logger.Error("couldn't kill hashcat process", "error", killErr)
+	}
+}
+
+// stopPreprocessor kills the preprocessor, if one was started. Its exit is then
+// not reported as a failure.
+func (sess *Session) stopPreprocessor() {
+	if sess.preprocessor == nil {
+		return
+	}
+
+	sess.preprocessorKilled.Store(true)
+
+	if sess.preprocessor.Process == nil {
+		return
+	}
+
+	if err := sess.preprocessor.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		agentstate.Logger.Warn("couldn't kill preprocessor process", "error", err)
+	}
+}
+
+// mergePreprocessorExit combines hashcat's exit status done with the
+// preprocessor's once hashcat has exited. The preprocessor is stopped, since
+// nothing reads its output any more. A preprocessor failure that stopped
+// hashcat is returned in place of hashcat's status; a hashcat that finished on
+// its own, such as after cracking every hash, keeps its status.
+func (sess *Session) mergePreprocessorExit(done error) error {
+	if sess.preprocessorDone == nil {
+		return done
+	}
+
+	sess.stopPreprocessor()
+	<-sess.preprocessorDone
+
+	if sess.preprocessorErr != nil && done != nil {
+		return sess.preprocessorErr
+	}
+
+	return done
+}
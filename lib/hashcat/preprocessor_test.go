@@ -0,0 +1,195 @@
+package hashcat
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+)
+
+// helperCmd returns a command running TestHelperProcess in mode.
+func helperCmd(ctx context.Context, mode string) *exec.Cmd {
+	//nolint:gosec // G204 - test helper process pattern
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestHelperProcess", "--")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "GO_HELPER_MODE="+mode)
+
+	return cmd
+}
+
+// pipelineSession returns a session running hashcat in hashcatMode fed by a
+// preprocessor in preprocessorMode, both started, and hashcat's stdout.
+func pipelineSession(t *testing.T, hashcatMode, preprocessorMode string) (*Session, *bytes.Buffer) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var out bytes.Buffer
+
+	sess := &Session{
+		ctx:                ctx,
+		cancel:             cancel,
+		proc:               helperCmd(ctx, hashcatMode),
+		preprocessor:       helperCmd(ctx, preprocessorMode),
+		preprocessorStderr: &stderrTail{},
+	}
+	sess.proc.Stdout = &out
+	sess.preprocessor.Stderr = sess.preprocessorStderr
+
+	require.NoError(t, sess.pipePreprocessor())
+	require.NoError(t, sess.proc.Start())
+	require.NoError(t, sess.startPreprocessor())
+
+	return sess, &out
+}
+
+func TestValidate_Preprocessor(t *testing.T) {
+	params := Params{AttackMode: attackModeDictionary, PreprocessorFilename: "pp64.bin"}
+	require.NoError(t, params.Validate(), "the preprocessor stands in for the word list")
+
+	params.Skip = 1000
+	require.ErrorIs(t, params.Validate(), ErrPreprocessorSkipLimit)
+
+	mask := Params{AttackMode: AttackModeMask, Mask: "?d?d", PreprocessorFilename: "pp64.bin"}
+	require.ErrorIs(t, mask.Validate(), ErrPreprocessorAttackMode)
+}
+
+func TestParams_ToCmdArgs_Preprocessor(t *testing.T) {
+	cleanup := setupTestState(t)
+	defer cleanup()
+
+	wordlist := createTestFile(t, agentstate.State.FilePath, "words.txt", "password\n")
+	rules := createTestFile(t, agentstate.State.FilePath, "best64.rule", ":\n")
+	hashFile := createTestHashFile(t)
+
+	params := withInjectedTestPaths(Params{
+		AttackMode:           attackModeDictionary,
+		WordListFilename:     "words.txt",
+		RuleListFilename:     "best64.rule",
+		PreprocessorFilename: "pp64.bin",
+		PreprocessorArgs:     []string{"--pw-min=8", "words.txt"},
+	})
+
+	args, err := params.toCmdArgs("test-session", hashFile, "/tmp/out.txt")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{hashFile, "-r", rules}, args[len(args)-3:])
+	assert.NotContains(t, args, wordlist, "the word list is read by the preprocessor, not hashcat")
+}
+
+func TestNewPreprocessorCmd(t *testing.T) {
+	preprocessorsDir := t.TempDir()
+	filesDir := t.TempDir()
+	path := filepath.Join(preprocessorsDir, "pp64.bin")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0o600))
+
+	params := Params{
+		PreprocessorFilename: "pp64.bin",
+		PreprocessorArgs:     []string{"--pw-min=8", "words.txt"},
+		PreprocessorsPath:    preprocessorsDir,
+		FilePath:             filesDir,
+	}
+
+	cmd, _, err := newPreprocessorCmd(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path, "--pw-min=8", "words.txt"}, cmd.Args)
+	assert.Equal(t, filesDir, cmd.Dir, "arguments name resources relative to the files directory")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0o100, "a downloaded preprocessor should be made executable")
+
+	params.PreprocessorFilename = "../pp64.bin"
+	_, _, err = newPreprocessorCmd(context.Background(), params)
+	require.ErrorIs(t, err, ErrPreprocessorNotOpened)
+
+	params.PreprocessorFilename = "missing.bin"
+	_, _, err = newPreprocessorCmd(context.Background(), params)
+	require.ErrorIs(t, err, ErrPreprocessorNotOpened)
+}
+
+// TestPreprocessorPipeline verifies that hashcat reads the preprocessor's output
+// as its input and that a clean run reports hashcat's own exit status.
+func TestPreprocessorPipeline(t *testing.T) {
+	sess, out := pipelineSession(t, "cat", "emit")
+
+	require.NoError(t, sess.mergePreprocessorExit(sess.proc.Wait()))
+	sess.wg.Wait()
+
+	assert.Equal(t, "password\nletmein\n", out.String())
+	require.ErrorIs(t, sess.sendKey(keyCheckpoint), ErrPreprocessorStdin,
+		"hashcat reading candidates from stdin takes no interactive commands")
+}
+
+// TestPreprocessorCheckpointKillsHashcat verifies that a checkpoint request,
+// which hashcat reading stdin cannot honour, kills the session instead.
+func TestPreprocessorCheckpointKillsHashcat(t *testing.T) {
+	sess, _ := pipelineSession(t, "sleep", "emit")
+
+	err := sess.RequestCheckpoint("pause")
+	require.ErrorIs(t, err, ErrCheckpointUnsupported)
+	require.ErrorIs(t, err, ErrPreprocessorStdin)
+	assert.True(t, sess.CheckpointKilled())
+
+	require.Error(t, sess.proc.Wait(), "hashcat should have been killed")
+	sess.wg.Wait()
+}
+
+// TestPreprocessorFailureStopsHashcat verifies that a failing preprocessor kills
+// hashcat and that its failure replaces hashcat's exit status.
+func TestPreprocessorFailureStopsHashcat(t *testing.T) {
+	sess, _ := pipelineSession(t, "sleep", "fail")
+
+	done := sess.mergePreprocessorExit(sess.proc.Wait())
+	sess.wg.Wait()
+
+	var preprocessorErr *PreprocessorError
+	require.ErrorAs(t, done, &preprocessorErr)
+	assert.Equal(t, 3, preprocessorErr.ExitCode())
+	assert.Equal(t, "unknown option", preprocessorErr.Stderr)
+}
+
+// TestPreprocessorStoppedWithHashcat verifies that the preprocessor is killed
+// once hashcat exits, without being reported as failed.
+func TestPreprocessorStoppedWithHashcat(t *testing.T) {
+	sess, _ := pipelineSession(t, "exit0", "sleep")
+
+	require.NoError(t, sess.mergePreprocessorExit(sess.proc.Wait()))
+	sess.wg.Wait()
+
+	assert.NotNil(t, sess.preprocessor.ProcessState, "the preprocessor should have been reaped")
+}
+
+// TestKill_StopsPreprocessor verifies that killing the session kills both
+// processes without reporting a preprocessor failure.
+func TestKill_StopsPreprocessor(t *testing.T) {
+	sess, _ := pipelineSession(t, "sleep", "sleep")
+
+	require.NoError(t, sess.Kill())
+
+	done := sess.mergePreprocessorExit(sess.proc.Wait())
+	sess.wg.Wait()
+
+	require.Error(t, done)
+	assert.False(t, strings.HasPrefix(done.Error(), "preprocessor failed"))
+	assert.NotNil(t, sess.preprocessor.ProcessState)
+}
+
+func TestStderrTail(t *testing.T) {
+	tail := &stderrTail{}
+
+	_, err := tail.Write(bytes.Repeat([]byte("x"), preprocessorStderrLimit))
+	require.NoError(t, err)
+	_, err = tail.Write([]byte("last line\n"))
+	require.NoError(t, err)
+
+	assert.Len(t, tail.String(), preprocessorStderrLimit-1)
+	assert.True(t, strings.HasSuffix(tail.String(), "last line"))
+}
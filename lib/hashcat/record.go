@@ -0,0 +1,123 @@
+package hashcat
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSeparator is hashcat's default --separator, the character joining the
+// fields of hash list, outfile, and zap lines.
+const DefaultSeparator = ":"
+
+// Markers around a hex-encoded plaintext in hashcat's $HEX[...] notation.
+const (
+	hexPlaintextPrefix = "$HEX["
+	hexPlaintextSuffix = "]"
+)
+
+// ErrMalformedRecord indicates an outfile or zap line that is not a crack record.
+var ErrMalformedRecord = errors.New("malformed crack record")
+
+// separatorOrDefault returns sep, or DefaultSeparator if sep is empty.
+func separatorOrDefault(sep string) string {
+	if sep == "" {
+		return DefaultSeparator
+	}
+
+	return sep
+}
+
+// EncodeHexPlaintext returns plaintext in hashcat's $HEX[...] notation.
+func EncodeHexPlaintext(plaintext string) string {
+	return hexPlaintextPrefix + hex.EncodeToString([]byte(plaintext)) + hexPlaintextSuffix
+}
+
+// DecodePlaintext returns the plaintext a $HEX[...] value encodes. Any other
+// value, including one that only looks like $HEX[...] but holds no valid hex,
+// is returned unchanged, as hashcat itself does.
+func DecodePlaintext(value string) string {
+	encoded, ok := hexPlaintext(value)
+	if !ok {
+		return value
+	}
+
+	bs, err := hex.DecodeString(encoded)
+	if err != nil {
+		return value
+	}
+
+	return string(bs)
+}
+
+// hexPlaintext returns the hex inside a $HEX[...] value.
+func hexPlaintext(value string) (string, bool) {
+	if !strings.HasPrefix(value, hexPlaintextPrefix) || !strings.HasSuffix(value, hexPlaintextSuffix) {
+		return "", false
+	}
+
+	return value[len(hexPlaintextPrefix) : len(value)-len(hexPlaintextSuffix)], true
+}
+
+// ParseOutfileLine parses a line hashcat wrote with --outfile-format 1,3,5 and
+// the given separator. Neither the timestamp nor the hex-encoded plaintext can
+// contain the separator, so the hash is everything between them, separators
+// included.
+func ParseOutfileLine(line, sep string) (Result, error) {
+	sep = separatorOrDefault(sep)
+
+	first := strings.Index(line, sep)
+	last := strings.LastIndex(line, sep)
+
+	if first < 0 || first == last {
+		return Result{}, fmt.Errorf("%w: expected timestamp, hash, and plaintext", ErrMalformedRecord)
+	}
+
+	timestamp, err := strconv.ParseInt(line[:first], 10, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: timestamp %q: %w", ErrMalformedRecord, line[:first], err)
+	}
+
+	plainHex := line[last+len(sep):]
+
+	plaintext, err := hex.DecodeString(plainHex)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: plaintext %q: %w", ErrMalformedRecord, plainHex, err)
+	}
+
+	return Result{
+		Timestamp: time.Unix(timestamp, 0),
+		Hash:      line[first+len(sep) : last],
+		Plaintext: string(plaintext),
+	}, nil
+}
+
+// FormatZapLine returns the zap line for a cracked hash, with the plaintext in
+// $HEX[...] notation so ParseZapLine recovers it exactly.
+func FormatZapLine(hash, plaintext, sep string) string {
+	return hash + separatorOrDefault(sep) + EncodeHexPlaintext(plaintext)
+}
+
+// ParseZapLine parses a hash<sep>plaintext zap line into its hash and
+// plaintext. A $HEX[...] plaintext is split off whole and decoded, so both
+// fields may contain the separator. Otherwise the line is split on the last
+// separator: hashes such as NetNTLMv2 or PBKDF2 embed it routinely, but a
+// plaintext containing it is misattributed, which only the $HEX[...] form
+// avoids.
+func ParseZapLine(line, sep string) (string, string, error) {
+	sep = separatorOrDefault(sep)
+
+	idx := strings.LastIndex(line, sep+hexPlaintextPrefix)
+	if idx < 0 || !strings.HasSuffix(line, hexPlaintextSuffix) {
+		idx = strings.LastIndex(line, sep)
+	}
+
+	if idx < 0 {
+		return "", "", fmt.Errorf("%w: no separator %q", ErrMalformedRecord, sep)
+	}
+
+	return line[:idx], DecodePlaintext(line[idx+len(sep):]), nil
+}
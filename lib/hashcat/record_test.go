@@ -0,0 +1,103 @@
+package hashcat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutfileLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		separator string
+		want      Result
+	}{
+		{
+			name: "plain hash",
+			line: "1700000000:5d41402abc4b2a76b9719d911017c592:68656c6c6f",
+			want: Result{Hash: "5d41402abc4b2a76b9719d911017c592", Plaintext: "hello"},
+		},
+		{
+			name: "colon-bearing hash and plaintext",
+			line: "1700000000:admin::CORP:1122334455667788:1f3a8b:0101000000:70613a7373",
+			want: Result{Hash: "admin::CORP:1122334455667788:1f3a8b:0101000000", Plaintext: "pa:ss"},
+		},
+		{
+			name: "empty plaintext",
+			line: "1700000000:31d6cfe0d16ae931b73c59d7e0c089c0:",
+			want: Result{Hash: "31d6cfe0d16ae931b73c59d7e0c089c0", Plaintext: ""},
+		},
+		{
+			name:      "custom separator",
+			line:      "1700000000|user|a:b|617c62",
+			separator: "|",
+			want:      Result{Hash: "user|a:b", Plaintext: "a|b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutfileLine(tt.line, tt.separator)
+			require.NoError(t, err)
+			assert.Equal(t, time.Unix(1700000000, 0), got.Timestamp)
+			assert.Equal(t, tt.want.Hash, got.Hash)
+			assert.Equal(t, tt.want.Plaintext, got.Plaintext)
+		})
+	}
+}
+
+func TestParseOutfileLine_Malformed(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"1700000000:68656c6c6f",
+		"yesterday:5d41402abc4b2a76b9719d911017c592:68656c6c6f",
+		"1700000000:5d41402abc4b2a76b9719d911017c592:hello",
+	} {
+		_, err := ParseOutfileLine(line, DefaultSeparator)
+		require.ErrorIs(t, err, ErrMalformedRecord, line)
+	}
+}
+
+func TestDecodePlaintext(t *testing.T) {
+	assert.Equal(t, "pa:ss", DecodePlaintext("$HEX[70613a7373]"))
+	assert.Equal(t, "", DecodePlaintext("$HEX[]"))
+	assert.Equal(t, "hello", DecodePlaintext("hello"))
+	assert.Equal(t, "$HEX[zz]", DecodePlaintext("$HEX[zz]"), "invalid hex is taken literally")
+	assert.Equal(t, "$HEX[70613a7373]", DecodePlaintext(EncodeHexPlaintext("$HEX[70613a7373]")))
+}
+
+// TestZapLine_RoundTrip verifies that hashes and plaintexts containing the
+// separator survive FormatZapLine and ParseZapLine unchanged.
+func TestZapLine_RoundTrip(t *testing.T) {
+	tests := []struct {
+		hash      string
+		plaintext string
+		separator string
+	}{
+		{"5d41402abc4b2a76b9719d911017c592", "hello", ""},
+		{"admin::CORP:1122334455667788:1f3a8b:0101000000", "pa:ss", DefaultSeparator},
+		{"sha256:20000:c2FsdHNhbHQ:aGFzaGhhc2g", ":$HEX[00]:", DefaultSeparator},
+		{"user|hash", "a|b", "|"},
+		{"31d6cfe0d16ae931b73c59d7e0c089c0", "", DefaultSeparator},
+	}
+
+	for _, tt := range tests {
+		hash, plaintext, err := ParseZapLine(FormatZapLine(tt.hash, tt.plaintext, tt.separator), tt.separator)
+		require.NoError(t, err)
+		assert.Equal(t, tt.hash, hash)
+		assert.Equal(t, tt.plaintext, plaintext)
+	}
+}
+
+func TestParseZapLine(t *testing.T) {
+	hash, plaintext, err := ParseZapLine("admin::CORP:0101000000:Spring2024!", DefaultSeparator)
+	require.NoError(t, err)
+	assert.Equal(t, "admin::CORP:0101000000", hash, "plain-text zaps split on the last separator")
+	assert.Equal(t, "Spring2024!", plaintext)
+
+	_, _, err = ParseZapLine("invalidline", DefaultSeparator)
+	require.ErrorIs(t, err, ErrMalformedRecord)
+}
@@ -6,7 +6,6 @@ package hashcat
 import (
 	"bufio"
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,17 +16,18 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nxadm/tail"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/cracker"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
 )
 
 const (
 	channelBufferSize = 5           // Buffer size for output channels
 	filePermissions   = 0o600       // Restrictive permissions for sensitive files
-	logParseMinParts  = 3           // Minimum expected parts when parsing output log lines
 	drainTimeout      = time.Second // Wait time for channel consumers to drain before signaling DoneChan
 )
 
@@ -41,6 +41,7 @@ type Session struct {
 	proc               *exec.Cmd       // Hashcat process command
 	hashFile           string          // Path to hash input file
 	outFile            *os.File        // Output file for cracked hashes
+	separator          string          // Field separator in the hash list and outfile lines
 	charsetFiles       []*os.File      // Custom charset files for mask attacks
 	shardedCharsetFile *os.File        // Sharded charset file for distributed attacks
 	ctx                context.Context //nolint:containedctx // session lifecycle context for I/O goroutines
@@ -54,12 +55,25 @@ type Session struct {
 	DoneChan           chan error     // Channel signaling process completion
 	SkipStatusUpdates  bool           // Flag to disable status update parsing
 	RestoreFilePath    string         // Path to session restore file
+	keepRestoreFile    bool           // Whether Cleanup keeps the restore file (see PreserveRestoreFile)
 	zapsPath           string         // Injected zaps directory, removed on cleanup unless retained
 	retainZaps         bool           // Whether Cleanup keeps the zaps directory
 	sessionLogFile     string         // Absolute path to hashcat session .log file for cleanup
 	sessionPidFile     string         // Absolute path to hashcat session .pid file for cleanup
+	pStdin             io.WriteCloser // Stdin pipe to hashcat process, used for interactive commands (pause/resume/checkpoint)
+	wordListStream     io.ReadCloser  // Decompressed word list fed to hashcat's stdin instead, when streamed
+	preprocessor       *exec.Cmd      // Candidate generator piped into hashcat's stdin, if any
+	preprocessorStderr *stderrTail    // Tail of the preprocessor's stderr, for error reports
+	preprocessorDone   chan struct{}  // Closed once the started preprocessor has exited
+	preprocessorErr    error          // Preprocessor failure, set before preprocessorDone is closed
+	preprocessorKilled atomic.Bool    // Whether the session stopped the preprocessor itself
+	stdinMu            sync.Mutex     // Serializes writes to pStdin
+	checkpointMu       sync.Mutex     // Serializes checkpoint requests with their key presses
+	checkpoints        checkpointSet  // Pending requests to stop at the next checkpoint (see RequestCheckpoint)
+	checkpointKilled   bool           // Whether a checkpoint request killed a session reading stdin instead
 	pStdout            io.ReadCloser  // Stdout pipe from hashcat process
 	pStderr            io.ReadCloser  // Stderr pipe from hashcat process
+	name               string         // Hashcat --session name, under which the PID is published
 	startedPID         int32          // OS PID of the running hashcat process (0 until started), published to agentstate for performance monitoring
 }
 
@@ -83,7 +97,8 @@ func NewHashcatSession(ctx context.Context, id string, params Params) (*Session,
 		return nil, fmt.Errorf("couldn't create output file: %w", err)
 	}
 
-	charsetFiles, resolvedCharsets, err := createCharsetFiles(params.OutPath, params.MaskCustomCharsets)
+	charsetFiles, resolvedCharsets, err := createCharsetFiles(params.OutPath, params.RestoreFilePath,
+		params.MaskCustomCharsets)
 	if err != nil {
 		cancel()
 		_ = outFile.Close()
@@ -108,25 +123,70 @@ func NewHashcatSession(ctx context.Context, id string, params Params) (*Session,
 		return nil, err
 	}
 
-	// Use restore arguments if restore file exists
-	if strings.TrimSpace(params.RestoreFilePath) != "" {
+	// A streamed word list or preprocessor output cannot be rewound, so such a
+	// session always starts over; RequestCheckpoint kills it rather than leave a
+	// restore file. Otherwise use restore arguments if a restore file exists.
+	streamed := params.streamedWordList()
+	preprocessed := strings.TrimSpace(params.PreprocessorFilename) != ""
+	if strings.TrimSpace(params.RestoreFilePath) != "" && streamed == "" && !preprocessed {
 		if _, err := os.Stat(params.RestoreFilePath); err == nil {
 			args = params.toRestoreArgs(id)
 		}
 	}
 
+	proc := exec.CommandContext(ctx, binaryPath, args...)
+
+	var wordListStream io.ReadCloser
+	if streamed != "" {
+		wordListStream, err = decompress.OpenFile(streamed)
+		if err != nil {
+			cancel()
+			_ = outFile.Close()
+			for _, f := range charsetFiles {
+				if f != nil {
+					_ = f.Close()
+				}
+			}
+
+			return nil, fmt.Errorf("%w: %w", ErrWordlistNotOpened, err)
+		}
+
+		proc.Stdin = wordListStream
+	}
+
+	var (
+		preprocessor       *exec.Cmd
+		preprocessorStderr *stderrTail
+	)
+
+	if preprocessed {
+		preprocessor, preprocessorStderr, err = newPreprocessorCmd(ctx, params)
+		if err != nil {
+			cancel()
+			_ = outFile.Close()
+			for _, f := range charsetFiles {
+				if f != nil {
+					_ = f.Close()
+				}
+			}
+
+			return nil, err
+		}
+	}
+
 	sessionName := sessionPrefix + id
 	sessDir := hashcatSessionDir(binaryPath)
 
 	return &Session{
-		proc: exec.CommandContext(
-			ctx,
-			binaryPath,
-			args...),
+		proc:               proc,
+		wordListStream:     wordListStream,
+		preprocessor:       preprocessor,
+		preprocessorStderr: preprocessorStderr,
 		ctx:                ctx,
 		cancel:             cancel,
 		hashFile:           params.HashFile,
 		outFile:            outFile,
+		separator:          separatorOrDefault(params.Separator),
 		charsetFiles:       charsetFiles,
 		shardedCharsetFile: nil,
 		CrackedHashes:      make(chan Result, channelBufferSize),
@@ -135,19 +195,27 @@ func NewHashcatSession(ctx context.Context, id string, params Params) (*Session,
 		StdoutLines:        make(chan string, channelBufferSize),
 		DoneChan:           make(chan error),
 		SkipStatusUpdates: params.AttackMode == AttackBenchmark || params.AttackMode == AttackBenchmarkSingle ||
-			params.AttackMode == AttackHashInfo,
+			params.AttackMode == AttackHashInfo || params.keyspace,
 		RestoreFilePath: params.RestoreFilePath,
 		zapsPath:        params.ZapsPath,
 		retainZaps:      params.RetainZapsOnCompletion,
+		name:            sessionName,
 		sessionLogFile:  filepath.Join(sessDir, sessionName+".log"),
 		sessionPidFile:  filepath.Join(sessDir, sessionName+".pid"),
 	}, nil
 }
 
 // Start initializes and starts the hashcat session.
-// It attaches necessary I/O pipes, launches the hashcat process, and starts
-// goroutines to handle output streams. Returns an error if process startup fails.
+// It attaches necessary I/O pipes, launches the hashcat process and any
+// preprocessor feeding it, and starts goroutines to handle output streams.
+// Returns an error if process startup fails.
 func (sess *Session) Start() error {
+	if sess.preprocessor != nil {
+		if err := sess.pipePreprocessor(); err != nil {
+			return err
+		}
+	}
+
 	if err := sess.attachPipes(); err != nil {
 		return err
 	}
@@ -158,6 +226,16 @@ func (sess *Session) Start() error {
 		return fmt.Errorf("couldn't start hashcat: %w", err)
 	}
 
+	if sess.preprocessor != nil {
+		if err := sess.startPreprocessor(); err != nil {
+			if killErr := sess.Kill(); killErr != nil {
+				agentstate.Logger.Error("couldn't kill hashcat process", "error", killErr)
+			}
+
+			return err
+		}
+	}
+
 	tailer, err := sess.startTailer()
 	if err != nil {
 		return err
@@ -169,7 +247,7 @@ func (sess *Session) Start() error {
 	// early publish followed by a startTailer failure would leave a stale PID in
 	// agentstate that never gets cleared. Cleared in Cleanup when the process exits.
 	sess.startedPID = int32(sess.proc.Process.Pid) //nolint:gosec // G115 - a process ID always fits in int32
-	agentstate.State.SetHashcatPID(sess.startedPID)
+	agentstate.State.SetHashcatPID(sess.name, sess.startedPID)
 
 	sess.wg.Go(func() { ; sess.handleTailerOutput(tailer) })
 	sess.wg.Go(func() { ; sess.handleStdout() })
@@ -178,9 +256,21 @@ func (sess *Session) Start() error {
 	return nil
 }
 
-// attachPipes attaches stdout and stderr pipes to the hashcat process.
-// Returns an error if pipe attachment fails.
+// attachPipes attaches stdin, stdout and stderr pipes to the hashcat process.
+// Stdin is kept open so interactive commands (pause, resume, checkpoint) can be
+// sent while the session runs, unless it carries a streamed word list or
+// preprocessor output. Returns
+// an error if pipe attachment fails.
 func (sess *Session) attachPipes() error {
+	if sess.proc.Stdin == nil {
+		pStdin, err := sess.proc.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("couldn't attach stdin to hashcat: %w", err)
+		}
+
+		sess.pStdin = pStdin
+	}
+
 	pStdout, err := sess.proc.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("couldn't attach stdout to hashcat: %w", err)
@@ -237,44 +327,18 @@ func (sess *Session) handleTailerOutput(tailer *tail.Tail) {
 				return
 			}
 
-			line := tailLine.Text
-
-			values := strings.Split(line, ":")
-			if len(values) < logParseMinParts {
-				agentstate.Logger.Error("unexpected line contents", "line", line)
-
-				continue
-			}
-
-			timestamp, plainHex := values[0], values[len(values)-1]
-
-			bs, err := hex.DecodeString(plainHex)
-			if err != nil {
-				agentstate.Logger.Error("couldn't decode hex string", "hex", plainHex, "error", err)
-
-				continue
-			}
-
-			plain := string(bs)
-			hashParts := values[1 : len(values)-1]
-			hash := strings.Join(hashParts, ":")
-
-			timestampI, err := strconv.ParseInt(timestamp, 10, 64)
+			result, err := ParseOutfileLine(tailLine.Text, sess.separator)
 			if err != nil {
-				agentstate.Logger.Error("couldn't parse hashcat timestamp", "timestamp", timestamp, "error", err)
+				agentstate.Logger.Error("couldn't parse hashcat outfile line", "line", tailLine.Text, "error", err)
 
 				continue
 			}
 
 			select {
-			case sess.CrackedHashes <- Result{
-				Timestamp: time.Unix(timestampI, 0),
-				Hash:      hash,
-				Plaintext: plain,
-			}:
+			case sess.CrackedHashes <- result:
 			case <-sess.ctx.Done():
 				agentstate.Logger.Warn("Cracked hash dropped due to context cancellation",
-					"hash", hash)
+					"hash", result.Hash)
 
 				return
 			}
@@ -334,7 +398,7 @@ scanLoop:
 		}
 	}
 
-	done := sess.proc.Wait()
+	done := sess.mergePreprocessorExit(sess.proc.Wait())
 
 	// Allow brief time for channel consumers to drain remaining stdout/stderr
 	// messages before signaling completion via DoneChan.
@@ -418,6 +482,52 @@ func (sess *Session) classifyAndForwardStdout(line string) bool {
 	}
 }
 
+// Interactive keys understood by hashcat's keypress handler.
+const (
+	keyPause      = "p" // Pause the attack
+	keyResume     = "r" // Resume a paused attack
+	keyCheckpoint = "c" // Stop at the next restore point (checkpoint)
+)
+
+// ErrSessionNotRunning indicates an interactive command was sent to a session
+// whose process has not been started (or whose stdin is unavailable).
+var ErrSessionNotRunning = errors.New("hashcat session is not running")
+
+// Pause asks the running hashcat process to pause its attack. Devices stay
+// initialized and status updates keep flowing, so the pause can be lifted with Resume.
+func (sess *Session) Pause() error {
+	return sess.sendKey(keyPause)
+}
+
+// Resume asks a paused hashcat process to continue its attack.
+func (sess *Session) Resume() error {
+	return sess.sendKey(keyResume)
+}
+
+// sendKey writes a single interactive command to hashcat's stdin.
+func (sess *Session) sendKey(key string) error {
+	sess.stdinMu.Lock()
+	defer sess.stdinMu.Unlock()
+
+	if sess.preprocessor != nil {
+		return ErrPreprocessorStdin
+	}
+
+	if sess.proc != nil && sess.proc.Stdin != nil {
+		return ErrWordListStreamed
+	}
+
+	if sess.pStdin == nil {
+		return ErrSessionNotRunning
+	}
+
+	if _, err := io.WriteString(sess.pStdin, key+"\n"); err != nil {
+		return fmt.Errorf("sending %q to hashcat: %w", key, err)
+	}
+
+	return nil
+}
+
 // Cancel requests cancellation of the running hashcat process via the session context.
 // This method is thread-safe and may be called concurrently from multiple goroutines.
 func (sess *Session) Cancel() {
@@ -430,11 +540,12 @@ func (sess *Session) Cancel() {
 	}
 }
 
-// Kill terminates the hashcat process.
+// Kill terminates the hashcat process and any preprocessor feeding it.
 // Returns nil if no process is running or if the process was already terminated.
 // The os.ErrProcessDone error is treated as a success case.
 func (sess *Session) Kill() error {
 	sess.Cancel()
+	sess.stopPreprocessor()
 
 	if sess.proc == nil || sess.proc.Process == nil {
 		return nil
@@ -448,6 +559,18 @@ func (sess *Session) Kill() error {
 	return err
 }
 
+// Separator returns the field separator of the session's hash list, which
+// hashcat also uses in its outfile and zap lines.
+func (sess *Session) Separator() string {
+	return separatorOrDefault(sess.separator)
+}
+
+// PreserveRestoreFile makes Cleanup keep the restore file, so a session stopped
+// at a checkpoint can later be resumed with the same Params.
+func (sess *Session) PreserveRestoreFile() {
+	sess.keepRestoreFile = true
+}
+
 // Cleanup kills the hashcat process, waits for all I/O goroutines to exit,
 // then removes all session-related temporary files: output file, charset files,
 // hash file, restore file, session log/pid files, and optionally the zaps
@@ -460,9 +583,10 @@ func (sess *Session) Cleanup() {
 	sess.wg.Wait() // Wait for all I/O goroutines to exit before removing files
 
 	// Stop publishing this session's PID to the performance monitor. Compare-and-clear
-	// so a newer session that already registered its own PID is left untouched.
+	// so a newer session of the same name that already registered its own PID is
+	// left untouched.
 	if sess.startedPID != 0 {
-		agentstate.State.ClearHashcatPID(sess.startedPID)
+		agentstate.State.ClearHashcatPID(sess.name, sess.startedPID)
 		sess.startedPID = 0
 	}
 
@@ -496,19 +620,33 @@ func (sess *Session) Cleanup() {
 		}
 	}
 
+	// Charset files named after a kept restore file are kept with it: resuming
+	// replays the original command line, which points at them.
+	keepCharsets := sess.keepRestoreFile && strings.TrimSpace(sess.RestoreFilePath) != ""
 	for _, f := range sess.charsetFiles {
 		if f != nil {
 			closeFile(f)
-			removeFile(f.Name())
+			if !keepCharsets {
+				removeFile(f.Name())
+			}
 		}
 	}
 	sess.charsetFiles = nil
 
+	if sess.wordListStream != nil {
+		if err := sess.wordListStream.Close(); err != nil {
+			agentstate.Logger.Debug("couldn't close streamed word list during cleanup", "error", err)
+		}
+		sess.wordListStream = nil
+	}
+
 	removeFile(sess.hashFile)
 	sess.hashFile = ""
 
-	if strings.TrimSpace(sess.RestoreFilePath) != "" {
-		removeFile(sess.RestoreFilePath)
+	if strings.TrimSpace(sess.RestoreFilePath) != "" && !sess.keepRestoreFile {
+		if err := RemoveRestoreFile(sess.RestoreFilePath); err != nil {
+			agentstate.Logger.Error("couldn't remove restore file", "error", err)
+		}
 		sess.RestoreFilePath = ""
 	}
 
@@ -554,6 +692,24 @@ func createOutFile(dir, id string, perm os.FileMode) (*os.File, error) {
 	return file, nil
 }
 
+// createFile creates, or truncates, the file at filePath with the specified
+// permissions. Returns the file handle or an error if creation or permission
+// setting fails.
+func createFile(filePath string, perm os.FileMode) (*os.File, error) {
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Chmod(perm); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, fmt.Errorf("setting file permissions: %w", err)
+	}
+
+	return file, nil
+}
+
 // createTempFile creates a temporary file with the specified pattern and permissions.
 // Returns the file handle or an error if creation or permission setting fails.
 func createTempFile(dir, pattern string, perm os.FileMode) (*os.File, error) {
@@ -571,13 +727,16 @@ func createTempFile(dir, pattern string, perm os.FileMode) (*os.File, error) {
 	return file, nil
 }
 
-// createCharsetFiles creates temporary files for custom charsets used in mask attacks.
-// Each non-empty charset string is written to a separate temporary file.
+// createCharsetFiles creates files for custom charsets used in mask attacks.
+// Each non-empty charset string is written to a separate file. With a restore
+// file, the files get fixed names beside it (see restoreCharsetPath), since a
+// resumed session replays the command line that named them; otherwise they are
+// temporary files in outPath.
 // The input slice is never mutated: the returned resolved slice is a copy where each
-// non-empty entry is replaced by its temp file path (empty entries are preserved by
+// non-empty entry is replaced by its file path (empty entries are preserved by
 // position), so callers can reference them in --custom-charset flags. Returns the open
 // file handles, the resolved paths, or an error if creation fails.
-func createCharsetFiles(outPath string, charsets []string) ([]*os.File, []string, error) {
+func createCharsetFiles(outPath, restoreFile string, charsets []string) ([]*os.File, []string, error) {
 	charsetFiles := make([]*os.File, 0, len(charsets))
 	resolved := make([]string, len(charsets))
 	copy(resolved, charsets)
@@ -594,7 +753,16 @@ func createCharsetFiles(outPath string, charsets []string) ([]*os.File, []string
 			continue
 		}
 
-		charsetFile, err := createTempFile(outPath, "charset*", filePermissions)
+		var (
+			charsetFile *os.File
+			err         error
+		)
+
+		if strings.TrimSpace(restoreFile) != "" {
+			charsetFile, err = createFile(restoreCharsetPath(restoreFile, i+1), filePermissions)
+		} else {
+			charsetFile, err = createTempFile(outPath, "charset*", filePermissions)
+		}
 		if err != nil {
 			closeAll()
 			return nil, nil, fmt.Errorf("couldn't create charset file: %w", err)
@@ -613,3 +781,29 @@ func createCharsetFiles(outPath string, charsets []string) ([]*os.File, []string
 
 	return charsetFiles, resolved, nil
 }
+
+// restoreCharsetPath returns the path of the nth (1-4) custom charset file of
+// the session using restoreFile: the restore file's path with its extension
+// replaced by ".charsetN".
+func restoreCharsetPath(restoreFile string, n int) string {
+	return strings.TrimSuffix(restoreFile, filepath.Ext(restoreFile)) + ".charset" + strconv.Itoa(n)
+}
+
+// RemoveRestoreFile removes restoreFile and the custom charset files kept with
+// it. Files already removed are skipped.
+func RemoveRestoreFile(restoreFile string) error {
+	paths := []string{restoreFile}
+	for n := 1; n <= maxCharsets; n++ {
+		paths = append(paths, restoreCharsetPath(restoreFile, n))
+	}
+
+	var errs []error
+
+	for _, filePath := range paths {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,6 +32,19 @@ func TestHelperProcess(_ *testing.T) {
 		select {}
 	case "exit0":
 		os.Exit(0)
+	case "fail":
+		// Fail like a misconfigured preprocessor
+		fmt.Fprintln(os.Stderr, "unknown option")
+		os.Exit(3)
+	case "emit":
+		// Generate candidates like a preprocessor
+		fmt.Println("password")
+		fmt.Println("letmein")
+		os.Exit(0)
+	case "cat":
+		// Consume candidates from stdin like hashcat
+		_, _ = io.Copy(os.Stdout, os.Stdin)
+		os.Exit(0)
 	default:
 		os.Exit(0)
 	}
@@ -255,7 +270,7 @@ func TestCreateCharsetFiles_DoesNotMutateInput(t *testing.T) {
 	input := []string{"abc", "", "xyz"}
 	original := append([]string(nil), input...)
 
-	files, resolved, err := createCharsetFiles(outPath, input)
+	files, resolved, err := createCharsetFiles(outPath, "", input)
 	require.NoError(t, err)
 	t.Cleanup(func() {
 		for _, f := range files {
@@ -283,6 +298,88 @@ func TestCreateCharsetFiles_DoesNotMutateInput(t *testing.T) {
 	require.Equal(t, "xyz", string(content2))
 }
 
+// TestNewHashcatSession_ResumeKeepsCharsetFiles verifies that a custom-charset
+// mask session paused at a checkpoint keeps the charset files its restore file
+// names, that the resumed session reuses them, and that they go once the
+// restore file does.
+func TestNewHashcatSession_ResumeKeepsCharsetFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake hashcat binary is a shell script")
+	}
+
+	setupSessionTestState(t)
+
+	binary := filepath.Join(t.TempDir(), "hashcat")
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\n"), 0o700)) //nolint:gosec // G306 - test binary
+	savedHashcatPath := agentstate.State.HashcatPath
+	agentstate.State.HashcatPath = binary
+	t.Cleanup(func() { agentstate.State.HashcatPath = savedHashcatPath })
+
+	restoreDir := t.TempDir()
+	params := Params{
+		AttackMode:         AttackModeMask,
+		HashFile:           filepath.Join(t.TempDir(), "789.hsh"),
+		Mask:               "?1?2?d",
+		MaskCustomCharsets: []string{"abc", "xyz"},
+		RestoreFilePath:    filepath.Join(restoreDir, "789.restore"),
+		OutPath:            t.TempDir(),
+	}
+	charset1 := filepath.Join(restoreDir, "789.charset1")
+	charset2 := filepath.Join(restoreDir, "789.charset2")
+	require.NoError(t, os.WriteFile(params.HashFile, []byte("hash"), 0o600))
+
+	sess, err := NewHashcatSession(context.Background(), "789", params)
+	require.NoError(t, err)
+	require.Contains(t, sess.CmdLine(), "--custom-charset1 "+charset1)
+	require.Contains(t, sess.CmdLine(), "--custom-charset2 "+charset2)
+
+	// Hashcat stops at a checkpoint, leaving its restore file.
+	require.NoError(t, os.WriteFile(params.RestoreFilePath, []byte("restore-data"), 0o600))
+	sess.PreserveRestoreFile()
+	sess.Cleanup()
+
+	require.FileExists(t, charset1, "charset files are kept with the restore file")
+	require.FileExists(t, charset2)
+
+	// The hash list is downloaded again before the task resumes.
+	require.NoError(t, os.WriteFile(params.HashFile, []byte("hash"), 0o600))
+
+	resumed, err := NewHashcatSession(context.Background(), "789", params)
+	require.NoError(t, err)
+	require.Contains(t, resumed.CmdLine(), "--restore")
+
+	content, err := os.ReadFile(charset1)
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(content))
+	content, err = os.ReadFile(charset2)
+	require.NoError(t, err)
+	require.Equal(t, "xyz", string(content))
+
+	resumed.Cleanup()
+
+	require.NoFileExists(t, params.RestoreFilePath)
+	require.NoFileExists(t, charset1, "charset files go with the restore file")
+	require.NoFileExists(t, charset2)
+}
+
+// TestRemoveRestoreFile verifies that RemoveRestoreFile removes a restore file
+// with its charset files, and skips files already gone.
+func TestRemoveRestoreFile(t *testing.T) {
+	dir := t.TempDir()
+	restoreFile := filepath.Join(dir, "789.restore")
+	charsetFile := filepath.Join(dir, "789.charset3")
+	other := filepath.Join(dir, "790.charset1")
+
+	for _, path := range []string{restoreFile, charsetFile, other} {
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+	}
+
+	require.NoError(t, RemoveRestoreFile(restoreFile))
+	require.NoFileExists(t, restoreFile)
+	require.NoFileExists(t, charsetFile)
+	require.FileExists(t, other, "another attack's charset file is left alone")
+}
+
 // testTimeout is the maximum time to wait for a goroutine to exit in cancellation tests.
 const testTimeout = 5 * time.Second
 
@@ -551,3 +648,27 @@ func TestHandleTailerOutput_FullChannelExitsOnCancellation(t *testing.T) {
 		t.Fatal("handleTailerOutput blocked on full CrackedHashes channel after context cancellation")
 	}
 }
+
+// TestSendKey_WritesInteractiveCommands verifies Pause/Resume/RequestCheckpoint write
+// the corresponding hashcat interactive keys to stdin.
+func TestSendKey_WritesInteractiveCommands(t *testing.T) {
+	var buf strings.Builder
+	sess := NewTestSession(true)
+	sess.SetTestStdin(&buf)
+
+	require.NoError(t, sess.Pause())
+	require.NoError(t, sess.Resume())
+	require.NoError(t, sess.RequestCheckpoint("test"))
+
+	require.Equal(t, "p\nr\nc\n", buf.String())
+}
+
+// TestSendKey_NotRunning verifies interactive commands fail cleanly before the
+// process has been started.
+func TestSendKey_NotRunning(t *testing.T) {
+	sess := NewTestSession(true)
+
+	require.ErrorIs(t, sess.Pause(), ErrSessionNotRunning)
+	require.ErrorIs(t, sess.Resume(), ErrSessionNotRunning)
+	require.ErrorIs(t, sess.RequestCheckpoint("test"), ErrSessionNotRunning)
+}
@@ -1,5 +1,10 @@
 package hashcat
 
+import (
+	"io"
+	"os/exec"
+)
+
 // NewTestSession creates a minimal Session for testing without requiring the hashcat binary.
 // It initializes only the channels needed for test communication. No process, files, or
 // context are set up — the session is not startable.
@@ -18,3 +23,23 @@ func NewTestSession(skipStatusUpdates bool) *Session {
 		SkipStatusUpdates: skipStatusUpdates,
 	}
 }
+
+// SetTestStdin routes the session's interactive commands (pause, resume,
+// checkpoint) to w, standing in for a running hashcat process's stdin so tests
+// in other packages can observe the keys sent.
+func (sess *Session) SetTestStdin(w io.Writer) {
+	sess.pStdin = nopWriteCloser{w}
+}
+
+// SetTestWordListStream makes the session look like one reading its word list
+// from r on stdin, so tests in other packages can exercise sessions that take
+// no interactive commands.
+func (sess *Session) SetTestWordListStream(r io.Reader) {
+	sess.proc = &exec.Cmd{Stdin: r}
+}
+
+// nopWriteCloser adapts a writer into the io.WriteCloser a stdin pipe is.
+type nopWriteCloser struct{ io.Writer }
+
+// Close implements io.Closer.
+func (nopWriteCloser) Close() error { return nil }
@@ -10,12 +10,17 @@ import (
 // Attack mode constants define the different types of hashcat attacks.
 const (
 	attackModeDictionary = 0 // Dictionary attack mode
+	attackModeCombinator = 1 // Combinator (left wordlist + right wordlist) attack mode
 	// AttackModeMask is the attack mode for mask attacks.
 	AttackModeMask     = 3
 	attackModeHybridDM = 6 // Hybrid dictionary + mask attack mode
 	attackModeHybridMD = 7 // Hybrid mask + dictionary attack mode
-	// AttackBenchmark is the attack mode for benchmarking.
-	AttackBenchmark = 9
+	// AttackModeAssociation is the attack mode for association attacks, which try
+	// each line of a hint word list only against the hash on the same line.
+	AttackModeAssociation = 9
+	// AttackBenchmark is the attack mode for benchmarking via --benchmark.
+	// This is an internal sentinel; hashcat itself uses -a 9 for association attacks.
+	AttackBenchmark = 12
 	// AttackHashInfo is the attack mode for capability detection via --hash-info --machine-readable.
 	// This value is an internal sentinel used only by the agent's toCmdArgs dispatch.
 	AttackHashInfo = 10
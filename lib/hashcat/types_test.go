@@ -203,5 +203,7 @@ func TestAttackModeConstants(t *testing.T) {
 	assert.Equal(t, int64(3), int64(AttackModeMask))
 	assert.Equal(t, int64(6), int64(attackModeHybridDM))
 	assert.Equal(t, int64(7), int64(attackModeHybridMD))
-	assert.Equal(t, int64(9), int64(AttackBenchmark))
+	assert.Equal(t, int64(9), int64(AttackModeAssociation))
+	assert.NotEqual(t, int64(AttackModeAssociation), int64(AttackBenchmark),
+		"the benchmark sentinel must not shadow hashcat's association attack mode")
 }
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// contentType is the Prometheus text exposition format version 0.0.4.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types used in # TYPE lines.
+const (
+	typeGauge   = "gauge"
+	typeCounter = "counter"
+)
+
+// family is a named metric with its samples.
+type family struct {
+	name    string
+	help    string
+	typ     string
+	samples []sample
+}
+
+// sample is one value of a family, identified by its labels.
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// label is a label name/value pair.
+type label struct {
+	name  string
+	value string
+}
+
+// gauge returns a single-sample gauge family.
+func gauge(name, help string, value float64) family {
+	return family{name: name, help: help, typ: typeGauge, samples: []sample{{value: value}}}
+}
+
+// counter returns a single-sample counter family.
+func counter(name, help string, value float64) family {
+	return family{name: name, help: help, typ: typeCounter, samples: []sample{{value: value}}}
+}
+
+// WriteText writes the current metrics to w in the Prometheus text format.
+// Families without samples are left out.
+func (r *Registry) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, f := range r.families() {
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		bw.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+		bw.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+
+		for _, s := range f.samples {
+			bw.WriteString(f.name)
+			writeLabels(bw, s.labels)
+			bw.WriteString(" " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Handler returns an http.Handler that serves the registry in the Prometheus
+// text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		//nolint:errcheck // The scraper sees a truncated response; nothing else to do
+		_ = r.WriteText(w)
+	})
+}
+
+// writeLabels writes a {name="value",...} label set; nothing when empty.
+func writeLabels(bw *bufio.Writer, labels []label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	bw.WriteByte('{')
+
+	for i, l := range labels {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+
+		bw.WriteString(l.name + `="` + escapeLabelValue(l.value) + `"`)
+	}
+
+	bw.WriteByte('}')
+}
+
+//nolint:gochecknoglobals // Immutable replacers
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes backslashes and line feeds in HELP text.
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
+
+// escapeLabelValue escapes backslashes, double quotes, and line feeds in a label value.
+func escapeLabelValue(s string) string { return labelEscaper.Replace(s) }
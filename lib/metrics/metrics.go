@@ -0,0 +1,264 @@
+// Package metrics exposes agent and hashcat telemetry in the Prometheus text
+// exposition format. It collects the latest performance monitor sample, the
+// per-device figures from hashcat status updates, the number of cracks
+// delivered to the server, API error counts, and the API circuit breaker state,
+// and serves them from an optional local HTTP listener.
+//
+// The registry is written by the monitor, task, and transport code paths and
+// read on each scrape; all methods are safe for concurrent use.
+package metrics
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/monitor"
+)
+
+// Default is the registry the agent records into and serves.
+//
+//nolint:gochecknoglobals // Process-wide metrics registry, like agentstate.State
+var Default = NewRegistry()
+
+// Device is the latest hashcat status of one backend device.
+type Device struct {
+	ID          int64
+	Name        string
+	Type        string
+	Speed       int64 // Hashes per second
+	Utilization int64 // Percent (0-100)
+	Temperature int64 // Celsius; negative when hashcat cannot read it
+}
+
+// Registry holds the current metric values.
+type Registry struct {
+	mu           sync.Mutex
+	system       *monitor.Metrics
+	devices      map[int64][]Device // Latest device statuses, keyed by task ID
+	apiErrors    map[string]uint64  // Failed API request attempts, keyed by reason
+	circuitState func() string
+
+	cracksSent atomic.Uint64
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		devices:   make(map[int64][]Device),
+		apiErrors: make(map[string]uint64),
+	}
+}
+
+// ObserveSystem records the latest performance monitor sample.
+func (r *Registry) ObserveSystem(m monitor.Metrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.system = &m
+}
+
+// ObserveDevices records the device statuses of a task's latest hashcat status
+// update, replacing the previous ones for that task.
+func (r *Registry) ObserveDevices(taskID int64, devices []Device) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.devices[taskID] = slices.Clone(devices)
+}
+
+// ForgetTask drops the device statuses of a task whose hashcat session ended,
+// so idle devices stop reporting stale speeds.
+func (r *Registry) ForgetTask(taskID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.devices, taskID)
+}
+
+// AddCrackSent counts a cracked hash accepted by the server.
+func (r *Registry) AddCrackSent() {
+	r.cracksSent.Add(1)
+}
+
+// AddAPIError counts a failed API request attempt.
+func (r *Registry) AddAPIError(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.apiErrors[reason]++
+}
+
+// SetCircuitState sets the function that reports the API circuit breaker state
+// on each scrape. A nil function omits the metric.
+func (r *Registry) SetCircuitState(state func() string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.circuitState = state
+}
+
+// circuitStates lists the states reported for the circuit breaker metric.
+//
+//nolint:gochecknoglobals // Fixed label set
+var circuitStates = []string{api.CircuitStateClosed, api.CircuitStateOpen, api.CircuitStateHalfOpen}
+
+// families snapshots the registry as metric families in a stable order.
+func (r *Registry) families() []family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fams := make([]family, 0)
+
+	if r.system != nil {
+		fams = append(fams, systemFamilies(r.system)...)
+	}
+
+	fams = append(fams, r.deviceFamilies()...)
+
+	fams = append(fams, family{
+		name: "cipherswarm_cracks_sent_total", typ: typeCounter,
+		help:    "Cracked hashes accepted by the server.",
+		samples: []sample{{value: float64(r.cracksSent.Load())}},
+	})
+
+	apiErrors := family{
+		name: "cipherswarm_api_errors_total", typ: typeCounter,
+		help: "Failed API request attempts by reason; each retry counts separately.",
+	}
+	for _, reason := range slices.Sorted(maps.Keys(r.apiErrors)) {
+		apiErrors.samples = append(apiErrors.samples, sample{
+			labels: []label{{"reason", reason}},
+			value:  float64(r.apiErrors[reason]),
+		})
+	}
+	fams = append(fams, apiErrors)
+
+	if r.circuitState != nil {
+		current := r.circuitState()
+		circuit := family{
+			name: "cipherswarm_api_circuit_breaker_state", typ: typeGauge,
+			help: "API circuit breaker state; the current state is 1.",
+		}
+		for _, state := range circuitStates {
+			circuit.samples = append(circuit.samples, sample{
+				labels: []label{{"state", state}},
+				value:  boolValue(state == current),
+			})
+		}
+		fams = append(fams, circuit)
+	}
+
+	return fams
+}
+
+// deviceFamilies returns the per-device hashcat metrics. Callers hold r.mu.
+func (r *Registry) deviceFamilies() []family {
+	speed := family{
+		name: "cipherswarm_hashcat_device_speed_hashes_per_second", typ: typeGauge,
+		help: "Hashcat speed per device from the latest status update.",
+	}
+	temp := family{
+		name: "cipherswarm_hashcat_device_temperature_celsius", typ: typeGauge,
+		help: "Device temperature from the latest hashcat status update.",
+	}
+	util := family{
+		name: "cipherswarm_hashcat_device_utilization_percent", typ: typeGauge,
+		help: "Device utilization from the latest hashcat status update.",
+	}
+
+	for _, taskID := range slices.Sorted(maps.Keys(r.devices)) {
+		for _, dev := range r.devices[taskID] {
+			labels := []label{
+				{"device_id", strconv.FormatInt(dev.ID, 10)},
+				{"device_name", dev.Name},
+				{"device_type", dev.Type},
+			}
+
+			speed.samples = append(speed.samples, sample{labels: labels, value: float64(dev.Speed)})
+			util.samples = append(util.samples, sample{labels: labels, value: float64(dev.Utilization)})
+
+			if dev.Temperature >= 0 {
+				temp.samples = append(temp.samples, sample{labels: labels, value: float64(dev.Temperature)})
+			}
+		}
+	}
+
+	return []family{speed, temp, util}
+}
+
+// systemFamilies returns the host and process metrics of a monitor sample.
+func systemFamilies(m *monitor.Metrics) []family {
+	fams := []family{
+		gauge("cipherswarm_system_cpu_percent", "Overall CPU utilization.", m.CPUPercent),
+		gauge("cipherswarm_system_memory_used_percent", "Physical memory in use.", m.Memory.UsedPercent),
+		gauge("cipherswarm_system_memory_used_bytes", "Physical memory in use.", float64(m.Memory.UsedBytes)),
+		gauge("cipherswarm_system_memory_available_bytes", "Physical memory available without swapping.",
+			float64(m.Memory.AvailableBytes)),
+		gauge("cipherswarm_system_memory_total_bytes", "Total physical memory.", float64(m.Memory.TotalBytes)),
+		gauge("cipherswarm_system_swap_used_percent", "Swap in use.", m.Swap.UsedPercent),
+		gauge("cipherswarm_system_swap_used_bytes", "Swap in use.", float64(m.Swap.UsedBytes)),
+		gauge("cipherswarm_system_swap_total_bytes", "Total swap.", float64(m.Swap.TotalBytes)),
+	}
+
+	if len(m.PerCPUPercent) > 0 {
+		perCPU := family{
+			name: "cipherswarm_system_cpu_core_percent", typ: typeGauge,
+			help: "CPU utilization per logical core.",
+		}
+		for i, pct := range m.PerCPUPercent {
+			perCPU.samples = append(perCPU.samples, sample{labels: []label{{"core", strconv.Itoa(i)}}, value: pct})
+		}
+		fams = append(fams, perCPU)
+	}
+
+	if m.LoadAvailable {
+		fams = append(fams,
+			gauge("cipherswarm_system_load1", "1-minute load average.", m.Load.Load1),
+			gauge("cipherswarm_system_load5", "5-minute load average.", m.Load.Load5),
+			gauge("cipherswarm_system_load15", "15-minute load average.", m.Load.Load15),
+		)
+	}
+
+	if m.DiskIOAvailable {
+		fams = append(fams,
+			counter("cipherswarm_system_disk_read_bytes_total", "Bytes read across all disks.",
+				float64(m.DiskIO.ReadBytes)),
+			counter("cipherswarm_system_disk_written_bytes_total", "Bytes written across all disks.",
+				float64(m.DiskIO.WriteBytes)),
+		)
+	}
+
+	if m.NetIOAvailable {
+		fams = append(fams,
+			counter("cipherswarm_system_network_sent_bytes_total", "Bytes sent across all interfaces.",
+				float64(m.NetIO.BytesSent)),
+			counter("cipherswarm_system_network_received_bytes_total", "Bytes received across all interfaces.",
+				float64(m.NetIO.BytesRecv)),
+		)
+	}
+
+	if m.Process != nil {
+		fams = append(fams,
+			gauge("cipherswarm_process_cpu_percent",
+				"CPU utilization of the monitored process (hashcat while a task runs, otherwise the agent).",
+				m.Process.CPUPercent),
+			gauge("cipherswarm_process_resident_memory_bytes", "Resident memory of the monitored process.",
+				float64(m.Process.MemoryRSSBytes)),
+		)
+	}
+
+	return fams
+}
+
+// boolValue converts a condition to a 0/1 metric value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/monitor"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	var sb strings.Builder
+	require.NoError(t, r.WriteText(&sb))
+
+	return sb.String()
+}
+
+func TestWriteText_Empty(t *testing.T) {
+	out := scrape(t, NewRegistry())
+
+	assert.Equal(t, "# HELP cipherswarm_cracks_sent_total Cracked hashes accepted by the server.\n"+
+		"# TYPE cipherswarm_cracks_sent_total counter\n"+
+		"cipherswarm_cracks_sent_total 0\n", out)
+}
+
+func TestWriteText_System(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveSystem(monitor.Metrics{
+		CPUPercent:    12.5,
+		PerCPUPercent: []float64{10, 15},
+		Memory:        monitor.MemoryStats{TotalBytes: 1024},
+		NetIO:         monitor.NetIOStats{BytesSent: 7},
+		Process:       &monitor.ProcessStats{PID: 1, MemoryRSSBytes: 2048},
+	})
+
+	out := scrape(t, r)
+
+	assert.Contains(t, out, "# TYPE cipherswarm_system_cpu_percent gauge\ncipherswarm_system_cpu_percent 12.5\n")
+	assert.Contains(t, out, `cipherswarm_system_cpu_core_percent{core="1"} 15`)
+	assert.Contains(t, out, "cipherswarm_system_memory_total_bytes 1024\n")
+	assert.Contains(t, out, "cipherswarm_process_resident_memory_bytes 2048\n")
+	assert.NotContains(t, out, "cipherswarm_system_load1", "load averages are unavailable")
+	assert.NotContains(t, out, "cipherswarm_system_network_sent_bytes_total", "net I/O is unavailable")
+}
+
+func TestWriteText_Devices(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveDevices(7, []Device{
+		{ID: 1, Name: `RTX "3090"`, Type: "GPU", Speed: 1000, Utilization: 99, Temperature: 70},
+		{ID: 2, Name: "CPU", Type: "CPU", Speed: 10, Utilization: 50, Temperature: -1},
+	})
+
+	out := scrape(t, r)
+
+	assert.Contains(t, out,
+		`cipherswarm_hashcat_device_speed_hashes_per_second{device_id="1",device_name="RTX \"3090\"",device_type="GPU"} 1000`)
+	assert.Contains(t, out, `cipherswarm_hashcat_device_utilization_percent{device_id="2",device_name="CPU",device_type="CPU"} 50`)
+	assert.Contains(t, out, `cipherswarm_hashcat_device_temperature_celsius{device_id="1"`)
+	assert.NotContains(t, out, `cipherswarm_hashcat_device_temperature_celsius{device_id="2"`,
+		"unreadable temperatures are left out")
+
+	r.ForgetTask(7)
+	assert.NotContains(t, scrape(t, r), "cipherswarm_hashcat_device")
+}
+
+func TestWriteText_APIAndCracks(t *testing.T) {
+	r := NewRegistry()
+	r.AddCrackSent()
+	r.AddCrackSent()
+	r.AddAPIError("server_error")
+	r.AddAPIError("network_error")
+	r.AddAPIError("server_error")
+	r.SetCircuitState(func() string { return "open" })
+
+	out := scrape(t, r)
+
+	assert.Contains(t, out, "cipherswarm_cracks_sent_total 2\n")
+	assert.Contains(t, out, "cipherswarm_api_errors_total{reason=\"network_error\"} 1\n"+
+		"cipherswarm_api_errors_total{reason=\"server_error\"} 2\n")
+	assert.Contains(t, out, "cipherswarm_api_circuit_breaker_state{state=\"closed\"} 0\n"+
+		"cipherswarm_api_circuit_breaker_state{state=\"open\"} 1\n"+
+		"cipherswarm_api_circuit_breaker_state{state=\"half_open\"} 0\n")
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewRegistry().Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "cipherswarm_cracks_sent_total 0")
+}
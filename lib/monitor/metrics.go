@@ -5,7 +5,10 @@
 //
 // Server-side ingestion of these metrics is intentionally out of scope: the v1
 // Agent API contract (docs/swagger.json) exposes no performance endpoint, so
-// metrics are surfaced locally via structured logging. GPU temperature and
+// metrics are surfaced locally via structured logging, the agent's optional
+// Prometheus endpoint (through the Options.Observe hook), and optional sinks
+// that forward each sample to a JSON-lines file, StatsD, or an OpenTelemetry
+// collector. GPU temperature and
 // utilization already flow to the server through per-device task status updates
 // (see the DeviceStatus schema), so they are not duplicated here.
 package monitor
@@ -16,34 +19,34 @@ import "time"
 // performance counters.
 type Metrics struct {
 	// Timestamp is when the sample was taken.
-	Timestamp time.Time
+	Timestamp time.Time `json:"timestamp"`
 	// CPUPercent is overall CPU utilization across all logical cores (0-100).
-	CPUPercent float64
+	CPUPercent float64 `json:"cpu_percent"`
 	// PerCPUPercent holds per-logical-core utilization (0-100). Empty when
 	// per-core collection is disabled.
-	PerCPUPercent []float64
+	PerCPUPercent []float64 `json:"per_cpu_percent,omitempty"`
 	// Memory holds physical RAM statistics.
-	Memory MemoryStats
+	Memory MemoryStats `json:"memory"`
 	// Swap holds swap/page-file statistics.
-	Swap SwapStats
+	Swap SwapStats `json:"swap"`
 	// Load holds system load averages. Only meaningful when LoadAvailable is true.
-	Load LoadStats
+	Load LoadStats `json:"load"`
 	// LoadAvailable reports whether the platform provided load averages
 	// (unsupported on some platforms, e.g. Windows).
-	LoadAvailable bool
+	LoadAvailable bool `json:"load_available"`
 	// DiskIO holds cumulative disk I/O counters aggregated across all disks.
 	// Only meaningful when DiskIOAvailable is true.
-	DiskIO DiskIOStats
+	DiskIO DiskIOStats `json:"disk_io"`
 	// DiskIOAvailable reports whether disk I/O counters were collected.
-	DiskIOAvailable bool
+	DiskIOAvailable bool `json:"disk_io_available"`
 	// NetIO holds cumulative network I/O counters aggregated across all interfaces.
 	// Only meaningful when NetIOAvailable is true.
-	NetIO NetIOStats
+	NetIO NetIOStats `json:"net_io"`
 	// NetIOAvailable reports whether network I/O counters were collected.
-	NetIOAvailable bool
+	NetIOAvailable bool `json:"net_io_available"`
 	// Process holds per-process metrics for the monitored PID, or nil when
 	// process collection is disabled or the process could not be sampled.
-	Process *ProcessStats
+	Process *ProcessStats `json:"process,omitempty"`
 }
 
 // DiskIOStats holds cumulative disk I/O counters (since boot) summed across all
@@ -51,9 +54,9 @@ type Metrics struct {
 // to derive throughput.
 type DiskIOStats struct {
 	// ReadBytes is the total bytes read across all disks.
-	ReadBytes uint64
+	ReadBytes uint64 `json:"read_bytes"`
 	// WriteBytes is the total bytes written across all disks.
-	WriteBytes uint64
+	WriteBytes uint64 `json:"write_bytes"`
 }
 
 // NetIOStats holds cumulative network I/O counters (since boot) summed across all
@@ -61,50 +64,50 @@ type DiskIOStats struct {
 // derive throughput.
 type NetIOStats struct {
 	// BytesSent is the total bytes transmitted across all interfaces.
-	BytesSent uint64
+	BytesSent uint64 `json:"bytes_sent"`
 	// BytesRecv is the total bytes received across all interfaces.
-	BytesRecv uint64
+	BytesRecv uint64 `json:"bytes_recv"`
 }
 
 // MemoryStats describes physical memory usage at sample time.
 type MemoryStats struct {
 	// UsedPercent is the fraction of physical memory in use (0-100).
-	UsedPercent float64
+	UsedPercent float64 `json:"used_percent"`
 	// UsedBytes is the number of bytes of physical memory in use.
-	UsedBytes uint64
+	UsedBytes uint64 `json:"used_bytes"`
 	// TotalBytes is the total physical memory.
-	TotalBytes uint64
+	TotalBytes uint64 `json:"total_bytes"`
 	// AvailableBytes is the memory available for new allocations without swapping.
-	AvailableBytes uint64
+	AvailableBytes uint64 `json:"available_bytes"`
 }
 
 // SwapStats describes swap/page-file usage at sample time.
 type SwapStats struct {
 	// UsedPercent is the fraction of swap in use (0-100).
-	UsedPercent float64
+	UsedPercent float64 `json:"used_percent"`
 	// UsedBytes is the number of bytes of swap in use.
-	UsedBytes uint64
+	UsedBytes uint64 `json:"used_bytes"`
 	// TotalBytes is the total swap size.
-	TotalBytes uint64
+	TotalBytes uint64 `json:"total_bytes"`
 }
 
 // LoadStats holds the 1-, 5-, and 15-minute system load averages.
 type LoadStats struct {
 	// Load1 is the 1-minute load average.
-	Load1 float64
+	Load1 float64 `json:"load1"`
 	// Load5 is the 5-minute load average.
-	Load5 float64
+	Load5 float64 `json:"load5"`
 	// Load15 is the 15-minute load average.
-	Load15 float64
+	Load15 float64 `json:"load15"`
 }
 
 // ProcessStats holds per-process performance counters.
 type ProcessStats struct {
 	// PID is the process identifier that was sampled.
-	PID int32
+	PID int32 `json:"pid"`
 	// CPUPercent is the process CPU utilization since the previous sample (0-100
 	// per logical core, so a fully-busy process may exceed 100 on multi-core hosts).
-	CPUPercent float64
+	CPUPercent float64 `json:"cpu_percent"`
 	// MemoryRSSBytes is the process resident set size in bytes.
-	MemoryRSSBytes uint64
+	MemoryRSSBytes uint64 `json:"memory_rss_bytes"`
 }
@@ -38,6 +38,12 @@ type Options struct {
 	PIDProvider PIDProvider
 	// Log reports each sample and any soft failures. When nil, reporting is a no-op.
 	Log LogFunc
+	// Observe receives each sample after it is reported, e.g. to export it as
+	// metrics. When nil, samples are only logged.
+	Observe func(Metrics)
+	// Sinks receive each sample after it is reported. Run closes them when it
+	// returns.
+	Sinks []Sink
 	// now returns the current time; injected for deterministic tests. Defaults to time.Now.
 	now func() time.Time
 }
@@ -57,6 +63,9 @@ type Monitor struct {
 	// caller (the Run goroutine, or a test), so no synchronization is needed.
 	diskIOUnavailable bool
 	netIOUnavailable  bool
+	// sinkFailing tracks, per sink, whether the last write failed, so a failing
+	// sink is logged on the transition only. Accessed only from Run.
+	sinkFailing []bool
 }
 
 // New returns a Monitor that draws samples from source using opts, applying
@@ -80,7 +89,7 @@ func New(source Source, opts Options) *Monitor {
 		opts.now = time.Now
 	}
 
-	return &Monitor{source: source, opts: opts}
+	return &Monitor{source: source, opts: opts, sinkFailing: make([]bool, len(opts.Sinks))}
 }
 
 // Collect gathers a single sample. Failures to read core counters (CPU, memory,
@@ -175,7 +184,7 @@ func (m *Monitor) Collect(ctx context.Context) (Metrics, error) {
 }
 
 // Run samples on m's interval until ctx is cancelled, reporting each sample via
-// the configured logger. The first sample is taken one interval in (the initial
+// the configured logger and passing it to the Observe hook and sinks. The first sample is taken one interval in (the initial
 // interval also primes delta-based CPU counters), and Run returns when ctx is done.
 func (m *Monitor) Run(ctx context.Context) {
 	// Prime delta-based CPU (and per-core CPU) and process counters so the first
@@ -200,6 +209,7 @@ func (m *Monitor) Run(ctx context.Context) {
 
 	ticker := time.NewTicker(m.opts.Interval)
 	defer ticker.Stop()
+	defer m.closeSinks()
 
 	for {
 		select {
@@ -212,6 +222,12 @@ func (m *Monitor) Run(ctx context.Context) {
 				m.opts.Log("Performance sampling partially failed", "error", err)
 			}
 			m.report(metrics)
+
+			if m.opts.Observe != nil {
+				m.opts.Observe(metrics)
+			}
+
+			m.writeSinks(ctx, metrics)
 		}
 	}
 }
@@ -260,6 +276,33 @@ func (m *Monitor) report(metrics Metrics) {
 	m.opts.Log("System performance", keyvals...)
 }
 
+// writeSinks delivers a sample to every sink. A sink's failure is logged when it
+// starts failing and again when it recovers, not on every sample.
+func (m *Monitor) writeSinks(ctx context.Context, metrics Metrics) {
+	for i, sink := range m.opts.Sinks {
+		err := sink.Write(ctx, metrics)
+
+		switch {
+		case err != nil && !m.sinkFailing[i]:
+			m.opts.Log("Performance sink write failed; suppressing repeat warnings until recovery",
+				"sink", sink.Name(), "error", err)
+			m.sinkFailing[i] = true
+		case err == nil && m.sinkFailing[i]:
+			m.opts.Log("Performance sink recovered", "sink", sink.Name())
+			m.sinkFailing[i] = false
+		}
+	}
+}
+
+// closeSinks closes every sink, logging failures.
+func (m *Monitor) closeSinks() {
+	for _, sink := range m.opts.Sinks {
+		if err := sink.Close(); err != nil {
+			m.opts.Log("Failed to close performance sink", "sink", sink.Name(), "error", err)
+		}
+	}
+}
+
 // twoDecimalScale scales a value so math.Round yields two-decimal precision.
 const twoDecimalScale = 100
 
@@ -452,3 +452,34 @@ func TestRound2(t *testing.T) {
 		assert.InDelta(t, tt.want, round2(tt.in), 0.0001)
 	}
 }
+
+func TestRun_PassesSamplesToObserver(t *testing.T) {
+	observed := make(chan Metrics, 1)
+	mon := New(healthySource(), Options{
+		Interval: 5 * time.Millisecond,
+		Observe: func(m Metrics) {
+			select {
+			case observed <- m:
+			default:
+			}
+		},
+		now: fixedNow,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		mon.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case m := <-observed:
+		assert.Equal(t, fixedNow(), m.Timestamp)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an observed sample")
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"context"
+	"strconv"
+)
+
+// Sink receives each performance sample taken by Monitor.Run, alongside the
+// structured log report. Sinks are written sequentially from the Run goroutine,
+// so an implementation need not be safe for concurrent use, but Write should
+// return promptly to keep sampling on schedule.
+type Sink interface {
+	// Name identifies the sink in log messages.
+	Name() string
+	// Write delivers one sample. A failure is logged by the monitor and the
+	// sample is dropped for this sink; later samples are still written.
+	Write(ctx context.Context, m Metrics) error
+	// Close releases the sink's resources. Run calls it once when it returns.
+	Close() error
+}
+
+// pointKind distinguishes point-in-time values from cumulative counters.
+type pointKind int
+
+const (
+	kindGauge   pointKind = iota // Point-in-time value
+	kindCounter                  // Monotonic total since boot
+)
+
+// point is one named value of a sample, flattened for sinks that export
+// individual metrics rather than whole samples.
+type point struct {
+	name  string
+	value float64
+	kind  pointKind
+	core  string // Logical core index for per-core CPU values; empty otherwise
+}
+
+// points flattens a sample into named values, leaving out sections the
+// platform did not provide.
+func points(m Metrics) []point {
+	pts := []point{
+		{name: "cpu_percent", value: m.CPUPercent},
+		{name: "memory_used_percent", value: m.Memory.UsedPercent},
+		{name: "memory_used_bytes", value: float64(m.Memory.UsedBytes)},
+		{name: "memory_available_bytes", value: float64(m.Memory.AvailableBytes)},
+		{name: "memory_total_bytes", value: float64(m.Memory.TotalBytes)},
+		{name: "swap_used_percent", value: m.Swap.UsedPercent},
+		{name: "swap_used_bytes", value: float64(m.Swap.UsedBytes)},
+		{name: "swap_total_bytes", value: float64(m.Swap.TotalBytes)},
+	}
+
+	for i, pct := range m.PerCPUPercent {
+		pts = append(pts, point{name: "cpu_core_percent", value: pct, core: strconv.Itoa(i)})
+	}
+
+	if m.LoadAvailable {
+		pts = append(pts,
+			point{name: "load1", value: m.Load.Load1},
+			point{name: "load5", value: m.Load.Load5},
+			point{name: "load15", value: m.Load.Load15},
+		)
+	}
+
+	if m.DiskIOAvailable {
+		pts = append(pts,
+			point{name: "disk_read_bytes", value: float64(m.DiskIO.ReadBytes), kind: kindCounter},
+			point{name: "disk_written_bytes", value: float64(m.DiskIO.WriteBytes), kind: kindCounter},
+		)
+	}
+
+	if m.NetIOAvailable {
+		pts = append(pts,
+			point{name: "net_sent_bytes", value: float64(m.NetIO.BytesSent), kind: kindCounter},
+			point{name: "net_received_bytes", value: float64(m.NetIO.BytesRecv), kind: kindCounter},
+		)
+	}
+
+	if m.Process != nil {
+		pts = append(pts,
+			point{name: "process_cpu_percent", value: m.Process.CPUPercent},
+			point{name: "process_resident_memory_bytes", value: float64(m.Process.MemoryRSSBytes)},
+		)
+	}
+
+	return pts
+}
@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// jsonlFilePermissions restricts sample files to the agent user.
+const jsonlFilePermissions = 0o600
+
+// JSONLSink appends each sample as one JSON object per line to a file, rotating
+// it once it would grow past a size limit. Rotated files are named path.1
+// (newest) through path.N (oldest).
+type JSONLSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewJSONLSink opens (or creates) the file at path, creating its directory if
+// needed. The file is rotated before a write would take it past maxBytes;
+// maxBytes <= 0 disables rotation. maxBackups rotated files are kept; with zero,
+// a full file is simply truncated.
+func NewJSONLSink(path string, maxBytes int64, maxBackups int) (*JSONLSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating sample file directory: %w", err)
+	}
+
+	s := &JSONLSink{path: path, maxBytes: maxBytes, maxBackups: max(maxBackups, 0)}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Name identifies the sink in log messages.
+func (s *JSONLSink) Name() string { return "jsonl" }
+
+// Write appends the sample as a JSON line.
+func (s *JSONLSink) Write(_ context.Context, m Metrics) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding sample: %w", err)
+	}
+
+	line = append(line, '\n')
+
+	if s.file == nil {
+		// A previous rotation failed to reopen the file; try again.
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("writing sample: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (s *JSONLSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
+
+// open opens the file for appending and records its current size.
+func (s *JSONLSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, jsonlFilePermissions)
+	if err != nil {
+		return fmt.Errorf("opening sample file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("reading sample file size: %w", err)
+	}
+
+	s.file = f
+	s.size = info.Size()
+
+	return nil
+}
+
+// rotate shifts path.1..path.N-1 up by one, moves the current file to path.1
+// (dropping the oldest backup), and starts a new file.
+func (s *JSONLSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return fmt.Errorf("closing sample file for rotation: %w", err)
+	}
+
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("removing full sample file: %w", err)
+		}
+	}
+
+	for i := s.maxBackups; i >= 1; i-- {
+		src := s.path
+		if i > 1 {
+			src = s.backupPath(i - 1)
+		}
+
+		if err := os.Rename(src, s.backupPath(i)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("rotating sample file: %w", err)
+		}
+	}
+
+	return s.open()
+}
+
+// backupPath returns the path of the n-th rotated file.
+func (s *JSONLSink) backupPath(n int) string {
+	return s.path + "." + strconv.Itoa(n)
+}
@@ -0,0 +1,205 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// otlpMetricPrefix namespaces the exported metric names.
+const otlpMetricPrefix = "cipherswarm.agent."
+
+// otlpScopeName is the instrumentation scope reported with every export.
+const otlpScopeName = "github.com/unclesp1d3r/cipherswarmagent/lib/monitor"
+
+// otlpCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE in the OTLP protocol.
+const otlpCumulative = 2
+
+// OTLPSink exports each sample to an OpenTelemetry collector using OTLP/HTTP
+// with JSON encoding. Point-in-time values are sent as gauges and cumulative
+// disk and network totals as monotonic cumulative sums, all named
+// cipherswarm.agent.<metric>.
+type OTLPSink struct {
+	endpoint string
+	client   *http.Client
+	resource []otlpKeyValue
+}
+
+// NewOTLPSink creates a sink posting to endpoint, the collector's full metrics
+// URL (typically http://host:4318/v1/metrics). resource holds the resource
+// attributes sent with every export, e.g. service.name. timeout bounds each
+// export request.
+func NewOTLPSink(endpoint string, resource map[string]string, timeout time.Duration) (*OTLPSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: must be an http(s) URL", endpoint)
+	}
+
+	attrs := make([]otlpKeyValue, 0, len(resource))
+	for _, key := range slices.Sorted(maps.Keys(resource)) {
+		attrs = append(attrs, otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: resource[key]}})
+	}
+
+	return &OTLPSink{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+		resource: attrs,
+	}, nil
+}
+
+// Name identifies the sink in log messages.
+func (s *OTLPSink) Name() string { return "otlp" }
+
+// Write exports the sample. Any non-2xx response is an error.
+func (s *OTLPSink) Write(ctx context.Context, m Metrics) error {
+	body, err := json.Marshal(s.request(m))
+	if err != nil {
+		return fmt.Errorf("encoding OTLP export: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating OTLP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending OTLP export: %w", err)
+	}
+	defer resp.Body.Close()
+
+	//nolint:errcheck // Drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("OTLP collector rejected export: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Close releases idle connections.
+func (s *OTLPSink) Close() error {
+	s.client.CloseIdleConnections()
+
+	return nil
+}
+
+// request builds the export request for a sample, grouping per-core values
+// under one metric.
+func (s *OTLPSink) request(m Metrics) otlpExportRequest {
+	timestamp := strconv.FormatInt(m.Timestamp.UnixNano(), 10)
+
+	var metrics []otlpMetric
+
+	index := make(map[string]int)
+
+	for _, p := range points(m) {
+		dp := otlpDataPoint{TimeUnixNano: timestamp, AsDouble: p.value}
+		if p.core != "" {
+			dp.Attributes = []otlpKeyValue{{Key: "cpu", Value: otlpAnyValue{StringValue: p.core}}}
+		}
+
+		if i, ok := index[p.name]; ok {
+			metrics[i].addPoint(dp)
+
+			continue
+		}
+
+		metric := otlpMetric{Name: otlpMetricPrefix + p.name}
+		if p.kind == kindCounter {
+			metric.Sum = &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+		} else {
+			metric.Gauge = &otlpGauge{}
+		}
+
+		metric.addPoint(dp)
+		index[p.name] = len(metrics)
+		metrics = append(metrics, metric)
+	}
+
+	return otlpExportRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: s.resource},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: otlpScopeName},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+// The types below mirror the OTLP/JSON ExportMetricsServiceRequest message,
+// limited to the fields this sink sends.
+
+type otlpExportRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name  string     `json:"name"`
+	Gauge *otlpGauge `json:"gauge,omitempty"`
+	Sum   *otlpSum   `json:"sum,omitempty"`
+}
+
+// addPoint appends a data point to the metric's gauge or sum.
+func (m *otlpMetric) addPoint(dp otlpDataPoint) {
+	if m.Sum != nil {
+		m.Sum.DataPoints = append(m.Sum.DataPoints, dp)
+
+		return
+	}
+
+	m.Gauge.DataPoints = append(m.Gauge.DataPoints, dp)
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpDataPoint struct {
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	AsDouble     float64        `json:"asDouble"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// statsdMaxPacket keeps StatsD datagrams within a typical Ethernet MTU so they
+// are not fragmented or dropped.
+const statsdMaxPacket = 1432
+
+// StatsDSink sends each sample to a StatsD server over UDP as gauges named
+// <prefix>.<metric>, e.g. "cipherswarm.agent.cpu_percent:12.5|g". Per-core CPU
+// values are named cpu_core_percent.<core>. Cumulative disk and network totals
+// are sent as gauges too, since StatsD counters expect per-interval deltas.
+type StatsDSink struct {
+	conn   net.Conn
+	prefix string
+}
+
+// NewStatsDSink creates a sink sending to the StatsD server at addr
+// (host:port). An empty prefix sends bare metric names.
+func NewStatsDSink(addr, prefix string) (*StatsDSink, error) {
+	conn, err := (&net.Dialer{}).DialContext(context.Background(), "udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to StatsD at %s: %w", addr, err)
+	}
+
+	prefix = strings.Trim(prefix, ".")
+	if prefix != "" {
+		prefix += "."
+	}
+
+	return &StatsDSink{conn: conn, prefix: prefix}, nil
+}
+
+// Name identifies the sink in log messages.
+func (s *StatsDSink) Name() string { return "statsd" }
+
+// Write sends the sample's values, packing as many lines per datagram as fit.
+func (s *StatsDSink) Write(_ context.Context, m Metrics) error {
+	var packet bytes.Buffer
+
+	for _, p := range points(m) {
+		name := p.name
+		if p.core != "" {
+			name += "." + p.core
+		}
+
+		line := s.prefix + name + ":" + strconv.FormatFloat(p.value, 'f', -1, 64) + "|g"
+
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdMaxPacket {
+			if err := s.send(packet.Bytes()); err != nil {
+				return err
+			}
+
+			packet.Reset()
+		}
+
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+
+		packet.WriteString(line)
+	}
+
+	if packet.Len() == 0 {
+		return nil
+	}
+
+	return s.send(packet.Bytes())
+}
+
+// Close closes the UDP socket.
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
+
+// send writes one datagram.
+func (s *StatsDSink) send(packet []byte) error {
+	if _, err := s.conn.Write(packet); err != nil {
+		return fmt.Errorf("sending to StatsD: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleMetrics returns a sample with every optional section populated.
+func sampleMetrics() Metrics {
+	return Metrics{
+		Timestamp:       fixedNow(),
+		CPUPercent:      12.5,
+		PerCPUPercent:   []float64{10, 15},
+		Memory:          MemoryStats{UsedPercent: 50, UsedBytes: 512, TotalBytes: 1024, AvailableBytes: 512},
+		Load:            LoadStats{Load1: 1, Load5: 2, Load15: 3},
+		LoadAvailable:   true,
+		DiskIO:          DiskIOStats{ReadBytes: 100, WriteBytes: 200},
+		DiskIOAvailable: true,
+		Process:         &ProcessStats{PID: 42, CPUPercent: 5, MemoryRSSBytes: 2048},
+	}
+}
+
+// recordingSink records writes and fails while failing is set.
+type recordingSink struct {
+	mu      sync.Mutex
+	writes  int
+	failing bool
+	closed  bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Write(context.Context, Metrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writes++
+	if s.failing {
+		return errors.New("sink down")
+	}
+
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	return nil
+}
+
+func TestWriteSinks_LogsFailureTransitionsOnly(t *testing.T) {
+	var logged []string
+	sink := &recordingSink{failing: true}
+	mon := New(healthySource(), Options{
+		Interval: time.Second,
+		Sinks:    []Sink{sink},
+		Log:      func(msg any, _ ...any) { logged = append(logged, msg.(string)) },
+		now:      fixedNow,
+	})
+
+	mon.writeSinks(context.Background(), sampleMetrics())
+	mon.writeSinks(context.Background(), sampleMetrics())
+	sink.failing = false
+	mon.writeSinks(context.Background(), sampleMetrics())
+
+	assert.Equal(t, 3, sink.writes)
+	assert.Equal(t, []string{
+		"Performance sink write failed; suppressing repeat warnings until recovery",
+		"Performance sink recovered",
+	}, logged)
+}
+
+func TestRun_WritesAndClosesSinks(t *testing.T) {
+	sink := &recordingSink{}
+	mon := New(healthySource(), Options{Interval: 5 * time.Millisecond, Sinks: []Sink{sink}, now: fixedNow})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		mon.Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+
+		return sink.writes > 0
+	}, 2*time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.True(t, sink.closed, "Run must close its sinks on return")
+}
+
+func TestJSONLSink_WritesLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "perf", "samples.jsonl")
+
+	sink, err := NewJSONLSink(path, 0, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), sampleMetrics()))
+	require.NoError(t, sink.Write(context.Background(), sampleMetrics()))
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+
+	require.Len(t, lines, 2)
+	assert.InDelta(t, 12.5, lines[0]["cpu_percent"], 0)
+	assert.Equal(t, fixedNow().Format(time.RFC3339Nano), lines[0]["timestamp"])
+	assert.Contains(t, lines[0], "process")
+}
+
+func TestJSONLSink_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.jsonl")
+
+	line, err := json.Marshal(sampleMetrics())
+	require.NoError(t, err)
+	lineSize := int64(len(line) + 1)
+
+	// Room for two lines per file, keeping two backups.
+	sink, err := NewJSONLSink(path, 2*lineSize, 2)
+	require.NoError(t, err)
+
+	for range 7 {
+		require.NoError(t, sink.Write(context.Background(), sampleMetrics()))
+	}
+	require.NoError(t, sink.Close())
+
+	countLines := func(p string) int {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err)
+
+		return strings.Count(string(data), "\n")
+	}
+
+	assert.Equal(t, 1, countLines(path))
+	assert.Equal(t, 2, countLines(path+".1"))
+	assert.Equal(t, 2, countLines(path+".2"))
+	assert.NoFileExists(t, path+".3", "only maxBackups rotated files are kept")
+}
+
+func TestStatsDSink_SendsGauges(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := NewStatsDSink(conn.LocalAddr().String(), "cs.agent.")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(context.Background(), sampleMetrics()))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	buf := make([]byte, statsdMaxPacket)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(buf[:n]), "\n")
+	assert.Contains(t, lines, "cs.agent.cpu_percent:12.5|g")
+	assert.Contains(t, lines, "cs.agent.cpu_core_percent.1:15|g")
+	assert.Contains(t, lines, "cs.agent.disk_read_bytes:100|g")
+	assert.Contains(t, lines, "cs.agent.process_resident_memory_bytes:2048|g")
+	assert.NotContains(t, string(buf[:n]), "net_sent_bytes", "unavailable sections are left out")
+}
+
+func TestStatsDSink_SplitsLargeSamples(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := NewStatsDSink(conn.LocalAddr().String(), "")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	m := sampleMetrics()
+	m.PerCPUPercent = make([]float64, 256)
+	require.NoError(t, sink.Write(context.Background(), m))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, n, statsdMaxPacket)
+}
+
+func TestOTLPSink_Exports(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received otlpExportRequest
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer srv.Close()
+
+	sink, err := NewOTLPSink(srv.URL+"/v1/metrics", map[string]string{"service.name": "cipherswarm-agent"}, time.Second)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(context.Background(), sampleMetrics()))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, received.ResourceMetrics, 1)
+	rm := received.ResourceMetrics[0]
+	assert.Equal(t, []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: "cipherswarm-agent"}}},
+		rm.Resource.Attributes)
+
+	byName := make(map[string]otlpMetric)
+	for _, metric := range rm.ScopeMetrics[0].Metrics {
+		byName[metric.Name] = metric
+	}
+
+	cpu := byName["cipherswarm.agent.cpu_percent"]
+	require.NotNil(t, cpu.Gauge)
+	assert.InDelta(t, 12.5, cpu.Gauge.DataPoints[0].AsDouble, 0)
+	assert.Equal(t, "1704110400000000000", cpu.Gauge.DataPoints[0].TimeUnixNano)
+
+	cores := byName["cipherswarm.agent.cpu_core_percent"]
+	require.NotNil(t, cores.Gauge)
+	assert.Len(t, cores.Gauge.DataPoints, 2, "per-core values share one metric")
+
+	disk := byName["cipherswarm.agent.disk_read_bytes"]
+	require.NotNil(t, disk.Sum)
+	assert.True(t, disk.Sum.IsMonotonic)
+	assert.Equal(t, otlpCumulative, disk.Sum.AggregationTemporality)
+}
+
+func TestOTLPSink_RejectedExport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	sink, err := NewOTLPSink(srv.URL, nil, time.Second)
+	require.NoError(t, err)
+
+	require.Error(t, sink.Write(context.Background(), sampleMetrics()))
+}
+
+func TestNewOTLPSink_InvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"", "collector:4318", "ftp://collector/v1/metrics"} {
+		_, err := NewOTLPSink(endpoint, nil, time.Second)
+		assert.Error(t, err, endpoint)
+	}
+}
@@ -0,0 +1,121 @@
+package progress
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+// Aggregate is a Tracker that combines several concurrent downloads into a
+// single progress bar. The bar shows their combined size and how many of them
+// have finished. It is drawn when the first download starts; call Finish once
+// every download is done.
+type Aggregate struct {
+	label string
+
+	mu        sync.Mutex
+	bar       *pb.ProgressBar
+	downloads []*aggregateDownload
+}
+
+// NewAggregate returns an Aggregate whose bar is labelled with label.
+func NewAggregate(label string) *Aggregate {
+	return &Aggregate{label: label}
+}
+
+// StartTracking adds a download to the combined bar. Any totalSize <= 0 is
+// treated as unknown; such a download counts towards the total only as far as
+// it has progressed.
+func (a *Aggregate) StartTracking(_ string, totalSize int64) DownloadProgress {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.bar == nil {
+		a.bar = pb.New64(0)
+		progressBarConfig(a.bar, a.label)
+		a.bar.Start()
+	}
+
+	d := &aggregateDownload{owner: a, size: max(totalSize, 0)}
+	a.downloads = append(a.downloads, d)
+	a.refresh()
+
+	return d
+}
+
+// Finish marks the combined bar as complete. Idempotent, and safe to call when
+// no download was ever tracked.
+func (a *Aggregate) Finish() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.bar != nil && !a.bar.IsFinished() {
+		a.bar.Finish()
+	}
+}
+
+// totals returns the combined progress of all tracked downloads.
+// Callers hold a.mu.
+func (a *Aggregate) totals() (current, total int64, finished int) {
+	for _, d := range a.downloads {
+		current += d.current
+
+		if d.size > 0 {
+			total += d.size
+		} else {
+			total += d.current
+		}
+
+		if d.finished {
+			finished++
+		}
+	}
+
+	return current, total, finished
+}
+
+// refresh redraws the combined bar. Callers hold a.mu.
+func (a *Aggregate) refresh() {
+	current, total, finished := a.totals()
+
+	a.bar.SetTotal(total)
+	a.bar.SetCurrent(current)
+	a.bar.Set("prefix", fmt.Sprintf("%s (%d/%d)", a.label, finished, len(a.downloads)))
+}
+
+// aggregateDownload is one download's share of an Aggregate.
+type aggregateDownload struct {
+	owner    *Aggregate
+	size     int64
+	current  int64
+	finished bool
+}
+
+// SetTotal records the download's size and redraws the combined bar. Any
+// totalSize <= 0 is treated as unknown.
+func (d *aggregateDownload) SetTotal(totalSize int64) {
+	d.owner.mu.Lock()
+	defer d.owner.mu.Unlock()
+
+	d.size = max(totalSize, 0)
+	d.owner.refresh()
+}
+
+// Update records the download's byte count and redraws the combined bar.
+func (d *aggregateDownload) Update(bytesComplete int64) {
+	d.owner.mu.Lock()
+	defer d.owner.mu.Unlock()
+
+	d.current = bytesComplete
+	d.owner.refresh()
+}
+
+// Finish counts the download as finished. Idempotent.
+func (d *aggregateDownload) Finish() {
+	d.owner.mu.Lock()
+	defer d.owner.mu.Unlock()
+
+	d.finished = true
+	d.owner.refresh()
+}
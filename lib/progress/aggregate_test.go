@@ -0,0 +1,53 @@
+package progress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate_CombinesDownloads(t *testing.T) {
+	agg := NewAggregate("attack files")
+	t.Cleanup(agg.Finish)
+
+	wordlist := agg.StartTracking("rockyou.txt", 1000)
+	rules := agg.StartTracking("best64.rule", 200)
+	unknown := agg.StartTracking("masks.hcmask", -1)
+
+	wordlist.Update(400)
+	rules.Update(200)
+	rules.Finish()
+	unknown.Update(50)
+
+	agg.mu.Lock()
+	current, total, finished := agg.totals()
+	agg.mu.Unlock()
+
+	assert.Equal(t, int64(650), current)
+	assert.Equal(t, int64(1250), total, "a download of unknown size counts only what it has received")
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, "attack files (1/3)", agg.bar.Get("prefix"))
+}
+
+func TestAggregate_SetTotal(t *testing.T) {
+	agg := NewAggregate("attack files")
+	t.Cleanup(agg.Finish)
+
+	wordlist := agg.StartTracking("rockyou.txt", -1)
+	wordlist.Update(100)
+	wordlist.SetTotal(1000)
+
+	agg.mu.Lock()
+	current, total, _ := agg.totals()
+	agg.mu.Unlock()
+
+	assert.Equal(t, int64(100), current)
+	assert.Equal(t, int64(1000), total, "a size reported after tracking started replaces the unknown one")
+}
+
+func TestAggregate_FinishWithoutDownloads(t *testing.T) {
+	agg := NewAggregate("attack files")
+
+	assert.NotPanics(t, agg.Finish)
+	assert.NotPanics(t, agg.Finish, "Finish should be idempotent")
+}
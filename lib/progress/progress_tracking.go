@@ -16,7 +16,9 @@ type Tracker interface {
 }
 
 // DownloadProgress tracks the progress of a single download via polling updates.
+// SetTotal replaces the size given to StartTracking once the server reports it.
 type DownloadProgress interface {
+	SetTotal(totalSize int64)
 	Update(bytesComplete int64)
 	Finish()
 }
@@ -25,6 +27,27 @@ type DownloadProgress interface {
 // It implements progress.Tracker for download progress display.
 var DefaultProgressBar = &progressBar{} //nolint:gochecknoglobals // Default progress bar instance
 
+// Silent is a Tracker that displays nothing, for downloads running in the
+// background while other output is on screen.
+var Silent Tracker = silentTracker{} //nolint:gochecknoglobals // Stateless tracker instance
+
+// silentTracker discards all progress.
+type silentTracker struct{}
+
+// StartTracking returns a DownloadProgress that ignores updates.
+func (silentTracker) StartTracking(string, int64) DownloadProgress {
+	return silentTracker{}
+}
+
+// SetTotal does nothing.
+func (silentTracker) SetTotal(int64) {}
+
+// Update does nothing.
+func (silentTracker) Update(int64) {}
+
+// Finish does nothing.
+func (silentTracker) Finish() {}
+
 // progressBar wraps a github.com/cheggaaa/pb.Pool
 // in order to display download progress for one or multiple
 // downloads.
@@ -47,8 +70,8 @@ func progressBarConfig(bar *pb.ProgressBar, prefix string) {
 }
 
 // StartTracking creates a new progress bar for the given file download.
-// Any totalSize <= 0 is treated as unknown, matching grab.Response.Size()
-// semantics where -1 indicates the server did not provide a content length.
+// Any totalSize <= 0 is treated as unknown; -1 indicates the server did not
+// provide a content length.
 func (cpb *progressBar) StartTracking(filename string, totalSize int64) DownloadProgress {
 	cpb.lock.Lock()
 	defer cpb.lock.Unlock()
@@ -81,6 +104,12 @@ type downloadProgress struct {
 	finished sync.Once
 }
 
+// SetTotal sets the download's size on the progress bar. Any totalSize <= 0 is
+// treated as unknown.
+func (dp *downloadProgress) SetTotal(totalSize int64) {
+	dp.bar.SetTotal(max(totalSize, 0))
+}
+
 // Update sets the current byte count on the progress bar.
 func (dp *downloadProgress) Update(bytesComplete int64) {
 	dp.bar.SetCurrent(bytesComplete)
@@ -0,0 +1,153 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/apierrors"
+)
+
+var (
+	// ErrTaskNotFound is returned when the server no longer knows a task.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskNotResumable is returned when an interrupted task can no longer be
+	// resumed (it was reassigned, finished, or its attack changed).
+	ErrTaskNotResumable = errors.New("task is not resumable")
+)
+
+// ActiveTask is the on-disk record of the task the agent is working on, used to
+// resume it after an agent restart.
+type ActiveTask struct {
+	TaskID    int64     `json:"task_id"`
+	AttackID  int64     `json:"attack_id"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// SaveActiveTask records the task as in progress so it can be resumed if the
+// agent is interrupted. The record is replaced atomically and fsync'd. It is a
+// no-op when no ActiveTaskFile is configured.
+func (m *Manager) SaveActiveTask(task *api.Task) error {
+	if m.Config.ActiveTaskFile == "" {
+		return nil
+	}
+
+	if task == nil {
+		return ErrTaskIsNil
+	}
+
+	data, err := json.Marshal(ActiveTask{TaskID: task.Id, AttackID: task.AttackId, StartedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("encoding active task record: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.Config.ActiveTaskFile), ".active_task-*")
+	if err != nil {
+		return fmt.Errorf("creating active task record: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op once renamed into place
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing active task record: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("syncing active task record: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing active task record: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), m.Config.ActiveTaskFile); err != nil {
+		return fmt.Errorf("saving active task record: %w", err)
+	}
+
+	return nil
+}
+
+// LoadActiveTask returns the task recorded by SaveActiveTask, or nil if there is none.
+func (m *Manager) LoadActiveTask() (*ActiveTask, error) {
+	if m.Config.ActiveTaskFile == "" {
+		return nil, nil //nolint:nilnil // no record configured is not an error
+	}
+
+	data, err := os.ReadFile(m.Config.ActiveTaskFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil //nolint:nilnil // no record is not an error
+		}
+
+		return nil, fmt.Errorf("reading active task record: %w", err)
+	}
+
+	var record ActiveTask
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("parsing active task record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// ClearActiveTask removes the active task record once the task no longer needs
+// to be resumed.
+func (m *Manager) ClearActiveTask() {
+	if m.Config.ActiveTaskFile == "" {
+		return
+	}
+
+	if err := os.Remove(m.Config.ActiveTaskFile); err != nil && !os.IsNotExist(err) {
+		agentstate.Logger.Error("Failed to remove active task record", "path", m.Config.ActiveTaskFile, "error", err)
+	}
+}
+
+// GetTask retrieves the server's current view of a task.
+// Returns ErrTaskNotFound if the server responds with HTTP 404 or 410.
+func (m *Manager) GetTask(ctx context.Context, taskID int64) (*api.Task, error) {
+	response, err := m.tasksClient.GetTask(ctx, taskID)
+	if err != nil {
+		if apierrors.IsNotFoundError(err) || apierrors.IsGoneError(err) {
+			return nil, fmt.Errorf("%w: %w", ErrTaskNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	if response.StatusCode() != http.StatusOK || response.JSON200 == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTaskBadResponse, response.Status())
+	}
+
+	return response.JSON200, nil
+}
+
+// RevalidateActiveTask asks the server whether an interrupted task is still
+// assigned to this agent and can be resumed. It returns the current task, or
+// ErrTaskNotFound / ErrTaskNotResumable when the task must be discarded.
+func (m *Manager) RevalidateActiveTask(ctx context.Context, record *ActiveTask) (*api.Task, error) {
+	task, err := m.GetTask(ctx, record.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.AttackId != record.AttackID {
+		return nil, fmt.Errorf("%w: attack changed from %d to %d", ErrTaskNotResumable, record.AttackID, task.AttackId)
+	}
+
+	switch task.Status {
+	case api.Running, api.Pending, api.Paused:
+		return task, nil
+	case api.Abandoned, api.Completed, api.Exhausted, api.Failed:
+		return nil, fmt.Errorf("%w: status %s", ErrTaskNotResumable, task.Status)
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrTaskNotResumable, task.Status)
+	}
+}
@@ -0,0 +1,124 @@
+package task
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+func TestActiveTask_SaveLoadClear(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	m := NewManager(&api.MockTasksClient{}, &api.MockAttacksClient{})
+	m.Config.ActiveTaskFile = filepath.Join(t.TempDir(), "active_task.json")
+
+	record, err := m.LoadActiveTask()
+	require.NoError(t, err)
+	assert.Nil(t, record, "missing record should load as nil")
+
+	require.NoError(t, m.SaveActiveTask(testhelpers.NewTestTask(12, 34)))
+
+	record, err = m.LoadActiveTask()
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, int64(12), record.TaskID)
+	assert.Equal(t, int64(34), record.AttackID)
+	assert.False(t, record.StartedAt.IsZero())
+
+	m.ClearActiveTask()
+	assert.NoFileExists(t, m.Config.ActiveTaskFile)
+
+	// Clearing twice is harmless.
+	m.ClearActiveTask()
+}
+
+func TestActiveTask_Disabled(t *testing.T) {
+	m := NewManager(&api.MockTasksClient{}, &api.MockAttacksClient{})
+
+	require.NoError(t, m.SaveActiveTask(testhelpers.NewTestTask(1, 2)))
+
+	record, err := m.LoadActiveTask()
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestLoadActiveTask_Corrupt(t *testing.T) {
+	m := NewManager(&api.MockTasksClient{}, &api.MockAttacksClient{})
+	m.Config.ActiveTaskFile = filepath.Join(t.TempDir(), "active_task.json")
+	require.NoError(t, os.WriteFile(m.Config.ActiveTaskFile, []byte(`{"task_id":`), filePermissions))
+
+	_, err := m.LoadActiveTask()
+	require.Error(t, err)
+}
+
+func TestRevalidateActiveTask(t *testing.T) {
+	tests := []struct {
+		name      string
+		task      *api.Task
+		apiErr    error
+		wantErr   error
+		wantValid bool
+	}{
+		{
+			name:      "running task is resumable",
+			task:      &api.Task{Id: 12, AttackId: 34, Status: api.Running},
+			wantValid: true,
+		},
+		{
+			name:      "paused task is resumable",
+			task:      &api.Task{Id: 12, AttackId: 34, Status: api.Paused},
+			wantValid: true,
+		},
+		{
+			name:    "completed task is not resumable",
+			task:    &api.Task{Id: 12, AttackId: 34, Status: api.Completed},
+			wantErr: ErrTaskNotResumable,
+		},
+		{
+			name:    "changed attack is not resumable",
+			task:    &api.Task{Id: 12, AttackId: 99, Status: api.Running},
+			wantErr: ErrTaskNotResumable,
+		},
+		{
+			name:    "task unknown to server",
+			apiErr:  &api.APIError{StatusCode: http.StatusNotFound},
+			wantErr: ErrTaskNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &api.MockTasksClient{
+				GetTaskFunc: func(_ context.Context, _ int64) (*api.GetTaskResponse, error) {
+					if tt.apiErr != nil {
+						return nil, tt.apiErr
+					}
+
+					return &api.GetTaskResponse{
+						HTTPResponse: &http.Response{StatusCode: http.StatusOK},
+						JSON200:      tt.task,
+					}, nil
+				},
+			}
+			m := NewManager(client, &api.MockAttacksClient{})
+
+			got, err := m.RevalidateActiveTask(context.Background(), &ActiveTask{TaskID: 12, AttackID: 34})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValid, got != nil)
+		})
+	}
+}
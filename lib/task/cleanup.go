@@ -6,9 +6,11 @@ import (
 	"strconv"
 
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
 )
 
-// CleanupTaskFiles removes task-related files (hash list and restore file) for the given attack ID.
+// CleanupTaskFiles removes task-related files (hash list, restore file, and the charset files kept
+// with it) for the given attack ID.
 // It is used to clean up files when a task fails before a hashcat session is created,
 // since Session.Cleanup() is not available in those code paths.
 // Resource files (word lists, rule lists, mask lists) are intentionally NOT cleaned here
@@ -28,5 +30,7 @@ func CleanupTaskFiles(attackID int64, hashlistPath, restoreFilePath string) {
 	removeTaskFile(hashFile)
 
 	restoreFile := filepath.Join(restoreFilePath, id+".restore")
-	removeTaskFile(restoreFile)
+	if err := hashcat.RemoveRestoreFile(restoreFile); err != nil {
+		agentstate.Logger.Error("couldn't remove task file", "file", restoreFile, "error", err)
+	}
 }
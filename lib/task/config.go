@@ -1,5 +1,12 @@
 package task
 
+import (
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+)
+
 // Config holds injected path and timer configuration for a Manager.
 // It is a value type (safe to copy).
 type Config struct {
@@ -9,12 +16,50 @@ type Config struct {
 	RestoreFilePath string
 	// FilePath is the directory where attack resource files are stored.
 	FilePath string
+	// PreprocessorsPath is the directory where preprocessor executables are stored.
+	PreprocessorsPath string
+	// ResourceCache stores attack resources by checksum and links them into
+	// FilePath. Nil downloads resources straight into FilePath.
+	ResourceCache *downloader.Cache
+	// PrefetchRateLimit caps the downloads that warm ResourceCache for the next
+	// task while one is running, and with them the disk writes competing with
+	// hashcat. Zero leaves only the bandwidth policy.
+	PrefetchRateLimit bandwidth.Rate
 	// OutPath is the directory where hashcat output files are written.
 	OutPath string
 	// ZapsPath is the directory where zap (cracked hash) files are stored.
 	ZapsPath string
+	// MinFreeDisk is the free space in bytes to keep in the download and output
+	// directories. Tasks whose resources would cut into it are refused, and a
+	// running session is stopped once OutPath, ZapsPath, or RestoreFilePath falls
+	// below it. Zero disables the running check.
+	MinFreeDisk int64
+	// JournalPath is the directory where per-task crack journals are stored.
+	// An empty path disables journaling.
+	JournalPath string
+	// ActiveTaskFile is the path of the record of the in-progress task, used to
+	// resume it after a restart. An empty path disables the record.
+	ActiveTaskFile string
 	// StatusTimer is the interval in seconds between status updates.
 	StatusTimer int
 	// RetainZapsOnCompletion specifies whether zap files are kept after task completion.
 	RetainZapsOnCompletion bool
+	// GPUTempThreshold is the device temperature in Celsius above which the thermal
+	// guard intervenes. Zero disables thermal protection.
+	GPUTempThreshold int
+	// GPUTempTripCount is the number of consecutive over-threshold status updates
+	// before the thermal guard acts (and under-threshold updates before it resumes).
+	GPUTempTripCount int
+	// GPUTempAction selects how the thermal guard responds to an overheating device.
+	GPUTempAction ThermalAction
+	// StallTimeout is how long a running session may go without progress or a
+	// status update before the stall watchdog kills it. Zero disables the watchdog.
+	StallTimeout time.Duration
+	// StallMaxRestarts is how many times a stalled session is restarted from its
+	// restore file before the task is abandoned.
+	StallMaxRestarts int
+	// Slot is the task pool slot this Manager runs tasks for, or zero outside
+	// the pool. Non-zero slots get their own hashcat session names so that
+	// concurrent sessions do not collide.
+	Slot int
 }
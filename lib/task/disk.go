@@ -0,0 +1,254 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/shirou/gopsutil/v4/disk"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/internal/util"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+)
+
+// ErrorCategoryDiskSpace classifies errors caused by a disk running out of
+// space. They are retryable: the task can run once space is freed.
+const ErrorCategoryDiskSpace = "disk_space"
+
+// diskProbeTimeout bounds how long the preflight waits for the servers to
+// report resource sizes.
+const diskProbeTimeout = 30 * time.Second
+
+// diskFree returns the space available to the agent on the filesystem holding
+// path. It is a variable so tests can stub it.
+//
+//nolint:gochecknoglobals // Replaced in tests
+var diskFree = func(path string) (uint64, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return usage.Free, nil
+}
+
+// DiskSpaceError reports a directory without enough free space for a task.
+type DiskSpaceError struct {
+	Path     string
+	Required uint64 // Bytes needed, including the configured floor
+	Free     uint64 // Bytes available
+}
+
+// Error implements the error interface.
+func (e *DiskSpaceError) Error() string {
+	return fmt.Sprintf("not enough disk space in %s: %s needed, %s free",
+		e.Path, humanize.IBytes(e.Required), humanize.IBytes(e.Free))
+}
+
+// ReportOptions returns the classification and context to send with the error.
+func (e *DiskSpaceError) ReportOptions() []cserrors.ErrorOption {
+	return []cserrors.ErrorOption{
+		cserrors.WithClassification(ErrorCategoryDiskSpace, true),
+		cserrors.WithContext(map[string]any{
+			"error_type":     ErrorCategoryDiskSpace,
+			"path":           e.Path,
+			"required_bytes": e.Required,
+			"free_bytes":     e.Free,
+		}),
+	}
+}
+
+// CheckDiskSpace checks that the attack's resources fit on disk before the task
+// is accepted. It asks the servers for the size of each resource still to be
+// downloaded, and of the decompressed copy of each compressed one as its gzip
+// trailer or zstd frame header records it, and compares the total for each
+// target directory, plus Config.MinFreeDisk, with the free space there; OutPath
+// must have the floor free for hashcat's output. It returns a *DiskSpaceError
+// for the first directory that falls short. Sizes that are unknown, such as a
+// bzip2 resource's decompressed size, and directories whose free space cannot
+// be read are skipped, so a failing probe never refuses a task.
+func (m *Manager) CheckDiskSpace(ctx context.Context, attack *api.Attack) error {
+	if attack == nil {
+		return errors.New("attack is nil")
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, diskProbeTimeout)
+	defer cancel()
+
+	needed := map[string]uint64{}
+	if m.Config.OutPath != "" {
+		needed[m.Config.OutPath] = 0
+	}
+
+	for _, resource := range []*api.AttackResourceFile{
+		attack.WordList,
+		attack.RightWordList,
+		attack.RuleList,
+		attack.MaskList,
+	} {
+		if resource == nil {
+			continue
+		}
+
+		destPath := m.resourceTarget(resource)
+
+		pending, err := downloader.PendingBytes(probeCtx, resource.DownloadUrl, destPath)
+		if err != nil || pending < 0 {
+			agentstate.Logger.Debug("Size of attack resource unknown, not counted in disk space check",
+				"resource_id", resource.Id, "file_name", resource.FileName, "error", err)
+		} else {
+			needed[filepath.Dir(destPath)] += uint64(pending)
+		}
+
+		// A streamed word list is never written out decompressed.
+		if resource == attack.WordList && streamsCompressedWordList(attack) {
+			continue
+		}
+
+		plain, err := downloader.PendingPlainBytes(probeCtx, resource.DownloadUrl, destPath)
+		if err != nil || plain < 0 {
+			agentstate.Logger.Debug("Decompressed size of attack resource unknown, not counted in disk space check",
+				"resource_id", resource.Id, "file_name", resource.FileName, "error", err)
+
+			continue
+		}
+
+		needed[filepath.Dir(destPath)] += uint64(plain)
+	}
+
+	for _, dir := range slices.Sorted(maps.Keys(needed)) {
+		free, err := freeSpace(dir)
+		if err != nil {
+			agentstate.Logger.Warn("Cannot read free disk space", "path", dir, "error", err)
+			continue
+		}
+
+		required := needed[dir] + uint64(max(m.Config.MinFreeDisk, 0))
+		if free < required {
+			return &DiskSpaceError{Path: dir, Required: required, Free: free}
+		}
+	}
+
+	return nil
+}
+
+// resourceTarget returns where DownloadFiles writes resource: its cache entry
+// when it is fetched through the cache, otherwise its path in FilePath.
+func (m *Manager) resourceTarget(resource *api.AttackResourceFile) string {
+	cache := m.Config.ResourceCache
+	if cache != nil && !agentstate.State.AlwaysTrustFiles {
+		algorithm := util.UnwrapOr(resource.ChecksumAlgorithm, api.Md5)
+
+		checksum, err := downloader.NewChecksum(string(algorithm), resource.Checksum)
+		if err == nil && !checksum.IsZero() {
+			return cache.EntryPath(checksum)
+		}
+	}
+
+	return filepath.Join(m.Config.FilePath, resource.FileName)
+}
+
+// freeSpace returns the free space for path, measured at its nearest existing
+// ancestor since download directories may not have been created yet.
+func freeSpace(path string) (uint64, error) {
+	path = filepath.Clean(path)
+
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			return diskFree(path)
+		}
+
+		parent := filepath.Dir(path)
+		if !errors.Is(err, fs.ErrNotExist) || parent == path {
+			return 0, err
+		}
+
+		path = parent
+	}
+}
+
+// diskGuard watches the free space where hashcat writes its outfile, restore
+// file, and zaps, and stops the session before the disk fills. It is owned by a
+// single task's event loop goroutine and is not safe for concurrent use.
+type diskGuard struct {
+	floor   uint64
+	paths   []string
+	stopped bool
+}
+
+// newDiskGuard returns a guard keeping floor bytes free in each of paths, or nil
+// when the floor is not positive.
+func newDiskGuard(floor int64, paths ...string) *diskGuard {
+	if floor <= 0 {
+		return nil
+	}
+
+	guard := &diskGuard{floor: uint64(floor)}
+
+	for _, path := range paths {
+		if path != "" && !slices.Contains(guard.paths, path) {
+			guard.paths = append(guard.paths, path)
+		}
+	}
+
+	return guard
+}
+
+// check returns a *DiskSpaceError for the first path whose free space has
+// fallen below the floor, or nil. Once it has reported one, it stays quiet.
+func (g *diskGuard) check() *DiskSpaceError {
+	if g.stopped {
+		return nil
+	}
+
+	for _, path := range g.paths {
+		free, err := freeSpace(path)
+		if err != nil {
+			continue
+		}
+
+		if free < g.floor {
+			g.stopped = true
+			return &DiskSpaceError{Path: path, Required: g.floor, Free: free}
+		}
+	}
+
+	return nil
+}
+
+// applyDiskGuard checks the free space after a status update and, if it has
+// fallen below the floor, checkpoints the session so it stops at the next
+// restore point and reports the shortage to the server. The restore file is
+// kept so the task can continue from it once space is freed.
+func applyDiskGuard(ctx context.Context, guard *diskGuard, task *api.Task, sess *hashcat.Session) {
+	if guard == nil {
+		return
+	}
+
+	diskErr := guard.check()
+	if diskErr == nil {
+		return
+	}
+
+	agentstate.Logger.Error("Free disk space below floor, stopping session at checkpoint",
+		"path", diskErr.Path, "free", diskErr.Free, "floor", diskErr.Required)
+
+	sess.PreserveRestoreFile()
+
+	stopAtCheckpoint(sess, checkpointForDisk)
+
+	cserrors.SendAgentError(ctx, diskErr.Error()+"; task stopped", task, api.SeverityMajor,
+		diskErr.ReportOptions()...)
+}
@@ -0,0 +1,242 @@
+package task
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+// stubDiskFree makes every filesystem report free bytes available.
+func stubDiskFree(t *testing.T, free uint64) {
+	t.Helper()
+
+	original := diskFree
+	diskFree = func(string) (uint64, error) { return free, nil }
+	t.Cleanup(func() { diskFree = original })
+}
+
+// wordListAttack returns an attack whose word list is served by srv.
+func wordListAttack(srv *httptest.Server, content []byte) *api.Attack {
+	sum := sha256.Sum256(content)
+
+	return &api.Attack{
+		Id: 1,
+		WordList: &api.AttackResourceFile{
+			Id:                11,
+			FileName:          "rockyou.txt",
+			DownloadUrl:       srv.URL + "/rockyou.txt",
+			Checksum:          sum[:],
+			ChecksumAlgorithm: new(api.Sha256),
+		},
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	content := bytes.Repeat([]byte("x"), 10_000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "rockyou.txt", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	cacheDir := t.TempDir()
+	mgr := NewManager(&api.MockTasksClient{}, &api.MockAttacksClient{})
+	mgr.Config = Config{
+		FilePath:      t.TempDir(),
+		OutPath:       t.TempDir(),
+		ResourceCache: downloader.NewCache(cacheDir, 0),
+		MinFreeDisk:   1000,
+	}
+
+	tests := []struct {
+		name    string
+		free    uint64
+		wantErr bool
+	}{
+		{name: "fits", free: 11_000},
+		{name: "cuts into the floor", free: 10_500, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubDiskFree(t, tt.free)
+
+			err := mgr.CheckDiskSpace(context.Background(), wordListAttack(srv, content))
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+
+			var diskErr *DiskSpaceError
+			require.ErrorAs(t, err, &diskErr)
+			assert.Equal(t, filepath.Join(cacheDir, "sha256"), diskErr.Path,
+				"resources fetched through the cache should be counted against the cache")
+			assert.Equal(t, uint64(11_000), diskErr.Required)
+			assert.Equal(t, tt.free, diskErr.Free)
+		})
+	}
+}
+
+// TestCheckDiskSpace_DecompressedSize verifies that a compressed resource's
+// decompressed copy is counted, as its gzip trailer records it, unless the word
+// list is streamed to hashcat instead.
+func TestCheckDiskSpace_DecompressedSize(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+	stubDiskFree(t, 5000)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(bytes.Repeat([]byte("x"), 10_000))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	content := buf.Bytes()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "rockyou.txt.gz", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	mgr := NewManager(&api.MockTasksClient{}, &api.MockAttacksClient{})
+	mgr.Config = Config{FilePath: t.TempDir()}
+
+	var diskErr *DiskSpaceError
+	require.ErrorAs(t, mgr.CheckDiskSpace(context.Background(), wordListAttack(srv, content)), &diskErr)
+	assert.Equal(t, uint64(len(content)+10_000), diskErr.Required)
+
+	agentstate.State.CompressedResourceMode = CompressedResourceStream
+	require.NoError(t, mgr.CheckDiskSpace(context.Background(), wordListAttack(srv, content)),
+		"a streamed word list is never decompressed to disk")
+}
+
+// TestCheckDiskSpace_OutPathFloor verifies that the output directory must
+// keep the floor free even when there is nothing to download.
+func TestCheckDiskSpace_OutPathFloor(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+	stubDiskFree(t, 500)
+
+	outPath := t.TempDir()
+	mgr := NewManager(&api.MockTasksClient{}, &api.MockAttacksClient{})
+	mgr.Config = Config{OutPath: outPath, MinFreeDisk: 1000}
+
+	var diskErr *DiskSpaceError
+	require.ErrorAs(t, mgr.CheckDiskSpace(context.Background(), &api.Attack{Id: 1}), &diskErr)
+	assert.Equal(t, outPath, diskErr.Path)
+}
+
+// TestCheckDiskSpace_UnknownSize verifies that a resource whose size cannot be
+// learned does not refuse the task.
+func TestCheckDiskSpace_UnknownSize(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+	stubDiskFree(t, 0)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	mgr := NewManager(&api.MockTasksClient{}, &api.MockAttacksClient{})
+	mgr.Config = Config{FilePath: t.TempDir()}
+
+	require.NoError(t, mgr.CheckDiskSpace(context.Background(), wordListAttack(srv, []byte("x"))))
+}
+
+func TestFreeSpace_UsesExistingAncestor(t *testing.T) {
+	root := t.TempDir()
+
+	var measured string
+
+	original := diskFree
+	diskFree = func(path string) (uint64, error) {
+		measured = path
+		return 42, nil
+	}
+	t.Cleanup(func() { diskFree = original })
+
+	free, err := freeSpace(filepath.Join(root, "cache", "sha256"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), free)
+	assert.Equal(t, root, measured)
+}
+
+func TestNewDiskGuard(t *testing.T) {
+	assert.Nil(t, newDiskGuard(0, "/out"), "a zero floor should disable the guard")
+
+	guard := newDiskGuard(100, "/out", "", "/out", "/restore")
+	require.NotNil(t, guard)
+	assert.Equal(t, []string{"/out", "/restore"}, guard.paths)
+}
+
+func TestDiskGuard_StopIsTerminal(t *testing.T) {
+	dir := t.TempDir()
+	guard := newDiskGuard(1000, dir)
+
+	stubDiskFree(t, 5000)
+	assert.Nil(t, guard.check())
+
+	stubDiskFree(t, 500)
+
+	diskErr := guard.check()
+	require.NotNil(t, diskErr)
+	assert.Equal(t, dir, diskErr.Path)
+	assert.Equal(t, uint64(500), diskErr.Free)
+
+	assert.Nil(t, guard.check(), "a stopped guard should not report again")
+}
+
+// TestApplyDiskGuard_KeepsRestoreFile verifies a session the disk guard stopped
+// at a checkpoint is reported as stopped rather than failed, and keeps its
+// restore file so the task can continue once space is freed.
+func TestApplyDiskGuard_KeepsRestoreFile(t *testing.T) {
+	t.Cleanup(testhelpers.SetupHTTPMock())
+	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+	testhelpers.MockSubmitErrorSuccess(123)
+
+	sess := hashcat.NewTestSession(true)
+	var keys strings.Builder
+	sess.SetTestStdin(&keys)
+
+	restoreFile := filepath.Join(t.TempDir(), "test.restore")
+	require.NoError(t, os.WriteFile(restoreFile, []byte("data"), 0o600))
+	sess.RestoreFilePath = restoreFile
+
+	task := testhelpers.NewTestTask(456, 789)
+	stubDiskFree(t, 500)
+	applyDiskGuard(context.Background(), newDiskGuard(1000, t.TempDir()), task, sess)
+	assert.Equal(t, "c\n", keys.String(), "the guard should request a checkpoint stop")
+
+	taskCtx, taskCancel := context.WithCancel(context.Background())
+	t.Cleanup(taskCancel)
+
+	taskTimer := time.NewTimer(24 * time.Hour)
+	pause := &checkpointPause{}
+	waitChan := make(chan struct{})
+
+	sess.DoneChan <- errors.New("exit status 3")
+	newTestManager().runEventLoop(context.Background(), taskCtx, taskCancel, sess, task,
+		24*time.Hour, taskTimer, pause, nil, waitChan)
+
+	select {
+	case <-waitChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runEventLoop did not exit after the checkpoint stop")
+	}
+
+	assert.False(t, pause.paused, "a guard stop is not a pause")
+	assert.FileExists(t, restoreFile)
+}
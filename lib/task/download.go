@@ -2,88 +2,311 @@ package task
 
 import (
 	"context"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/internal/util"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/display"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/progress"
 )
 
-// DownloadFiles downloads the necessary files for the provided attack.
-// It performs the following steps:
-// 1. Logs the start of the download process.
-// 2. Downloads the hash list associated with the attack.
-// 3. Iterates over resource files (word list, rule list, and mask list) and downloads each one.
-// filePath is the directory where resource files should be saved; hashlistPath is
-// the directory for the downloaded hash list. If any step encounters an error, the
-// function returns that error.
-func DownloadFiles(ctx context.Context, attack *api.Attack, filePath, hashlistPath string) error {
+// Values of compressed_resource_mode.
+const (
+	// CompressedResourceDecompress decompresses compressed resources to disk.
+	CompressedResourceDecompress = "decompress"
+	// CompressedResourceStream streams compressed dictionary attack word lists to
+	// hashcat through stdin and decompresses the other resources.
+	CompressedResourceStream = "stream"
+)
+
+// ResourceError identifies the attack resource whose download failed, so the
+// server can tell which file is broken.
+type ResourceError struct {
+	ResourceID int64
+	FileName   string
+	Err        error
+}
+
+// Error implements the error interface.
+func (e *ResourceError) Error() string {
+	return fmt.Sprintf("attack resource %d (%s): %v", e.ResourceID, e.FileName, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// DownloadFiles downloads the necessary files for the provided attack: the hash
+// list, the resource files (word list, right-hand word list for combinator
+// attacks, rule list, and mask list), and the preprocessor, if any. Up to
+// agentstate.State.DownloadConcurrency files are fetched at once, with their
+// progress combined into a single bar. filePath is the directory where resource
+// files should be saved; preprocessorsPath is the directory for the preprocessor;
+// hashlistPath is the directory for the downloaded hash list. When cache is non-nil, resources with
+// a checksum are fetched through it and stay pinned until the returned release
+// function is called. The first failure cancels the remaining downloads; the
+// function then releases what was pinned and returns that error. Resource failures
+// are returned as *ResourceError.
+func DownloadFiles(
+	ctx context.Context,
+	attack *api.Attack,
+	filePath, preprocessorsPath, hashlistPath string,
+	cache *downloader.Cache,
+) (func(), error) {
 	display.DownloadFileStart(attack)
 
-	if err := downloader.DownloadHashList(ctx, attack, hashlistPath); err != nil {
-		return err
+	if attack == nil {
+		return nil, errors.New("attack is nil")
 	}
 
 	resourceFiles := []*api.AttackResourceFile{
 		attack.WordList,
+		attack.RightWordList,
 		attack.RuleList,
 		attack.MaskList,
 	}
 
-	for _, resource := range resourceFiles {
-		if err := downloadResourceFile(ctx, resource, filePath); err != nil {
-			return err
+	bar := progress.NewAggregate(fmt.Sprintf("attack %d files", attack.Id))
+	defer bar.Finish()
+
+	downloadCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		releases []func()
+	)
+
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+
+	slots := make(chan struct{}, max(agentstate.State.DownloadConcurrency, 1))
+
+	// run downloads one file once a slot is free, cancelling the others on failure.
+	run := func(download func() (func(), error)) {
+		wg.Go(func() {
+			select {
+			case slots <- struct{}{}:
+			case <-downloadCtx.Done():
+				return
+			}
+			defer func() { <-slots }()
+
+			r, err := download()
+			if err != nil {
+				cancel(err)
+				return
+			}
+
+			mu.Lock()
+			releases = append(releases, r)
+			mu.Unlock()
+		})
+	}
+
+	run(func() (func(), error) {
+		return func() {}, downloader.DownloadHashList(downloadCtx, attack, hashlistPath)
+	})
+
+	for _, rd := range uniqueResourceDownloads(attack, resourceFiles, filePath) {
+		run(func() (func(), error) {
+			return downloadResourceFile(downloadCtx, rd.resource, filePath, cache, rd.decompressed,
+				downloader.WithProgress(bar))
+		})
+	}
+
+	if attack.Preprocessor != nil {
+		run(func() (func(), error) {
+			return downloadResourceFile(downloadCtx, attack.Preprocessor, preprocessorsPath, cache, false,
+				downloader.WithProgress(bar))
+		})
+	}
+
+	wg.Wait()
+
+	if err := context.Cause(downloadCtx); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// resourceDownload is a resource file DownloadFiles fetches, and whether it is
+// decompressed once downloaded.
+type resourceDownload struct {
+	resource     *api.AttackResourceFile
+	decompressed bool
+}
+
+// uniqueResourceDownloads returns the non-nil resources to download into
+// filePath, once per destination file. Resources sharing a file, such as the
+// two word lists of a combinator attack on one file, would otherwise race on
+// its partial download. A shared file is decompressed if any of its uses needs
+// it decompressed.
+func uniqueResourceDownloads(
+	attack *api.Attack,
+	resources []*api.AttackResourceFile,
+	filePath string,
+) []resourceDownload {
+	downloads := make([]resourceDownload, 0, len(resources))
+	seen := make(map[string]int, len(resources))
+
+	for _, resource := range resources {
+		if resource == nil {
+			continue
+		}
+
+		// A streamed word list is decompressed as hashcat reads it.
+		decompressed := resource != attack.WordList || !streamsCompressedWordList(attack)
+
+		destPath := filepath.Join(filePath, resource.FileName)
+		if i, ok := seen[destPath]; ok {
+			downloads[i].decompressed = downloads[i].decompressed || decompressed
+			continue
 		}
+
+		seen[destPath] = len(downloads)
+		downloads = append(downloads, resourceDownload{resource: resource, decompressed: decompressed})
 	}
 
-	return nil
+	return downloads
 }
 
 // downloadResourceFile downloads a resource file if the provided resource is not nil.
 // Constructs the file path based on filePath (the resource directory) and the resource file name.
-// If checksum verification is not always skipped, converts the checksum bytes to hex.
-// Downloads the file using the resource's download URL, target file path, and checksum for verification.
-// Logs and sends an error report if file download fails or if the downloaded file is empty.
-func downloadResourceFile(ctx context.Context, resource *api.AttackResourceFile, filePath string) error {
+// Unless checksum verification is always skipped, builds the checksum from the resource's digest and algorithm.
+// Downloads the file using the resource's download URL, target file path, and checksum for verification,
+// through cache when it is non-nil and the resource has a checksum; opts apply to the download. The
+// checksum covers the file as served, so compressed resources are verified before they are
+// decompressed; when decompressed is set, a compressed resource is then decompressed next to it. The
+// returned function releases the cache pin and is never nil on success.
+// Failures, including an empty downloaded file, are returned as *ResourceError and reported to the
+// server with the resource ID, unless ctx was cancelled because a sibling download failed first.
+func downloadResourceFile(
+	ctx context.Context,
+	resource *api.AttackResourceFile,
+	filePath string,
+	cache *downloader.Cache,
+	decompressed bool,
+	opts ...downloader.Option,
+) (func(), error) {
+	release := func() {}
+
 	if resource == nil {
-		return nil
+		return release, nil
 	}
 
 	destPath := filepath.Join(filePath, resource.FileName)
 	agentstate.Logger.Debug("Downloading resource file", "url", resource.DownloadUrl, "path", destPath)
 
-	checksum := ""
+	fail := func(message string, err error) error {
+		resourceErr := &ResourceError{ResourceID: resource.Id, FileName: resource.FileName, Err: err}
+		if ctx.Err() != nil {
+			return resourceErr
+		}
+
+		//nolint:errcheck,gosec // resourceErr is returned below
+		cserrors.LogAndSendError(ctx, message, resourceErr, api.SeverityCritical, nil,
+			cserrors.WithContext(map[string]any{
+				"resource_id": resource.Id,
+				"file_name":   resource.FileName,
+			}))
+
+		return resourceErr
+	}
+
+	var checksum downloader.Checksum
 	if !agentstate.State.AlwaysTrustFiles {
-		checksum = hex.EncodeToString(resource.Checksum)
+		var err error
+
+		algorithm := util.UnwrapOr(resource.ChecksumAlgorithm, api.Md5)
+
+		checksum, err = downloader.NewChecksum(string(algorithm), resource.Checksum)
+		if err != nil {
+			return nil, fail("Invalid checksum for attack resource "+resource.FileName, err)
+		}
 	} else {
 		agentstate.Logger.Debug("Skipping checksum verification")
 	}
 
-	if err := downloader.DownloadFile(ctx, resource.DownloadUrl, destPath, checksum); err != nil {
-		return cserrors.LogAndSendError(ctx, "Error downloading attack resource", err, api.SeverityCritical, nil)
+	cached := cache != nil && !checksum.IsZero()
+
+	var err error
+	if cached {
+		release, err = cache.Fetch(ctx, resource.DownloadUrl, checksum, destPath, opts...)
+	} else {
+		err = downloader.DownloadFile(ctx, resource.DownloadUrl, destPath, checksum, opts...)
+	}
+
+	if err != nil {
+		return nil, fail("Error downloading attack resource "+resource.FileName, err)
 	}
 
 	fileInfo, statErr := os.Stat(destPath)
 	if statErr != nil {
-		return cserrors.LogAndSendError(ctx, "Error checking downloaded file", statErr, api.SeverityCritical, nil)
+		release()
+		return nil, fail("Error checking downloaded file", statErr)
 	}
 
 	if fileInfo.Size() == 0 {
-		return cserrors.LogAndSendError(
-			ctx,
-			"Downloaded file is empty: "+destPath,
-			fmt.Errorf("file %s has zero bytes", destPath),
-			api.SeverityCritical,
-			nil,
-		)
+		release()
+
+		return nil, fail("Downloaded file is empty: "+destPath, fmt.Errorf("file %s has zero bytes", destPath))
+	}
+
+	if decompressed {
+		if err := decompressResource(destPath, cached, cache, checksum); err != nil {
+			release()
+			return nil, fail("Error decompressing attack resource "+resource.FileName, err)
+		}
 	}
 
 	agentstate.Logger.Debug("Downloaded resource file", "path", destPath)
 
-	return nil
+	return release, nil
+}
+
+// decompressResource writes the decompressed copy hashcat reads for a compressed
+// resource at destPath to destPath+decompress.PlainSuffix. When the resource came
+// from cache, the copy is kept in the cache alongside the entry and linked into
+// place. Uncompressed resources are left alone.
+func decompressResource(destPath string, cached bool, cache *downloader.Cache, checksum downloader.Checksum) error {
+	format, err := decompress.Detect(destPath)
+	if err != nil || format == decompress.None {
+		return err
+	}
+
+	agentstate.Logger.Debug("Decompressing resource file", "path", destPath, "format", format)
+
+	plainPath := destPath + decompress.PlainSuffix
+	if cached {
+		return cache.LinkDecompressed(checksum, plainPath)
+	}
+
+	return decompress.File(destPath, plainPath)
+}
+
+// streamsCompressedWordList reports whether a compressed word list of attack is
+// fed to hashcat through stdin rather than decompressed to disk, as selected by
+// compressed_resource_mode. Hashcat only reads dictionary attack word lists from
+// stdin; it still falls back to decompressing when the task uses --skip or --limit.
+// A preprocessor attack's word list is read by the preprocessor, so is never streamed.
+func streamsCompressedWordList(attack *api.Attack) bool {
+	return agentstate.State.CompressedResourceMode == CompressedResourceStream &&
+		hashcat.CanStreamWordList(int64(attack.AttackModeHashcat)) && attack.Preprocessor == nil
 }
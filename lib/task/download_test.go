@@ -1,14 +1,26 @@
 package task
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/decompress"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
 )
 
@@ -44,7 +56,7 @@ func TestDownloadResourceFile_UsesInjectedFilePath(t *testing.T) {
 		Checksum:    []byte{},
 	}
 
-	err := downloadResourceFile(context.Background(), resource, injectedDir)
+	_, err := downloadResourceFile(context.Background(), resource, injectedDir, nil, true)
 	require.NoError(t, err, "should succeed: file already valid at injected path")
 
 	// The file must exist at injectedDir — confirming the injected path was used.
@@ -56,3 +68,299 @@ func TestDownloadResourceFile_UsesInjectedFilePath(t *testing.T) {
 	_, wrongErr := os.Stat(wrongFile)
 	require.True(t, os.IsNotExist(wrongErr), "file should NOT be at agentstate.State.FilePath")
 }
+
+// TestDownloadResourceFile_SHA256Checksum verifies that a resource declaring a
+// SHA-256 checksum is verified with SHA-256. The pre-created file matches, so no
+// download is attempted.
+func TestDownloadResourceFile_SHA256Checksum(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	agentstate.State.AlwaysTrustFiles = false
+
+	dir := t.TempDir()
+	content := []byte("word1\nword2\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wordlist.txt"), content, 0o600))
+
+	sum := sha256.Sum256(content)
+	algorithm := api.Sha256
+	resource := &api.AttackResourceFile{
+		FileName:          "wordlist.txt",
+		DownloadUrl:       "http://127.0.0.1:1/unused", // never reached; file is already valid
+		Checksum:          sum[:],
+		ChecksumAlgorithm: &algorithm,
+	}
+
+	_, err := downloadResourceFile(context.Background(), resource, dir, nil, true)
+	require.NoError(t, err)
+}
+
+// TestDownloadResourceFile_Cache verifies that a resource with a checksum is
+// served from the resource cache: a valid file already in place is moved into
+// the cache and linked back, so no download is attempted.
+func TestDownloadResourceFile_Cache(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	agentstate.State.AlwaysTrustFiles = false
+
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+	content := []byte("word1\nword2\n")
+	destPath := filepath.Join(dir, "wordlist.txt")
+	require.NoError(t, os.WriteFile(destPath, content, 0o600))
+
+	sum := sha256.Sum256(content)
+	algorithm := api.Sha256
+	resource := &api.AttackResourceFile{
+		FileName:          "wordlist.txt",
+		DownloadUrl:       "http://127.0.0.1:1/unused", // never reached; file is adopted into the cache
+		Checksum:          sum[:],
+		ChecksumAlgorithm: &algorithm,
+	}
+
+	release, err := downloadResourceFile(context.Background(), resource, dir, downloader.NewCache(cacheDir, 0), true)
+	require.NoError(t, err)
+	require.NotNil(t, release)
+	t.Cleanup(release)
+
+	data, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+	require.FileExists(t, filepath.Join(cacheDir, "sha256", hex.EncodeToString(sum[:])))
+}
+
+// TestDownloadResourceFile_Decompress verifies that a compressed resource gets
+// a decompressed copy next to it, unless it is left compressed to be streamed.
+func TestDownloadResourceFile_Decompress(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	agentstate.State.AlwaysTrustFiles = true
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte("word1\nword2\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	dir := t.TempDir()
+	for _, name := range []string{"decompressed.txt.gz", "streamed.txt.gz"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0o600))
+	}
+
+	resource := func(name string) *api.AttackResourceFile {
+		return &api.AttackResourceFile{
+			FileName:    name,
+			DownloadUrl: "http://127.0.0.1:1/unused", // never reached; file is already valid
+			Checksum:    []byte{},
+		}
+	}
+
+	_, err = downloadResourceFile(context.Background(), resource("decompressed.txt.gz"), dir, nil, true)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "decompressed.txt.gz"+decompress.PlainSuffix))
+	require.NoError(t, err)
+	require.Equal(t, "word1\nword2\n", string(data))
+
+	_, err = downloadResourceFile(context.Background(), resource("streamed.txt.gz"), dir, nil, false)
+	require.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(dir, "streamed.txt.gz"+decompress.PlainSuffix))
+}
+
+// setupDownloadFilesState configures the agent for DownloadFiles tests: an API
+// client serving a one-line hash list and recording submitted errors, and no
+// download retries.
+func setupDownloadFilesState(t *testing.T, concurrency int) *[]api.SubmitErrorAgentJSONRequestBody {
+	t.Helper()
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	prevConcurrency := agentstate.State.DownloadConcurrency
+	prevRetries := agentstate.State.DownloadMaxRetries
+	prevTrust := agentstate.State.AlwaysTrustFiles
+	t.Cleanup(func() {
+		agentstate.State.DownloadConcurrency = prevConcurrency
+		agentstate.State.DownloadMaxRetries = prevRetries
+		agentstate.State.AlwaysTrustFiles = prevTrust
+		agentstate.State.SetAPIClient(nil)
+	})
+
+	agentstate.State.DownloadConcurrency = concurrency
+	agentstate.State.DownloadMaxRetries = 1
+	agentstate.State.AlwaysTrustFiles = true
+
+	var (
+		mu        sync.Mutex
+		submitted []api.SubmitErrorAgentJSONRequestBody
+	)
+
+	agentstate.State.SetAPIClient(&api.MockClient{
+		AttacksImpl: &api.MockAttacksClient{
+			GetHashListFunc: func(_ context.Context, _ int64) (*api.GetHashListResponse, error) {
+				return &api.GetHashListResponse{
+					Body:         []byte("5f4dcc3b5aa765d61d8327deb882cf99\n"),
+					HTTPResponse: &http.Response{StatusCode: http.StatusOK},
+				}, nil
+			},
+		},
+		AgentsImpl: &api.MockAgentsClient{
+			SubmitErrorAgentFunc: func(
+				_ context.Context,
+				_ int64,
+				body api.SubmitErrorAgentJSONRequestBody,
+			) (*api.SubmitErrorAgentResponse, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				submitted = append(submitted, body)
+
+				return &api.SubmitErrorAgentResponse{HTTPResponse: &http.Response{StatusCode: http.StatusNoContent}}, nil
+			},
+		},
+	})
+
+	return &submitted
+}
+
+// testAttack returns an attack whose word list, rule list, and mask list are
+// served from baseURL.
+func testAttack(baseURL string) *api.Attack {
+	resource := func(id int64, name string) *api.AttackResourceFile {
+		return &api.AttackResourceFile{Id: id, FileName: name, DownloadUrl: baseURL + "/" + name}
+	}
+
+	return &api.Attack{
+		Id:       7,
+		WordList: resource(11, "rockyou.txt"),
+		RuleList: resource(12, "best64.rule"),
+		MaskList: resource(13, "masks.hcmask"),
+	}
+}
+
+// TestDownloadFiles_Concurrent verifies that resources are downloaded in
+// parallel, never exceeding the configured limit.
+func TestDownloadFiles_Concurrent(t *testing.T) {
+	setupDownloadFilesState(t, 2)
+
+	var inFlight, peak atomic.Int32
+
+	bothStarted := make(chan struct{})
+
+	var once sync.Once
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		if n == 2 {
+			once.Do(func() { close(bothStarted) })
+		}
+
+		// Hold each download until two are running at once.
+		select {
+		case <-bothStarted:
+		case <-time.After(5 * time.Second):
+		}
+
+		_, _ = w.Write([]byte("contents of " + r.URL.Path + "\n"))
+	}))
+	t.Cleanup(srv.Close)
+
+	filesDir := t.TempDir()
+	hashlistDir := t.TempDir()
+
+	release, err := DownloadFiles(context.Background(), testAttack(srv.URL), filesDir, t.TempDir(), hashlistDir, nil)
+	require.NoError(t, err)
+	release()
+
+	require.Equal(t, int32(2), peak.Load(), "downloads should run two at a time")
+
+	for _, name := range []string{"rockyou.txt", "best64.rule", "masks.hcmask"} {
+		require.FileExists(t, filepath.Join(filesDir, name))
+	}
+
+	require.FileExists(t, filepath.Join(hashlistDir, "7.hsh"))
+}
+
+// TestDownloadFiles_SharedFile verifies that a combinator attack using one
+// file for both word lists downloads it once, even without the cache.
+func TestDownloadFiles_SharedFile(t *testing.T) {
+	setupDownloadFilesState(t, 4)
+
+	var requests sync.Map
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, _ := requests.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		count.(*atomic.Int32).Add(1) //nolint:forcetypeassert // only *atomic.Int32 is stored
+
+		_, _ = w.Write([]byte("contents of " + r.URL.Path + "\n"))
+	}))
+	t.Cleanup(srv.Close)
+
+	attack := testAttack(srv.URL)
+	attack.RightWordList = &api.AttackResourceFile{
+		Id: 11, FileName: "rockyou.txt", DownloadUrl: srv.URL + "/rockyou.txt",
+	}
+
+	filesDir := t.TempDir()
+
+	release, err := DownloadFiles(context.Background(), attack, filesDir, t.TempDir(), t.TempDir(), nil)
+	require.NoError(t, err)
+	release()
+
+	count, ok := requests.Load("/rockyou.txt")
+	require.True(t, ok)
+	require.Equal(t, int32(1), count.(*atomic.Int32).Load(), //nolint:forcetypeassert // only *atomic.Int32 is stored
+		"the shared word list is downloaded once")
+	require.FileExists(t, filepath.Join(filesDir, "rockyou.txt"))
+}
+
+// TestDownloadFiles_FailureCancelsSiblings verifies that the first failed
+// download cancels the others and is reported with its resource ID.
+func TestDownloadFiles_FailureCancelsSiblings(t *testing.T) {
+	submitted := setupDownloadFilesState(t, 4)
+
+	siblingCancelled := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/best64.rule" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Other downloads hang until the failure cancels them.
+		select {
+		case <-r.Context().Done():
+			close(siblingCancelled)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	attack := testAttack(srv.URL)
+	attack.MaskList = nil
+
+	_, err := DownloadFiles(context.Background(), attack, t.TempDir(), t.TempDir(), t.TempDir(), nil)
+
+	var resourceErr *ResourceError
+	require.ErrorAs(t, err, &resourceErr)
+	require.Equal(t, int64(12), resourceErr.ResourceID)
+	require.Contains(t, err.Error(), "attack resource 12 (best64.rule)")
+
+	select {
+	case <-siblingCancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the word list download should have been cancelled")
+	}
+
+	require.Len(t, *submitted, 1, "only the failure, not the cancelled siblings, should be reported")
+
+	metadata, err := json.Marshal((*submitted)[0].Metadata)
+	require.NoError(t, err)
+	require.Contains(t, string(metadata), `"resource_id":12`)
+}
@@ -25,9 +25,10 @@ func handleAPIError(ctx context.Context, message string, err error) {
 }
 
 // handleStatusUpdateError handles specific error types during a status update and logs or processes them accordingly.
-// On task-not-found (404) or task-gone (410), it cancels taskCtx via taskCancel so the event loop tears the
-// session down through its single Kill+Cleanup path, rather than killing the session inline (which left the
-// loop blocked until the 24h task timer).
+// On task-not-found (404), it cancels taskCtx via taskCancel so the event loop tears the session down through its
+// single Kill+Cleanup path, rather than killing the session inline (which left the loop blocked until the 24h task
+// timer). On task-gone (410), which the server sends once it has paused the task, the session is stopped at its next
+// checkpoint instead, keeping its progress.
 func handleStatusUpdateError(
 	ctx context.Context,
 	err error,
@@ -42,7 +43,7 @@ func handleStatusUpdateError(
 	}
 
 	if apierrors.IsGoneError(err) {
-		handleTaskGone(ctx, task, sess, taskCancel)
+		handleTaskGone(ctx, task, sess)
 		return
 	}
 
@@ -62,12 +63,19 @@ func handleTaskNotFound(_ context.Context, task *api.Task, _ *hashcat.Session, t
 	taskCancel()
 }
 
-// handleTaskGone handles the termination of a task when it is no longer needed,
-// signalling taskCancel so the event loop tears the session down on its own.
-func handleTaskGone(_ context.Context, task *api.Task, _ *hashcat.Session, taskCancel context.CancelFunc) {
-	agentstate.Logger.Info("Pausing task", "task_id", task.Id)
+// handleTaskGone handles a task the server has paused. It asks the session to
+// stop at its next checkpoint and keeps the restore file, so the event loop
+// reports the task as paused once hashcat exits there and the task can later
+// continue from the checkpoint. Repeated 410 responses are ignored.
+func handleTaskGone(_ context.Context, task *api.Task, sess *hashcat.Session) {
+	if sess.CheckpointRequested(checkpointForTaskPause) {
+		return
+	}
 
-	taskCancel()
+	agentstate.Logger.Info("Task paused by server, stopping task at next checkpoint", "task_id", task.Id)
+
+	sess.PreserveRestoreFile()
+	stopAtCheckpoint(sess, checkpointForTaskPause)
 }
 
 // handleAcceptTaskError handles errors that occur when attempting to accept a task.
@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
 )
 
@@ -57,7 +63,7 @@ func TestHandleStatusUpdateError(t *testing.T) {
 			name:             "APIError_Gone",
 			err:              testhelpers.NewAPIError(http.StatusGone, "gone"),
 			task:             testhelpers.NewTestTask(456, 789),
-			wantCancelCalled: true,
+			wantCancelCalled: false,
 		},
 		{
 			name:             "generic error",
@@ -93,7 +99,7 @@ func TestHandleStatusUpdateError(t *testing.T) {
 			handleStatusUpdateError(context.Background(), tt.err, tt.task, sess, taskCancel)
 
 			assert.Equal(t, tt.wantCancelCalled, cancelCalled,
-				"taskCancel invocation should match the 404/410 classification")
+				"taskCancel invocation should match the 404 classification")
 		})
 	}
 }
@@ -120,25 +126,47 @@ func TestHandleTaskNotFound(t *testing.T) {
 	assert.True(t, cancelCalled, "handleTaskNotFound must signal taskCancel")
 }
 
-// TestHandleTaskGone asserts that a gone task signals taskCancel rather than
-// killing the session inline.
+// TestHandleTaskGone asserts that a task the server paused (410) is stopped at
+// its next checkpoint once, rather than cancelled, and keeps its restore file
+// through the event loop's handling of the checkpoint exit.
 func TestHandleTaskGone(t *testing.T) {
-	cleanupState := testhelpers.SetupTestState(123, "https://test.api", "test-token")
-	t.Cleanup(cleanupState)
+	t.Cleanup(testhelpers.SetupHTTPMock())
+	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+	testhelpers.MockSubmitErrorSuccess(123)
 
-	sess, err := testhelpers.NewMockSession("test-session")
-	if err != nil {
-		t.Skipf("Skipping test: failed to create mock session: %v", err)
-		return
-	}
-	t.Cleanup(sess.Cleanup)
+	var buf strings.Builder
+	sess := hashcat.NewTestSession(true)
+	sess.SetTestStdin(&buf)
 
-	cancelCalled := false
-	taskCancel := func() { cancelCalled = true }
+	restoreFile := filepath.Join(t.TempDir(), "789.restore")
+	require.NoError(t, os.WriteFile(restoreFile, []byte("data"), 0o600))
+	sess.RestoreFilePath = restoreFile
+
+	task := testhelpers.NewTestTask(456, 789)
+	handleTaskGone(context.Background(), task, sess)
+	handleTaskGone(context.Background(), task, sess)
 
-	handleTaskGone(context.Background(), testhelpers.NewTestTask(456, 789), sess, taskCancel)
+	assert.Equal(t, "c\n", buf.String(), "repeated 410 responses send the checkpoint key once")
+	assert.True(t, sess.CheckpointRequested(checkpointForTaskPause))
+
+	taskCtx, taskCancel := context.WithCancel(context.Background())
+	t.Cleanup(taskCancel)
+
+	pause := &checkpointPause{}
+	waitChan := make(chan struct{})
+	sess.DoneChan <- errors.New("exit status 3")
+	newTestManager().runEventLoop(context.Background(), taskCtx, taskCancel, sess,
+		task, 24*time.Hour, time.NewTimer(24*time.Hour), pause, nil, waitChan)
+
+	select {
+	case <-waitChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runEventLoop did not exit after the checkpoint stop")
+	}
 
-	assert.True(t, cancelCalled, "handleTaskGone must signal taskCancel")
+	require.NoError(t, taskCtx.Err(), "a paused task is not cancelled")
+	assert.False(t, pause.paused, "the task is not resumed by the agent")
+	assert.FileExists(t, restoreFile)
 }
 
 // TestHandleAcceptTaskError tests the handleAcceptTaskError function.
@@ -0,0 +1,524 @@
+package task
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/apierrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/metrics"
+)
+
+const (
+	journalExt = ".journal" // File extension of per-task crack journals
+
+	journalOpCrack = "crack" // Journal record for a cracked hash awaiting delivery
+	journalOpAck   = "ack"   // Journal record marking a crack as accepted by the server
+
+	journalDrainAttempts = 3               // Delivery attempts per crack when a task finishes
+	journalRetryDelay    = 2 * time.Second // Base delay between delivery attempts (linear backoff)
+)
+
+// ErrJournalClosed is returned when writing to a crack journal that has been closed.
+var ErrJournalClosed = errors.New("crack journal is closed")
+
+// journalRecord is a single line in a crack journal file.
+type journalRecord struct {
+	Op        string    `json:"op"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	Hash      string    `json:"hash"`
+	Plaintext string    `json:"plaintext"`
+}
+
+// crackKey identifies a crack for deduplication.
+type crackKey struct {
+	hash      string
+	plaintext string
+}
+
+// crackJournal is an append-only, fsync'd on-disk record of the cracks found for a
+// single task. Every crack is written before it is sent to the server and an ack
+// record is appended once the server accepts it, so cracks that could not be
+// delivered (API outage, open circuit breaker, agent crash) survive and can be
+// replayed later. It is safe for concurrent use.
+type crackJournal struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	pending []journalRecord       // unacknowledged cracks, in discovery order
+	seen    map[crackKey]struct{} // every crack recorded, pending or acknowledged
+	acked   map[crackKey]struct{} // cracks accepted by the server
+}
+
+// journalPath returns the journal file path for a task.
+func journalPath(dir string, taskID int64) string {
+	return filepath.Join(dir, strconv.FormatInt(taskID, 10)+journalExt)
+}
+
+// openCrackJournal opens (creating if needed) the journal for a task and loads any
+// records left by a previous run. A truncated trailing record from an interrupted
+// write is skipped with a warning.
+func openCrackJournal(dir string, taskID int64) (*crackJournal, error) {
+	path := journalPath(dir, taskID)
+	j := &crackJournal{
+		path:  path,
+		seen:  make(map[crackKey]struct{}),
+		acked: make(map[crackKey]struct{}),
+	}
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("opening crack journal %s: %w", path, err)
+	}
+
+	j.file = file
+
+	return j, nil
+}
+
+// load replays the journal file into memory.
+func (j *crackJournal) load() error {
+	file, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("reading crack journal %s: %w", j.path, err)
+	}
+	defer file.Close() //nolint:errcheck // read-only file
+
+	var records []journalRecord
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var rec journalRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			agentstate.Logger.Warn("Skipping corrupt crack journal record", "path", j.path, "error", err)
+
+			continue
+		}
+
+		records = append(records, rec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading crack journal %s: %w", j.path, err)
+	}
+
+	for _, rec := range records {
+		key := crackKey{hash: rec.Hash, plaintext: rec.Plaintext}
+
+		switch rec.Op {
+		case journalOpCrack:
+			if _, ok := j.seen[key]; ok {
+				continue
+			}
+
+			j.seen[key] = struct{}{}
+			j.pending = append(j.pending, rec)
+		case journalOpAck:
+			j.seen[key] = struct{}{}
+			j.acked[key] = struct{}{}
+		}
+	}
+
+	j.pending = j.unacked()
+
+	return nil
+}
+
+// unacked returns the pending records that have not been acknowledged.
+func (j *crackJournal) unacked() []journalRecord {
+	kept := j.pending[:0]
+	for _, rec := range j.pending {
+		if _, ok := j.acked[crackKey{hash: rec.Hash, plaintext: rec.Plaintext}]; !ok {
+			kept = append(kept, rec)
+		}
+	}
+
+	return kept
+}
+
+// append writes a record and fsyncs it. The caller must hold j.mu.
+func (j *crackJournal) append(rec journalRecord) error {
+	if j.file == nil {
+		return ErrJournalClosed
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding crack journal record: %w", err)
+	}
+
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing crack journal %s: %w", j.path, err)
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("syncing crack journal %s: %w", j.path, err)
+	}
+
+	return nil
+}
+
+// record durably stores a newly found crack. It returns false without writing when
+// the crack is already in the journal (pending or acknowledged).
+func (j *crackJournal) record(timestamp time.Time, hash, plaintext string) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key := crackKey{hash: hash, plaintext: plaintext}
+	if _, ok := j.seen[key]; ok {
+		return false, nil
+	}
+
+	rec := journalRecord{Op: journalOpCrack, Timestamp: timestamp, Hash: hash, Plaintext: plaintext}
+	if err := j.append(rec); err != nil {
+		return false, err
+	}
+
+	j.seen[key] = struct{}{}
+	j.pending = append(j.pending, rec)
+
+	return true, nil
+}
+
+// isAcked reports whether the server has already accepted the crack.
+func (j *crackJournal) isAcked(hash, plaintext string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, ok := j.acked[crackKey{hash: hash, plaintext: plaintext}]
+
+	return ok
+}
+
+// ack durably marks a crack as delivered to the server.
+func (j *crackJournal) ack(hash, plaintext string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key := crackKey{hash: hash, plaintext: plaintext}
+	if _, ok := j.acked[key]; ok {
+		return nil
+	}
+
+	if err := j.append(journalRecord{Op: journalOpAck, Hash: hash, Plaintext: plaintext}); err != nil {
+		return err
+	}
+
+	j.seen[key] = struct{}{}
+	j.acked[key] = struct{}{}
+	j.pending = j.unacked()
+
+	return nil
+}
+
+// pendingRecords returns a copy of the cracks not yet accepted by the server.
+func (j *crackJournal) pendingRecords() []journalRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]journalRecord(nil), j.pending...)
+}
+
+// close closes the journal file. It is idempotent.
+func (j *crackJournal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+
+	err := j.file.Close()
+	j.file = nil
+
+	if err != nil {
+		return fmt.Errorf("closing crack journal %s: %w", j.path, err)
+	}
+
+	return nil
+}
+
+// remove closes the journal and deletes its file.
+func (j *crackJournal) remove() error {
+	if err := j.close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing crack journal %s: %w", j.path, err)
+	}
+
+	return nil
+}
+
+// journalTaskIDs returns the task IDs of all crack journals found in dir.
+func journalTaskIDs(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("listing crack journals in %s: %w", dir, err)
+	}
+
+	var ids []int64
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, journalExt) {
+			continue
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSuffix(name, journalExt), 10, 64)
+		if err != nil {
+			agentstate.Logger.Warn("Ignoring unrecognized file in journal directory", "file", name)
+
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// taskJournal returns the open crack journal for a task, opening it on first use.
+// It returns nil when journaling is disabled (no JournalPath configured) or the
+// journal cannot be opened; the latter is reported to the server.
+func (m *Manager) taskJournal(ctx context.Context, task *api.Task) *crackJournal {
+	if m.Config.JournalPath == "" {
+		return nil
+	}
+
+	m.journalMu.Lock()
+	defer m.journalMu.Unlock()
+
+	if journal, ok := m.journals[task.Id]; ok {
+		return journal
+	}
+
+	journal, err := openCrackJournal(m.Config.JournalPath, task.Id)
+	if err != nil {
+		//nolint:errcheck // LogAndSendError handles logging+sending internally
+		_ = cserrors.LogAndSendError(ctx, "Error opening crack journal", err, api.SeverityCritical, task)
+
+		return nil
+	}
+
+	if m.journals == nil {
+		m.journals = make(map[int64]*crackJournal)
+	}
+
+	m.journals[task.Id] = journal
+
+	return journal
+}
+
+// releaseJournal detaches a task's journal from the manager, returning nil if none is open.
+func (m *Manager) releaseJournal(taskID int64) *crackJournal {
+	m.journalMu.Lock()
+	defer m.journalMu.Unlock()
+
+	journal := m.journals[taskID]
+	delete(m.journals, taskID)
+
+	return journal
+}
+
+// isJournalOpen reports whether a task's journal is owned by a running task.
+func (m *Manager) isJournalOpen(taskID int64) bool {
+	m.journalMu.Lock()
+	defer m.journalMu.Unlock()
+
+	_, ok := m.journals[taskID]
+
+	return ok
+}
+
+// replayPendingCracks makes a single delivery attempt for each crack still pending
+// in the task's journal. It is called after a successful status update, which
+// shows the server is reachable again.
+func (m *Manager) replayPendingCracks(ctx context.Context, task *api.Task) {
+	if m.Config.JournalPath == "" || !m.isJournalOpen(task.Id) {
+		return
+	}
+
+	journal := m.taskJournal(ctx, task)
+	if journal == nil || len(journal.pendingRecords()) == 0 {
+		return
+	}
+
+	if remaining := m.drainJournal(ctx, task.Id, journal, 1); remaining > 0 {
+		agentstate.Logger.Debug("Cracks still pending in journal", "task_id", task.Id, "pending", remaining)
+	}
+}
+
+// finishJournal drains a finished task's journal with retries. A fully delivered
+// journal is deleted; otherwise it is kept on disk so ReplayJournals can deliver
+// the remaining cracks once the server is reachable again.
+func (m *Manager) finishJournal(ctx context.Context, task *api.Task) {
+	journal := m.releaseJournal(task.Id)
+	if journal == nil {
+		return
+	}
+
+	m.settleJournal(ctx, task.Id, journal, journalDrainAttempts)
+}
+
+// ReplayJournals delivers cracks left in journals by earlier tasks or a previous
+// agent run. Journals owned by a running task are skipped. Fully delivered
+// journals are removed.
+func (m *Manager) ReplayJournals(ctx context.Context) {
+	if m.Config.JournalPath == "" {
+		return
+	}
+
+	taskIDs, err := journalTaskIDs(m.Config.JournalPath)
+	if err != nil {
+		agentstate.Logger.Error("Failed to list crack journals", "error", err)
+
+		return
+	}
+
+	for _, taskID := range taskIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if m.isJournalOpen(taskID) {
+			continue
+		}
+
+		journal, err := openCrackJournal(m.Config.JournalPath, taskID)
+		if err != nil {
+			agentstate.Logger.Error("Failed to open crack journal for replay", "task_id", taskID, "error", err)
+
+			continue
+		}
+
+		m.settleJournal(ctx, taskID, journal, 1)
+	}
+}
+
+// settleJournal drains a journal that is no longer in use, then removes it when
+// empty or closes it for a later replay.
+func (m *Manager) settleJournal(ctx context.Context, taskID int64, journal *crackJournal, attempts int) {
+	if remaining := m.drainJournal(ctx, taskID, journal, attempts); remaining > 0 {
+		agentstate.Logger.Warn("Cracks could not be delivered, keeping journal for replay",
+			"task_id", taskID, "pending", remaining, "path", journal.path)
+
+		if err := journal.close(); err != nil {
+			agentstate.Logger.Error("Failed to close crack journal", "error", err)
+		}
+
+		return
+	}
+
+	if err := journal.remove(); err != nil {
+		agentstate.Logger.Error("Failed to remove crack journal", "error", err)
+	}
+}
+
+// drainJournal sends every pending crack to the server, making up to attempts
+// tries per crack. It stops at the first crack that cannot be delivered (the
+// server is presumably unreachable) and returns the number still pending. Cracks
+// the server rejects for good (see isPermanentCrackError) are dropped.
+func (m *Manager) drainJournal(ctx context.Context, taskID int64, journal *crackJournal, attempts int) int {
+	for _, rec := range journal.pendingRecords() {
+		err := m.sendJournalRecord(ctx, taskID, rec, attempts)
+		if err != nil && !isPermanentCrackError(err) {
+			agentstate.Logger.Debug("Crack replay failed", "task_id", taskID, "error", err)
+
+			return len(journal.pendingRecords())
+		}
+
+		if err != nil {
+			agentstate.Logger.Warn("Server rejected journaled crack, dropping it",
+				"task_id", taskID, "hash", rec.Hash, "error", err)
+		}
+
+		if ackErr := journal.ack(rec.Hash, rec.Plaintext); ackErr != nil {
+			agentstate.Logger.Error("Failed to acknowledge crack in journal", "task_id", taskID, "error", ackErr)
+
+			return len(journal.pendingRecords())
+		}
+	}
+
+	return 0
+}
+
+// sendJournalRecord sends one journaled crack, retrying with a linear backoff.
+// It gives up immediately when the circuit breaker is open or the error is permanent.
+func (m *Manager) sendJournalRecord(ctx context.Context, taskID int64, rec journalRecord, attempts int) error {
+	result := api.HashcatResult{Timestamp: rec.Timestamp, Hash: rec.Hash, PlainText: rec.Plaintext}
+
+	var err error
+
+	for attempt := range max(attempts, 1) {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(journalRetryDelay * time.Duration(attempt)):
+			}
+		}
+
+		if _, err = m.tasksClient.SendCrack(ctx, taskID, result); err == nil {
+			metrics.Default.AddCrackSent()
+
+			return nil
+		}
+
+		if apierrors.IsCircuitOpen(err) || isPermanentCrackError(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// isPermanentCrackError reports whether the server rejected a crack for good:
+// the task no longer exists (404, 410) or the crack itself is invalid (422).
+// Other client errors, such as an expired token (401), a timeout (408), or rate
+// limiting (429), may pass on a later try, so the crack stays pending.
+func isPermanentCrackError(err error) bool {
+	var ae *api.APIError
+	if !errors.As(err, &ae) {
+		return false
+	}
+
+	switch ae.StatusCode {
+	case http.StatusNotFound, http.StatusGone, http.StatusUnprocessableEntity:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,269 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+var errServerDown = errors.New("connection refused")
+
+// newJournalTestManager returns a Manager whose SendCrack is served by sendCrack
+// and whose journals live in a temporary directory.
+func newJournalTestManager(
+	t *testing.T,
+	sendCrack func(context.Context, int64, api.HashcatResult) (*api.SendCrackResponse, error),
+) *Manager {
+	t.Helper()
+
+	m := NewManager(&api.MockTasksClient{SendCrackFunc: sendCrack}, &api.MockAttacksClient{})
+	m.Config.JournalPath = t.TempDir()
+
+	return m
+}
+
+func crackAccepted(context.Context, int64, api.HashcatResult) (*api.SendCrackResponse, error) {
+	return &api.SendCrackResponse{HTTPResponse: &http.Response{StatusCode: http.StatusOK}}, nil
+}
+
+func TestCrackJournal_RecordAckAndReload(t *testing.T) {
+	dir := t.TempDir()
+
+	journal, err := openCrackJournal(dir, 42)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	added, err := journal.record(now, "hash1", "pass1")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = journal.record(now, "hash2", "pass2")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	// Duplicates are not written twice.
+	added, err = journal.record(now, "hash1", "pass1")
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	require.NoError(t, journal.ack("hash1", "pass1"))
+	require.NoError(t, journal.close())
+
+	reopened, err := openCrackJournal(dir, 42)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = reopened.close() })
+
+	pending := reopened.pendingRecords()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "hash2", pending[0].Hash)
+	assert.Equal(t, "pass2", pending[0].Plaintext)
+	assert.True(t, pending[0].Timestamp.Equal(now))
+	assert.True(t, reopened.isAcked("hash1", "pass1"))
+
+	// An acknowledged crack found again is still recognized as a duplicate.
+	added, err = reopened.record(now, "hash1", "pass1")
+	require.NoError(t, err)
+	assert.False(t, added)
+}
+
+func TestCrackJournal_SkipsTruncatedRecord(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"op":"crack","hash":"hash1","plaintext":"pass1"}` + "\n" + `{"op":"crack","hash":"ha`
+	require.NoError(t, os.WriteFile(journalPath(dir, 7), []byte(content), filePermissions))
+
+	journal, err := openCrackJournal(dir, 7)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = journal.close() })
+
+	pending := journal.pendingRecords()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "hash1", pending[0].Hash)
+}
+
+func TestCrackJournal_WriteAfterClose(t *testing.T) {
+	journal, err := openCrackJournal(t.TempDir(), 1)
+	require.NoError(t, err)
+	require.NoError(t, journal.close())
+
+	_, err = journal.record(time.Now(), "hash", "pass")
+	require.ErrorIs(t, err, ErrJournalClosed)
+}
+
+func TestJournalTaskIDs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"12.journal", "34.journal", "notes.txt", "bogus.journal"} {
+		require.NoError(t, os.WriteFile(dir+"/"+name, nil, filePermissions))
+	}
+
+	ids, err := journalTaskIDs(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{12, 34}, ids)
+
+	ids, err = journalTaskIDs(dir + "/missing")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestSendCrackedHash_JournalsFailedSend(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	m := newJournalTestManager(t, func(context.Context, int64, api.HashcatResult) (*api.SendCrackResponse, error) {
+		return nil, errServerDown
+	})
+	tsk := testhelpers.NewTestTask(5, 6)
+
+	m.sendCrackedHash(context.Background(), time.Now(), "hash", "pass", tsk)
+
+	pending := m.journals[tsk.Id].pendingRecords()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "hash", pending[0].Hash)
+}
+
+func TestSendCrackedHash_SkipsDeliveredDuplicate(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	calls := 0
+	m := newJournalTestManager(t, func(ctx context.Context, id int64, r api.HashcatResult) (*api.SendCrackResponse, error) {
+		calls++
+		return crackAccepted(ctx, id, r)
+	})
+	tsk := testhelpers.NewTestTask(5, 6)
+
+	m.sendCrackedHash(context.Background(), time.Now(), "hash", "pass", tsk)
+	m.sendCrackedHash(context.Background(), time.Now(), "hash", "pass", tsk)
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestFinishJournal(t *testing.T) {
+	tests := []struct {
+		name        string
+		sendErr     error
+		expectFile  bool
+		expectCalls int
+	}{
+		{name: "delivered journal is removed", sendErr: nil, expectFile: false, expectCalls: 1},
+		{
+			name:        "rejected crack is dropped",
+			sendErr:     &api.APIError{StatusCode: http.StatusNotFound},
+			expectFile:  false,
+			expectCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+			calls := 0
+			m := newJournalTestManager(t,
+				func(ctx context.Context, id int64, r api.HashcatResult) (*api.SendCrackResponse, error) {
+					calls++
+					if tt.sendErr != nil {
+						return nil, tt.sendErr
+					}
+
+					return crackAccepted(ctx, id, r)
+				})
+			tsk := testhelpers.NewTestTask(9, 10)
+
+			journal := m.taskJournal(context.Background(), tsk)
+			require.NotNil(t, journal)
+			_, err := journal.record(time.Now(), "hash", "pass")
+			require.NoError(t, err)
+
+			m.finishJournal(context.Background(), tsk)
+
+			_, statErr := os.Stat(journalPath(m.Config.JournalPath, tsk.Id))
+			assert.Equal(t, tt.expectFile, statErr == nil)
+			assert.Equal(t, tt.expectCalls, calls)
+			assert.False(t, m.isJournalOpen(tsk.Id))
+		})
+	}
+}
+
+func TestReplayJournals(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	serverUp := false
+	var delivered []string
+	m := newJournalTestManager(t, func(ctx context.Context, id int64, r api.HashcatResult) (*api.SendCrackResponse, error) {
+		if !serverUp {
+			return nil, errServerDown
+		}
+
+		delivered = append(delivered, r.Hash)
+
+		return crackAccepted(ctx, id, r)
+	})
+
+	// Leave a journal behind as if a previous run could not reach the server.
+	journal, err := openCrackJournal(m.Config.JournalPath, 77)
+	require.NoError(t, err)
+	_, err = journal.record(time.Now(), "hash1", "pass1")
+	require.NoError(t, err)
+	_, err = journal.record(time.Now(), "hash2", "pass2")
+	require.NoError(t, err)
+	require.NoError(t, journal.close())
+
+	m.ReplayJournals(context.Background())
+	assert.FileExists(t, journalPath(m.Config.JournalPath, 77), "undelivered journal must be kept")
+
+	serverUp = true
+	m.ReplayJournals(context.Background())
+	assert.Equal(t, []string{"hash1", "hash2"}, delivered)
+	assert.NoFileExists(t, journalPath(m.Config.JournalPath, 77))
+}
+
+func TestReplayJournals_SkipsOpenJournal(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	calls := 0
+	m := newJournalTestManager(t, func(ctx context.Context, id int64, r api.HashcatResult) (*api.SendCrackResponse, error) {
+		calls++
+		return crackAccepted(ctx, id, r)
+	})
+	tsk := testhelpers.NewTestTask(3, 4)
+
+	journal := m.taskJournal(context.Background(), tsk)
+	require.NotNil(t, journal)
+	_, err := journal.record(time.Now(), "hash", "pass")
+	require.NoError(t, err)
+
+	m.ReplayJournals(context.Background())
+
+	assert.Zero(t, calls)
+	assert.Len(t, journal.pendingRecords(), 1)
+	require.NoError(t, journal.close())
+}
+
+func TestIsPermanentCrackError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "not found", err: testhelpers.NewAPIError(http.StatusNotFound, "not found"), expected: true},
+		{name: "gone", err: testhelpers.NewAPIError(http.StatusGone, "gone"), expected: true},
+		{name: "unprocessable", err: testhelpers.NewAPIError(http.StatusUnprocessableEntity, "invalid"), expected: true},
+		{name: "unauthorized", err: testhelpers.NewAPIError(http.StatusUnauthorized, "unauthorized"), expected: false},
+		{name: "request timeout", err: testhelpers.NewAPIError(http.StatusRequestTimeout, "timeout"), expected: false},
+		{name: "rate limited", err: testhelpers.NewAPIError(http.StatusTooManyRequests, "slow down"), expected: false},
+		{name: "server error", err: testhelpers.NewAPIError(http.StatusInternalServerError, "oops"), expected: false},
+		{name: "network error", err: errServerDown, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isPermanentCrackError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+)
+
+// ErrorCategoryKeyspace classifies tasks whose skip and limit do not fit the
+// keyspace the agent's hashcat computes for the attack.
+const ErrorCategoryKeyspace = "keyspace"
+
+// keyspaceTimeout bounds how long the preflight waits for hashcat --keyspace.
+const keyspaceTimeout = 2 * time.Minute
+
+// errKeyspaceNotReported is returned when hashcat --keyspace exits without
+// printing a keyspace.
+var errKeyspaceNotReported = errors.New("hashcat did not report a keyspace")
+
+// KeyspaceError reports a task range that does not fit the attack's keyspace
+// as computed by the agent's hashcat.
+type KeyspaceError struct {
+	Keyspace int64 // Keyspace reported by hashcat --keyspace
+	Skip     int64 // Task's --skip
+	Limit    int64 // Task's --limit, or zero for the rest of the keyspace
+}
+
+// Error implements the error interface.
+func (e *KeyspaceError) Error() string {
+	if e.Skip >= e.Keyspace {
+		return fmt.Sprintf("task skip %d is beyond the attack keyspace %d", e.Skip, e.Keyspace)
+	}
+
+	return fmt.Sprintf("task range %d+%d runs past the attack keyspace %d", e.Skip, e.Limit, e.Keyspace)
+}
+
+// ReportOptions returns the classification and context to send with the error.
+func (e *KeyspaceError) ReportOptions() []cserrors.ErrorOption {
+	return []cserrors.ErrorOption{
+		cserrors.WithClassification(ErrorCategoryKeyspace, false),
+		cserrors.WithContext(map[string]any{
+			"error_type": ErrorCategoryKeyspace,
+			"keyspace":   e.Keyspace,
+			"skip":       e.Skip,
+			"limit":      e.Limit,
+		}),
+	}
+}
+
+// checkKeyspace compares the task's skip and limit, as set in params, with
+// the keyspace the agent's hashcat computes for the attack. A skip at or past
+// the end of the keyspace, on which hashcat would exit at once, is reported,
+// the task is abandoned so the server can reassign its range, and a
+// *KeyspaceError is returned. A limit running past the end, which would leave
+// the task short of the work the server assigned, is reported as a warning and
+// the task runs. Tasks without a range, and attacks whose candidates arrive on
+// stdin, are not checked; a preflight that fails is logged and the task runs.
+func (m *Manager) checkKeyspace(ctx context.Context, task *api.Task, attackID int64, params hashcat.Params) error {
+	if params.Skip <= 0 && params.Limit <= 0 {
+		return nil
+	}
+
+	keyspace, err := m.attackKeyspace(ctx, attackID, params)
+	if err != nil {
+		if !errors.Is(err, hashcat.ErrKeyspaceStdin) {
+			agentstate.Logger.Warn("Keyspace preflight failed, running task without it",
+				"attack_id", attackID, "error", err)
+		}
+
+		return nil
+	}
+
+	keyspaceErr := &KeyspaceError{Keyspace: keyspace, Skip: params.Skip, Limit: params.Limit}
+
+	switch {
+	case params.Skip >= keyspace:
+		agentstate.ErrorLogger.Error("Task range does not fit the attack keyspace, abandoning task", "error", keyspaceErr)
+		cserrors.SendAgentError(ctx, keyspaceErr.Error()+"; task abandoned", task, api.SeverityCritical,
+			keyspaceErr.ReportOptions()...)
+		m.AbandonTask(ctx, task)
+
+		return keyspaceErr
+	case params.Limit > keyspace-params.Skip:
+		agentstate.Logger.Warn("Task range runs past the attack keyspace", "error", keyspaceErr)
+		cserrors.SendAgentError(ctx, keyspaceErr.Error()+"; running to the end of the keyspace", task,
+			api.SeverityWarning, keyspaceErr.ReportOptions()...)
+	}
+
+	return nil
+}
+
+// attackKeyspace returns the keyspace of the attack, running hashcat
+// --keyspace the first time it is asked for an attack and caching the result.
+func (m *Manager) attackKeyspace(ctx context.Context, attackID int64, params hashcat.Params) (int64, error) {
+	m.keyspaceMu.Lock()
+	keyspace, ok := m.keyspaces[attackID]
+	m.keyspaceMu.Unlock()
+
+	if ok {
+		return keyspace, nil
+	}
+
+	keyspace, err := runKeyspace(ctx, "keyspace-"+m.sessionID(attackID), params)
+	if err != nil {
+		return 0, err
+	}
+
+	agentstate.Logger.Debug("Computed attack keyspace", "attack_id", attackID, "keyspace", keyspace)
+
+	m.keyspaceMu.Lock()
+	defer m.keyspaceMu.Unlock()
+
+	if m.keyspaces == nil {
+		m.keyspaces = make(map[int64]int64)
+	}
+
+	m.keyspaces[attackID] = keyspace
+
+	return keyspace, nil
+}
+
+// runKeyspace runs a hashcat --keyspace session for the attack and returns the
+// keyspace it reports.
+func runKeyspace(ctx context.Context, id string, params hashcat.Params) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, keyspaceTimeout)
+	defer cancel()
+
+	sess, err := hashcat.NewKeyspaceSession(ctx, id, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create keyspace session: %w", err)
+	}
+	defer sess.Cleanup()
+
+	if err := sess.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start keyspace session: %w", err)
+	}
+
+	return collectKeyspace(ctx, sess)
+}
+
+// collectKeyspace reads the keyspace from a started hashcat --keyspace session
+// and waits for it to exit. A keyspace printed before a failing exit is kept.
+func collectKeyspace(ctx context.Context, sess *hashcat.Session) (int64, error) {
+	var (
+		keyspace int64
+		found    bool
+		lastErr  string
+	)
+
+	readLine := func(line string) {
+		if value, ok := hashcat.ParseKeyspace(line); ok {
+			keyspace, found = value, true
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("keyspace session: %w", ctx.Err())
+		case line := <-sess.StdoutLines:
+			readLine(line)
+		case errInfo := <-sess.StderrMessages:
+			lastErr = errInfo.Message
+		case <-sess.StatusUpdates:
+			// --keyspace does not produce status updates; drain if any.
+		case <-sess.CrackedHashes:
+			// --keyspace does not crack hashes; drain if any.
+		case procErr := <-sess.DoneChan:
+			for drained := false; !drained; {
+				select {
+				case line := <-sess.StdoutLines:
+					readLine(line)
+				default:
+					drained = true
+				}
+			}
+
+			switch {
+			case found:
+				return keyspace, nil
+			case procErr != nil && lastErr != "":
+				return 0, fmt.Errorf("keyspace session failed: %w: %s", procErr, lastErr)
+			case procErr != nil:
+				return 0, fmt.Errorf("keyspace session failed: %w", procErr)
+			default:
+				return 0, errKeyspaceNotReported
+			}
+		}
+	}
+}
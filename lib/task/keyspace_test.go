@@ -0,0 +1,88 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+func TestCollectKeyspace(t *testing.T) {
+	sess := hashcat.NewTestSession(true)
+	sess.StdoutLines <- "14344384"
+	sess.DoneChan <- nil
+
+	keyspace, err := collectKeyspace(context.Background(), sess)
+	require.NoError(t, err)
+	assert.Equal(t, int64(14344384), keyspace)
+}
+
+func TestCollectKeyspace_Failure(t *testing.T) {
+	sess := hashcat.NewTestSession(true)
+	sess.DoneChan <- errors.New("exit status 255")
+
+	_, err := collectKeyspace(context.Background(), sess)
+	require.ErrorContains(t, err, "exit status 255")
+
+	sess.DoneChan <- nil
+
+	_, err = collectKeyspace(context.Background(), sess)
+	require.ErrorIs(t, err, errKeyspaceNotReported)
+}
+
+// TestCheckKeyspace verifies that task ranges are checked against the cached
+// keyspace: a skip past the end abandons the task, and a limit past the end
+// only warns.
+func TestCheckKeyspace(t *testing.T) {
+	submitted := setupDownloadFilesState(t, 1)
+
+	abandoned := false
+	mgr := NewManager(&api.MockTasksClient{
+		SetTaskAbandonedFunc: func(_ context.Context, _ int64) (*api.SetTaskAbandonedResponse, error) {
+			abandoned = true
+			return &api.SetTaskAbandonedResponse{}, nil
+		},
+	}, &api.MockAttacksClient{})
+	mgr.keyspaces = map[int64]int64{789: 1000}
+	task := testhelpers.NewTestTask(456, 789)
+
+	ranges := []hashcat.Params{
+		{},
+		{Skip: 0, Limit: 500},
+		{Skip: 500, Limit: 500},
+	}
+	for _, params := range ranges {
+		require.NoError(t, mgr.checkKeyspace(context.Background(), task, 789, params))
+	}
+
+	assert.Empty(t, *submitted, "ranges inside the keyspace are not reported")
+
+	require.NoError(t, mgr.checkKeyspace(context.Background(), task, 789, hashcat.Params{Skip: 900, Limit: 500}))
+	require.Len(t, *submitted, 1)
+	assert.Equal(t, api.SeverityWarning, (*submitted)[0].Severity)
+	assert.Contains(t, (*submitted)[0].Message, "running to the end of the keyspace")
+	assert.False(t, abandoned)
+
+	err := mgr.checkKeyspace(context.Background(), task, 789, hashcat.Params{Skip: 1000, Limit: 500})
+
+	var keyspaceErr *KeyspaceError
+	require.ErrorAs(t, err, &keyspaceErr)
+	assert.Equal(t, int64(1000), keyspaceErr.Keyspace)
+	assert.True(t, abandoned, "a skip past the keyspace abandons the task")
+
+	require.Len(t, *submitted, 2)
+	assert.Equal(t, api.SeverityCritical, (*submitted)[1].Severity)
+	assert.Contains(t, (*submitted)[1].Message, "task skip 1000 is beyond the attack keyspace 1000")
+	assert.Contains(t, (*submitted)[1].Message, "task abandoned")
+
+	metadata, err := json.Marshal((*submitted)[1].Metadata)
+	require.NoError(t, err)
+	assert.Contains(t, string(metadata), `"category":"keyspace"`)
+	assert.Contains(t, string(metadata), `"retryable":false`)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"sync"
 
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/internal/util"
@@ -29,6 +30,12 @@ type Manager struct {
 	DeviceConfig  devices.DeviceConfig
 	// Config holds injected path and timer configuration for this Manager.
 	Config Config
+
+	journalMu sync.Mutex              // Guards journals
+	journals  map[int64]*crackJournal // Open crack journals keyed by task ID
+
+	keyspaceMu sync.Mutex      // Guards keyspaces
+	keyspaces  map[int64]int64 // Keyspaces computed by hashcat, keyed by attack ID
 }
 
 // NewManager creates a new task Manager with the given API clients.
@@ -161,6 +168,11 @@ func (m *Manager) AbandonTask(ctx context.Context, task *api.Task) {
 
 // RunTask performs a hashcat attack based on the provided task and attack objects.
 // It initializes the task, creates job parameters, starts the hashcat session, and handles task completion or errors.
+// When a restore file from an earlier checkpoint exists, the session resumes from it.
+// The task's skip and limit are first checked against the attack's keyspace, and
+// a task whose skip lies past its end is abandoned without running.
+// A session killed by the stall watchdog is restarted from its restore file up to
+// Config.StallMaxRestarts times before the task is abandoned.
 func (m *Manager) RunTask(ctx context.Context, task *api.Task, attack *api.Attack) error {
 	display.RunTaskStarting(task)
 
@@ -170,30 +182,87 @@ func (m *Manager) RunTask(ctx context.Context, task *api.Task, attack *api.Attac
 
 	jobParams := m.createJobParams(task, attack)
 
-	sess, err := hashcat.NewHashcatSession(ctx, strconv.FormatInt(attack.Id, 10), jobParams)
-	if err != nil {
-		if detail := hashFileErrorDetail(err); detail != "" {
-			agentstate.ErrorLogger.Error("Hash file validation failed", "error", err)
-			cserrors.SendAgentError(
-				ctx, "Hash file validation failed", task, api.SeverityCritical,
-				cserrors.WithClassification("file_access", false),
-				cserrors.WithContext(map[string]any{
-					"error_type":    "hash_file_validation",
-					"detail":        detail,
-					"error_message": err.Error(),
-				}),
-			)
-		} else {
-			return cserrors.LogAndSendError(ctx, "Failed to create attack session", err, api.SeverityCritical, task)
+	if err := m.checkKeyspace(ctx, task, attack.Id, jobParams); err != nil {
+		return err
+	}
+
+	for restarts := 0; ; restarts++ {
+		sess, err := m.newSession(ctx, task, attack, jobParams)
+		if err != nil {
+			return err
 		}
 
-		return err
+		err = m.runAttackTask(ctx, sess, task)
+
+		var stallErr *StallError
+		if !errors.As(err, &stallErr) {
+			if err == nil {
+				display.RunTaskCompleted()
+			}
+
+			return err
+		}
+
+		stallErr.Restarts = restarts
+		if err := m.handleStalledSession(ctx, task, attack, sess, stallErr); err != nil {
+			return err
+		}
+	}
+}
+
+// newSession creates the hashcat session for a task, reporting hash file and
+// association pairing problems to the server with their own classification.
+func (m *Manager) newSession(
+	ctx context.Context,
+	task *api.Task,
+	attack *api.Attack,
+	jobParams hashcat.Params,
+) (*hashcat.Session, error) {
+	sess, err := hashcat.NewHashcatSession(ctx, m.sessionID(attack.Id), jobParams)
+	if err == nil {
+		return sess, nil
 	}
 
-	m.runAttackTask(ctx, sess, task)
-	display.RunTaskCompleted()
+	switch detail := hashFileErrorDetail(err); {
+	case detail != "":
+		agentstate.ErrorLogger.Error("Hash file validation failed", "error", err)
+		cserrors.SendAgentError(
+			ctx, "Hash file validation failed", task, api.SeverityCritical,
+			cserrors.WithClassification("file_access", false),
+			cserrors.WithContext(map[string]any{
+				"error_type":    "hash_file_validation",
+				"detail":        detail,
+				"error_message": err.Error(),
+			}),
+		)
+	case errors.Is(err, hashcat.ErrAssociationLineCount):
+		// The server paired the attack with a hint word list generated for a
+		// different hash list; retrying on any agent fails the same way.
+		agentstate.ErrorLogger.Error("Association attack word list does not match the hash list", "error", err)
+		cserrors.SendAgentError(
+			ctx, "Association attack word list does not match the hash list", task, api.SeverityCritical,
+			cserrors.WithClassification(hashcat.ErrorCategoryConfiguration.String(), false),
+			cserrors.WithContext(map[string]any{
+				"error_type":    "association_pairing",
+				"error_message": err.Error(),
+			}),
+		)
+	default:
+		return nil, cserrors.LogAndSendError(ctx, "Failed to create attack session", err, api.SeverityCritical, task)
+	}
 
-	return nil
+	return nil, err
+}
+
+// sessionID returns the hashcat session identifier for an attack, qualified
+// with the pool slot when the Manager serves one.
+func (m *Manager) sessionID(attackID int64) string {
+	id := strconv.FormatInt(attackID, 10)
+	if m.Config.Slot > 0 {
+		id += "-slot" + strconv.Itoa(m.Config.Slot)
+	}
+
+	return id
 }
 
 // createJobParams creates hashcat parameters from the given Task and Attack objects.
@@ -215,26 +284,50 @@ func (m *Manager) createJobParams(task *api.Task, attack *api.Attack) hashcat.Pa
 			util.UnwrapOr(attack.CustomCharset3, ""),
 			util.UnwrapOr(attack.CustomCharset4, ""),
 		},
-		WordListFilename: resourceNameOrBlank(attack.WordList),
-		RuleListFilename: resourceNameOrBlank(attack.RuleList),
-		MaskListFilename: resourceNameOrBlank(attack.MaskList),
-		AdditionalArgs:   arch.GetAdditionalHashcatArgs(),
-		OptimizedKernels: attack.Optimized,
-		SlowCandidates:   attack.SlowCandidateGenerators,
-		Skip:             util.UnwrapOr(task.Skip, 0),
-		Limit:            util.UnwrapOr(task.Limit, 0),
-		BackendDevices:   m.DeviceConfig.ResolvedBackendDevices(),
-		OpenCLDevices:    m.DeviceConfig.ResolvedOpenCLDevices(),
+		WordListFilename:      resourceNameOrBlank(attack.WordList),
+		RightWordListFilename: resourceNameOrBlank(attack.RightWordList),
+		LeftRule:              util.UnwrapOr(attack.LeftRule, ""),
+		RightRule:             util.UnwrapOr(attack.RightRule, ""),
+		RuleListFilename:      resourceNameOrBlank(attack.RuleList),
+		MaskListFilename:      resourceNameOrBlank(attack.MaskList),
+		PreprocessorFilename:  resourceNameOrBlank(attack.Preprocessor),
+		PreprocessorArgs:      util.UnwrapOr(attack.PreprocessorArgs, nil),
+		Separator:             util.UnwrapOr(attack.HashListSeparator, ""),
+		AdditionalArgs:        arch.GetAdditionalHashcatArgs(),
+		OptimizedKernels:      attack.Optimized,
+		SlowCandidates:        attack.SlowCandidateGenerators,
+		WorkloadProfile:       int64(attack.WorkloadProfile),
+		ClassicMarkov:         attack.ClassicMarkov,
+		DisableMarkov:         attack.DisableMarkov,
+		MarkovThreshold:       int64(util.UnwrapOr(attack.MarkovThreshold, 0)),
+		Skip:                  util.UnwrapOr(task.Skip, 0),
+		Limit:                 util.UnwrapOr(task.Limit, 0),
+		BackendDevices:        m.DeviceConfig.ResolvedBackendDevices(),
+		OpenCLDevices:         m.DeviceConfig.ResolvedOpenCLDevices(),
 		RestoreFilePath: filepath.Join(
 			m.Config.RestoreFilePath,
 			strconv.FormatInt(attack.Id, 10)+".restore",
 		),
-		OutPath:                m.Config.OutPath,
-		ZapsPath:               m.Config.ZapsPath,
-		FilePath:               m.Config.FilePath,
-		StatusTimer:            m.Config.StatusTimer,
-		RetainZapsOnCompletion: m.Config.RetainZapsOnCompletion,
+		OutPath:                  m.Config.OutPath,
+		ZapsPath:                 m.Config.ZapsPath,
+		StreamCompressedWordList: streamsCompressedWordList(attack),
+		FilePath:                 m.Config.FilePath,
+		PreprocessorsPath:        m.Config.PreprocessorsPath,
+		StatusTimer:              m.Config.StatusTimer,
+		RetainZapsOnCompletion:   m.Config.RetainZapsOnCompletion,
+		HwmonTempAbort:           m.hwmonTempAbort(),
+	}
+}
+
+// hwmonTempAbort returns the --hwmon-temp-abort value to pass to hashcat: the
+// configured GPU temperature threshold when enforcement is delegated to hashcat,
+// otherwise zero (hashcat's built-in default applies).
+func (m *Manager) hwmonTempAbort() int64 {
+	if m.Config.GPUTempAction != ThermalActionAbort || m.Config.GPUTempThreshold <= 0 {
+		return 0
 	}
+
+	return int64(m.Config.GPUTempThreshold)
 }
 
 func resourceNameOrBlank(resource *api.AttackResourceFile) string {
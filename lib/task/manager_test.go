@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
 )
 
@@ -38,6 +39,7 @@ func newTestManager() *Manager {
 		FilePath:               agentstate.State.FilePath,
 		OutPath:                agentstate.State.OutPath,
 		ZapsPath:               agentstate.State.ZapsPath,
+		JournalPath:            agentstate.State.JournalPath,
 		StatusTimer:            agentstate.State.StatusTimer,
 		RetainZapsOnCompletion: agentstate.State.RetainZapsOnCompletion,
 	}
@@ -366,3 +368,96 @@ func TestCreateJobParams_UsesConfigValues(t *testing.T) {
 	assert.Equal(t, 42, params.StatusTimer)
 	assert.True(t, params.RetainZapsOnCompletion)
 }
+
+// TestCreateJobParams_Combinator verifies that the right-hand wordlist and the
+// -j/-k rules of a combinator attack are mapped into hashcat.Params.
+func TestCreateJobParams_Combinator(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	leftRule := "c"
+	rightRule := "$!"
+	attack := &api.Attack{
+		Id:                78,
+		AttackModeHashcat: 1,
+		HashMode:          0,
+		WordList:          &api.AttackResourceFile{FileName: "left.txt"},
+		RightWordList:     &api.AttackResourceFile{FileName: "right.txt"},
+		LeftRule:          &leftRule,
+		RightRule:         &rightRule,
+	}
+	tsk := testhelpers.NewTestTask(1, 78)
+
+	params := (&Manager{}).createJobParams(tsk, attack)
+
+	assert.Equal(t, int64(1), params.AttackMode)
+	assert.Equal(t, "left.txt", params.WordListFilename)
+	assert.Equal(t, "right.txt", params.RightWordListFilename)
+	assert.Equal(t, "c", params.LeftRule)
+	assert.Equal(t, "$!", params.RightRule)
+	assert.NoError(t, params.Validate())
+}
+
+// TestCreateJobParams_Association verifies that an association attack's hint
+// word list is mapped into hashcat.Params.
+func TestCreateJobParams_Association(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	attack := &api.Attack{
+		Id:                79,
+		AttackModeHashcat: 9,
+		HashMode:          0,
+		WordList:          &api.AttackResourceFile{FileName: "hints.txt"},
+	}
+	tsk := testhelpers.NewTestTask(1, 79)
+
+	params := (&Manager{}).createJobParams(tsk, attack)
+
+	assert.Equal(t, int64(hashcat.AttackModeAssociation), params.AttackMode)
+	assert.Equal(t, "hints.txt", params.WordListFilename)
+	assert.NoError(t, params.Validate())
+}
+
+// TestCreateJobParams_TuningOptions verifies that the server's workload profile
+// and Markov settings are carried into hashcat.Params.
+func TestCreateJobParams_TuningOptions(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	threshold := 48
+	attack := &api.Attack{
+		Id:                79,
+		AttackModeHashcat: 3,
+		WorkloadProfile:   4,
+		ClassicMarkov:     true,
+		DisableMarkov:     false,
+		MarkovThreshold:   &threshold,
+	}
+	tsk := testhelpers.NewTestTask(1, 79)
+
+	params := (&Manager{}).createJobParams(tsk, attack)
+
+	assert.Equal(t, int64(4), params.WorkloadProfile)
+	assert.True(t, params.ClassicMarkov)
+	assert.False(t, params.DisableMarkov)
+	assert.Equal(t, int64(48), params.MarkovThreshold)
+}
+
+// TestCreateJobParams_HashListSeparator verifies that the hash list separator
+// is carried into hashcat.Params, and that attacks without one use hashcat's.
+func TestCreateJobParams_HashListSeparator(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	tsk := testhelpers.NewTestTask(1, 79)
+
+	params := (&Manager{}).createJobParams(tsk, &api.Attack{Id: 79, AttackModeHashcat: 3})
+	assert.Empty(t, params.Separator)
+
+	separator := "|"
+	params = (&Manager{}).createJobParams(tsk, &api.Attack{Id: 79, AttackModeHashcat: 3, HashListSeparator: &separator})
+	assert.Equal(t, "|", params.Separator)
+}
+
+// TestSessionID verifies that pool slots get distinct hashcat session names.
+func TestSessionID(t *testing.T) {
+	assert.Equal(t, "42", (&Manager{}).sessionID(42))
+	assert.Equal(t, "42-slot3", (&Manager{Config: Config{Slot: 3}}).sessionID(42))
+}
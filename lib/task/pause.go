@@ -0,0 +1,201 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+)
+
+// ErrTaskPaused is returned by RunTask when the session stopped at a checkpoint
+// because the server asked the agent to pause. The restore file is kept so the
+// task can be resumed with another RunTask call.
+var ErrTaskPaused = errors.New("task paused at checkpoint")
+
+// Reasons the task package asks a session to stop at its next checkpoint. They
+// are recorded on the session, so the pauses and the guards share hashcat's
+// checkpoint toggle without cancelling each other's stop. checkpointForPause
+// follows the agent-wide pause of heartbeat responses; checkpointForTaskPause
+// is the pause of a single task, signalled by a 410 on a status update.
+const (
+	checkpointForPause     hashcat.CheckpointReason = "pause"
+	checkpointForTaskPause hashcat.CheckpointReason = "task_paused"
+	checkpointForThermal   hashcat.CheckpointReason = "thermal"
+	checkpointForDisk      hashcat.CheckpointReason = "disk_space"
+)
+
+// checkpointPause tracks a server-requested pause for one running session. It is
+// owned by the session's event loop goroutine and is not safe for concurrent use.
+type checkpointPause struct {
+	paused bool // session exited at the checkpoint
+}
+
+// sync reconciles the session with the server's pause directive. A pause
+// requests a checkpoint stop; a directive lifted before hashcat reached the
+// checkpoint withdraws the request, which cancels the stop unless a guard has
+// requested one too. A session that cannot stop at a checkpoint is killed by
+// the request and has nothing left to reconcile.
+func (p *checkpointPause) sync(sess *hashcat.Session) {
+	want := agentstate.State.GetPauseRequested()
+	if want == sess.CheckpointRequested(checkpointForPause) || sess.CheckpointKilled() {
+		return
+	}
+
+	if want {
+		err := sess.RequestCheckpoint(checkpointForPause)
+		if errors.Is(err, hashcat.ErrCheckpointUnsupported) {
+			logCheckpointUnsupported(checkpointForPause, err)
+
+			return
+		}
+
+		if err != nil {
+			agentstate.Logger.Error("Failed to request hashcat checkpoint stop for pause", "error", err)
+
+			return
+		}
+
+		agentstate.Logger.Info("Pause requested by server, stopping task at next checkpoint")
+
+		return
+	}
+
+	if err := sess.CancelCheckpoint(checkpointForPause); err != nil {
+		agentstate.Logger.Error("Failed to withdraw hashcat checkpoint stop for pause", "error", err)
+
+		return
+	}
+
+	agentstate.Logger.Info("Pause lifted before checkpoint, continuing task")
+}
+
+// stopAtCheckpoint asks the session, on behalf of reason, to stop at its next
+// checkpoint, killing it if the request cannot be sent.
+func stopAtCheckpoint(sess *hashcat.Session, reason hashcat.CheckpointReason) {
+	err := sess.RequestCheckpoint(reason)
+	if err == nil {
+		return
+	}
+
+	if errors.Is(err, hashcat.ErrCheckpointUnsupported) {
+		logCheckpointUnsupported(reason, err)
+
+		return
+	}
+
+	agentstate.Logger.Error("Failed to checkpoint session, killing it", "reason", reason, "error", err)
+
+	if killErr := sess.Kill(); killErr != nil {
+		agentstate.Logger.Error("Failed to kill session", "reason", reason, "error", killErr)
+	}
+}
+
+// logCheckpointUnsupported logs that a checkpoint request killed a session
+// reading stdin. The server is told once the session has exited.
+func logCheckpointUnsupported(reason hashcat.CheckpointReason, err error) {
+	agentstate.Logger.Warn("Session cannot stop at a checkpoint, killed it; the attack restarts from the beginning",
+		"reason", reason, "error", err)
+}
+
+// checkpointStop returns who asked for the stop if a session exit is a stop at
+// a checkpoint, or the kill of a session that could not stop at one, and nil
+// otherwise.
+func checkpointStop(sess *hashcat.Session, err error) []hashcat.CheckpointReason {
+	if sess.CheckpointKilled() {
+		return sess.CheckpointRequests()
+	}
+
+	if err == nil || parseExitCode(err.Error()) != hashcat.ExitCodeCheckpoint {
+		return nil
+	}
+
+	return sess.CheckpointRequests()
+}
+
+// checkpointOutcome describes for the server what became of the attack's
+// progress after a checkpoint stop.
+func checkpointOutcome(sess *hashcat.Session) string {
+	if sess.CheckpointKilled() {
+		return "session killed since it reads candidates from stdin; the attack restarts from the beginning"
+	}
+
+	return "restore file kept"
+}
+
+// pausedAtCheckpoint reports whether the server's pause is the only reason for
+// a checkpoint stop. A stop a guard also asked for is the guard's.
+func pausedAtCheckpoint(reasons []hashcat.CheckpointReason) bool {
+	return slices.Equal(reasons, []hashcat.CheckpointReason{checkpointForPause})
+}
+
+// taskPausedAtCheckpoint reports whether a checkpoint stop was asked for
+// because the server paused the task. Such a stop is reported as a pause
+// whoever else asked for it, since the server no longer runs the task.
+func taskPausedAtCheckpoint(reasons []hashcat.CheckpointReason) bool {
+	return slices.Contains(reasons, checkpointForTaskPause)
+}
+
+// handlePausedSession reports a checkpointed task as paused and cleans up the
+// session while keeping its restore file for the resume.
+func handlePausedSession(ctx context.Context, task *api.Task, sess *hashcat.Session) {
+	agentstate.Logger.Info("Task paused at checkpoint", "task_id", task.Id, "restore_file", sess.RestoreFilePath)
+
+	cserrors.SendAgentError(ctx, "Task paused at checkpoint per server request; "+checkpointOutcome(sess),
+		task, api.SeverityInfo,
+		cserrors.WithContext(map[string]any{
+			"task_status":       string(api.Paused),
+			"checkpoint_killed": sess.CheckpointKilled(),
+		}))
+
+	sess.PreserveRestoreFile()
+	sess.Cleanup()
+}
+
+// handleGuardStop reports a task that stopped at a checkpoint a guard asked
+// for, and cleans up the session. The guard kept the restore file when it
+// fired, so the task can continue from it once the condition clears, unless
+// the session was killed because it could not stop at a checkpoint.
+func handleGuardStop(ctx context.Context, task *api.Task, sess *hashcat.Session, reasons []hashcat.CheckpointReason) {
+	agentstate.Logger.Warn("Task stopped at checkpoint", "task_id", task.Id,
+		"requested_by", reasons, "restore_file", sess.RestoreFilePath)
+
+	message := "Task stopped at checkpoint for GPU temperature"
+	category := hashcat.ErrorCategoryDevice.String()
+
+	if slices.Contains(reasons, checkpointForDisk) {
+		message = "Task stopped at checkpoint for disk space"
+		category = ErrorCategoryDiskSpace
+	}
+
+	cserrors.SendAgentError(ctx, message+"; "+checkpointOutcome(sess), task, api.SeverityWarning,
+		cserrors.WithClassification(category, true),
+		cserrors.WithContext(map[string]any{
+			"error_type":        "checkpoint_stop",
+			"requested_by":      reasons,
+			"checkpoint_killed": sess.CheckpointKilled(),
+		}))
+
+	sess.Cleanup()
+}
+
+// WaitForResume blocks while the server's pause directive is in effect. It
+// returns false if ctx is cancelled first.
+func WaitForResume(ctx context.Context, pollInterval time.Duration) bool {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for agentstate.State.GetPauseRequested() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+
+	return true
+}
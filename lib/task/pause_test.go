@@ -0,0 +1,176 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+func TestPausedAtCheckpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		reasons  []hashcat.CheckpointReason
+		expected bool
+	}{
+		{name: "pause only", reasons: []hashcat.CheckpointReason{checkpointForPause}, expected: true},
+		{name: "no request", reasons: nil, expected: false},
+		{name: "disk guard", reasons: []hashcat.CheckpointReason{checkpointForDisk}, expected: false},
+		{
+			name:     "pause and disk guard",
+			reasons:  []hashcat.CheckpointReason{checkpointForDisk, checkpointForPause},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, pausedAtCheckpoint(tt.reasons))
+		})
+	}
+}
+
+func TestCheckpointStop(t *testing.T) {
+	sess, err := testhelpers.NewMockSession("test-session-checkpoint-stop")
+	require.NoError(t, err)
+
+	assert.Empty(t, checkpointStop(sess, errors.New("exit status 3")), "stop nobody asked for")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected []hashcat.CheckpointReason
+	}{
+		{name: "checkpoint", err: errors.New("exit status 3"), expected: []hashcat.CheckpointReason{checkpointForDisk}},
+		{name: "exhausted", err: errors.New("exit status 1"), expected: nil},
+		{name: "clean exit", err: nil, expected: nil},
+	}
+
+	var buf strings.Builder
+	sess.SetTestStdin(&buf)
+	require.NoError(t, sess.RequestCheckpoint(checkpointForDisk))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checkpointStop(sess, tt.err))
+		})
+	}
+}
+
+// TestCheckpointPause_SyncWithoutProcess verifies a failed checkpoint key write
+// leaves the pause unrequested so the next status update retries it.
+func TestCheckpointPause_SyncWithoutProcess(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+	agentstate.State.SetPauseRequested(true)
+
+	sess := hashcat.NewTestSession(true)
+	p := &checkpointPause{}
+	p.sync(sess)
+
+	assert.False(t, sess.CheckpointRequested(checkpointForPause))
+}
+
+// TestCheckpointPause_SyncKeepsGuardStop verifies lifting a pause does not
+// cancel a checkpoint stop the disk guard requested too.
+func TestCheckpointPause_SyncKeepsGuardStop(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	var buf strings.Builder
+	sess := hashcat.NewTestSession(true)
+	sess.SetTestStdin(&buf)
+	p := &checkpointPause{}
+
+	agentstate.State.SetPauseRequested(true)
+	p.sync(sess)
+	require.NoError(t, sess.RequestCheckpoint(checkpointForDisk))
+
+	agentstate.State.SetPauseRequested(false)
+	p.sync(sess)
+
+	assert.Equal(t, "c\n", buf.String(), "only the first request sends the checkpoint key")
+	assert.Equal(t, []hashcat.CheckpointReason{checkpointForDisk}, sess.CheckpointRequests())
+}
+
+// TestCheckpointPause_StreamedSession verifies a pause kills a session that
+// cannot stop at a checkpoint, once, and that its exit is handled as a pause so
+// the task is run again from the beginning on resume.
+func TestCheckpointPause_StreamedSession(t *testing.T) {
+	t.Cleanup(testhelpers.SetupHTTPMock())
+	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+	testhelpers.MockSubmitErrorSuccess(123)
+	agentstate.State.SetPauseRequested(true)
+
+	sess := hashcat.NewTestSession(true)
+	sess.SetTestWordListStream(strings.NewReader("password\n"))
+
+	p := &checkpointPause{}
+	p.sync(sess)
+	assert.True(t, sess.CheckpointKilled())
+
+	agentstate.State.SetPauseRequested(false)
+	p.sync(sess)
+	assert.True(t, sess.CheckpointRequested(checkpointForPause), "a killed session stays stopped")
+
+	taskCtx, taskCancel := context.WithCancel(context.Background())
+	t.Cleanup(taskCancel)
+
+	waitChan := make(chan struct{})
+	sess.DoneChan <- errors.New("signal: killed")
+	newTestManager().runEventLoop(context.Background(), taskCtx, taskCancel, sess,
+		testhelpers.NewTestTask(456, 789), 24*time.Hour, time.NewTimer(24*time.Hour), p, nil, waitChan)
+
+	select {
+	case <-waitChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runEventLoop did not exit after the session was killed")
+	}
+
+	assert.True(t, p.paused)
+}
+
+func TestWaitForResume(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	agentstate.State.SetPauseRequested(false)
+	assert.True(t, WaitForResume(context.Background(), time.Millisecond), "not paused returns immediately")
+
+	agentstate.State.SetPauseRequested(true)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		agentstate.State.SetPauseRequested(false)
+	}()
+	assert.True(t, WaitForResume(context.Background(), time.Millisecond))
+
+	agentstate.State.SetPauseRequested(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, WaitForResume(ctx, time.Millisecond), "cancelled context stops waiting")
+}
+
+// TestHandlePausedSession_KeepsRestoreFile verifies a paused session keeps its
+// restore file so the task can resume from it.
+func TestHandlePausedSession_KeepsRestoreFile(t *testing.T) {
+	t.Cleanup(testhelpers.SetupHTTPMock())
+	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+	testhelpers.MockSubmitErrorSuccess(123)
+
+	sess, err := testhelpers.NewMockSession("test-session-pause")
+	require.NoError(t, err)
+
+	restoreFile := filepath.Join(t.TempDir(), "test.restore")
+	require.NoError(t, os.WriteFile(restoreFile, []byte("data"), 0o600))
+	sess.RestoreFilePath = restoreFile
+
+	handlePausedSession(context.Background(), testhelpers.NewTestTask(456, 789), sess)
+
+	assert.FileExists(t, restoreFile)
+}
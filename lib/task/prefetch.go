@@ -0,0 +1,124 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/internal/util"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/apierrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/progress"
+)
+
+// ErrPeekUnsupported is returned by PeekNextTask when the server has no
+// endpoint for previewing the next task.
+var ErrPeekUnsupported = errors.New("server does not support previewing the next task")
+
+// PeekNextTask asks the server which task it would likely assign next, without
+// assigning it. It returns ErrNoTaskAvailable if there is none, and
+// ErrPeekUnsupported if the server predates the preview endpoint. Errors are
+// not reported to the server: prefetching is only an optimization.
+func (m *Manager) PeekNextTask(ctx context.Context) (*api.Task, error) {
+	response, err := m.tasksClient.PeekNextTask(ctx)
+	if err != nil {
+		switch apierrors.GetStatusCode(err) {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return nil, fmt.Errorf("%w: %w", ErrPeekUnsupported, err)
+		}
+
+		return nil, err
+	}
+
+	switch response.StatusCode() {
+	case http.StatusNoContent:
+		return nil, ErrNoTaskAvailable
+	case http.StatusOK:
+		if response.JSON200 == nil {
+			return nil, fmt.Errorf("%w: HTTP 200 with nil task body", ErrTaskBadResponse)
+		}
+
+		return response.JSON200, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrTaskBadResponse, response.Status())
+	}
+}
+
+// PrefetchNext warms the resource cache with the resources of the task the
+// server expects to assign after current, one file at a time and held to
+// Config.PrefetchRateLimit so it stays out of the running task's way. Resources
+// are only cached, not linked into FilePath, so files the running task uses are
+// never touched. A download cut short when ctx is cancelled is resumed by the
+// real download later. Nothing is reported to the server.
+func (m *Manager) PrefetchNext(ctx context.Context, current *api.Task) error {
+	cache := m.Config.ResourceCache
+	if cache == nil || agentstate.State.AlwaysTrustFiles {
+		return nil
+	}
+
+	next, err := m.PeekNextTask(ctx)
+	if err != nil {
+		if errors.Is(err, ErrNoTaskAvailable) {
+			return nil
+		}
+
+		return err
+	}
+
+	if current != nil && next.AttackId == current.AttackId {
+		return nil
+	}
+
+	response, err := m.attacksClient.GetAttack(ctx, next.AttackId)
+	if err != nil {
+		return fmt.Errorf("getting attack %d: %w", next.AttackId, err)
+	}
+
+	attack := response.JSON200
+	if response.StatusCode() != http.StatusOK || attack == nil {
+		return fmt.Errorf("%w: %s", ErrTaskBadResponse, response.Status())
+	}
+
+	agentstate.Logger.Debug("Prefetching resources for the next task",
+		"task_id", next.Id, "attack_id", attack.Id, "rate_limit", m.Config.PrefetchRateLimit)
+
+	for _, resource := range []*api.AttackResourceFile{
+		attack.WordList,
+		attack.RightWordList,
+		attack.RuleList,
+		attack.MaskList,
+		attack.Preprocessor,
+	} {
+		if resource == nil {
+			continue
+		}
+
+		algorithm := util.UnwrapOr(resource.ChecksumAlgorithm, api.Md5)
+
+		checksum, err := downloader.NewChecksum(string(algorithm), resource.Checksum)
+		if err != nil || checksum.IsZero() {
+			continue
+		}
+
+		err = cache.Warm(ctx, resource.DownloadUrl, checksum,
+			downloader.WithProgress(progress.Silent),
+			downloader.WithRateLimit(m.Config.PrefetchRateLimit))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			agentstate.Logger.Debug("Failed to prefetch resource",
+				"resource_id", resource.Id, "file_name", resource.FileName, "error", err)
+
+			continue
+		}
+
+		agentstate.Logger.Debug("Prefetched resource", "resource_id", resource.Id, "file_name", resource.FileName)
+	}
+
+	return nil
+}
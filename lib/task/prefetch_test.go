@@ -0,0 +1,150 @@
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+// peekClient returns a tasks client whose PeekNextTask returns resp and err.
+func peekClient(resp *api.PeekNextTaskResponse, err error) *api.MockTasksClient {
+	return &api.MockTasksClient{
+		PeekNextTaskFunc: func(_ context.Context) (*api.PeekNextTaskResponse, error) {
+			return resp, err
+		},
+	}
+}
+
+func TestPeekNextTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *api.PeekNextTaskResponse
+		err     error
+		wantID  int64
+		wantErr error
+	}{
+		{
+			name: "upcoming task",
+			resp: &api.PeekNextTaskResponse{
+				HTTPResponse: &http.Response{StatusCode: http.StatusOK},
+				JSON200:      &api.Task{Id: 42, AttackId: 9},
+			},
+			wantID: 42,
+		},
+		{
+			name:    "no upcoming task",
+			resp:    &api.PeekNextTaskResponse{HTTPResponse: &http.Response{StatusCode: http.StatusNoContent}},
+			wantErr: ErrNoTaskAvailable,
+		},
+		{
+			name:    "server without the endpoint",
+			err:     &api.APIError{StatusCode: http.StatusNotFound},
+			wantErr: ErrPeekUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := NewManager(peekClient(tt.resp, tt.err), &api.MockAttacksClient{})
+
+			got, err := mgr.PeekNextTask(context.Background())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantID, got.Id)
+		})
+	}
+}
+
+// TestPrefetchNext_WarmsCache verifies that the next task's resources are
+// downloaded into the cache without being linked into the resource directory,
+// so a later fetch needs no download.
+func TestPrefetchNext_WarmsCache(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	agentstate.State.DownloadMaxRetries = 1
+
+	content := []byte("password\n123456\n")
+	sum := sha256.Sum256(content)
+
+	var requests int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(srv.Close)
+
+	filesDir := t.TempDir()
+	cache := downloader.NewCache(t.TempDir(), 0)
+
+	attacks := &api.MockAttacksClient{
+		GetAttackFunc: func(_ context.Context, id int64) (*api.GetAttackResponse, error) {
+			return &api.GetAttackResponse{
+				HTTPResponse: &http.Response{StatusCode: http.StatusOK},
+				JSON200: &api.Attack{
+					Id: id,
+					WordList: &api.AttackResourceFile{
+						Id:                11,
+						FileName:          "rockyou.txt",
+						DownloadUrl:       srv.URL + "/rockyou.txt",
+						Checksum:          sum[:],
+						ChecksumAlgorithm: new(api.Sha256),
+					},
+				},
+			}, nil
+		},
+	}
+
+	mgr := NewManager(peekClient(&api.PeekNextTaskResponse{
+		HTTPResponse: &http.Response{StatusCode: http.StatusOK},
+		JSON200:      &api.Task{Id: 2, AttackId: 9},
+	}, nil), attacks)
+	mgr.Config = Config{FilePath: filesDir, ResourceCache: cache}
+
+	require.NoError(t, mgr.PrefetchNext(context.Background(), &api.Task{Id: 1, AttackId: 8}))
+	require.Equal(t, 1, requests)
+	require.NoFileExists(t, filepath.Join(filesDir, "rockyou.txt"), "prefetching must not touch the resource directory")
+
+	// The real download is served from the cache.
+	checksum := downloader.Checksum{Algorithm: downloader.ChecksumSHA256, Digest: sum[:]}
+	dest := filepath.Join(filesDir, "rockyou.txt")
+
+	release, err := cache.Fetch(context.Background(), srv.URL+"/rockyou.txt", checksum, dest)
+	require.NoError(t, err)
+	defer release()
+
+	require.Equal(t, 1, requests, "the cached resource should not be downloaded again")
+
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
+
+// TestPrefetchNext_SkipsCurrentAttack verifies nothing is fetched when the
+// upcoming task belongs to the attack already running.
+func TestPrefetchNext_SkipsCurrentAttack(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	mgr := NewManager(peekClient(&api.PeekNextTaskResponse{
+		HTTPResponse: &http.Response{StatusCode: http.StatusOK},
+		JSON200:      &api.Task{Id: 2, AttackId: 8},
+	}, nil), &api.MockAttacksClient{})
+	mgr.Config = Config{FilePath: t.TempDir(), ResourceCache: downloader.NewCache(t.TempDir(), 0)}
+
+	// The attacks mock is unconfigured, so fetching the attack would fail.
+	require.NoError(t, mgr.PrefetchNext(context.Background(), &api.Task{Id: 1, AttackId: 8}))
+}
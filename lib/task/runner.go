@@ -3,8 +3,8 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -13,42 +13,63 @@ import (
 	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/display"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/metrics"
 )
 
 // runAttackTask starts the attack session and handles real-time outputs and status updates.
 // It processes stdout, stderr, status updates, cracked hashes, and handles session completion.
 // A configurable timeout (task_timeout) prevents indefinite blocking if hashcat hangs.
-func (m *Manager) runAttackTask(ctx context.Context, sess *hashcat.Session, task *api.Task) {
+// A stall watchdog (stall_timeout) kills a session that stops making progress.
+// Once the session ends, the task's crack journal is drained and cleaned up.
+// It returns ErrTaskPaused when the session stopped at a checkpoint for a
+// server-requested pause of the agent, and a *StallError when the watchdog
+// killed it. A stop at a checkpoint a guard asked for, or because the server
+// paused the task itself, returns nil, keeping the restore file.
+func (m *Manager) runAttackTask(ctx context.Context, sess *hashcat.Session, task *api.Task) error {
 	err := sess.Start()
 	if err != nil {
 		agentstate.Logger.Error("Failed to start attack session", "error", err)
 		cserrors.SendAgentError(ctx, err.Error(), task, api.SeverityFatal)
 		sess.Cleanup()
 
-		return
+		return nil
 	}
 
 	// Create timeout timer with configurable duration
 	taskTimeout := agentstate.State.TaskTimeout
 	taskTimer := time.NewTimer(taskTimeout)
 
-	// Per-task cancellation context. A server-side task cancellation (404/410 on
+	// Per-task cancellation context. A server-side task cancellation (404 on
 	// a status update) cancels taskCtx, which makes the event loop exit via its
 	// <-taskCtx.Done() branch — the single owner of Kill+Cleanup. Without this,
-	// the 404/410 handler killed the session inline and the loop then blocked on
+	// the 404 handler killed the session inline and the loop then blocked on
 	// channels that never deliver until the 24h task timer (the P1 stall).
 	taskCtx, taskCancel := context.WithCancel(ctx)
 	defer taskCancel()
 
+	pause := &checkpointPause{}
+	stall := newStallWatchdog(m.Config.StallTimeout, time.Now())
 	waitChan := make(chan struct{})
-	m.runEventLoop(ctx, taskCtx, taskCancel, sess, task, taskTimeout, taskTimer, waitChan)
+	m.runEventLoop(ctx, taskCtx, taskCancel, sess, task, taskTimeout, taskTimer, pause, stall, waitChan)
 	<-waitChan
+
+	metrics.Default.ForgetTask(task.Id)
+	m.finishJournal(ctx, task)
+
+	if pause.paused {
+		return ErrTaskPaused
+	}
+
+	return stall.err()
 }
 
 // runEventLoop runs the select-driven event loop for a hashcat session in a goroutine.
 // It handles task context cancellation, session timeout, stdout/stderr output,
-// status updates, cracked hashes, and session completion. The goroutine closes
-// waitChan on exit so the caller can block until the loop finishes.
+// status updates (including the GPU thermal and disk space guards and server pause requests), cracked hashes, and session
+// completion. A non-nil stall watchdog is fed every status update and checked on a ticker.
+// The goroutine closes waitChan on exit so the caller can block until the loop finishes;
+// pause.paused is set before then if the session stopped at a checkpoint for a pause,
+// and stall records the stall if the watchdog killed the session.
 func (m *Manager) runEventLoop(
 	ctx context.Context,
 	taskCtx context.Context,
@@ -57,6 +78,8 @@ func (m *Manager) runEventLoop(
 	task *api.Task,
 	taskTimeout time.Duration,
 	taskTimer *time.Timer,
+	pause *checkpointPause,
+	stall *stallWatchdog,
 	waitChan chan struct{},
 ) {
 	//nolint:gosec // G118 - goroutine manages session lifecycle with ctx cancellation
@@ -64,11 +87,24 @@ func (m *Manager) runEventLoop(
 		defer close(waitChan)
 		defer taskTimer.Stop()
 
+		thermal := newThermalGuard(m.Config.GPUTempThreshold, m.Config.GPUTempTripCount, m.Config.GPUTempAction)
+		diskSpace := newDiskGuard(m.Config.MinFreeDisk,
+			m.Config.OutPath, m.Config.ZapsPath, m.Config.RestoreFilePath)
+
+		stallTicks, stopStallTicks := stall.ticks()
+		defer stopStallTicks()
+
 		for {
 			select {
 			case <-taskCtx.Done():
 				agentstate.Logger.Warn("Task context cancelled, killing session")
 
+				// Agent shutdown (as opposed to a server-side cancellation) keeps the
+				// restore file so the task can be resumed after a restart.
+				if ctx.Err() != nil {
+					sess.PreserveRestoreFile()
+				}
+
 				if err := sess.Kill(); err != nil {
 					agentstate.Logger.Error("Failed to kill session on context cancellation", "error", err)
 					//nolint:contextcheck // must-complete: parent ctx already cancelled
@@ -104,15 +140,43 @@ func (m *Manager) runEventLoop(
 				sess.Cleanup()
 
 				return
+			case now := <-stallTicks:
+				if applyStallWatchdog(stall, now, sess) {
+					return
+				}
 			case stdoutLine := <-sess.StdoutLines:
 				handleStdOutLine(ctx, stdoutLine, task)
 			case errInfo := <-sess.StderrMessages:
 				handleStdErrLine(ctx, errInfo, task)
 			case statusUpdate := <-sess.StatusUpdates:
 				m.handleStatusUpdate(ctx, statusUpdate, task, sess, taskCancel)
+				stall.observe(statusUpdate, time.Now())
+				applyThermalGuard(ctx, thermal, statusUpdate, task, sess)
+				applyDiskGuard(ctx, diskSpace, task, sess)
+				pause.sync(sess)
 			case crackedHash := <-sess.CrackedHashes:
 				m.handleCrackedHash(ctx, crackedHash, task)
 			case err := <-sess.DoneChan:
+				reasons := checkpointStop(sess, err)
+				if taskPausedAtCheckpoint(reasons) {
+					handlePausedSession(ctx, task, sess)
+
+					return
+				}
+
+				if pausedAtCheckpoint(reasons) {
+					pause.paused = true
+					handlePausedSession(ctx, task, sess)
+
+					return
+				}
+
+				if len(reasons) > 0 {
+					handleGuardStop(ctx, task, sess, reasons)
+
+					return
+				}
+
 				m.handleDoneChan(ctx, err, task, sess)
 
 				return
@@ -186,9 +250,27 @@ func (m *Manager) handleStatusUpdate(
 	}
 
 	display.JobStatus(statusUpdate)
+	metrics.Default.ObserveDevices(task.Id, metricsDevices(statusUpdate.Devices))
 	m.sendStatusUpdate(ctx, statusUpdate, task, sess, taskCancel)
 }
 
+// metricsDevices converts hashcat device statuses for the metrics registry.
+func metricsDevices(devices []hashcat.StatusDevice) []metrics.Device {
+	out := make([]metrics.Device, len(devices))
+	for i, device := range devices {
+		out[i] = metrics.Device{
+			ID:          device.DeviceID,
+			Name:        device.DeviceName,
+			Type:        device.DeviceType,
+			Speed:       device.Speed,
+			Utilization: device.Util,
+			Temperature: device.Temp,
+		}
+	}
+
+	return out
+}
+
 // handleCrackedHash processes a cracked hash by displaying it and then sending it to a task server.
 func (m *Manager) handleCrackedHash(ctx context.Context, crackedHash hashcat.Result, task *api.Task) {
 	display.JobCrackedHash(crackedHash)
@@ -199,9 +281,15 @@ func (m *Manager) handleCrackedHash(ctx context.Context, crackedHash hashcat.Res
 // and taking appropriate action based on the error category.
 // Note: When hashcat completes successfully with exit code 0, proc.Wait() returns nil,
 // so the err != nil block only handles non-zero exit codes. Successful completion
-// (nil error) proceeds directly to cleanup.
+// (nil error) proceeds directly to cleanup. A *hashcat.PreprocessorError, merged
+// into the exit status by the session, is reported as a preprocessor failure.
 func (m *Manager) handleDoneChan(ctx context.Context, err error, task *api.Task, sess *hashcat.Session) {
-	if err != nil {
+	var preprocessorErr *hashcat.PreprocessorError
+	if errors.As(err, &preprocessorErr) {
+		// hashcat was stopped because its input broke off; its own exit status
+		// would misreport the attack as exhausted.
+		handlePreprocessorError(ctx, preprocessorErr, task)
+	} else if err != nil {
 		exitCode := parseExitCode(err.Error())
 		exitInfo := hashcat.ClassifyExitCode(exitCode)
 
@@ -222,6 +310,24 @@ func (m *Manager) handleDoneChan(ctx context.Context, err error, task *api.Task,
 	sess.Cleanup()
 }
 
+// ErrorCategoryPreprocessor classifies errors from a preprocessor feeding hashcat.
+const ErrorCategoryPreprocessor = "preprocessor"
+
+// handlePreprocessorError reports a preprocessor that failed while feeding
+// hashcat, with its exit code and the end of its stderr.
+func handlePreprocessorError(ctx context.Context, err *hashcat.PreprocessorError, task *api.Task) {
+	agentstate.ErrorLogger.Error("Preprocessor failed", "error", err)
+	cserrors.SendAgentError(ctx, err.Error(), task, api.SeverityCritical,
+		cserrors.WithClassification(ErrorCategoryPreprocessor, false),
+		cserrors.WithContext(map[string]any{
+			"error_type": ErrorCategoryPreprocessor,
+			"exit_code":  err.ExitCode(),
+			"stderr":     err.Stderr,
+		}),
+	)
+	display.JobFailed(err)
+}
+
 // parseExitCode extracts the exit code from an error message like "exit status N".
 // On Unix, negative exit codes (e.g., hashcat's -11) are reported by the kernel as
 // unsigned 8-bit values (e.g., 245). This function normalizes codes in the 245-255
@@ -274,7 +380,7 @@ func handleNonExhaustedError(
 		strings.Contains(err.Error(), "Cannot read "+sess.RestoreFilePath) {
 		agentstate.Logger.Info("Removing restore file", "file", sess.RestoreFilePath)
 
-		if removeErr := os.Remove(sess.RestoreFilePath); removeErr != nil && !os.IsNotExist(removeErr) {
+		if removeErr := hashcat.RemoveRestoreFile(sess.RestoreFilePath); removeErr != nil {
 			agentstate.Logger.Error("Failed to remove restore file", "error", removeErr)
 		}
 
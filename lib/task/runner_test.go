@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -481,7 +482,7 @@ func TestHandleDoneChan_ExitCodeHandling(t *testing.T) {
 
 // TestRunEventLoop_ContextCancel verifies that cancelling the task context causes
 // the runEventLoop goroutine to exit promptly via the <-taskCtx.Done() branch,
-// covering the P1 goroutine-stall fix (404/410 → taskCancel → loop exit).
+// covering the P1 goroutine-stall fix (404 → taskCancel → loop exit).
 func TestRunEventLoop_ContextCancel(t *testing.T) {
 	t.Cleanup(testhelpers.SetupHTTPMock())
 	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
@@ -512,6 +513,8 @@ func TestRunEventLoop_ContextCancel(t *testing.T) {
 		task,
 		24*time.Hour,
 		taskTimer,
+		&checkpointPause{},
+		nil,
 		waitChan,
 	)
 
@@ -525,3 +528,33 @@ func TestRunEventLoop_ContextCancel(t *testing.T) {
 		t.Fatal("runEventLoop did not exit after context cancellation within 5 seconds")
 	}
 }
+
+// TestHandleDoneChan_PreprocessorError verifies that a failed preprocessor is
+// reported as such, and the attack is not marked exhausted even though hashcat
+// ran out of input.
+func TestHandleDoneChan_PreprocessorError(t *testing.T) {
+	submitted := setupDownloadFilesState(t, 1)
+
+	exhausted := false
+	mgr := NewManager(&api.MockTasksClient{
+		SetTaskExhaustedFunc: func(_ context.Context, _ int64) (*api.SetTaskExhaustedResponse, error) {
+			exhausted = true
+			return &api.SetTaskExhaustedResponse{}, nil
+		},
+	}, &api.MockAttacksClient{})
+
+	sess, err := testhelpers.NewMockSession("test-session")
+	require.NoError(t, err)
+
+	preprocessorErr := &hashcat.PreprocessorError{Err: errors.New("exit status 3"), Stderr: "unknown option --pw-mim"}
+	mgr.handleDoneChan(context.Background(), preprocessorErr, testhelpers.NewTestTask(456, 789), sess)
+
+	assert.False(t, exhausted, "a preprocessor failure must not exhaust the task")
+	require.Len(t, *submitted, 1)
+	assert.Contains(t, (*submitted)[0].Message, "unknown option --pw-mim")
+
+	metadata, err := json.Marshal((*submitted)[0].Metadata)
+	require.NoError(t, err)
+	assert.Contains(t, string(metadata), `"category":"preprocessor"`)
+	assert.Contains(t, string(metadata), `"retryable":false`)
+}
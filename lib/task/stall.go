@@ -0,0 +1,212 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/downloader"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+)
+
+// ErrorCategoryStall classifies errors from hashcat sessions that stopped
+// making progress. They are retryable: a hang is usually particular to the
+// agent's devices or drivers.
+const ErrorCategoryStall = "stall"
+
+// Reasons a session is judged stalled.
+const (
+	stallNoStatus   = "no_status"   // hashcat stopped sending status updates
+	stallNoProgress = "no_progress" // status updates kept reporting the same progress
+)
+
+// Hashcat status codes during which the progress counter is expected to move.
+// Other states, such as initializing, autotuning, or paused, may legitimately
+// hold it still.
+const (
+	hashcatStatusRunning        = 3
+	hashcatStatusCheckpointQuit = 12 // Running until the next checkpoint
+)
+
+// stallChecksPerWindow is how many times per stall window the watchdog checks
+// the session.
+const stallChecksPerWindow = 4
+
+// StallError reports a hashcat session killed by the stall watchdog.
+type StallError struct {
+	Reason   string        // stallNoStatus or stallNoProgress
+	Window   time.Duration // How long the session went without status or progress
+	Restarts int           // Restarts already made for the task
+}
+
+// Error implements the error interface.
+func (e *StallError) Error() string {
+	if e.Reason == stallNoStatus {
+		return fmt.Sprintf("hashcat sent no status update for %s", e.Window)
+	}
+
+	return fmt.Sprintf("hashcat made no progress for %s", e.Window)
+}
+
+// ReportOptions returns the classification and context to send with the error.
+func (e *StallError) ReportOptions() []cserrors.ErrorOption {
+	return []cserrors.ErrorOption{
+		cserrors.WithClassification(ErrorCategoryStall, true),
+		cserrors.WithContext(map[string]any{
+			"error_type": ErrorCategoryStall,
+			"reason":     e.Reason,
+			"window":     e.Window.String(),
+			"restarts":   e.Restarts,
+		}),
+	}
+}
+
+// stallWatchdog tracks a session's status updates and judges it stalled when
+// none arrive, or hashcat reports the same progress while running, for a whole
+// window. It is owned by a single task's event loop goroutine and is not safe
+// for concurrent use.
+type stallWatchdog struct {
+	window     time.Duration
+	lastStatus time.Time   // When the last status update arrived
+	lastChange time.Time   // When progress last moved or hashcat was last not running
+	progress   int64       // Progress reported by the last status update
+	stalled    *StallError // Set once the watchdog has stopped the session
+}
+
+// newStallWatchdog returns a watchdog for a session started at start, or nil
+// when the window is not positive.
+func newStallWatchdog(window time.Duration, start time.Time) *stallWatchdog {
+	if window <= 0 {
+		return nil
+	}
+
+	return &stallWatchdog{window: window, lastStatus: start, lastChange: start}
+}
+
+// observe records a status update that arrived at the given time.
+func (w *stallWatchdog) observe(status hashcat.Status, at time.Time) {
+	if w == nil {
+		return
+	}
+
+	var progress int64
+	if len(status.Progress) > 0 {
+		progress = status.Progress[0]
+	}
+
+	running := status.Status == hashcatStatusRunning || status.Status == hashcatStatusCheckpointQuit
+	if !running || progress != w.progress {
+		w.lastChange = at
+	}
+
+	w.lastStatus = at
+	w.progress = progress
+}
+
+// check returns a *StallError if, at the given time, the session has gone a
+// whole window without a status update or without progress, or nil.
+func (w *stallWatchdog) check(at time.Time) *StallError {
+	if w == nil {
+		return nil
+	}
+
+	switch {
+	case at.Sub(w.lastStatus) >= w.window:
+		return &StallError{Reason: stallNoStatus, Window: w.window}
+	case at.Sub(w.lastChange) >= w.window:
+		return &StallError{Reason: stallNoProgress, Window: w.window}
+	default:
+		return nil
+	}
+}
+
+// ticks returns a channel delivering the times to check the session and a
+// function that stops it. A nil watchdog's channel never delivers.
+func (w *stallWatchdog) ticks() (<-chan time.Time, func()) {
+	if w == nil {
+		return nil, func() {}
+	}
+
+	ticker := time.NewTicker(max(w.window/stallChecksPerWindow, time.Millisecond))
+
+	return ticker.C, ticker.Stop
+}
+
+// err returns the stall that stopped the session, or nil.
+func (w *stallWatchdog) err() error {
+	if w == nil || w.stalled == nil {
+		return nil
+	}
+
+	return w.stalled
+}
+
+// applyStallWatchdog checks the session at the given time and, if it has
+// stalled, kills it and keeps its restore file so it can be restarted. It
+// returns true when the session was stopped.
+func applyStallWatchdog(watchdog *stallWatchdog, at time.Time, sess *hashcat.Session) bool {
+	stallErr := watchdog.check(at)
+	if stallErr == nil {
+		return false
+	}
+
+	agentstate.Logger.Error("Hashcat session stalled, killing it",
+		"reason", stallErr.Reason, "window", stallErr.Window)
+
+	watchdog.stalled = stallErr
+
+	sess.PreserveRestoreFile()
+
+	if err := sess.Kill(); err != nil {
+		agentstate.Logger.Error("Failed to kill stalled session", "error", err)
+	}
+
+	sess.Cleanup()
+
+	return true
+}
+
+// handleStalledSession follows up a session stopped by the stall watchdog.
+// While restarts remain, it reports the stall and downloads the hash list the
+// session cleanup removed, so the caller can restart the task from its restore
+// file. Otherwise it removes the restore file, reports the stall, and abandons
+// the task, returning stallErr.
+func (m *Manager) handleStalledSession(
+	ctx context.Context,
+	task *api.Task,
+	attack *api.Attack,
+	sess *hashcat.Session,
+	stallErr *StallError,
+) error {
+	if stallErr.Restarts >= m.Config.StallMaxRestarts {
+		agentstate.ErrorLogger.Error("Hashcat session kept stalling, abandoning task",
+			"task_id", task.Id, "restarts", stallErr.Restarts, "error", stallErr)
+
+		if sess.RestoreFilePath != "" {
+			if err := hashcat.RemoveRestoreFile(sess.RestoreFilePath); err != nil {
+				agentstate.Logger.Error("Failed to remove restore file", "error", err)
+			}
+		}
+
+		cserrors.SendAgentError(ctx, stallErr.Error()+"; task abandoned", task, api.SeverityCritical,
+			stallErr.ReportOptions()...)
+		m.AbandonTask(ctx, task)
+
+		return stallErr
+	}
+
+	agentstate.Logger.Warn("Restarting stalled hashcat session from its restore file",
+		"task_id", task.Id, "restart", stallErr.Restarts+1, "max_restarts", m.Config.StallMaxRestarts)
+	cserrors.SendAgentError(ctx, stallErr.Error()+"; restarting from restore file", task, api.SeverityWarning,
+		stallErr.ReportOptions()...)
+
+	if err := downloader.DownloadHashList(ctx, attack, m.Config.HashlistPath); err != nil {
+		return cserrors.LogAndSendError(ctx, "Failed to download hash list to restart stalled session",
+			err, api.SeverityCritical, task)
+	}
+
+	return nil
+}
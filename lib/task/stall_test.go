@@ -0,0 +1,184 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+// running returns a status update for a running session at the given progress.
+func running(progress int64) hashcat.Status {
+	return hashcat.Status{Status: hashcatStatusRunning, Progress: []int64{progress, 1000}}
+}
+
+func TestNewStallWatchdog_Disabled(t *testing.T) {
+	assert.Nil(t, newStallWatchdog(0, time.Now()))
+	assert.Nil(t, newStallWatchdog(-time.Minute, time.Now()))
+
+	var watchdog *stallWatchdog
+	watchdog.observe(running(1), time.Now())
+	assert.Nil(t, watchdog.check(time.Now().Add(time.Hour)))
+	require.NoError(t, watchdog.err())
+
+	ticks, stop := watchdog.ticks()
+	assert.Nil(t, ticks)
+	stop()
+}
+
+func TestStallWatchdog_NoProgress(t *testing.T) {
+	start := time.Now()
+	watchdog := newStallWatchdog(time.Minute, start)
+	require.NotNil(t, watchdog)
+
+	watchdog.observe(running(10), start.Add(30*time.Second))
+	watchdog.observe(running(10), start.Add(80*time.Second))
+	assert.Nil(t, watchdog.check(start.Add(85*time.Second)), "progress moved 55s ago")
+
+	stallErr := watchdog.check(start.Add(90 * time.Second))
+	require.NotNil(t, stallErr)
+	assert.Equal(t, stallNoProgress, stallErr.Reason)
+	assert.Equal(t, time.Minute, stallErr.Window)
+
+	watchdog.observe(running(20), start.Add(95*time.Second))
+	assert.Nil(t, watchdog.check(start.Add(100*time.Second)), "moving progress clears the stall")
+}
+
+// TestStallWatchdog_NotRunning verifies that a session that is paused or still
+// starting up is not judged stalled while it keeps sending status updates.
+func TestStallWatchdog_NotRunning(t *testing.T) {
+	const hashcatStatusPaused = 4
+
+	start := time.Now()
+	watchdog := newStallWatchdog(time.Minute, start)
+
+	for i := range 5 {
+		watchdog.observe(hashcat.Status{Status: hashcatStatusPaused, Progress: []int64{10, 1000}},
+			start.Add(time.Duration(i+1)*30*time.Second))
+	}
+
+	assert.Nil(t, watchdog.check(start.Add(160*time.Second)))
+}
+
+func TestStallWatchdog_NoStatus(t *testing.T) {
+	start := time.Now()
+	watchdog := newStallWatchdog(time.Minute, start)
+
+	assert.Nil(t, watchdog.check(start.Add(59*time.Second)))
+
+	stallErr := watchdog.check(start.Add(time.Minute))
+	require.NotNil(t, stallErr)
+	assert.Equal(t, stallNoStatus, stallErr.Reason)
+	assert.Contains(t, stallErr.Error(), "no status update for 1m0s")
+}
+
+// TestApplyStallWatchdog_KeepsRestoreFile verifies a stalled session is stopped
+// with its restore file kept for the restart.
+func TestApplyStallWatchdog_KeepsRestoreFile(t *testing.T) {
+	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+
+	sess, err := testhelpers.NewMockSession("test-session-stall")
+	require.NoError(t, err)
+
+	restoreFile := filepath.Join(t.TempDir(), "test.restore")
+	require.NoError(t, os.WriteFile(restoreFile, []byte("data"), 0o600))
+	sess.RestoreFilePath = restoreFile
+
+	start := time.Now()
+	watchdog := newStallWatchdog(time.Minute, start)
+
+	assert.False(t, applyStallWatchdog(watchdog, start.Add(time.Second), sess))
+	require.NoError(t, watchdog.err())
+
+	assert.True(t, applyStallWatchdog(watchdog, start.Add(time.Minute), sess))
+	assert.FileExists(t, restoreFile)
+
+	var stallErr *StallError
+	require.ErrorAs(t, watchdog.err(), &stallErr)
+	assert.Equal(t, stallNoStatus, stallErr.Reason)
+}
+
+// TestRunEventLoop_Stall verifies that the event loop exits once a session
+// sends no status updates for the stall window.
+func TestRunEventLoop_Stall(t *testing.T) {
+	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+
+	sess := hashcat.NewTestSession(false)
+	mgr := newTestManager()
+
+	taskCtx, taskCancel := context.WithCancel(context.Background())
+	t.Cleanup(taskCancel)
+
+	taskTimer := time.NewTimer(24 * time.Hour)
+	stall := newStallWatchdog(20*time.Millisecond, time.Now())
+	waitChan := make(chan struct{})
+
+	mgr.runEventLoop(context.Background(), taskCtx, taskCancel, sess, testhelpers.NewTestTask(456, 789),
+		24*time.Hour, taskTimer, &checkpointPause{}, stall, waitChan)
+
+	select {
+	case <-waitChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runEventLoop did not stop a stalled session within 5 seconds")
+	}
+
+	var stallErr *StallError
+	require.ErrorAs(t, stall.err(), &stallErr)
+}
+
+// TestHandleStalledSession verifies that a stalled task is restarted with a
+// fresh hash list while restarts remain, and abandoned with a classified error
+// once they run out.
+func TestHandleStalledSession(t *testing.T) {
+	submitted := setupDownloadFilesState(t, 1)
+
+	abandoned := false
+	mgr := NewManager(&api.MockTasksClient{
+		SetTaskAbandonedFunc: func(_ context.Context, _ int64) (*api.SetTaskAbandonedResponse, error) {
+			abandoned = true
+			return &api.SetTaskAbandonedResponse{}, nil
+		},
+	}, &api.MockAttacksClient{})
+	mgr.Config.HashlistPath = t.TempDir()
+	mgr.Config.StallMaxRestarts = 1
+
+	sess, err := testhelpers.NewMockSession("test-session-stall")
+	require.NoError(t, err)
+
+	restoreFile := filepath.Join(t.TempDir(), "test.restore")
+	require.NoError(t, os.WriteFile(restoreFile, []byte("data"), 0o600))
+	sess.RestoreFilePath = restoreFile
+
+	task := testhelpers.NewTestTask(456, 789)
+	attack := &api.Attack{Id: 789}
+
+	stallErr := &StallError{Reason: stallNoProgress, Window: time.Minute}
+	require.NoError(t, mgr.handleStalledSession(context.Background(), task, attack, sess, stallErr))
+	assert.FileExists(t, filepath.Join(mgr.Config.HashlistPath, "789.hsh"), "hash list is downloaded again")
+	assert.FileExists(t, restoreFile)
+	assert.False(t, abandoned)
+
+	stallErr = &StallError{Reason: stallNoProgress, Window: time.Minute, Restarts: 1}
+	err = mgr.handleStalledSession(context.Background(), task, attack, sess, stallErr)
+	require.ErrorIs(t, err, stallErr)
+	assert.True(t, abandoned)
+	assert.NoFileExists(t, restoreFile)
+
+	require.Len(t, *submitted, 2)
+	assert.Contains(t, (*submitted)[0].Message, "restarting from restore file")
+	assert.Contains(t, (*submitted)[1].Message, "task abandoned")
+
+	metadata, err := json.Marshal((*submitted)[1].Metadata)
+	require.NoError(t, err)
+	assert.Contains(t, string(metadata), `"category":"stall"`)
+	assert.Contains(t, string(metadata), `"retryable":true`)
+	assert.Contains(t, string(metadata), `"restarts":1`)
+}
@@ -2,16 +2,14 @@ package task
 
 import (
 	"context"
-	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/metrics"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/zap"
 )
 
@@ -48,7 +46,10 @@ func (m *Manager) sendStatusUpdate(
 		return
 	}
 
-	m.handleSendStatusResponse(ctx, resp, task)
+	m.handleSendStatusResponse(ctx, resp, task, sess.Separator())
+
+	// The server is reachable again; deliver any cracks a previous failure left behind.
+	m.replayPendingCracks(ctx, task)
 }
 
 func convertDeviceStatuses(devices []hashcat.StatusDevice) []api.DeviceStatus {
@@ -106,7 +107,12 @@ func convertAndWarn(s []int64, field string) []int {
 	return result
 }
 
-func (m *Manager) handleSendStatusResponse(ctx context.Context, resp *api.SendStatusResponse, task *api.Task) {
+func (m *Manager) handleSendStatusResponse(
+	ctx context.Context,
+	resp *api.SendStatusResponse,
+	task *api.Task,
+	separator string,
+) {
 	switch resp.StatusCode() {
 	case http.StatusNoContent:
 		if agentstate.State.ExtraDebugging {
@@ -114,13 +120,13 @@ func (m *Manager) handleSendStatusResponse(ctx context.Context, resp *api.SendSt
 		}
 	case http.StatusAccepted:
 		agentstate.Logger.Debug("Status update sent, but stale")
-		zap.GetZaps(ctx, m.tasksClient, task, m.sendCrackedHash, m.Config.ZapsPath)
+		zap.GetZaps(ctx, m.tasksClient, task, separator, m.sendCrackedHash, m.Config.ZapsPath)
 	default:
 		if resp.StatusCode() >= http.StatusOK && resp.StatusCode() < http.StatusMultipleChoices {
 			agentstate.Logger.Warn("Unexpected success status code for status update",
 				"status_code", resp.StatusCode(), "task_id", task.Id)
 			// Defensively fetch zaps for any other 2xx success code to avoid losing cracked hashes
-			zap.GetZaps(ctx, m.tasksClient, task, m.sendCrackedHash, m.Config.ZapsPath)
+			zap.GetZaps(ctx, m.tasksClient, task, separator, m.sendCrackedHash, m.Config.ZapsPath)
 		} else {
 			agentstate.Logger.Error("Failed to send status update",
 				"status_code", resp.StatusCode(), "task_id", task.Id)
@@ -130,9 +136,9 @@ func (m *Manager) handleSendStatusResponse(ctx context.Context, resp *api.SendSt
 
 // sendCrackedHash sends a cracked hash result to the task server and logs relevant information.
 // If the task pointer is nil, it logs an error and returns early.
-// Constructs a HashcatResult object and sends it to the server via the API client interface.
-// Logs and handles any errors encountered during the sending process.
-// If configured, writes the cracked hash to a file.
+// The crack is first recorded in the task's crack journal so it survives a failed
+// send; cracks already accepted by the server are skipped. On success the journal
+// entry is acknowledged, otherwise it stays pending for a later replay.
 // Logs additional information based on the HTTP response status.
 func (m *Manager) sendCrackedHash(ctx context.Context, timestamp time.Time, hash, plaintext string, task *api.Task) {
 	if task == nil {
@@ -141,62 +147,48 @@ func (m *Manager) sendCrackedHash(ctx context.Context, timestamp time.Time, hash
 		return
 	}
 
-	hashcatResult := api.HashcatResult{
-		Timestamp: timestamp,
-		Hash:      hash,
-		PlainText: plaintext,
-	}
-
 	agentstate.Logger.Debug("Cracked hash", "hash", hash)
 
-	response, err := m.tasksClient.SendCrack(ctx, task.Id, hashcatResult)
-	if err != nil {
-		handleSendCrackError(ctx, err)
-
-		return
-	}
+	journal := m.taskJournal(ctx, task)
+	if journal != nil {
+		if journal.isAcked(hash, plaintext) {
+			agentstate.Logger.Debug("Cracked hash already delivered, skipping", "hash", hash)
 
-	if agentstate.State.WriteZapsToFile {
-		hashFile := filepath.Join(m.Config.ZapsPath, fmt.Sprintf("%d_clientout.zap", task.Id))
+			return
+		}
 
-		file, err := os.OpenFile(
-			hashFile,
-			os.O_APPEND|os.O_CREATE|os.O_WRONLY,
-			filePermissions,
-		)
-		if err != nil {
+		if _, err := journal.record(timestamp, hash, plaintext); err != nil {
 			//nolint:errcheck // LogAndSendError handles logging+sending internally
 			_ = cserrors.LogAndSendError(
 				ctx,
-				"Error opening cracked hash file",
+				"Error recording cracked hash in journal",
 				err,
 				api.SeverityCritical,
 				task,
 			)
-			return
 		}
+	}
 
-		defer func() {
-			if cerr := file.Close(); cerr != nil {
-				agentstate.Logger.Error("Error closing cracked hash file; data may not be persisted",
-					"error", cerr, "path", hashFile)
-			}
-		}()
+	hashcatResult := api.HashcatResult{
+		Timestamp: timestamp,
+		Hash:      hash,
+		PlainText: plaintext,
+	}
 
-		_, err = file.WriteString(fmt.Sprintf("%s:%s", hash, plaintext) + "\n")
-		if err != nil {
-			//nolint:errcheck // LogAndSendError handles logging+sending internally
-			_ = cserrors.LogAndSendError(
-				ctx,
-				"Error writing cracked hash to file",
-				err,
-				api.SeverityCritical,
-				task,
-			)
-			return
+	response, err := m.tasksClient.SendCrack(ctx, task.Id, hashcatResult)
+	if err != nil {
+		handleSendCrackError(ctx, err)
+
+		return
+	}
+
+	if journal != nil {
+		if err := journal.ack(hash, plaintext); err != nil {
+			agentstate.Logger.Error("Failed to acknowledge cracked hash in journal", "error", err, "hash", hash)
 		}
 	}
 
+	metrics.Default.AddCrackSent()
 	agentstate.Logger.Debug("Cracked hash sent")
 
 	if response.StatusCode() == http.StatusNoContent {
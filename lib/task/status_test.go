@@ -138,7 +138,7 @@ func TestSendStatusUpdate(t *testing.T) {
 			expectCancel: true,
 		},
 		{
-			name: "task gone (410) invokes taskCancel",
+			name: "task gone (410) stops at a checkpoint instead of taskCancel",
 			setupMock: func(taskID int64) {
 				apiErr := testhelpers.NewAPIError(http.StatusGone, "task gone")
 				testhelpers.MockAPIError(
@@ -153,7 +153,7 @@ func TestSendStatusUpdate(t *testing.T) {
 				)
 			},
 			status:       testhelpers.NewTestHashcatStatus("test-session"),
-			expectCancel: true,
+			expectCancel: false,
 		},
 	}
 
@@ -182,7 +182,7 @@ func TestSendStatusUpdate(t *testing.T) {
 			mgr := newTestManager()
 			mgr.sendStatusUpdate(context.Background(), tt.status, task, sess, taskCancel)
 
-			// Verify taskCancel was called for 404/410 responses
+			// Verify taskCancel was called for 404 responses
 			if tt.expectCancel {
 				assert.True(t, cancelCalled, "taskCancel should be called for %s", tt.name)
 			} else {
@@ -207,7 +207,6 @@ func TestSendCrackedHash(t *testing.T) {
 		name              string
 		setupMock         func(taskID int64)
 		task              *api.Task
-		writeZapsToFile   bool
 		expectedError     bool
 		expectSubmitError bool
 	}{
@@ -217,7 +216,6 @@ func TestSendCrackedHash(t *testing.T) {
 				testhelpers.MockSendCrackSuccess(taskID)
 			},
 			task:              testhelpers.NewTestTask(456, 789),
-			writeZapsToFile:   false,
 			expectedError:     false,
 			expectSubmitError: false,
 		},
@@ -227,7 +225,6 @@ func TestSendCrackedHash(t *testing.T) {
 				testhelpers.MockSendCrackComplete(taskID)
 			},
 			task:              testhelpers.NewTestTask(456, 789),
-			writeZapsToFile:   false,
 			expectedError:     false,
 			expectSubmitError: false,
 		},
@@ -237,7 +234,6 @@ func TestSendCrackedHash(t *testing.T) {
 				// No mock needed, function returns early
 			},
 			task:              nil,
-			writeZapsToFile:   false,
 			expectedError:     false,
 			expectSubmitError: false,
 		},
@@ -254,7 +250,6 @@ func TestSendCrackedHash(t *testing.T) {
 				testhelpers.MockSubmitErrorSuccess(123)
 			},
 			task:              testhelpers.NewTestTask(456, 789),
-			writeZapsToFile:   false,
 			expectedError:     false,
 			expectSubmitError: true,
 		},
@@ -270,42 +265,19 @@ func TestSendCrackedHash(t *testing.T) {
 				testhelpers.MockSubmitErrorSuccess(123)
 			},
 			task:              testhelpers.NewTestTask(456, 789),
-			writeZapsToFile:   false,
 			expectedError:     false,
 			expectSubmitError: true,
 		},
 		{
-			name: "with WriteZapsToFile enabled",
-			setupMock: func(taskID int64) {
-				testhelpers.MockSendCrackSuccess(taskID)
-			},
-			task:              testhelpers.NewTestTask(456, 789),
-			writeZapsToFile:   true,
-			expectedError:     false,
-			expectSubmitError: false,
-		},
-		{
-			name: "file open error - non-writable directory",
+			name: "journal open error - non-writable directory",
 			setupMock: func(taskID int64) {
 				testhelpers.MockSendCrackSuccess(taskID)
 				testhelpers.MockSubmitErrorSuccess(123)
 			},
 			task:              testhelpers.NewTestTask(456, 789),
-			writeZapsToFile:   true,
 			expectedError:     false,
 			expectSubmitError: true,
 		},
-		{
-			name: "file write error - read-only file",
-			setupMock: func(taskID int64) {
-				testhelpers.MockSendCrackSuccess(taskID)
-				testhelpers.MockSubmitErrorSuccess(123)
-			},
-			task:              testhelpers.NewTestTask(456, 789),
-			writeZapsToFile:   true,
-			expectedError:     false,
-			expectSubmitError: false, // Platform-dependent
-		},
 	}
 
 	for _, tt := range tests {
@@ -320,11 +292,8 @@ func TestSendCrackedHash(t *testing.T) {
 			testhelpers.MockSubmitErrorSuccess(123)
 			initialCallCount := testhelpers.GetSubmitErrorCallCount(123, "https://test.api")
 
-			agentstate.State.WriteZapsToFile = tt.writeZapsToFile
-
-			// Handle file I/O error test cases
-			switch tt.name {
-			case "file open error - non-writable directory":
+			// Handle journal I/O error test cases
+			if tt.name == "journal open error - non-writable directory" {
 				if runtime.GOOS == "windows" {
 					t.Skip("Skipping Unix permission test on Windows")
 				}
@@ -340,27 +309,7 @@ func TestSendCrackedHash(t *testing.T) {
 				)
 				skipIfDirWritable(t, tempDir)
 
-				agentstate.State.ZapsPath = tempDir
-			case "file write error - read-only file":
-				if runtime.GOOS == "windows" {
-					t.Skip("Skipping Unix permission test on Windows")
-				}
-
-				tempDir := t.TempDir()
-				t.Cleanup(func() {
-					require.NoError(t, os.Chmod(tempDir, 0o755)) //nolint:gosec // adjusting temp dir perms for cleanup
-				})
-
-				hashFile := filepath.Join(tempDir, fmt.Sprintf("%d_clientout.zap", tt.task.Id))
-
-				file, err := os.OpenFile(hashFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
-				require.NoError(t, err)
-				require.NoError(t, file.Close())
-
-				require.NoError(t, os.Chmod(tempDir, 0o500)) //nolint:gosec // Read-only directory for error path
-				skipIfDirWritable(t, tempDir)
-
-				agentstate.State.ZapsPath = tempDir
+				agentstate.State.JournalPath = tempDir
 			}
 
 			if tt.task != nil {
@@ -381,11 +330,12 @@ func TestSendCrackedHash(t *testing.T) {
 				assert.Positive(t, callCount, "send_crack endpoint should be called")
 			}
 
-			// Verify file was created if WriteZapsToFile is enabled and no errors expected
-			if tt.writeZapsToFile && tt.task != nil && !tt.expectSubmitError {
-				hashFile := filepath.Join(agentstate.State.ZapsPath, fmt.Sprintf("%d_clientout.zap", tt.task.Id))
-				_, err := os.Stat(hashFile)
-				require.NoError(t, err, "zap file should be created")
+			// Verify a delivered crack is acknowledged in the task's journal
+			if tt.task != nil && !tt.expectSubmitError {
+				journal := mgr.journals[tt.task.Id]
+				require.NotNil(t, journal, "crack journal should be opened")
+				assert.Empty(t, journal.pendingRecords(), "delivered crack should be acknowledged")
+				assert.True(t, journal.isAcked("testhash", "plaintext"))
 			}
 
 			// Verify submit_error was called for error cases
@@ -620,7 +570,7 @@ func TestHandleSendStatusResponse(t *testing.T) {
 			}
 
 			mgr := newTestManager()
-			mgr.handleSendStatusResponse(context.Background(), resp, tt.task)
+			mgr.handleSendStatusResponse(context.Background(), resp, tt.task, hashcat.DefaultSeparator)
 		})
 	}
 }
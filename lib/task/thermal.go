@@ -0,0 +1,253 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+)
+
+// ThermalAction selects how the agent responds when a device stays above the
+// configured GPU temperature threshold.
+type ThermalAction string
+
+const (
+	// ThermalActionPause pauses the hashcat session and resumes it once every
+	// device has cooled below the threshold.
+	ThermalActionPause ThermalAction = "pause"
+	// ThermalActionStop checkpoints the hashcat session so it stops at the next
+	// restore point, and reports the overheat to the server.
+	ThermalActionStop ThermalAction = "stop"
+	// ThermalActionAbort delegates enforcement to hashcat by passing the threshold
+	// as --hwmon-temp-abort; the agent does not intervene itself.
+	ThermalActionAbort ThermalAction = "abort"
+)
+
+// thermalHysteresis is how far (in Celsius) below the threshold every device must
+// fall before a paused session is resumed, so a device hovering at the threshold
+// does not flap between pause and resume on every status update.
+const thermalHysteresis = 5
+
+// ParseThermalAction converts a configuration string into a ThermalAction.
+// Unknown values fall back to ThermalActionPause with ok=false.
+func ParseThermalAction(s string) (ThermalAction, bool) {
+	switch ThermalAction(s) {
+	case ThermalActionPause, ThermalActionStop, ThermalActionAbort:
+		return ThermalAction(s), true
+	default:
+		return ThermalActionPause, false
+	}
+}
+
+// thermalDecision is the action the thermal guard asks the runner to take after
+// observing a status update.
+type thermalDecision int
+
+const (
+	thermalNone thermalDecision = iota
+	thermalPause
+	thermalResume
+	thermalStop
+)
+
+// thermalGuard tracks per-device temperatures across status updates and decides
+// when a session must be throttled. It is owned by a single task's event loop
+// goroutine and is not safe for concurrent use.
+type thermalGuard struct {
+	threshold int64
+	tripCount int
+	action    ThermalAction
+	hot       map[int64]int // consecutive over-threshold updates per device ID
+	cool      int           // consecutive updates with every device below threshold - hysteresis
+	paused    bool
+	stopped   bool
+}
+
+// newThermalGuard returns a guard for the given settings, or nil when the guard is
+// disabled (non-positive threshold) or enforcement is delegated to hashcat.
+func newThermalGuard(threshold, tripCount int, action ThermalAction) *thermalGuard {
+	if threshold <= 0 || action == ThermalActionAbort {
+		return nil
+	}
+
+	return &thermalGuard{
+		threshold: int64(threshold),
+		tripCount: max(tripCount, 1),
+		action:    action,
+		hot:       make(map[int64]int),
+	}
+}
+
+// observe records the device temperatures from one status update and returns the
+// action to take. Devices reporting a non-positive temperature (no hwmon support)
+// are ignored.
+func (g *thermalGuard) observe(devices []hashcat.StatusDevice) thermalDecision {
+	if g.stopped {
+		return thermalNone
+	}
+
+	tripped := false
+	allCool := true
+
+	for _, device := range devices {
+		if device.Temp <= 0 {
+			continue
+		}
+
+		if device.Temp > g.threshold {
+			g.hot[device.DeviceID]++
+			if g.hot[device.DeviceID] >= g.tripCount {
+				tripped = true
+			}
+		} else {
+			g.hot[device.DeviceID] = 0
+		}
+
+		if device.Temp > g.threshold-thermalHysteresis {
+			allCool = false
+		}
+	}
+
+	if g.paused {
+		if !allCool {
+			g.cool = 0
+			return thermalNone
+		}
+
+		g.cool++
+		if g.cool < g.tripCount {
+			return thermalNone
+		}
+
+		g.paused = false
+		g.cool = 0
+		clear(g.hot)
+
+		return thermalResume
+	}
+
+	if !tripped {
+		return thermalNone
+	}
+
+	if g.action == ThermalActionStop {
+		g.stopped = true
+		return thermalStop
+	}
+
+	g.paused = true
+	g.cool = 0
+
+	return thermalPause
+}
+
+// pauseFailed records that the session could not be paused after observe asked
+// for it, so the session is being stopped instead and the guard goes quiet.
+func (g *thermalGuard) pauseFailed() {
+	g.paused = false
+	g.stopped = true
+}
+
+// hottestDevice returns the device with the highest reported temperature.
+func hottestDevice(devices []hashcat.StatusDevice) hashcat.StatusDevice {
+	var hottest hashcat.StatusDevice
+	for _, device := range devices {
+		if device.Temp > hottest.Temp {
+			hottest = device
+		}
+	}
+
+	return hottest
+}
+
+// applyThermalGuard feeds a status update to the guard and acts on its decision:
+// pausing, resuming, or checkpointing the session. A session that cannot be
+// paused is checkpointed instead. Overheat events are reported to
+// the server with the device error category.
+func applyThermalGuard(
+	ctx context.Context,
+	guard *thermalGuard,
+	update hashcat.Status,
+	task *api.Task,
+	sess *hashcat.Session,
+) {
+	if guard == nil {
+		return
+	}
+
+	decision := guard.observe(update.Devices)
+	if decision == thermalNone {
+		return
+	}
+
+	hottest := hottestDevice(update.Devices)
+	thermalCtx := map[string]any{
+		"error_type":  "thermal_threshold",
+		"device_id":   hottest.DeviceID,
+		"device_name": hottest.DeviceName,
+		"temperature": hottest.Temp,
+		"threshold":   guard.threshold,
+		"action":      string(guard.action),
+	}
+
+	switch decision {
+	case thermalPause:
+		agentstate.Logger.Warn("GPU temperature threshold exceeded, pausing session",
+			"device_id", hottest.DeviceID, "temperature", hottest.Temp, "threshold", guard.threshold)
+
+		if err := sess.Pause(); err != nil {
+			agentstate.Logger.Error("Failed to pause session for thermal protection, stopping it instead", "error", err)
+			guard.pauseFailed()
+			stopOverheatedSession(ctx, guard, hottest, task, sess, thermalCtx)
+
+			return
+		}
+
+		cserrors.SendAgentError(ctx,
+			fmt.Sprintf("Device %d at %d°C exceeded threshold %d°C; task paused",
+				hottest.DeviceID, hottest.Temp, guard.threshold),
+			task, api.SeverityWarning,
+			cserrors.WithClassification(hashcat.ErrorCategoryDevice.String(), true),
+			cserrors.WithContext(thermalCtx))
+	case thermalResume:
+		agentstate.Logger.Info("GPU temperatures back below threshold, resuming session",
+			"threshold", guard.threshold)
+
+		if err := sess.Resume(); err != nil {
+			agentstate.Logger.Error("Failed to resume session after thermal pause", "error", err)
+		}
+	case thermalStop:
+		agentstate.Logger.Error("GPU temperature threshold exceeded, stopping session at checkpoint",
+			"device_id", hottest.DeviceID, "temperature", hottest.Temp, "threshold", guard.threshold)
+
+		stopOverheatedSession(ctx, guard, hottest, task, sess, thermalCtx)
+	case thermalNone:
+	}
+}
+
+// stopOverheatedSession checkpoints the session so it stops at the next restore
+// point, killing it if the checkpoint cannot be requested, and reports the
+// overheat to the server. The restore file is kept so the task can continue
+// from it once the device has cooled.
+func stopOverheatedSession(
+	ctx context.Context,
+	guard *thermalGuard,
+	hottest hashcat.StatusDevice,
+	task *api.Task,
+	sess *hashcat.Session,
+	thermalCtx map[string]any,
+) {
+	sess.PreserveRestoreFile()
+
+	stopAtCheckpoint(sess, checkpointForThermal)
+
+	cserrors.SendAgentError(ctx,
+		fmt.Sprintf("Device %d at %d°C exceeded threshold %d°C; task stopped",
+			hottest.DeviceID, hottest.Temp, guard.threshold),
+		task, api.SeverityMajor,
+		cserrors.WithClassification(hashcat.ErrorCategoryDevice.String(), true),
+		cserrors.WithContext(thermalCtx))
+}
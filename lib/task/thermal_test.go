@@ -0,0 +1,159 @@
+package task
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
+)
+
+// temps builds a status device list with one device per temperature, numbered from 1.
+func temps(values ...int64) []hashcat.StatusDevice {
+	devices := make([]hashcat.StatusDevice, len(values))
+	for i, v := range values {
+		devices[i] = hashcat.StatusDevice{DeviceID: int64(i + 1), Temp: v}
+	}
+
+	return devices
+}
+
+func TestParseThermalAction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ThermalAction
+		ok       bool
+	}{
+		{input: "pause", expected: ThermalActionPause, ok: true},
+		{input: "stop", expected: ThermalActionStop, ok: true},
+		{input: "abort", expected: ThermalActionAbort, ok: true},
+		{input: "", expected: ThermalActionPause, ok: false},
+		{input: "melt", expected: ThermalActionPause, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			action, ok := ParseThermalAction(tt.input)
+			assert.Equal(t, tt.expected, action)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestNewThermalGuard_Disabled(t *testing.T) {
+	assert.Nil(t, newThermalGuard(0, 3, ThermalActionPause))
+	assert.Nil(t, newThermalGuard(-1, 3, ThermalActionStop))
+	assert.Nil(t, newThermalGuard(80, 3, ThermalActionAbort))
+	require.NotNil(t, newThermalGuard(80, 0, ThermalActionPause))
+	assert.Equal(t, 1, newThermalGuard(80, 0, ThermalActionPause).tripCount)
+}
+
+func TestThermalGuard_PauseAndResume(t *testing.T) {
+	guard := newThermalGuard(80, 2, ThermalActionPause)
+	require.NotNil(t, guard)
+
+	// A single hot reading does not trip the guard.
+	assert.Equal(t, thermalNone, guard.observe(temps(70, 85)))
+	assert.Equal(t, thermalPause, guard.observe(temps(70, 86)))
+
+	// Cooling to just below the threshold is inside the hysteresis band.
+	assert.Equal(t, thermalNone, guard.observe(temps(70, 78)))
+	assert.Equal(t, thermalNone, guard.observe(temps(70, 78)))
+
+	// Two consecutive readings below threshold - hysteresis resume the session.
+	assert.Equal(t, thermalNone, guard.observe(temps(70, 74)))
+	assert.Equal(t, thermalResume, guard.observe(temps(70, 73)))
+	assert.Equal(t, thermalNone, guard.observe(temps(70, 73)))
+}
+
+func TestThermalGuard_TripCountResetsWhenCool(t *testing.T) {
+	guard := newThermalGuard(80, 2, ThermalActionPause)
+	require.NotNil(t, guard)
+
+	assert.Equal(t, thermalNone, guard.observe(temps(85)))
+	assert.Equal(t, thermalNone, guard.observe(temps(79)))
+	assert.Equal(t, thermalNone, guard.observe(temps(85)))
+	assert.Equal(t, thermalPause, guard.observe(temps(85)))
+}
+
+func TestThermalGuard_StopIsTerminal(t *testing.T) {
+	guard := newThermalGuard(80, 1, ThermalActionStop)
+	require.NotNil(t, guard)
+
+	assert.Equal(t, thermalStop, guard.observe(temps(90)))
+	assert.Equal(t, thermalNone, guard.observe(temps(90)))
+	assert.Equal(t, thermalNone, guard.observe(temps(40)))
+}
+
+// TestApplyThermalGuard_PauseFailureStopsSession verifies a session that cannot
+// be paused is stopped like the stop action, keeping its restore file, and the
+// guard does not wait to resume it.
+func TestApplyThermalGuard_PauseFailureStopsSession(t *testing.T) {
+	t.Cleanup(testhelpers.SetupHTTPMock())
+	t.Cleanup(testhelpers.SetupTestState(123, "https://test.api", "test-token"))
+	testhelpers.MockSubmitErrorSuccess(123)
+
+	sess := hashcat.NewTestSession(true)
+	restoreFile := filepath.Join(t.TempDir(), "test.restore")
+	require.NoError(t, os.WriteFile(restoreFile, []byte("data"), 0o600))
+	sess.RestoreFilePath = restoreFile
+
+	guard := newThermalGuard(80, 1, ThermalActionPause)
+	require.NotNil(t, guard)
+
+	update := hashcat.Status{Devices: temps(90)}
+	applyThermalGuard(context.Background(), guard, update, testhelpers.NewTestTask(456, 789), sess)
+
+	assert.False(t, guard.paused)
+	assert.True(t, guard.stopped)
+	assert.Equal(t, thermalNone, guard.observe(temps(40)), "a stopped session is not resumed")
+
+	sess.Cleanup()
+	assert.FileExists(t, restoreFile)
+}
+
+func TestThermalGuard_IgnoresDevicesWithoutTemperature(t *testing.T) {
+	guard := newThermalGuard(80, 1, ThermalActionPause)
+	require.NotNil(t, guard)
+
+	assert.Equal(t, thermalNone, guard.observe(temps(0, -1)))
+	assert.Equal(t, thermalNone, guard.observe(nil))
+}
+
+func TestHottestDevice(t *testing.T) {
+	assert.Equal(t, int64(2), hottestDevice(temps(70, 91, 88)).DeviceID)
+	assert.Equal(t, int64(0), hottestDevice(nil).DeviceID)
+}
+
+// TestCreateJobParams_HwmonTempAbort verifies the threshold is delegated to
+// hashcat only when the abort action is configured.
+func TestCreateJobParams_HwmonTempAbort(t *testing.T) {
+	t.Cleanup(testhelpers.SetupMinimalTestState(1))
+
+	attack := &api.Attack{Id: 80, AttackModeHashcat: 3}
+	tsk := testhelpers.NewTestTask(1, 80)
+
+	tests := []struct {
+		name      string
+		threshold int
+		action    ThermalAction
+		expected  int64
+	}{
+		{name: "abort delegates to hashcat", threshold: 85, action: ThermalActionAbort, expected: 85},
+		{name: "pause is handled by the agent", threshold: 85, action: ThermalActionPause, expected: 0},
+		{name: "disabled threshold", threshold: 0, action: ThermalActionAbort, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := &Manager{Config: Config{GPUTempThreshold: tt.threshold, GPUTempAction: tt.action}}
+			params := mgr.createJobParams(tsk, attack)
+			assert.Equal(t, tt.expected, params.HwmonTempAbort)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/jarcoal/httpmock"
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/config"
 )
 
@@ -43,6 +44,7 @@ func SetupTestState(agentID int64, apiURL, apiToken string) func() {
 	agentstate.State.OutPath = filepath.Join(testDataDir, "out")
 	agentstate.State.FilePath = filepath.Join(testDataDir, "files")
 	agentstate.State.RestoreFilePath = filepath.Join(testDataDir, "restore")
+	agentstate.State.JournalPath = filepath.Join(testDataDir, "journal")
 	agentstate.State.BenchmarkCachePath = filepath.Join(testDataDir, "benchmark_cache.json")
 	agentstate.State.Debug = false
 	agentstate.State.ExtraDebugging = false
@@ -76,6 +78,7 @@ func SetupTestState(agentID int64, apiURL, apiToken string) func() {
 	mustMkdirAll(agentstate.State.OutPath)
 	mustMkdirAll(agentstate.State.FilePath)
 	mustMkdirAll(agentstate.State.RestoreFilePath)
+	mustMkdirAll(agentstate.State.JournalPath)
 
 	return func() {
 		// Cleanup: remove temporary directories
@@ -92,6 +95,7 @@ func SetupTestState(agentID int64, apiURL, apiToken string) func() {
 		agentstate.State.OutPath = ""
 		agentstate.State.FilePath = ""
 		agentstate.State.RestoreFilePath = ""
+		agentstate.State.JournalPath = ""
 		agentstate.State.BenchmarkCachePath = ""
 		agentstate.State.Debug = false
 		agentstate.State.AgentID = 0
@@ -100,7 +104,6 @@ func SetupTestState(agentID int64, apiURL, apiToken string) func() {
 		agentstate.State.AlwaysTrustFiles = false
 		agentstate.State.ExtraDebugging = false
 		agentstate.State.StatusTimer = 0
-		agentstate.State.WriteZapsToFile = false
 		agentstate.State.RetainZapsOnCompletion = false
 		agentstate.State.EnableAdditionalHashTypes = false
 		agentstate.State.SetAPIClient(nil)
@@ -108,7 +111,18 @@ func SetupTestState(agentID int64, apiURL, apiToken string) func() {
 		agentstate.State.InsecureDownloads = false
 		agentstate.State.DownloadMaxRetries = 0
 		agentstate.State.DownloadRetryDelay = 0
+		agentstate.State.DownloadConcurrency = 0
+		agentstate.State.MinFreeDiskMB = 0
+		agentstate.State.CompressedResourceMode = ""
+		agentstate.State.DownloadBandwidthLimit = ""
+		agentstate.State.DownloadBandwidthPerDownload = ""
+		agentstate.State.DownloadBandwidthSchedule = ""
+		bandwidth.Configure(bandwidth.Policy{})
+		agentstate.State.PrefetchResources = false
+		agentstate.State.PrefetchBandwidthLimit = ""
 		agentstate.State.TaskTimeout = 0
+		agentstate.State.StallTimeout = 0
+		agentstate.State.StallMaxRestarts = 0
 		agentstate.State.MaxHeartbeatBackoff = 0
 		agentstate.State.SleepOnFailure = 0
 		agentstate.State.AlwaysUseNativeHashcat = false
@@ -131,8 +145,9 @@ func SetupTestState(agentID int64, apiURL, apiToken string) func() {
 		agentstate.State.SetReload(false)
 		agentstate.State.SetCurrentActivity("")
 		agentstate.State.SetJobCheckingStopped(false)
+		agentstate.State.SetPauseRequested(false)
 		agentstate.State.SetBenchmarksSubmitted(false)
-		agentstate.State.SetHashcatPID(0)
+		agentstate.State.ResetHashcatPIDs()
 		// Deactivate httpmock
 		httpmock.DeactivateAndReset()
 	}
@@ -152,6 +167,7 @@ func ResetTestState() {
 	agentstate.State.OutPath = ""
 	agentstate.State.FilePath = ""
 	agentstate.State.RestoreFilePath = ""
+	agentstate.State.JournalPath = ""
 	agentstate.State.BenchmarkCachePath = ""
 	agentstate.State.Debug = false
 	agentstate.State.AgentID = 0
@@ -160,7 +176,6 @@ func ResetTestState() {
 	agentstate.State.AlwaysTrustFiles = false
 	agentstate.State.ExtraDebugging = false
 	agentstate.State.StatusTimer = 0
-	agentstate.State.WriteZapsToFile = false
 	agentstate.State.RetainZapsOnCompletion = false
 	agentstate.State.EnableAdditionalHashTypes = false
 	agentstate.State.SetAPIClient(nil)
@@ -168,7 +183,18 @@ func ResetTestState() {
 	agentstate.State.InsecureDownloads = false
 	agentstate.State.DownloadMaxRetries = 0
 	agentstate.State.DownloadRetryDelay = 0
+	agentstate.State.DownloadConcurrency = 0
+	agentstate.State.MinFreeDiskMB = 0
+	agentstate.State.CompressedResourceMode = ""
+	agentstate.State.DownloadBandwidthLimit = ""
+	agentstate.State.DownloadBandwidthPerDownload = ""
+	agentstate.State.DownloadBandwidthSchedule = ""
+	bandwidth.Configure(bandwidth.Policy{})
+	agentstate.State.PrefetchResources = false
+	agentstate.State.PrefetchBandwidthLimit = ""
 	agentstate.State.TaskTimeout = 0
+	agentstate.State.StallTimeout = 0
+	agentstate.State.StallMaxRestarts = 0
 	agentstate.State.MaxHeartbeatBackoff = 0
 	agentstate.State.SleepOnFailure = 0
 	agentstate.State.AlwaysUseNativeHashcat = false
@@ -191,8 +217,9 @@ func ResetTestState() {
 	agentstate.State.SetReload(false)
 	agentstate.State.SetCurrentActivity("")
 	agentstate.State.SetJobCheckingStopped(false)
+	agentstate.State.SetPauseRequested(false)
 	agentstate.State.SetBenchmarksSubmitted(false)
-	agentstate.State.SetHashcatPID(0)
+	agentstate.State.ResetHashcatPIDs()
 }
 
 // SetupMinimalTestState sets up minimal state (just AgentID and basic paths)
@@ -215,6 +242,7 @@ func SetupMinimalTestState(agentID int64) func() {
 	agentstate.State.OutPath = filepath.Join(testDataDir, "out")
 	agentstate.State.FilePath = filepath.Join(testDataDir, "files")
 	agentstate.State.RestoreFilePath = filepath.Join(testDataDir, "restore")
+	agentstate.State.JournalPath = filepath.Join(testDataDir, "journal")
 	agentstate.State.BenchmarkCachePath = filepath.Join(testDataDir, "benchmark_cache.json")
 
 	return func() {
@@ -229,6 +257,7 @@ func SetupMinimalTestState(agentID int64) func() {
 		agentstate.State.OutPath = ""
 		agentstate.State.FilePath = ""
 		agentstate.State.RestoreFilePath = ""
+		agentstate.State.JournalPath = ""
 		agentstate.State.BenchmarkCachePath = ""
 		agentstate.State.ConnectTimeout = 0
 		agentstate.State.ReadTimeout = 0
@@ -243,7 +272,7 @@ func SetupMinimalTestState(agentID int64) func() {
 		agentstate.State.PerformanceMonitoringInterval = 0
 		agentstate.State.CollectProcessMetrics = false
 		agentstate.State.CollectPerCPUMetrics = false
-		agentstate.State.SetHashcatPID(0)
+		agentstate.State.ResetHashcatPIDs()
 	}
 }
 
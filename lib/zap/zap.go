@@ -4,6 +4,9 @@ package zap
 import (
 	"bufio"
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,21 +16,21 @@ import (
 
 	"github.com/unclesp1d3r/cipherswarmagent/agentstate"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/bandwidth"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/cserrors"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
 )
 
-const (
-	zapPlaintextSeparator = ":"   // Default hash-list separator the server joins hash and plaintext with
-	zapFileMode           = 0o600 // Restrictive permissions for cracked hash data
-)
+const zapFileMode = 0o600 // Restrictive permissions for cracked hash data
 
 // GetZaps fetches zap data for a given task, handles errors, and processes the response stream if available.
 // Logs an error if the task is nil, displays job progress, and retrieves zaps via the injected TasksClient.
-// zapsPath is the directory where zap files should be written.
+// separator is the task's hash list separator, and zapsPath is the directory where zap files should be written.
 func GetZaps(
 	ctx context.Context,
 	tasksClient api.TasksClient,
 	task *api.Task,
+	separator string,
 	sendCrackedHashFunc func(context.Context, time.Time, string, string, *api.Task),
 	zapsPath string,
 ) {
@@ -52,7 +55,10 @@ func GetZaps(
 		return
 	}
 
-	if err := handleResponseStream(ctx, task, responseStream, sendCrackedHashFunc, zapsPath); err != nil {
+	format := api.ZapContentType(res)
+
+	err = handleResponseStream(ctx, task, responseStream, format, separator, sendCrackedHashFunc, zapsPath)
+	if err != nil {
 		agentstate.Logger.Warn("Failed to process zap response stream; cracked hashes may be lost",
 			"task_id", task.Id, "error", err)
 	}
@@ -71,9 +77,17 @@ func removeExistingZapFile(zapFilePath string) error {
 }
 
 // createAndWriteZapFile creates a zap file at the specified path and writes data from the provided responseStream.
-// The task parameter is used for logging and error reporting in case of failures.
-// Returns an error if file creation, writing, or closing fails.
-func createAndWriteZapFile(ctx context.Context, zapFilePath string, responseStream io.Reader, task *api.Task) error {
+// A stream of ZapRecords (format api.ZapRecordsContentType) is written as hashcat zap lines joined with the
+// separator; any other format is written as received. The task parameter is used for logging and error reporting
+// in case of failures. Returns an error if file creation, writing, or closing fails.
+func createAndWriteZapFile(
+	ctx context.Context,
+	zapFilePath string,
+	responseStream io.Reader,
+	format string,
+	separator string,
+	task *api.Task,
+) error {
 	outFile, err := os.OpenFile(zapFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, zapFileMode)
 	if err != nil {
 		return fmt.Errorf("error creating zap file: %w", err)
@@ -93,7 +107,53 @@ func createAndWriteZapFile(ctx context.Context, zapFilePath string, responseStre
 		}
 	}()
 
-	if _, err := io.Copy(outFile, responseStream); err != nil {
+	reader := bandwidth.NewReader(ctx, responseStream)
+
+	if format == api.ZapRecordsContentType {
+		return writeZapRecords(outFile, reader, separator)
+	}
+
+	if _, err := io.Copy(outFile, reader); err != nil {
+		return fmt.Errorf("error writing zap file: %w", err)
+	}
+
+	return nil
+}
+
+// writeZapRecords decodes a stream of newline-delimited ZapRecords and writes
+// each as a hash<separator>$HEX[...] line, which hashcat reads from its outfile
+// check directory and processZapFile parses back without ambiguity.
+func writeZapRecords(w io.Writer, records io.Reader, separator string) error {
+	out := bufio.NewWriter(w)
+	decoder := json.NewDecoder(records)
+
+	for {
+		var record api.ZapRecord
+
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("error decoding zap record: %w", err)
+		}
+
+		if record.Hash == "" || strings.ContainsAny(record.Hash, "\r\n") {
+			return fmt.Errorf("%w: hash %q", hashcat.ErrMalformedRecord, record.Hash)
+		}
+
+		plaintext, err := hex.DecodeString(record.PlaintextHex)
+		if err != nil {
+			return fmt.Errorf("%w: plaintext %q: %w", hashcat.ErrMalformedRecord, record.PlaintextHex, err)
+		}
+
+		if _, err := out.WriteString(hashcat.FormatZapLine(record.Hash, string(plaintext), separator) + "\n"); err != nil {
+			return fmt.Errorf("error writing zap file: %w", err)
+		}
+	}
+
+	if err := out.Flush(); err != nil {
 		return fmt.Errorf("error writing zap file: %w", err)
 	}
 
@@ -102,11 +162,14 @@ func createAndWriteZapFile(ctx context.Context, zapFilePath string, responseStre
 
 // handleResponseStream processes the response stream from a zap request.
 // It writes the stream to a zap file on disk and then processes its contents.
-// zapsPath is the directory where the zap file should be written.
+// format is the response's content type, separator the task's hash list separator,
+// and zapsPath the directory where the zap file should be written.
 func handleResponseStream(
 	ctx context.Context,
 	task *api.Task,
 	responseStream io.ReadCloser,
+	format string,
+	separator string,
 	sendCrackedHashFunc func(context.Context, time.Time, string, string, *api.Task),
 	zapsPath string,
 ) error {
@@ -130,11 +193,11 @@ func handleResponseStream(
 		)
 	}
 
-	if err := createAndWriteZapFile(ctx, zapFilePath, responseStream, task); err != nil {
+	if err := createAndWriteZapFile(ctx, zapFilePath, responseStream, format, separator, task); err != nil {
 		return cserrors.LogAndSendError(ctx, "Error creating and writing zap file", err, api.SeverityCritical, task)
 	}
 
-	if err := processZapFile(ctx, zapFilePath, task, sendCrackedHashFunc); err != nil {
+	if err := processZapFile(ctx, zapFilePath, separator, task, sendCrackedHashFunc); err != nil {
 		return cserrors.LogAndSendError(ctx, "Error processing zap file", err, api.SeverityCritical, task)
 	}
 
@@ -146,6 +209,7 @@ func handleResponseStream(
 func processZapFile(
 	ctx context.Context,
 	zapFilePath string,
+	separator string,
 	task *api.Task,
 	sendCrackedHashFunc func(context.Context, time.Time, string, string, *api.Task),
 ) error {
@@ -164,25 +228,17 @@ func processZapFile(
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		line := scanner.Text()
-
-		// The server serializes each cracked entry as `hash<sep>plaintext` using the
-		// hash list's separator (default ":"). Many hash types embed colons (NTLMv2,
-		// Kerberos krb5asrep, PBKDF2 sha256:rounds:salt), so split on the LAST
-		// separator: everything before it is the hash (internal colons preserved),
-		// everything after is the plaintext.
-		//
-		// Limitation (KTD3): a plaintext that itself contains a colon is
-		// misattributed. This is accepted because hash colons are systematic while
-		// plaintext colons are rare, and LastIndex is strictly better than splitting
-		// on the first colon, which truncated every colon-bearing hash.
-		idx := strings.LastIndex(line, zapPlaintextSeparator)
-		if idx < 0 {
+		// Each line is hash<separator>plaintext. Zaps written from ZapRecords carry
+		// a $HEX[...] plaintext, so hash and plaintext may both contain the
+		// separator. Plain-text zaps from servers without ZapRecords are split on
+		// the last separator (see hashcat.ParseZapLine), which keeps colon-bearing
+		// hashes (NetNTLMv2, krb5asrep, PBKDF2) whole but still misattributes a
+		// plaintext containing the separator unless the server hex-encodes it.
+		hash, plaintext, err := hashcat.ParseZapLine(scanner.Text(), separator)
+		if err != nil {
 			continue
 		}
 
-		hash := line[:idx]
-		plaintext := line[idx+len(zapPlaintextSeparator):]
 		sendCrackedHashFunc(ctx, time.Now(), hash, plaintext, task)
 	}
 
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/api"
+	"github.com/unclesp1d3r/cipherswarmagent/lib/hashcat"
 	"github.com/unclesp1d3r/cipherswarmagent/lib/testhelpers"
 )
 
@@ -87,7 +88,8 @@ func TestCreateAndWriteZapFile(t *testing.T) {
 			zapFilePath := filepath.Join(tempDir, fmt.Sprintf("test_%d.zap", i))
 			reader := strings.NewReader(tt.content)
 
-			err := createAndWriteZapFile(context.Background(), zapFilePath, reader, tt.task)
+			err := createAndWriteZapFile(context.Background(), zapFilePath, reader,
+				api.ZapTextContentType, hashcat.DefaultSeparator, tt.task)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -103,7 +105,8 @@ func TestCreateAndWriteZapFile(t *testing.T) {
 }
 
 // TestProcessZapFile_ColonSafe verifies that colon-bearing hashes round-trip
-// intact (split on the last colon, not the first).
+// intact (split on the last colon, not the first), and that $HEX[...]
+// plaintexts keep their colons too.
 func TestProcessZapFile_ColonSafe(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -138,14 +141,26 @@ func TestProcessZapFile_ColonSafe(t *testing.T) {
 			wantPlaintext: "hello",
 		},
 		{
-			// KTD3 documented limitation: a plaintext containing a colon is
-			// misattributed because the split is on the last colon. Pinned here so
-			// a future format/escaping change is greppable.
+			// KTD3 documented limitation: in plain-text zaps, a plaintext containing
+			// a colon is misattributed because the split is on the last colon. Zaps
+			// from ZapRecords, and servers that send $HEX[...], avoid it (below).
 			name:          "plaintext containing a colon (documented limitation)",
 			line:          "5d41402abc4b2a76b9719d911017c592:pa:ss",
 			wantHash:      "5d41402abc4b2a76b9719d911017c592:pa",
 			wantPlaintext: "ss",
 		},
+		{
+			name:          "$HEX plaintext containing a colon",
+			line:          "5d41402abc4b2a76b9719d911017c592:$HEX[70613a7373]",
+			wantHash:      "5d41402abc4b2a76b9719d911017c592",
+			wantPlaintext: "pa:ss",
+		},
+		{
+			name:          "NTLMv2 hash with a $HEX plaintext containing colons",
+			line:          "admin::CORP:1122334455667788:1f3a8b:0101000000:$HEX[3a3a3a]",
+			wantHash:      "admin::CORP:1122334455667788:1f3a8b:0101000000",
+			wantPlaintext: ":::",
+		},
 	}
 
 	for i, tt := range tests {
@@ -162,7 +177,7 @@ func TestProcessZapFile_ColonSafe(t *testing.T) {
 			}
 
 			task := testhelpers.NewTestTask(123, 456)
-			require.NoError(t, processZapFile(context.Background(), zapFilePath, task, mockSendFunc))
+			require.NoError(t, processZapFile(context.Background(), zapFilePath, hashcat.DefaultSeparator, task, mockSendFunc))
 
 			require.Equal(t, 1, calls, "exactly one cracked hash should be submitted")
 			assert.Equal(t, tt.wantHash, gotHash, "hash must not be truncated")
@@ -177,7 +192,7 @@ func TestProcessZapFile_OpenError(t *testing.T) {
 	task := testhelpers.NewTestTask(123, 456)
 	noopSend := func(context.Context, time.Time, string, string, *api.Task) {}
 
-	err := processZapFile(context.Background(), missing, task, noopSend)
+	err := processZapFile(context.Background(), missing, hashcat.DefaultSeparator, task, noopSend)
 	require.Error(t, err)
 	assert.ErrorIs(t, err, os.ErrNotExist, "open failure should wrap the underlying cause")
 }
@@ -244,7 +259,7 @@ invalidline
 			}
 
 			task := testhelpers.NewTestTask(123, 456)
-			err = processZapFile(context.Background(), zapFilePath, task, mockSendFunc)
+			err = processZapFile(context.Background(), zapFilePath, hashcat.DefaultSeparator, task, mockSendFunc)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -272,7 +287,8 @@ func TestHandleResponseStream_UsesInjectedZapsPath(t *testing.T) {
 		gotPlaintext = plaintext
 	}
 
-	err := handleResponseStream(context.Background(), task, reader, sendFunc, zapsDir)
+	err := handleResponseStream(context.Background(), task, reader,
+		api.ZapTextContentType, hashcat.DefaultSeparator, sendFunc, zapsDir)
 	require.NoError(t, err)
 
 	zapFile := filepath.Join(zapsDir, "123.zap")
@@ -282,3 +298,89 @@ func TestHandleResponseStream_UsesInjectedZapsPath(t *testing.T) {
 	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", gotHash)
 	assert.Equal(t, "hello", gotPlaintext)
 }
+
+// TestHandleResponseStream_ZapRecords verifies that hashes and plaintexts sent
+// as ZapRecords round-trip exactly, whatever separators they contain, and are
+// written to the zap file in hashcat's $HEX[...] notation.
+func TestHandleResponseStream_ZapRecords(t *testing.T) {
+	tests := []struct {
+		name      string
+		separator string
+		hash      string
+		plaintext string
+		wantLine  string
+	}{
+		{
+			name:      "colon-bearing hash and plaintext",
+			separator: hashcat.DefaultSeparator,
+			hash:      "admin::CORP:1122334455667788:1f3a8b:0101000000",
+			plaintext: "pa:ss",
+			wantLine:  "admin::CORP:1122334455667788:1f3a8b:0101000000:$HEX[70613a7373]",
+		},
+		{
+			name:      "custom separator in hash and plaintext",
+			separator: "|",
+			hash:      "user|5d41402abc4b2a76b9719d911017c592",
+			plaintext: "a|b:c",
+			wantLine:  "user|5d41402abc4b2a76b9719d911017c592|$HEX[617c623a63]",
+		},
+		{
+			name:      "empty plaintext",
+			separator: hashcat.DefaultSeparator,
+			hash:      "31d6cfe0d16ae931b73c59d7e0c089c0",
+			plaintext: "",
+			wantLine:  "31d6cfe0d16ae931b73c59d7e0c089c0:$HEX[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zapsDir := t.TempDir()
+			task := testhelpers.NewTestTask(123, 456)
+
+			content := fmt.Sprintf("{\"hash\":%q,\"plaintext_hex\":\"%x\"}\n", tt.hash, tt.plaintext)
+			reader := io.NopCloser(strings.NewReader(content))
+
+			var gotHash, gotPlaintext string
+			calls := 0
+			sendFunc := func(_ context.Context, _ time.Time, hash, plaintext string, _ *api.Task) {
+				calls++
+				gotHash = hash
+				gotPlaintext = plaintext
+			}
+
+			err := handleResponseStream(context.Background(), task, reader,
+				api.ZapRecordsContentType, tt.separator, sendFunc, zapsDir)
+			require.NoError(t, err)
+
+			written, err := os.ReadFile(filepath.Join(zapsDir, "123.zap"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLine+"\n", string(written))
+
+			require.Equal(t, 1, calls)
+			assert.Equal(t, tt.hash, gotHash)
+			assert.Equal(t, tt.plaintext, gotPlaintext)
+		})
+	}
+}
+
+// TestWriteZapRecords_Malformed verifies that a record stream the agent cannot
+// write as zap lines is rejected rather than partly misread.
+func TestWriteZapRecords_Malformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "not JSON", content: "5d41402abc4b2a76b9719d911017c592:hello\n"},
+		{name: "plaintext not hex", content: `{"hash":"5d41402abc4b2a76b9719d911017c592","plaintext_hex":"zz"}`},
+		{name: "hash with a line break", content: `{"hash":"abc\ndef","plaintext_hex":"00"}`},
+		{name: "empty hash", content: `{"hash":"","plaintext_hex":"00"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			require.Error(t, writeZapRecords(&out, strings.NewReader(tt.content), hashcat.DefaultSeparator))
+		})
+	}
+}